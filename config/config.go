@@ -21,11 +21,15 @@ import (
 	"github.com/zalando/skipper"
 	"github.com/zalando/skipper/dataclients/kubernetes"
 	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/cache"
 	"github.com/zalando/skipper/filters/openpolicyagent"
+	"github.com/zalando/skipper/loadbalancer"
 	"github.com/zalando/skipper/metrics"
 	"github.com/zalando/skipper/net"
 	"github.com/zalando/skipper/otel"
 	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/ratelimit"
+	"github.com/zalando/skipper/secrets/revocation"
 	"github.com/zalando/skipper/swarm"
 )
 
@@ -55,6 +59,10 @@ type Config struct {
 	DefaultHTTPStatus                     int            `yaml:"default-http-status"`
 	PluginDir                             string         `yaml:"plugindir"`
 	LoadBalancerHealthCheckInterval       time.Duration  `yaml:"lb-healthcheck-interval"`
+	LoadBalancerRingHashEndpointEntries   int            `yaml:"lb-ring-hash-endpoint-entries"`
+	LoadBalancerZoneAware                 bool           `yaml:"lb-zone-aware"`
+	LoadBalancerZone                      string         `yaml:"lb-zone"`
+	LoadBalancerZoneAwareMinHealthy       float64        `yaml:"lb-zone-aware-min-healthy-percent"`
 	ReverseSourcePredicate                bool           `yaml:"reverse-source-predicate"`
 	RemoveHopHeaders                      bool           `yaml:"remove-hop-headers"`
 	RfcPatchPath                          bool           `yaml:"rfc-patch-path"`
@@ -215,6 +223,13 @@ type Config struct {
 	KubernetesDefaultLoadBalancerAlgorithm               string                             `yaml:"kubernetes-default-lb-algorithm"`
 	KubernetesForceService                               bool                               `yaml:"kubernetes-force-service"`
 	KubernetesStatusFromService                          string                             `yaml:"kubernetes-status-from-service"`
+	KubernetesEnableGatewayAPI                           bool                               `yaml:"enable-kubernetes-gateway-api"`
+	KubernetesGatewayControllerName                      string                             `yaml:"kubernetes-gateway-controller-name"`
+	KubernetesEnableWatch                                bool                               `yaml:"enable-kubernetes-watch"`
+	KubernetesEnableRouteGroupStatus                     bool                               `yaml:"enable-kubernetes-routegroup-status"`
+	KubernetesEnableRouteGroupCrossNamespaceBackends     bool                               `yaml:"enable-kubernetes-routegroup-cross-namespace-backends"`
+	KubernetesRemoteClusters                             mapFlags                           `yaml:"kubernetes-remote-clusters"`
+	KubernetesEnablePolicies                             bool                               `yaml:"enable-kubernetes-policies"`
 
 	// RouteServer
 	RouteServerFilters *defaultFiltersFlags `yaml:"route-server-filters"`
@@ -274,6 +289,15 @@ type Config struct {
 	TLSMinVersion string             `yaml:"tls-min-version"`
 	TLSClientAuth tls.ClientAuthType `yaml:"tls-client-auth"`
 
+	// TLS client certificate revocation
+	TLSClientCRLs                      *listFlag     `yaml:"tls-client-crl"`
+	TLSClientCRLDistributionPoints     bool          `yaml:"tls-client-crl-distribution-points"`
+	TLSClientOCSP                      bool          `yaml:"tls-client-ocsp"`
+	TLSClientRevocationFailClosed      bool          `yaml:"tls-client-revocation-fail-closed"`
+	TLSClientRevocationRefreshInterval time.Duration `yaml:"tls-client-revocation-refresh-interval"`
+	TLSClientRevocationFilterOnly      bool          `yaml:"tls-client-revocation-filter-only"`
+	TLSOCSPStapling                    bool          `yaml:"tls-ocsp-stapling"`
+
 	// TLS debugging
 	TLSKeyLogFile   string    `yaml:"tls-key-log-file"`
 	TLSKeyLogWriter io.Writer `yaml:"-"`
@@ -284,6 +308,8 @@ type Config struct {
 	// TLS Config
 	KubernetesEnableTLS bool `yaml:"kubernetes-enable-tls"`
 
+	KubernetesEnableSecrets bool `yaml:"kubernetes-enable-secrets"`
+
 	// API Monitoring
 	ApiUsageMonitoringEnable                       bool   `yaml:"enable-api-usage-monitoring"`
 	ApiUsageMonitoringRealmKeys                    string `yaml:"api-usage-monitoring-realm-keys"`
@@ -353,7 +379,15 @@ type Config struct {
 	SwarmStaticSelf                   string        `yaml:"swarm-static-self"`
 	SwarmStaticOther                  string        `yaml:"swarm-static-other"`
 
-	ClusterRatelimitMaxGroupShards int `yaml:"cluster-ratelimit-max-group-shards"`
+	ClusterRatelimitMaxGroupShards   int           `yaml:"cluster-ratelimit-max-group-shards"`
+	RatelimitOverridesFile           string        `yaml:"ratelimit-overrides-file"`
+	RatelimitOverridesUpdateInterval time.Duration `yaml:"ratelimit-overrides-update-interval"`
+
+	ResponseCacheStorage    string        `yaml:"response-cache-storage"`
+	ResponseCacheL1MaxAge   time.Duration `yaml:"response-cache-l1-max-age"`
+	ResponseCacheAdminToken string        `yaml:"response-cache-admin-token"`
+
+	DPoPReplayStorage string `yaml:"dpop-replay-storage"`
 
 	EnableLua  bool      `yaml:"enable-lua"`
 	LuaModules *listFlag `yaml:"lua-modules"`
@@ -376,6 +410,8 @@ type Config struct {
 	OpenPolicyAgentMaxMemoryBodyParsing                int64         `yaml:"open-policy-agent-max-memory-body-parsing"`
 
 	PassiveHealthCheck mapFlags `yaml:"passive-health-check"`
+	ActiveHealthCheck  mapFlags `yaml:"active-health-check"`
+	OutlierDetection   mapFlags `yaml:"outlier-detection"`
 
 	EnableProxyProtocol bool      `yaml:"enable-proxy-protocol"`
 	ProxyAllowListCIDRs *listFlag `yaml:"proxy-allow-cidrs"`
@@ -390,6 +426,8 @@ const (
 	// environment keys:
 	redisPasswordEnv  = "SWARM_REDIS_PASSWORD"
 	valkeyPasswordEnv = "SWARM_VALKEY_PASSWORD"
+
+	responseCacheAdminTokenEnv = "RESPONSE_CACHE_ADMIN_TOKEN"
 )
 
 func NewConfig() *Config {
@@ -421,6 +459,7 @@ func NewConfig() *Config {
 	cfg.ProxyDenyListCIDRs = commaListFlag()
 	cfg.ProxySkipListCIDRs = commaListFlag()
 	cfg.EnsureDataClients = commaListFlag()
+	cfg.TLSClientCRLs = commaListFlag()
 
 	flag := flag.NewFlagSet("", flag.ExitOnError)
 	flag.StringVar(&cfg.ConfigFile, "config-file", "", "if provided the flags will be loaded/overwritten by the values on the file (yaml)")
@@ -447,6 +486,10 @@ func NewConfig() *Config {
 	flag.IntVar(&cfg.DefaultHTTPStatus, "default-http-status", http.StatusNotFound, "default HTTP status used when no route is found for a request")
 	flag.StringVar(&cfg.PluginDir, "plugindir", "", "set the directory to load plugins from, default is ./")
 	flag.DurationVar(&cfg.LoadBalancerHealthCheckInterval, "lb-healthcheck-interval", 0, "This is *deprecated* and not in use anymore")
+	flag.IntVar(&cfg.LoadBalancerRingHashEndpointEntries, "lb-ring-hash-endpoint-entries", loadbalancer.DefaultRingHashEndpointEntries, "sets the number of entries of the endpoint with the smallest weight in the hash ring of the ringHash load balancing algorithm")
+	flag.BoolVar(&cfg.LoadBalancerZoneAware, "lb-zone-aware", false, "enables zone aware load balancing, which prefers the LB endpoints in the zone of skipper and keeps the endpoints of the other zones in the routes created by the kubernetes dataclient")
+	flag.StringVar(&cfg.LoadBalancerZone, "lb-zone", "", "sets the zone of skipper used by the zone aware load balancing, defaults to the value of -kubernetes-topology-zone")
+	flag.Float64Var(&cfg.LoadBalancerZoneAwareMinHealthy, "lb-zone-aware-min-healthy-percent", loadbalancer.DefaultZoneAwareMinHealthyPercent, "sets the percentage of healthy LB endpoints in the zone of skipper, below which the zone aware load balancing sends a proportional share of the requests to the other zones")
 	flag.BoolVar(&cfg.ReverseSourcePredicate, "reverse-source-predicate", false, "reverse the order of finding the client IP from X-Forwarded-For header")
 	flag.BoolVar(&cfg.RemoveHopHeaders, "remove-hop-headers", false, "enables removal of Hop-Headers according to RFC-2616")
 	flag.BoolVar(&cfg.RfcPatchPath, "rfc-patch-path", false, "patches the incoming request path to preserve uncoded reserved characters according to RFC 2616 and RFC 3986")
@@ -595,9 +638,16 @@ func NewConfig() *Config {
 	flag.StringVar(&cfg.KubernetesValkeyServiceName, "kubernetes-valkey-service-name", "", "Sets name for valkey to be used to lookup endpoints")
 	flag.IntVar(&cfg.KubernetesValkeyServicePort, "kubernetes-valkey-service-port", 6379, "Sets the port for valkey to be used to lookup endpoints")
 	flag.StringVar(&cfg.KubernetesBackendTrafficAlgorithmString, "kubernetes-backend-traffic-algorithm", kubernetes.TrafficPredicateAlgorithm.String(), "sets the algorithm to be used for traffic splitting between backends: traffic-predicate or traffic-segment-predicate")
-	flag.StringVar(&cfg.KubernetesDefaultLoadBalancerAlgorithm, "kubernetes-default-lb-algorithm", kubernetes.DefaultLoadBalancerAlgorithm, "sets the default algorithm to be used for load balancing between backend endpoints, available options: roundRobin, consistentHash, random, powerOfRandomNChoices, weightedRoundRobin, leastRequests")
+	flag.StringVar(&cfg.KubernetesDefaultLoadBalancerAlgorithm, "kubernetes-default-lb-algorithm", kubernetes.DefaultLoadBalancerAlgorithm, "sets the default algorithm to be used for load balancing between backend endpoints, available options: roundRobin, consistentHash, random, powerOfRandomNChoices, weightedRoundRobin, leastRequests, ringHash, maglev")
 	flag.BoolVar(&cfg.KubernetesForceService, "kubernetes-force-service", false, "overrides default Skipper functionality and routes traffic using Kubernetes Services instead of Endpoints")
 	flag.StringVar(&cfg.KubernetesStatusFromService, "kubernetes-status-from-service", "", "when set to <namespace>/<name>, updates Ingress status.loadBalancer.ingress from the referenced service")
+	flag.BoolVar(&cfg.KubernetesEnableGatewayAPI, "enable-kubernetes-gateway-api", false, "enables the Kubernetes Gateway API resources GatewayClass, Gateway, HTTPRoute and ReferenceGrant as routing source, and updates their status")
+	flag.StringVar(&cfg.KubernetesGatewayControllerName, "kubernetes-gateway-controller-name", kubernetes.DefaultGatewayControllerName, "sets the controller name of the Gateway API GatewayClasses implemented by skipper")
+	flag.BoolVar(&cfg.KubernetesEnableWatch, "enable-kubernetes-watch", false, "enables watching the Kubernetes resources instead of listing them on every poll, and converts the routes only when the resources change")
+	flag.BoolVar(&cfg.KubernetesEnableRouteGroupStatus, "enable-kubernetes-routegroup-status", false, "enables updating the RouteGroup status with the validation and route errors, and the load balancer addresses when -kubernetes-status-from-service is set")
+	flag.BoolVar(&cfg.KubernetesEnableRouteGroupCrossNamespaceBackends, "enable-kubernetes-routegroup-cross-namespace-backends", false, "enables RouteGroup service backends referencing Services in other namespaces, when allowed by a ReferenceGrant in the namespace of the Service")
+	flag.Var(&cfg.KubernetesRemoteClusters, "kubernetes-remote-clusters", "sets the remote clusters that RouteGroup service backends can reference, as pairs of cluster name and kubeconfig path, e.g. eu-west=/etc/skipper/eu-west.kubeconfig")
+	flag.BoolVar(&cfg.KubernetesEnablePolicies, "enable-kubernetes-policies", false, "enables the RatelimitPolicy and AuthPolicy resources, applying their filters to the routes of the targeted RouteGroups and Ingresses")
 
 	// Auth:
 	flag.BoolVar(&cfg.EnableOAuth2GrantFlow, "enable-oauth2-grant-flow", false, "enables OAuth2 Grant Flow filter")
@@ -667,6 +717,15 @@ func NewConfig() *Config {
 	flag.Func("tls-client-auth", "TLS client authentication policy for server, one of: "+
 		"NoClientCert, RequestClientCert, RequireAnyClientCert, VerifyClientCertIfGiven or RequireAndVerifyClientCert. "+
 		"See https://pkg.go.dev/crypto/tls#ClientAuthType for details.", cfg.setTLSClientAuth)
+
+	// TLS client certificate revocation
+	flag.Var(cfg.TLSClientCRLs, "tls-client-crl", "PEM or DER encoded CRL files or http(s) URLs to check the revocation status of client certificates, multiple may be given comma separated")
+	flag.BoolVar(&cfg.TLSClientCRLDistributionPoints, "tls-client-crl-distribution-points", false, "enables to fetch the CRLs from the CRL distribution points of client certificates")
+	flag.BoolVar(&cfg.TLSClientOCSP, "tls-client-ocsp", false, "enables to check the revocation status of client certificates, which are not covered by a CRL, at their OCSP responders")
+	flag.BoolVar(&cfg.TLSClientRevocationFailClosed, "tls-client-revocation-fail-closed", false, "rejects client certificates with unknown revocation status, by default they are accepted")
+	flag.DurationVar(&cfg.TLSClientRevocationRefreshInterval, "tls-client-revocation-refresh-interval", revocation.DefaultRefreshInterval, "interval to refresh CRLs and OCSP staples, and maximum time to cache OCSP responses")
+	flag.BoolVar(&cfg.TLSClientRevocationFilterOnly, "tls-client-revocation-filter-only", false, "disables the revocation checking of client certificates on the listener, so that it is only done by the mtlsRevocation() filter")
+	flag.BoolVar(&cfg.TLSOCSPStapling, "tls-ocsp-stapling", false, "enables stapling OCSP responses to the server certificates of -tls-cert")
 	flag.StringVar(&cfg.TLSKeyLogFile, "tls-key-log-file", "", "path to a file where TLS master secrets are logged in NSS Key Log Format for debugging with tools like Wireshark. "+
 		"WARNING: anyone with access to this file can decrypt all TLS traffic — never use in production")
 
@@ -712,6 +771,7 @@ func NewConfig() *Config {
 	flag.IntVar(&cfg.MaxIdleConnsBackend, "max-idle-connection-backend", 0, "sets the maximum idle connections for all backend connections")
 	flag.BoolVar(&cfg.DisableHTTPKeepalives, "disable-http-keepalives", false, "forces backend to always create a new connection")
 	flag.BoolVar(&cfg.KubernetesEnableTLS, "kubernetes-enable-tls", false, "enable using kubernetes resources to terminate tls")
+	flag.BoolVar(&cfg.KubernetesEnableSecrets, "kubernetes-enable-secrets", false, "enables filters to read kubernetes secrets as namespace/name/key, e.g. jwtValidationSecret")
 
 	// Swarm:
 	flag.BoolVar(&cfg.EnableSwarm, "enable-swarm", false, "enable swarm communication between nodes in a skipper fleet")
@@ -746,6 +806,12 @@ func NewConfig() *Config {
 	flag.StringVar(&cfg.SwarmStaticOther, "swarm-static-other", "", "set static swarm all nodes, for example 127.0.0.1:9002,127.0.0.1:9003")
 
 	flag.IntVar(&cfg.ClusterRatelimitMaxGroupShards, "cluster-ratelimit-max-group-shards", 1, "sets the maximum number of group shards for the clusterRatelimit filter")
+	flag.StringVar(&cfg.RatelimitOverridesFile, "ratelimit-overrides-file", "", "YAML file with overrides of the limits of cluster ratelimit groups and clients, that is reread at runtime, for example a mounted Kubernetes ConfigMap")
+	flag.DurationVar(&cfg.RatelimitOverridesUpdateInterval, "ratelimit-overrides-update-interval", ratelimit.DefaultOverridesUpdateInterval, "sets the interval to reread the ratelimit overrides file")
+
+	flag.StringVar(&cfg.ResponseCacheStorage, "response-cache-storage", "memory", `storage of the cache() filter, one of "memory", "redis" or "valkey". The redis and valkey storages share the cached responses across instances using the ring of the swarm, and require the redis or valkey based swarm.`+"\nUse "+responseCacheAdminTokenEnv+" environment variable or 'response-cache-admin-token' key in config file to enable the cache admin endpoints on the support listener")
+	flag.StringVar(&cfg.DPoPReplayStorage, "dpop-replay-storage", "memory", `storage of the dpop() filter to detect replayed proofs, one of "memory", "redis" or "valkey". The redis and valkey storages detect replays across instances using the ring of the swarm, and require the redis or valkey based swarm.`)
+	flag.DurationVar(&cfg.ResponseCacheL1MaxAge, "response-cache-l1-max-age", cache.DefaultL1MaxAge, "maximum time an entry of the redis or valkey response cache storage is served from the local in-memory cache")
 
 	flag.BoolVar(&cfg.EnableLua, "enable-lua", false, "enable the Lua scripting engine to be able to use the lua() filter")
 	flag.Var(cfg.LuaModules, "lua-modules", "comma separated list of lua filter modules. Use <module>.<symbol> to selectively enable module symbols, for example: package,base._G,base.print,json")
@@ -754,6 +820,10 @@ func NewConfig() *Config {
 	// Passive Health Checks
 	flag.Var(&cfg.PassiveHealthCheck, "passive-health-check", "sets the parameters for passive health check feature")
 
+	// Active Health Checks
+	flag.Var(&cfg.ActiveHealthCheck, "active-health-check", "enables the active health check of all LB route endpoints and sets its parameters, e.g. path=/health,interval=10s,timeout=1s,expected-status=200,healthy-threshold=2,unhealthy-threshold=3")
+	flag.Var(&cfg.OutlierDetection, "outlier-detection", "enables the ejection of LB endpoints after consecutive errors and sets its parameters, e.g. consecutive-errors=5,base-ejection-time=30s,max-ejection-time=5m,max-ejection-percent=10")
+
 	// PROXY protocol
 	flag.BoolVar(&cfg.EnableProxyProtocol, "enable-proxy-protocol", false, "enable the haproxy PROXY protocol v1 and v2. Default is false and if enabled the default will reject all connections. Please check allow, deny and skip list.")
 	flag.Var(cfg.ProxyAllowListCIDRs, "proxy-allow-cidrs", `comma separated list of CIDRs that are allowed to use the PROXY protocol v1/v2. To allow all ipv6 and ipv4 addresses use: "::/0,0.0.0.0/0"`)
@@ -966,9 +1036,20 @@ func (c *Config) ToOptions() skipper.Options {
 		KeyPathTLS:                            c.KeyPathTLS,
 		TLSClientAuth:                         c.TLSClientAuth,
 		TLSMinVersion:                         c.getMinTLSVersion(),
+		TLSClientCRLs:                         c.TLSClientCRLs.values,
+		TLSClientCRLDistributionPoints:        c.TLSClientCRLDistributionPoints,
+		TLSClientOCSP:                         c.TLSClientOCSP,
+		TLSClientRevocationFailClosed:         c.TLSClientRevocationFailClosed,
+		TLSClientRevocationRefreshInterval:    c.TLSClientRevocationRefreshInterval,
+		TLSClientRevocationFilterOnly:         c.TLSClientRevocationFilterOnly,
+		TLSOCSPStapling:                       c.TLSOCSPStapling,
 		CipherSuites:                          c.filterCipherSuites(),
 		MaxLoopbacks:                          c.MaxLoopbacks,
 		DefaultHTTPStatus:                     c.DefaultHTTPStatus,
+		LoadBalancerRingHashEndpointEntries:   c.LoadBalancerRingHashEndpointEntries,
+		LoadBalancerZoneAware:                 c.LoadBalancerZoneAware,
+		LoadBalancerZone:                      c.LoadBalancerZone,
+		LoadBalancerZoneAwareMinHealthy:       c.LoadBalancerZoneAwareMinHealthy,
 		ReverseSourcePredicate:                c.ReverseSourcePredicate,
 		MaxAuditBody:                          c.MaxAuditBody,
 		MaxMatcherBufferSize:                  c.MaxMatcherBufferSize,
@@ -1099,6 +1180,13 @@ func (c *Config) ToOptions() skipper.Options {
 		KubernetesDefaultLoadBalancerAlgorithm:         c.KubernetesDefaultLoadBalancerAlgorithm,
 		KubernetesForceService:                         c.KubernetesForceService,
 		KubernetesStatusFromService:                    c.KubernetesStatusFromService,
+		KubernetesEnableGatewayAPI:                     c.KubernetesEnableGatewayAPI,
+		KubernetesGatewayControllerName:                c.KubernetesGatewayControllerName,
+		KubernetesEnableWatch:                          c.KubernetesEnableWatch,
+		KubernetesEnableRouteGroupStatus:               c.KubernetesEnableRouteGroupStatus,
+		KubernetesEnableRouteGroupCrossNamespace:       c.KubernetesEnableRouteGroupCrossNamespaceBackends,
+		KubernetesRemoteClusters:                       c.KubernetesRemoteClusters.values,
+		KubernetesEnablePolicies:                       c.KubernetesEnablePolicies,
 
 		// API Monitoring:
 		ApiUsageMonitoringEnable:                c.ApiUsageMonitoringEnable,
@@ -1174,6 +1262,7 @@ func (c *Config) ToOptions() skipper.Options {
 		MaxIdleConnsBackend:          c.MaxIdleConnsBackend,
 		DisableHTTPKeepalives:        c.DisableHTTPKeepalives,
 		KubernetesEnableTLS:          c.KubernetesEnableTLS,
+		KubernetesEnableSecrets:      c.KubernetesEnableSecrets,
 
 		// swarm:
 		EnableSwarm: c.EnableSwarm,
@@ -1212,7 +1301,15 @@ func (c *Config) ToOptions() skipper.Options {
 		SwarmStaticSelf:  c.SwarmStaticSelf,
 		SwarmStaticOther: c.SwarmStaticOther,
 
-		ClusterRatelimitMaxGroupShards: c.ClusterRatelimitMaxGroupShards,
+		ClusterRatelimitMaxGroupShards:   c.ClusterRatelimitMaxGroupShards,
+		RatelimitOverridesFile:           c.RatelimitOverridesFile,
+		RatelimitOverridesUpdateInterval: c.RatelimitOverridesUpdateInterval,
+
+		ResponseCacheStorage:    c.ResponseCacheStorage,
+		ResponseCacheL1MaxAge:   c.ResponseCacheL1MaxAge,
+		ResponseCacheAdminToken: c.ResponseCacheAdminToken,
+
+		DPoPReplayStorage: c.DPoPReplayStorage,
 
 		EnableLua:  c.EnableLua,
 		LuaModules: c.LuaModules.values,
@@ -1235,6 +1332,8 @@ func (c *Config) ToOptions() skipper.Options {
 		OpenPolicyAgentMaxMemoryBodyParsing:                c.OpenPolicyAgentMaxMemoryBodyParsing,
 
 		PassiveHealthCheck: c.PassiveHealthCheck.values,
+		ActiveHealthCheck:  c.ActiveHealthCheck.values,
+		OutlierDetection:   c.OutlierDetection.values,
 
 		EnableProxyProtocol: c.EnableProxyProtocol,
 		ProxyAllowListCIDRs: c.ProxyAllowListCIDRs.values,
@@ -1455,6 +1554,10 @@ func (c *Config) parseEnv() {
 	if c.SwarmValkeyPassword == "" {
 		c.SwarmValkeyPassword = os.Getenv(valkeyPasswordEnv)
 	}
+	// Set response cache admin token from environment variable if not set earlier (configuration file)
+	if c.ResponseCacheAdminToken == "" {
+		c.ResponseCacheAdminToken = os.Getenv(responseCacheAdminTokenEnv)
+	}
 }
 
 func (c *Config) checkDeprecated(configKeys map[string]interface{}, options ...string) {
@@ -18,7 +18,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/zalando/skipper/dataclients/kubernetes"
 	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/cache"
 	"github.com/zalando/skipper/filters/openpolicyagent"
+	"github.com/zalando/skipper/loadbalancer"
 	"github.com/zalando/skipper/metrics"
 	"github.com/zalando/skipper/net"
 	"github.com/zalando/skipper/proxy"
@@ -80,6 +82,8 @@ func defaultConfig(with func(*Config)) *Config {
 		SupportListener:                         ":9911",
 		MaxLoopbacks:                            proxy.DefaultMaxLoopbacks,
 		DefaultHTTPStatus:                       404,
+		LoadBalancerRingHashEndpointEntries:     loadbalancer.DefaultRingHashEndpointEntries,
+		LoadBalancerZoneAwareMinHealthy:         loadbalancer.DefaultZoneAwareMinHealthyPercent,
 		MaxAuditBody:                            1024,
 		MaxMatcherBufferSize:                    2097152,
 		MetricsFlavour:                          commaListFlag("codahale", "prometheus", "otel"),
@@ -119,6 +123,7 @@ func defaultConfig(with func(*Config)) *Config {
 		KubernetesValkeyServicePort:             6379,
 		KubernetesBackendTrafficAlgorithmString: "traffic-predicate",
 		KubernetesDefaultLoadBalancerAlgorithm:  "roundRobin",
+		KubernetesGatewayControllerName:         "zalando.org/skipper",
 		KubernetesApplicationAnnotationLabelKey: "",
 		RouteServerFilters:                      &defaultFiltersFlags{},
 		Oauth2TokeninfoTimeout:                  2 * time.Second,
@@ -176,10 +181,16 @@ func defaultConfig(with func(*Config)) *Config {
 		SwarmMaxMessageBuffer:                   4194304,
 		SwarmLeaveTimeout:                       5 * time.Second,
 		TLSMinVersion:                           defaultMinTLSVersion,
+		TLSClientCRLs:                           commaListFlag(),
+		TLSClientRevocationRefreshInterval:      time.Hour,
 		RoutesURLs:                              commaListFlag(),
 		ForwardedHeadersList:                    commaListFlag(),
 		ForwardedHeadersExcludeCIDRList:         commaListFlag(),
 		ClusterRatelimitMaxGroupShards:          1,
+		RatelimitOverridesUpdateInterval:        10 * time.Second,
+		ResponseCacheStorage:                    "memory",
+		DPoPReplayStorage:                       "memory",
+		ResponseCacheL1MaxAge:                   cache.DefaultL1MaxAge,
 		ValidateQuery:                           true,
 		ValidateQueryLog:                        true,
 		EnableLua:                               false,
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,33 +26,52 @@ import (
 )
 
 const (
-	ingressClassKey            = "kubernetes.io/ingress.class"
-	IngressesV1ClusterURI      = "/apis/networking.k8s.io/v1/ingresses"
-	ZalandoResourcesClusterURI = "/apis/zalando.org/v1"
-	RouteGroupsName            = "routegroups"
-	RouteGroupsClusterURI      = "/apis/zalando.org/v1/routegroups"
-	routeGroupClassKey         = "zalando.org/routegroup.class"
-	ServicesClusterURI         = "/api/v1/services"
-	EndpointsClusterURI        = "/api/v1/endpoints"
-	EndpointSlicesClusterURI   = "/apis/discovery.k8s.io/v1/endpointslices"
-	SecretsClusterURI          = "/api/v1/secrets"
-	defaultKubernetesURL       = "http://localhost:8001"
-	IngressesV1NamespaceFmt    = "/apis/networking.k8s.io/v1/namespaces/%s/ingresses"
-	RouteGroupsNamespaceFmt    = "/apis/zalando.org/v1/namespaces/%s/routegroups"
-	ServicesNamespaceFmt       = "/api/v1/namespaces/%s/services"
-	EndpointsNamespaceFmt      = "/api/v1/namespaces/%s/endpoints"
-	EndpointSlicesNamespaceFmt = "/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices"
-	SecretsNamespaceFmt        = "/api/v1/namespaces/%s/secrets"
-	serviceAccountDir          = "/var/run/secrets/kubernetes.io/serviceaccount/"
-	serviceAccountTokenKey     = "token"
-	serviceAccountRootCAKey    = "ca.crt"
-	labelSelectorFmt           = "%s=%s"
-	labelSelectorQueryFmt      = "?labelSelector=%s"
+	ingressClassKey             = "kubernetes.io/ingress.class"
+	IngressesV1ClusterURI       = "/apis/networking.k8s.io/v1/ingresses"
+	ZalandoResourcesClusterURI  = "/apis/zalando.org/v1"
+	RouteGroupsName             = "routegroups"
+	RouteGroupsClusterURI       = "/apis/zalando.org/v1/routegroups"
+	routeGroupClassKey          = "zalando.org/routegroup.class"
+	ServicesClusterURI          = "/api/v1/services"
+	EndpointsClusterURI         = "/api/v1/endpoints"
+	EndpointSlicesClusterURI    = "/apis/discovery.k8s.io/v1/endpointslices"
+	SecretsClusterURI           = "/api/v1/secrets"
+	defaultKubernetesURL        = "http://localhost:8001"
+	IngressesV1NamespaceFmt     = "/apis/networking.k8s.io/v1/namespaces/%s/ingresses"
+	RouteGroupsNamespaceFmt     = "/apis/zalando.org/v1/namespaces/%s/routegroups"
+	ServicesNamespaceFmt        = "/api/v1/namespaces/%s/services"
+	EndpointsNamespaceFmt       = "/api/v1/namespaces/%s/endpoints"
+	EndpointSlicesNamespaceFmt  = "/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices"
+	SecretsNamespaceFmt         = "/api/v1/namespaces/%s/secrets"
+	GatewayAPIClusterURI        = "/apis/gateway.networking.k8s.io/v1"
+	GatewayClassesName          = "gatewayclasses"
+	GatewayClassesClusterURI    = "/apis/gateway.networking.k8s.io/v1/gatewayclasses"
+	GatewaysClusterURI          = "/apis/gateway.networking.k8s.io/v1/gateways"
+	HTTPRoutesClusterURI        = "/apis/gateway.networking.k8s.io/v1/httproutes"
+	ReferenceGrantsClusterURI   = "/apis/gateway.networking.k8s.io/v1beta1/referencegrants"
+	GatewaysNamespaceFmt        = "/apis/gateway.networking.k8s.io/v1/namespaces/%s/gateways"
+	HTTPRoutesNamespaceFmt      = "/apis/gateway.networking.k8s.io/v1/namespaces/%s/httproutes"
+	ReferenceGrantsNamespaceFmt = "/apis/gateway.networking.k8s.io/v1beta1/namespaces/%s/referencegrants"
+	serviceAccountDir           = "/var/run/secrets/kubernetes.io/serviceaccount/"
+	serviceAccountTokenKey      = "token"
+	serviceAccountRootCAKey     = "ca.crt"
+	labelSelectorFmt            = "%s=%s"
+	labelSelectorQueryFmt       = "?labelSelector=%s"
+)
+
+const (
+	RatelimitPoliciesClusterURI   = "/apis/zalando.org/v1/ratelimitpolicies"
+	AuthPoliciesClusterURI        = "/apis/zalando.org/v1/authpolicies"
+	RatelimitPoliciesNamespaceFmt = "/apis/zalando.org/v1/namespaces/%s/ratelimitpolicies"
+	AuthPoliciesNamespaceFmt      = "/apis/zalando.org/v1/namespaces/%s/authpolicies"
 )
 
 const RouteGroupsNotInstalledMessage = `RouteGroups CRD is not installed in the cluster.
 See: https://opensource.zalando.com/skipper/kubernetes/routegroups/#installation`
 
+const GatewayAPINotInstalledMessage = `Gateway API CRDs are not installed in the cluster.
+See: https://opensource.zalando.com/skipper/kubernetes/gateway-api/#installation`
+
 type clusterClient struct {
 	ingressesURI        string
 	routeGroupsURI      string
@@ -59,10 +79,17 @@ type clusterClient struct {
 	endpointsURI        string
 	endpointSlicesURI   string
 	secretsURI          string
+	gatewaysURI         string
+	httpRoutesURI       string
+	referenceGrantsURI  string
+	ratelimitPolicyURI  string
+	authPolicyURI       string
 	tokenProvider       secrets.SecretsProvider
 	tokenFile           string
+	token               string
 	apiURL              string
 	certificateRegistry *certregistry.CertRegistry
+	secretsMap          *secrets.SecretsMap
 
 	routeGroupClass          *regexp.Regexp
 	ingressClass             *regexp.Regexp
@@ -77,11 +104,26 @@ type clusterClient struct {
 	secretsLabelSelectors        string
 	routeGroupsLabelSelectors    string
 
-	enableEndpointSlices bool
+	enableEndpointSlices   bool
+	enableGatewayAPI       bool
+	crossNamespaceBackends bool
+	enablePolicies         bool
+	gatewayControllerName  string
 
 	loggedMissingRouteGroups bool
+	loggedMissingGatewayAPI  bool
 	routeGroupValidator      *definitions.RouteGroupValidator
 	ingressValidator         *definitions.IngressV1Validator
+
+	// watches is set when the resources are watched instead of
+	// listed on every poll
+	watches *watches
+
+	// remoteClusters are the clients of the clusters referenced by
+	// the RouteGroup service backends, and remoteStates their last
+	// successfully fetched states
+	remoteClusters map[string]*clusterClient
+	remoteStates   map[string]*clusterState
 }
 
 var (
@@ -105,6 +147,13 @@ func buildHTTPClient(certFilePath string, inCluster bool, quit <-chan struct{})
 		return nil, errInvalidCertificate
 	}
 
+	return newHTTPClient(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    certPool,
+	}, quit), nil
+}
+
+func newHTTPClient(tlsConfig *tls.Config, quit <-chan struct{}) *http.Client {
 	transport := &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   10 * time.Second,
@@ -116,10 +165,7 @@ func buildHTTPClient(certFilePath string, inCluster bool, quit <-chan struct{})
 		ExpectContinueTimeout: 30 * time.Second,
 		MaxIdleConns:          5,
 		MaxIdleConnsPerHost:   5,
-		TLSClientConfig: &tls.Config{
-			MinVersion: tls.VersionTLS12,
-			RootCAs:    certPool,
-		},
+		TLSClientConfig:       tlsConfig,
 	}
 
 	// regularly force closing idle connections
@@ -138,7 +184,7 @@ func buildHTTPClient(certFilePath string, inCluster bool, quit <-chan struct{})
 
 	return &http.Client{
 		Transport: transport,
-	}, nil
+	}
 }
 
 func newClusterClient(o Options, apiURL, ingCls, rgCls string, quit <-chan struct{}) (*clusterClient, error) {
@@ -164,6 +210,11 @@ func newClusterClient(o Options, apiURL, ingCls, rgCls string, quit <-chan struc
 		endpointsURI:                 EndpointsClusterURI,
 		endpointSlicesURI:            EndpointSlicesClusterURI,
 		secretsURI:                   SecretsClusterURI,
+		gatewaysURI:                  GatewaysClusterURI,
+		httpRoutesURI:                HTTPRoutesClusterURI,
+		referenceGrantsURI:           ReferenceGrantsClusterURI,
+		ratelimitPolicyURI:           RatelimitPoliciesClusterURI,
+		authPolicyURI:                AuthPoliciesClusterURI,
 		ingressClass:                 ingClsRx,
 		ingressLabelSelectors:        toLabelSelectorQuery(o.IngressLabelSelectors),
 		servicesLabelSelectors:       toLabelSelectorQuery(o.ServicesLabelSelectors),
@@ -175,9 +226,14 @@ func newClusterClient(o Options, apiURL, ingCls, rgCls string, quit <-chan struc
 		httpClient:                   httpClient,
 		apiURL:                       apiURL,
 		certificateRegistry:          o.CertificateRegistry,
+		secretsMap:                   o.SecretsMap,
 		routeGroupValidator:          &definitions.RouteGroupValidator{EnableAdvancedValidation: false},
 		ingressValidator:             &definitions.IngressV1Validator{EnableAdvancedValidation: false},
 		enableEndpointSlices:         o.KubernetesEnableEndpointslices,
+		enableGatewayAPI:             o.EnableGatewayAPI,
+		crossNamespaceBackends:       o.EnableRouteGroupCrossNamespaceBackends,
+		enablePolicies:               o.EnablePolicies,
+		gatewayControllerName:        o.GatewayControllerName,
 		zone:                         o.TopologyZone,
 		ingressStatusFromService:     o.IngressStatusFromService,
 	}
@@ -206,6 +262,74 @@ func newClusterClient(o Options, apiURL, ingCls, rgCls string, quit <-chan struc
 		c.setNamespace(o.KubernetesNamespace)
 	}
 
+	if o.EnableWatch {
+		c.watches = newWatches(c, quit)
+	}
+
+	for name, kubeconfig := range o.RemoteClusters {
+		rc, err := newRemoteClusterClient(o, kubeconfig, quit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client of remote cluster %s: %w", name, err)
+		}
+
+		if c.remoteClusters == nil {
+			c.remoteClusters = make(map[string]*clusterClient)
+			c.remoteStates = make(map[string]*clusterState)
+		}
+
+		c.remoteClusters[name] = rc
+	}
+
+	return c, nil
+}
+
+// newRemoteClusterClient creates the client of a remote cluster from its
+// kubeconfig. The client loads only the resources needed to resolve the
+// RouteGroup service backends.
+func newRemoteClusterClient(o Options, kubeconfig string, quit <-chan struct{}) (*clusterClient, error) {
+	a, err := loadKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &clusterClient{
+		servicesURI:                  ServicesClusterURI,
+		endpointsURI:                 EndpointsClusterURI,
+		endpointSlicesURI:            EndpointSlicesClusterURI,
+		referenceGrantsURI:           ReferenceGrantsClusterURI,
+		servicesLabelSelectors:       toLabelSelectorQuery(o.ServicesLabelSelectors),
+		endpointsLabelSelectors:      toLabelSelectorQuery(o.EndpointsLabelSelectors),
+		endpointSlicesLabelSelectors: toLabelSelectorQuery(o.EndpointSlicesLabelSelectors),
+		httpClient:                   newHTTPClient(a.tlsConfig, quit),
+		apiURL:                       strings.TrimSuffix(a.server, "/"),
+		token:                        a.token,
+		enableEndpointSlices:         o.KubernetesEnableEndpointslices,
+		crossNamespaceBackends:       o.EnableRouteGroupCrossNamespaceBackends,
+		zone:                         o.TopologyZone,
+	}
+
+	if a.tokenFile != "" {
+		c.tokenProvider = secrets.NewSecretPaths(time.Minute)
+		c.tokenFile = a.tokenFile
+		if err := c.tokenProvider.Add(c.tokenFile); err != nil {
+			c.tokenProvider.Close()
+			return nil, fmt.Errorf("failed to add secret %s: %w", c.tokenFile, err)
+		}
+
+		go func() {
+			<-quit
+			c.tokenProvider.Close()
+		}()
+	}
+
+	if o.KubernetesNamespace != "" {
+		c.setNamespace(o.KubernetesNamespace)
+	}
+
+	if o.EnableWatch {
+		c.watches = newWatches(c, quit)
+	}
+
 	return c, nil
 }
 
@@ -240,6 +364,11 @@ func (c *clusterClient) setNamespace(namespace string) {
 	c.endpointsURI = fmt.Sprintf(EndpointsNamespaceFmt, namespace)
 	c.endpointSlicesURI = fmt.Sprintf(EndpointSlicesNamespaceFmt, namespace)
 	c.secretsURI = fmt.Sprintf(SecretsNamespaceFmt, namespace)
+	c.gatewaysURI = fmt.Sprintf(GatewaysNamespaceFmt, namespace)
+	c.httpRoutesURI = fmt.Sprintf(HTTPRoutesNamespaceFmt, namespace)
+	c.referenceGrantsURI = fmt.Sprintf(ReferenceGrantsNamespaceFmt, namespace)
+	c.ratelimitPolicyURI = fmt.Sprintf(RatelimitPoliciesNamespaceFmt, namespace)
+	c.authPolicyURI = fmt.Sprintf(AuthPoliciesNamespaceFmt, namespace)
 }
 
 func (c *clusterClient) createRequest(uri string, body io.Reader) (*http.Request, error) {
@@ -258,6 +387,8 @@ func (c *clusterClient) createRequestWithMethod(method, uri string, body io.Read
 			return nil, fmt.Errorf("secret not found: %v", c.tokenFile)
 		}
 		req.Header.Set("Authorization", "Bearer "+string(token))
+	} else if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
 	}
 
 	return req, nil
@@ -328,6 +459,83 @@ func (c *clusterClient) getJSON(uri string, a interface{}) error {
 	return err
 }
 
+// listJSON loads a resource list. When watching is enabled, the list is
+// decoded from the watched resources, and it is requested from the API
+// server only when the watch is not in sync.
+func (c *clusterClient) listJSON(uri string, a interface{}) error {
+	if c.watches == nil {
+		return c.getJSON(uri, a)
+	}
+
+	w, err := c.watches.get(uri)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := w.decode(a); ok {
+		return err
+	}
+
+	return c.getJSON(uri, a)
+}
+
+// hasChanges tells whether the cluster state may have changed since the
+// last call. Without watching, it is always true.
+func (c *clusterClient) hasChanges() bool {
+	services, other := c.changes()
+	return other || len(services) > 0
+}
+
+// changes returns the services, whose endpoints changed since the last
+// call, and whether any other resources may have changed. Without
+// watching, the other resources are always considered changed.
+func (c *clusterClient) changes() (map[definitions.ResourceID]bool, bool) {
+	other := c.watches == nil
+	for _, rc := range c.remoteClusters {
+		// all checked to reset their changes
+		if rc.hasChanges() {
+			other = true
+		}
+	}
+
+	if c.watches == nil {
+		return nil, other
+	}
+
+	services := make(map[definitions.ResourceID]bool)
+	for uri, wc := range c.watches.takeChanges() {
+		var service func(*definitions.Metadata) definitions.ResourceID
+		switch uri {
+		case c.endpointsURI + c.endpointsLabelSelectors:
+			service = func(m *definitions.Metadata) definitions.ResourceID {
+				return m.ToResourceID()
+			}
+		case c.endpointSlicesURI + c.endpointSlicesLabelSelectors:
+			service = func(m *definitions.Metadata) definitions.ResourceID {
+				return newResourceID(m.Namespace, m.Labels[endpointSliceServiceNameLabel])
+			}
+		}
+
+		if service == nil || wc.relisted {
+			other = true
+			continue
+		}
+
+		for _, m := range wc.objects {
+			services[service(m)] = true
+		}
+	}
+
+	// the ingress status may be set from the endpoints of a service
+	if c.ingressStatusFromService != "" {
+		if ns, name, err := parseNamespaceName(c.ingressStatusFromService); err != nil || services[newResourceID(ns, name)] {
+			other = true
+		}
+	}
+
+	return services, other
+}
+
 func (c *clusterClient) clusterHasRouteGroups() (bool, error) {
 	var crl ClusterResourceList
 	if err := c.getJSON(ZalandoResourcesClusterURI, &crl); err != nil { // it probably should bounce once
@@ -343,6 +551,21 @@ func (c *clusterClient) clusterHasRouteGroups() (bool, error) {
 	return false, nil
 }
 
+func (c *clusterClient) clusterHasGatewayAPI() (bool, error) {
+	var crl ClusterResourceList
+	if err := c.getJSON(GatewayAPIClusterURI, &crl); err != nil {
+		return false, err
+	}
+
+	for _, cr := range crl.Items {
+		if cr.Name == GatewayClassesName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (c *clusterClient) ingressClassMismatch(m *definitions.Metadata) bool {
 	// No Metadata is the same as no annotations for us
 	if m != nil {
@@ -393,7 +616,7 @@ func sortByMetadata(slice interface{}, getMetadata func(int) *definitions.Metada
 
 func (c *clusterClient) loadIngressesV1() ([]*definitions.IngressV1Item, error) {
 	var il definitions.IngressV1List
-	if err := c.getJSON(c.ingressesURI+c.ingressLabelSelectors, &il); err != nil {
+	if err := c.listJSON(c.ingressesURI+c.ingressLabelSelectors, &il); err != nil {
 		log.Debugf("requesting all ingresses failed: %v", err)
 		return nil, err
 	}
@@ -417,29 +640,48 @@ func (c *clusterClient) loadIngressesV1() ([]*definitions.IngressV1Item, error)
 }
 
 func (c *clusterClient) LoadRouteGroups() ([]*definitions.RouteGroupItem, error) {
+	rgs, _, err := c.loadRouteGroups()
+	return rgs, err
+}
+
+// invalidRouteGroup is a RouteGroup of the configured class, that failed
+// the validation.
+type invalidRouteGroup struct {
+	item *definitions.RouteGroupItem
+	err  error
+}
+
+// loadRouteGroups loads the valid RouteGroups of the configured class,
+// and the invalid ones with their validation errors.
+func (c *clusterClient) loadRouteGroups() ([]*definitions.RouteGroupItem, []*invalidRouteGroup, error) {
 	var rgl definitions.RouteGroupList
-	if err := c.getJSON(c.routeGroupsURI+c.routeGroupsLabelSelectors, &rgl); err != nil {
-		return nil, err
+	if err := c.listJSON(c.routeGroupsURI+c.routeGroupsLabelSelectors, &rgl); err != nil {
+		return nil, nil, err
 	}
 	log.Debugf("all routegroups received: %d", len(rgl.Items))
 
+	var invalid []*invalidRouteGroup
 	rgs := make([]*definitions.RouteGroupItem, 0, len(rgl.Items))
 	for _, i := range rgl.Items {
-		// Validate RouteGroup item.
-		if err := c.routeGroupValidator.Validate(i); err != nil {
-			log.Errorf("[routegroup] %v", err)
-			continue
-		}
-
 		// Check the RouteGroup has a valid class annotation.
 		// Not defined, or empty are ok too.
-		if i.Metadata != nil {
+		if i != nil && i.Metadata != nil {
 			cls, ok := i.Metadata.Annotations[routeGroupClassKey]
 			if ok && cls != "" && !c.routeGroupClass.MatchString(cls) {
 				continue
 			}
 		}
 
+		// Validate RouteGroup item.
+		if err := c.routeGroupValidator.Validate(i); err != nil {
+			log.Errorf("[routegroup] %v", err)
+			if i != nil && i.Metadata != nil && i.Metadata.Name != "" && i.Metadata.Namespace != "" {
+				invalid = append(invalid, &invalidRouteGroup{item: i, err: err})
+			}
+
+			continue
+		}
+
 		rgs = append(rgs, i)
 	}
 
@@ -447,12 +689,152 @@ func (c *clusterClient) LoadRouteGroups() ([]*definitions.RouteGroupItem, error)
 
 	sortByMetadata(rgs, func(i int) *definitions.Metadata { return rgs[i].Metadata })
 
-	return rgs, nil
+	return rgs, invalid, nil
+}
+
+// loadGatewayClasses loads the cluster scoped gateway classes, also when
+// the client is limited to a namespace.
+func (c *clusterClient) loadGatewayClasses() ([]*definitions.GatewayClassItem, error) {
+	var gcl definitions.GatewayClassList
+	if err := c.listJSON(GatewayClassesClusterURI, &gcl); err != nil {
+		return nil, err
+	}
+
+	gcs := make([]*definitions.GatewayClassItem, 0, len(gcl.Items))
+	for _, i := range gcl.Items {
+		if i.Metadata == nil || i.Metadata.Name == "" || i.Spec == nil {
+			continue
+		}
+
+		if i.Spec.ControllerName == c.gatewayControllerName {
+			gcs = append(gcs, i)
+		}
+	}
+
+	sortByMetadata(gcs, func(i int) *definitions.Metadata { return gcs[i].Metadata })
+	return gcs, nil
+}
+
+func (c *clusterClient) loadGateways() ([]*definitions.GatewayItem, error) {
+	var gl definitions.GatewayList
+	if err := c.listJSON(c.gatewaysURI, &gl); err != nil {
+		return nil, err
+	}
+
+	gws := make([]*definitions.GatewayItem, 0, len(gl.Items))
+	for _, i := range gl.Items {
+		if i.Metadata == nil || i.Metadata.Name == "" || i.Spec == nil {
+			continue
+		}
+
+		gws = append(gws, i)
+	}
+
+	sortByMetadata(gws, func(i int) *definitions.Metadata { return gws[i].Metadata })
+	return gws, nil
+}
+
+func (c *clusterClient) loadHTTPRoutes() ([]*definitions.HTTPRouteItem, error) {
+	var hl definitions.HTTPRouteList
+	if err := c.listJSON(c.httpRoutesURI, &hl); err != nil {
+		return nil, err
+	}
+
+	hrs := make([]*definitions.HTTPRouteItem, 0, len(hl.Items))
+	for _, i := range hl.Items {
+		if i.Metadata == nil || i.Metadata.Name == "" || i.Spec == nil {
+			continue
+		}
+
+		hrs = append(hrs, i)
+	}
+
+	sortByMetadata(hrs, func(i int) *definitions.Metadata { return hrs[i].Metadata })
+	return hrs, nil
+}
+
+// loadReferenceGrants loads the reference grants. Clusters without the
+// ReferenceGrant resource don't allow cross namespace references.
+func (c *clusterClient) loadReferenceGrants() ([]*definitions.ReferenceGrantItem, error) {
+	var rgl definitions.ReferenceGrantList
+	if err := c.listJSON(c.referenceGrantsURI, &rgl); errors.Is(err, errResourceNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	grants := make([]*definitions.ReferenceGrantItem, 0, len(rgl.Items))
+	for _, i := range rgl.Items {
+		if i.Metadata == nil || i.Metadata.Name == "" || i.Spec == nil {
+			continue
+		}
+
+		grants = append(grants, i)
+	}
+
+	return grants, nil
+}
+
+// loadPolicies loads the RatelimitPolicies and the AuthPolicies. Clusters
+// without the policy resources have no policies applied to the routes.
+func (c *clusterClient) loadPolicies() (*policies, error) {
+	var rl definitions.RatelimitPolicyList
+	if err := c.listJSON(c.ratelimitPolicyURI, &rl); err != nil && !errors.Is(err, errResourceNotFound) {
+		return nil, err
+	}
+
+	var auth definitions.AuthPolicyList
+	if err := c.listJSON(c.authPolicyURI, &auth); err != nil && !errors.Is(err, errResourceNotFound) {
+		return nil, err
+	}
+
+	var ratelimitPolicies []*definitions.RatelimitPolicyItem
+	for _, i := range rl.Items {
+		if i.Metadata != nil && i.Metadata.Name != "" {
+			ratelimitPolicies = append(ratelimitPolicies, i)
+		}
+	}
+
+	var authPolicies []*definitions.AuthPolicyItem
+	for _, i := range auth.Items {
+		if i.Metadata != nil && i.Metadata.Name != "" {
+			authPolicies = append(authPolicies, i)
+		}
+	}
+
+	return newPolicies(ratelimitPolicies, authPolicies), nil
+}
+
+func (c *clusterClient) loadGatewayAPI(state *clusterState) error {
+	hasGatewayAPI, err := c.clusterHasGatewayAPI()
+	if errors.Is(err, errResourceNotFound) || err == nil && !hasGatewayAPI {
+		c.logMissingGatewayAPIOnce()
+		return nil
+	} else if err != nil {
+		log.Errorf("Error while checking known Gateway API resource types: %v.", err)
+		return nil
+	}
+
+	c.loggedMissingGatewayAPI = false
+	if state.gatewayClasses, err = c.loadGatewayClasses(); err != nil {
+		return err
+	}
+
+	if state.gateways, err = c.loadGateways(); err != nil {
+		return err
+	}
+
+	if state.httpRoutes, err = c.loadHTTPRoutes(); err != nil {
+		return err
+	}
+
+	state.referenceGrants, err = c.loadReferenceGrants()
+	return err
 }
 
 func (c *clusterClient) loadServices() (map[definitions.ResourceID]*service, error) {
 	var services serviceList
-	if err := c.getJSON(c.servicesURI+c.servicesLabelSelectors, &services); err != nil {
+	if err := c.listJSON(c.servicesURI+c.servicesLabelSelectors, &services); err != nil {
 		log.Debugf("requesting all services failed: %v", err)
 		return nil, err
 	}
@@ -478,7 +860,7 @@ func (c *clusterClient) loadServices() (map[definitions.ResourceID]*service, err
 
 func (c *clusterClient) loadSecrets() (map[definitions.ResourceID]*secret, error) {
 	var secrets secretList
-	if err := c.getJSON(c.secretsURI+c.secretsLabelSelectors, &secrets); err != nil {
+	if err := c.listJSON(c.secretsURI+c.secretsLabelSelectors, &secrets); err != nil {
 		log.Debugf("requesting all secrets failed: %v", err)
 		return nil, err
 	}
@@ -496,9 +878,26 @@ func (c *clusterClient) loadSecrets() (map[definitions.ResourceID]*secret, error
 	return result, nil
 }
 
+// secretsData returns the decoded data of the secrets with the names in
+// the format namespace/name/key.
+func secretsData(secrets map[definitions.ResourceID]*secret) map[string][]byte {
+	result := make(map[string][]byte)
+	for id, s := range secrets {
+		for key, value := range s.Data {
+			data, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				log.Errorf("Failed to decode key %s of secret %s/%s: %v", key, id.Namespace, id.Name, err)
+				continue
+			}
+			result[id.Namespace+"/"+id.Name+"/"+key] = data
+		}
+	}
+	return result
+}
+
 func (c *clusterClient) loadEndpoints() (map[definitions.ResourceID]*endpoint, error) {
 	var endpoints endpointList
-	if err := c.getJSON(c.endpointsURI+c.endpointsLabelSelectors, &endpoints); err != nil {
+	if err := c.listJSON(c.endpointsURI+c.endpointsLabelSelectors, &endpoints); err != nil {
 		log.Debugf("requesting all endpoints failed: %v", err)
 		return nil, err
 	}
@@ -524,7 +923,7 @@ func (c *clusterClient) loadEndpoints() (map[definitions.ResourceID]*endpoint, e
 // given service, check [endpointSlice.ToResourceID].
 func (c *clusterClient) loadEndpointSlices() (map[definitions.ResourceID]*skipperEndpointSlice, error) {
 	var endpointSlices endpointSliceList
-	if err := c.getJSON(c.endpointSlicesURI+c.endpointSlicesLabelSelectors, &endpointSlices); err != nil {
+	if err := c.listJSON(c.endpointSlicesURI+c.endpointSlicesLabelSelectors, &endpointSlices); err != nil {
 		log.Debugf("requesting all endpointslices failed: %v", err)
 		return nil, err
 	}
@@ -639,6 +1038,15 @@ func (c *clusterClient) logMissingRouteGroupsOnce() {
 	log.Warn(RouteGroupsNotInstalledMessage)
 }
 
+func (c *clusterClient) logMissingGatewayAPIOnce() {
+	if c.loggedMissingGatewayAPI {
+		return
+	}
+
+	c.loggedMissingGatewayAPI = true
+	log.Warn(GatewayAPINotInstalledMessage)
+}
+
 func (c *clusterClient) fetchClusterState() (*clusterState, error) {
 	var (
 		err         error
@@ -649,14 +1057,17 @@ func (c *clusterClient) fetchClusterState() (*clusterState, error) {
 		return nil, err
 	}
 
-	var routeGroups []*definitions.RouteGroupItem
+	var (
+		routeGroups        []*definitions.RouteGroupItem
+		invalidRouteGroups []*invalidRouteGroup
+	)
 	if hasRouteGroups, err := c.clusterHasRouteGroups(); errors.Is(err, errResourceNotFound) {
 		c.logMissingRouteGroupsOnce()
 	} else if err != nil {
 		log.Errorf("Error while checking known resource types: %v.", err)
 	} else if hasRouteGroups {
 		c.loggedMissingRouteGroups = false
-		if routeGroups, err = c.LoadRouteGroups(); err != nil {
+		if routeGroups, invalidRouteGroups, err = c.loadRouteGroups(); err != nil {
 			return nil, err
 		}
 	}
@@ -669,31 +1080,48 @@ func (c *clusterClient) fetchClusterState() (*clusterState, error) {
 	state := &clusterState{
 		ingressesV1:          ingressesV1,
 		routeGroups:          routeGroups,
+		invalidRouteGroups:   invalidRouteGroups,
 		services:             services,
 		cachedEndpoints:      make(map[endpointID][]string),
 		cachedEndpointSlices: make(map[endpointID][]skipperEndpoint),
 		enableEndpointSlices: c.enableEndpointSlices,
 	}
 
-	if c.enableEndpointSlices {
-		state.endpointSlices, err = c.loadEndpointSlices()
-		if err != nil {
+	if c.enableGatewayAPI {
+		if err := c.loadGatewayAPI(state); err != nil {
 			return nil, err
 		}
-	} else {
-		state.endpoints, err = c.loadEndpoints()
-		if err != nil {
+	}
+
+	if c.crossNamespaceBackends && state.referenceGrants == nil {
+		if state.referenceGrants, err = c.loadReferenceGrants(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.enablePolicies {
+		if state.policies, err = c.loadPolicies(); err != nil {
 			return nil, err
 		}
 	}
 
-	if c.certificateRegistry != nil {
+	if err := c.loadEndpointsState(state); err != nil {
+		return nil, err
+	}
+
+	c.fetchRemoteClusterStates(state)
+
+	if c.certificateRegistry != nil || c.secretsMap != nil {
 		state.secrets, err = c.loadSecrets()
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if c.secretsMap != nil {
+		c.secretsMap.Update(secretsData(state.secrets))
+	}
+
 	if err := c.updateIngressesV1Status(state); err != nil {
 		log.Errorf("failed to update ingress status: %v", err)
 	}
@@ -701,6 +1129,96 @@ func (c *clusterClient) fetchClusterState() (*clusterState, error) {
 	return state, nil
 }
 
+func (c *clusterClient) loadEndpointsState(state *clusterState) error {
+	var err error
+	if c.enableEndpointSlices {
+		state.endpointSlices, err = c.loadEndpointSlices()
+	} else {
+		state.endpoints, err = c.loadEndpoints()
+	}
+
+	return err
+}
+
+// fetchEndpointsState returns a copy of the cluster state with the
+// current endpoints, when only the endpoints changed.
+func (c *clusterClient) fetchEndpointsState(state *clusterState) (*clusterState, error) {
+	next := &clusterState{
+		ingressesV1:          state.ingressesV1,
+		routeGroups:          state.routeGroups,
+		invalidRouteGroups:   state.invalidRouteGroups,
+		gatewayClasses:       state.gatewayClasses,
+		gateways:             state.gateways,
+		httpRoutes:           state.httpRoutes,
+		referenceGrants:      state.referenceGrants,
+		services:             state.services,
+		secrets:              state.secrets,
+		cachedEndpoints:      make(map[endpointID][]string),
+		cachedEndpointSlices: make(map[endpointID][]skipperEndpoint),
+		enableEndpointSlices: state.enableEndpointSlices,
+		remoteClusters:       state.remoteClusters,
+		policies:             state.policies,
+	}
+
+	if err := c.loadEndpointsState(next); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// fetchBackendState loads the state of a remote cluster, the services,
+// the endpoints and, when cross namespace backends are enabled, the
+// reference grants.
+func (c *clusterClient) fetchBackendState() (*clusterState, error) {
+	services, err := c.loadServices()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &clusterState{
+		services:             services,
+		cachedEndpoints:      make(map[endpointID][]string),
+		cachedEndpointSlices: make(map[endpointID][]skipperEndpoint),
+		enableEndpointSlices: c.enableEndpointSlices,
+	}
+
+	if c.crossNamespaceBackends {
+		if state.referenceGrants, err = c.loadReferenceGrants(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.loadEndpointsState(state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// fetchRemoteClusterStates sets the states of the remote clusters. When
+// a remote cluster is not reachable, its last fetched state is used, so
+// that its failure doesn't affect the routes of the other clusters.
+func (c *clusterClient) fetchRemoteClusterStates(state *clusterState) {
+	if len(c.remoteClusters) == 0 {
+		return
+	}
+
+	state.remoteClusters = make(map[string]*clusterState)
+	for name, rc := range c.remoteClusters {
+		rs, err := rc.fetchBackendState()
+		if err != nil {
+			log.Errorf("Failed to fetch the state of remote cluster %s: %v", name, err)
+		} else {
+			c.remoteStates[name] = rs
+		}
+
+		if rs, ok := c.remoteStates[name]; ok {
+			state.remoteClusters[name] = rs
+		}
+	}
+}
+
 func parseNamespaceName(resource string) (string, string, error) {
 	ns, name, ok := strings.Cut(resource, "/")
 	if !ok || ns == "" || name == "" {
@@ -860,3 +1378,320 @@ func (c *clusterClient) updateIngressesV1Status(state *clusterState) error {
 
 	return nil
 }
+
+// mergeConditions keeps the last transition time of the conditions, that
+// did not change their status.
+func mergeConditions(current, next []*definitions.Condition, now time.Time) {
+	for _, n := range next {
+		n.LastTransitionTime = now
+		for _, c := range current {
+			if c.Type == n.Type && c.Status == n.Status {
+				n.LastTransitionTime = c.LastTransitionTime
+				break
+			}
+		}
+	}
+}
+
+func statusEqual(current, next interface{}) bool {
+	c, err := json.Marshal(current)
+	if err != nil {
+		return false
+	}
+
+	n, err := json.Marshal(next)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(c, n)
+}
+
+func (c *clusterClient) patchStatus(uri string, status interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err
+	}
+
+	return c.patchJSON(uri+"/status", payload)
+}
+
+func gatewayAddresses(addresses []definitions.IngressLoadBalancerIngress) []*definitions.GatewayStatusAddress {
+	result := make([]*definitions.GatewayStatusAddress, 0, len(addresses))
+	for _, a := range addresses {
+		if a.IP != "" {
+			result = append(result, &definitions.GatewayStatusAddress{Type: "IPAddress", Value: a.IP})
+		} else if a.Hostname != "" {
+			result = append(result, &definitions.GatewayStatusAddress{Type: "Hostname", Value: a.Hostname})
+		}
+	}
+
+	return result
+}
+
+// updateGatewayAPIStatus updates the status of the Gateway API resources, when it
+// differs from the current one. The status of the HTTPRoute parents managed by
+// other controllers is preserved.
+func (c *clusterClient) updateGatewayAPIStatus(state *clusterState, status *gatewayAPIStatus) error {
+	if state == nil || status == nil {
+		return nil
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	var errs []error
+
+	for _, gc := range state.gatewayClasses {
+		next, ok := status.gatewayClasses[gc.Metadata.Name]
+		if !ok {
+			continue
+		}
+
+		current := gc.Status
+		if current == nil {
+			current = &definitions.GatewayClassStatus{}
+		}
+
+		mergeConditions(current.Conditions, next.Conditions, now)
+		if statusEqual(current, next) {
+			continue
+		}
+
+		if err := c.patchStatus(GatewayClassesClusterURI+"/"+gc.Metadata.Name, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var addresses []*definitions.GatewayStatusAddress
+	if c.ingressStatusFromService != "" {
+		a, err := c.ingressStatusAddressesFromService(state)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			addresses = gatewayAddresses(a)
+		}
+	}
+
+	for _, gw := range state.gateways {
+		namespace := namespaceString(gw.Metadata.Namespace)
+		next, ok := status.gateways[newResourceID(namespace, gw.Metadata.Name)]
+		if !ok {
+			continue
+		}
+
+		current := gw.Status
+		if current == nil {
+			current = &definitions.GatewayStatus{}
+		}
+
+		next.Addresses = current.Addresses
+		if c.ingressStatusFromService != "" {
+			next.Addresses = addresses
+		}
+
+		mergeConditions(current.Conditions, next.Conditions, now)
+		for _, nl := range next.Listeners {
+			var conditions []*definitions.Condition
+			for _, cl := range current.Listeners {
+				if cl.Name == nl.Name {
+					conditions = cl.Conditions
+					break
+				}
+			}
+
+			mergeConditions(conditions, nl.Conditions, now)
+		}
+
+		if statusEqual(current, next) {
+			continue
+		}
+
+		if err := c.patchStatus(fmt.Sprintf(GatewaysNamespaceFmt, namespace)+"/"+gw.Metadata.Name, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, hr := range state.httpRoutes {
+		namespace := namespaceString(hr.Metadata.Namespace)
+		parents, ok := status.httpRoutes[newResourceID(namespace, hr.Metadata.Name)]
+		if !ok {
+			continue
+		}
+
+		current := hr.Status
+		if current == nil {
+			current = &definitions.HTTPRouteStatus{}
+		}
+
+		next := &definitions.HTTPRouteStatus{}
+		for _, cp := range current.Parents {
+			if cp.ControllerName != c.gatewayControllerName {
+				next.Parents = append(next.Parents, cp)
+			}
+		}
+
+		for _, np := range parents {
+			var conditions []*definitions.Condition
+			for _, cp := range current.Parents {
+				if cp.ControllerName == c.gatewayControllerName && statusEqual(cp.ParentRef, np.ParentRef) {
+					conditions = cp.Conditions
+					break
+				}
+			}
+
+			mergeConditions(conditions, np.Conditions, now)
+			next.Parents = append(next.Parents, np)
+		}
+
+		if statusEqual(current, next) {
+			continue
+		}
+
+		if err := c.patchStatus(fmt.Sprintf(HTTPRoutesNamespaceFmt, namespace)+"/"+hr.Metadata.Name, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reasonInvalidRoutes is the reason of the Programmed condition of the
+// RouteGroups with invalid routes.
+const reasonInvalidRoutes = "InvalidRoutes"
+
+// routeGroupStatus returns the next status of a valid RouteGroup, with the
+// errors of its invalid routes.
+func routeGroupStatus(rg *definitions.RouteGroupItem, status *routeGroupsStatus, invalidRoutes map[string]string) *definitions.RouteGroupStatus {
+	id := rg.Metadata.ToResourceID()
+	if err := status.errors[id]; err != nil {
+		return &definitions.RouteGroupStatus{Conditions: []*definitions.Condition{
+			newCondition(conditionAccepted, false, reasonInvalid, err.Error(), rg.Metadata),
+		}}
+	}
+
+	next := &definitions.RouteGroupStatus{}
+	routeIDs := status.routeIDs[id]
+	for _, routeID := range routeIDs {
+		if err, ok := invalidRoutes[routeID]; ok {
+			next.RouteErrors = append(next.RouteErrors, &definitions.RouteGroupRouteError{RouteID: routeID, Error: err})
+		}
+	}
+
+	sort.Slice(next.RouteErrors, func(i, j int) bool {
+		return next.RouteErrors[i].RouteID < next.RouteErrors[j].RouteID
+	})
+
+	programmed := newCondition(conditionProgrammed, true, reasonProgrammed, "All routes are valid", rg.Metadata)
+	if len(next.RouteErrors) > 0 {
+		programmed = newCondition(
+			conditionProgrammed,
+			false,
+			reasonInvalidRoutes,
+			fmt.Sprintf("%d of %d routes are invalid", len(next.RouteErrors), len(routeIDs)),
+			rg.Metadata,
+		)
+	}
+
+	next.Conditions = []*definitions.Condition{
+		newCondition(conditionAccepted, true, reasonAccepted, "RouteGroup accepted", rg.Metadata),
+		programmed,
+	}
+
+	return next
+}
+
+// updateRouteGroupsStatus updates the status of the RouteGroups, when it
+// differs from the current one. The load balancer addresses are only
+// updated when they are taken from a Service, otherwise they are left to
+// other controllers.
+func (c *clusterClient) updateRouteGroupsStatus(state *clusterState, status *routeGroupsStatus, invalidRoutes map[string]string) error {
+	if state == nil || status == nil {
+		return nil
+	}
+
+	var (
+		errs         []error
+		loadBalancer *definitions.RouteGroupLoadBalancer
+	)
+
+	if c.ingressStatusFromService != "" {
+		addresses, err := c.ingressStatusAddressesFromService(state)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			loadBalancer = &definitions.RouteGroupLoadBalancer{
+				RouteGroup: append([]definitions.IngressLoadBalancerIngress{}, addresses...),
+			}
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	patch := func(rg *definitions.RouteGroupItem, next *definitions.RouteGroupStatus) {
+		var current definitions.RouteGroupStatus
+		if rg.Status != nil {
+			current = *rg.Status
+		}
+
+		if loadBalancer == nil {
+			current.LoadBalancer = nil
+		} else {
+			next.LoadBalancer = loadBalancer
+		}
+
+		mergeConditions(current.Conditions, next.Conditions, now)
+		if statusEqual(&current, next) {
+			return
+		}
+
+		uri := fmt.Sprintf(RouteGroupsNamespaceFmt, namespaceString(rg.Metadata.Namespace)) + "/" + rg.Metadata.Name
+		if err := c.patchStatus(uri, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, rg := range state.routeGroups {
+		patch(rg, routeGroupStatus(rg, status, invalidRoutes))
+	}
+
+	for _, i := range state.invalidRouteGroups {
+		patch(i.item, &definitions.RouteGroupStatus{Conditions: []*definitions.Condition{
+			newCondition(conditionAccepted, false, reasonInvalid, i.err.Error(), i.item.Metadata),
+		}})
+	}
+
+	return errors.Join(errs...)
+}
+
+// updatePoliciesStatus updates the status of the policies, when it differs
+// from the current one. The routeIDs are the IDs of the final routes.
+func (c *clusterClient) updatePoliciesStatus(state *clusterState, routeIDs map[string]bool) error {
+	if state == nil {
+		return nil
+	}
+
+	var errs []error
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, p := range state.policies.all() {
+		var current definitions.PolicyStatus
+		if p.status != nil {
+			current = *p.status
+		}
+
+		next := policyStatus(p, routeIDs)
+		mergeConditions(current.Conditions, next.Conditions, now)
+		if statusEqual(&current, next) {
+			continue
+		}
+
+		uriFmt := RatelimitPoliciesNamespaceFmt
+		if p.kind == definitions.AuthPolicyKind {
+			uriFmt = AuthPoliciesNamespaceFmt
+		}
+
+		uri := fmt.Sprintf(uriFmt, namespaceString(p.metadata.Namespace)) + "/" + p.metadata.Name
+		if err := c.patchStatus(uri, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
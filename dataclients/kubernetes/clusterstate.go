@@ -8,12 +8,18 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
+	"github.com/zalando/skipper/eskip"
 )
 
 type clusterState struct {
 	mu                   sync.Mutex
 	ingressesV1          []*definitions.IngressV1Item
 	routeGroups          []*definitions.RouteGroupItem
+	invalidRouteGroups   []*invalidRouteGroup
+	gatewayClasses       []*definitions.GatewayClassItem
+	gateways             []*definitions.GatewayItem
+	httpRoutes           []*definitions.HTTPRouteItem
+	referenceGrants      []*definitions.ReferenceGrantItem
 	services             map[definitions.ResourceID]*service
 	endpoints            map[definitions.ResourceID]*endpoint
 	endpointSlices       map[definitions.ResourceID]*skipperEndpointSlice
@@ -21,6 +27,90 @@ type clusterState struct {
 	cachedEndpoints      map[endpointID][]string
 	cachedEndpointSlices map[endpointID][]skipperEndpoint
 	enableEndpointSlices bool
+
+	// remoteClusters contains the states of the remote clusters
+	// referenced by the RouteGroup service backends
+	remoteClusters map[string]*clusterState
+
+	// policies are the RatelimitPolicies and AuthPolicies applied to
+	// the routes, nil when the policies are not enabled
+	policies *policies
+
+	// endpointsLookups are the lookups of the endpoints of the
+	// converted routes
+	endpointsLookups map[*eskip.Route]*endpointsLookup
+}
+
+// endpointsLookup looks up the endpoints of a route again, to update the
+// endpoints of the route, when only the endpoints of the service changed.
+type endpointsLookup struct {
+	service   definitions.ResourceID
+	endpoints func(*clusterState) []skipperEndpoint
+}
+
+// setEndpointsLookup records the lookup of the endpoints of a route, that
+// is created from the endpoints of a service.
+func (state *clusterState) setEndpointsLookup(r *eskip.Route, l *endpointsLookup) {
+	if l == nil {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.endpointsLookups == nil {
+		state.endpointsLookups = make(map[*eskip.Route]*endpointsLookup)
+	}
+
+	state.endpointsLookups[r] = l
+}
+
+// copyEndpointsLookup records the lookup of the endpoints of a route for
+// its copy with the same backend.
+func (state *clusterState) copyEndpointsLookup(from, to *eskip.Route) {
+	if to == nil {
+		return
+	}
+
+	state.mu.Lock()
+	l := state.endpointsLookups[from]
+	state.mu.Unlock()
+	state.setEndpointsLookup(to, l)
+}
+
+// routeEndpointsLookups returns the lookups of the endpoints of the routes
+// by route ID.
+func (state *clusterState) routeEndpointsLookups(routes map[string]*eskip.Route) map[string]*endpointsLookup {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	lookups := make(map[string]*endpointsLookup)
+	for id, r := range routes {
+		if l, ok := state.endpointsLookups[r]; ok {
+			lookups[id] = l
+		}
+	}
+
+	return lookups
+}
+
+// newLBEndpoints creates the LB endpoints of a route.
+func newLBEndpoints(eps []skipperEndpoint) []*eskip.LBEndpoint {
+	lbeps := make([]*eskip.LBEndpoint, len(eps))
+	for i, ep := range eps {
+		lbeps[i] = &eskip.LBEndpoint{Address: ep.Address, Zone: ep.Zone}
+	}
+
+	return lbeps
+}
+
+// endpointsFromAddresses converts the endpoint addresses without zone.
+func endpointsFromAddresses(addresses []string) []skipperEndpoint {
+	eps := make([]skipperEndpoint, len(addresses))
+	for i, a := range addresses {
+		eps[i] = skipperEndpoint{Address: a}
+	}
+
+	return eps
 }
 
 func (state *clusterState) getService(namespace, name string) (*service, error) {
@@ -178,7 +268,7 @@ func (state *clusterState) GetEndpointsByTarget(namespace, name, protocol, schem
 }
 
 // GetEndpointSlicesByTarget returns the skipper endpointslices for kubernetes endpointslices.
-func (state *clusterState) GetEndpointSlicesByTarget(zone, namespace, name, protocol, scheme string, annotationSet bool, target *definitions.BackendPort, rgc *routeGroupContext) []skipperEndpoint {
+func (state *clusterState) GetEndpointSlicesByTarget(zone, namespace, name, protocol, scheme string, annotationSet bool, target *definitions.BackendPort, disableZoneAwareness bool) []skipperEndpoint {
 	epID := endpointID{
 		ResourceID: newResourceID(namespace, name),
 		Protocol:   protocol,
@@ -203,13 +293,23 @@ func (state *clusterState) GetEndpointSlicesByTarget(zone, namespace, name, prot
 		state.cachedEndpointSlices[epID] = targets
 	}
 
-	if rgc.disableZoneAwareness {
+	if disableZoneAwareness {
 		return targets
 	}
 
 	return filterByZone(zone, targets)
 }
 
+// getTargetEndpoints returns the endpoints of the service target port
+// from the endpointslices, when enabled, or from the endpoints.
+func (state *clusterState) getTargetEndpoints(zone, namespace, name, scheme string, annotationSet bool, target *definitions.BackendPort, disableZoneAwareness bool) []skipperEndpoint {
+	if state.enableEndpointSlices {
+		return state.GetEndpointSlicesByTarget(zone, namespace, name, "TCP", scheme, annotationSet, target, disableZoneAwareness)
+	}
+
+	return endpointsFromAddresses(state.GetEndpointsByTarget(namespace, name, "TCP", scheme, target))
+}
+
 func filterByZone(zone string, targets []skipperEndpoint) []skipperEndpoint {
 	if zone != "" {
 		var zoneTargets []skipperEndpoint
@@ -12,6 +12,7 @@ type Metadata struct {
 	Namespace       string            `json:"namespace"`
 	Name            string            `json:"name"`
 	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Generation      int64             `json:"generation,omitempty"`
 	Created         time.Time         `json:"creationTimestamp"`
 	Uid             string            `json:"uid"`
 	Annotations     map[string]string `json:"annotations"`
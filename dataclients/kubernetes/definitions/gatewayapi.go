@@ -0,0 +1,277 @@
+package definitions
+
+import (
+	"time"
+)
+
+// Gateway API resources, see https://gateway-api.sigs.k8s.io/reference/spec/.
+// Only the fields used by Skipper are defined.
+
+const (
+	GatewayAPIGroup = "gateway.networking.k8s.io"
+
+	GatewayKind        = "Gateway"
+	HTTPRouteKind      = "HTTPRoute"
+	ServiceKind        = "Service"
+	SecretKind         = "Secret"
+	ConditionTrue      = "True"
+	ConditionFalse     = "False"
+	PathMatchExact     = "Exact"
+	PathMatchPrefix    = "PathPrefix"
+	PathMatchRegexp    = "RegularExpression"
+	HeaderMatchExact   = "Exact"
+	HeaderMatchRegexp  = "RegularExpression"
+	ReplaceFullPath    = "ReplaceFullPath"
+	ReplacePrefixMatch = "ReplacePrefixMatch"
+
+	RequestHeaderModifierFilter  = "RequestHeaderModifier"
+	ResponseHeaderModifierFilter = "ResponseHeaderModifier"
+	RequestRedirectFilter        = "RequestRedirect"
+	URLRewriteFilter             = "URLRewrite"
+	RequestMirrorFilter          = "RequestMirror"
+
+	NamespacesFromSame = "Same"
+	NamespacesFromAll  = "All"
+)
+
+type GatewayClassList struct {
+	Items []*GatewayClassItem `json:"items"`
+}
+
+type GatewayClassItem struct {
+	Metadata *Metadata           `json:"metadata"`
+	Spec     *GatewayClassSpec   `json:"spec"`
+	Status   *GatewayClassStatus `json:"status,omitempty"`
+}
+
+type GatewayClassSpec struct {
+	ControllerName string `json:"controllerName"`
+}
+
+type GatewayClassStatus struct {
+	Conditions []*Condition `json:"conditions,omitempty"`
+}
+
+type GatewayList struct {
+	Items []*GatewayItem `json:"items"`
+}
+
+type GatewayItem struct {
+	Metadata *Metadata      `json:"metadata"`
+	Spec     *GatewaySpec   `json:"spec"`
+	Status   *GatewayStatus `json:"status,omitempty"`
+}
+
+type GatewaySpec struct {
+	GatewayClassName string             `json:"gatewayClassName"`
+	Listeners        []*GatewayListener `json:"listeners"`
+}
+
+type GatewayListener struct {
+	Name          string         `json:"name"`
+	Hostname      string         `json:"hostname,omitempty"`
+	Port          int            `json:"port"`
+	Protocol      string         `json:"protocol"`
+	TLS           *GatewayTLS    `json:"tls,omitempty"`
+	AllowedRoutes *AllowedRoutes `json:"allowedRoutes,omitempty"`
+}
+
+type GatewayTLS struct {
+	Mode            string             `json:"mode,omitempty"`
+	CertificateRefs []*ObjectReference `json:"certificateRefs,omitempty"`
+}
+
+type AllowedRoutes struct {
+	Namespaces *RouteNamespaces `json:"namespaces,omitempty"`
+	Kinds      []*RouteKind     `json:"kinds,omitempty"`
+}
+
+type RouteNamespaces struct {
+	From string `json:"from,omitempty"`
+}
+
+type RouteKind struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+}
+
+// ObjectReference is used for the certificate references of the
+// listeners and the backend references of the routes.
+type ObjectReference struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Port      int    `json:"port,omitempty"`
+}
+
+type GatewayStatus struct {
+	Addresses  []*GatewayStatusAddress `json:"addresses,omitempty"`
+	Conditions []*Condition            `json:"conditions,omitempty"`
+	Listeners  []*ListenerStatus       `json:"listeners,omitempty"`
+}
+
+type GatewayStatusAddress struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type ListenerStatus struct {
+	Name           string       `json:"name"`
+	SupportedKinds []*RouteKind `json:"supportedKinds"`
+	AttachedRoutes int          `json:"attachedRoutes"`
+	Conditions     []*Condition `json:"conditions"`
+}
+
+type HTTPRouteList struct {
+	Items []*HTTPRouteItem `json:"items"`
+}
+
+type HTTPRouteItem struct {
+	Metadata *Metadata        `json:"metadata"`
+	Spec     *HTTPRouteSpec   `json:"spec"`
+	Status   *HTTPRouteStatus `json:"status,omitempty"`
+}
+
+type HTTPRouteSpec struct {
+	ParentRefs []*ParentReference `json:"parentRefs,omitempty"`
+	Hostnames  []string           `json:"hostnames,omitempty"`
+	Rules      []*HTTPRouteRule   `json:"rules,omitempty"`
+}
+
+type ParentReference struct {
+	Group       string `json:"group,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name"`
+	SectionName string `json:"sectionName,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+type HTTPRouteRule struct {
+	Matches     []*HTTPRouteMatch  `json:"matches,omitempty"`
+	Filters     []*HTTPRouteFilter `json:"filters,omitempty"`
+	BackendRefs []*HTTPBackendRef  `json:"backendRefs,omitempty"`
+}
+
+type HTTPRouteMatch struct {
+	Path        *HTTPPathMatch    `json:"path,omitempty"`
+	Headers     []*HTTPValueMatch `json:"headers,omitempty"`
+	QueryParams []*HTTPValueMatch `json:"queryParams,omitempty"`
+	Method      string            `json:"method,omitempty"`
+}
+
+type HTTPPathMatch struct {
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// HTTPValueMatch matches a header or a query parameter.
+type HTTPValueMatch struct {
+	Type  string `json:"type,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HTTPRouteFilter struct {
+	Type                   string                `json:"type"`
+	RequestHeaderModifier  *HTTPHeaderFilter     `json:"requestHeaderModifier,omitempty"`
+	ResponseHeaderModifier *HTTPHeaderFilter     `json:"responseHeaderModifier,omitempty"`
+	RequestRedirect        *HTTPRequestRedirect  `json:"requestRedirect,omitempty"`
+	URLRewrite             *HTTPURLRewrite       `json:"urlRewrite,omitempty"`
+	RequestMirror          *HTTPRequestMirror    `json:"requestMirror,omitempty"`
+	ExtensionRef           *LocalObjectReference `json:"extensionRef,omitempty"`
+}
+
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HTTPHeaderFilter struct {
+	Set    []*HTTPHeader `json:"set,omitempty"`
+	Add    []*HTTPHeader `json:"add,omitempty"`
+	Remove []string      `json:"remove,omitempty"`
+}
+
+type HTTPPathModifier struct {
+	Type               string `json:"type"`
+	ReplaceFullPath    string `json:"replaceFullPath,omitempty"`
+	ReplacePrefixMatch string `json:"replacePrefixMatch,omitempty"`
+}
+
+type HTTPRequestRedirect struct {
+	Scheme     string            `json:"scheme,omitempty"`
+	Hostname   string            `json:"hostname,omitempty"`
+	Path       *HTTPPathModifier `json:"path,omitempty"`
+	Port       int               `json:"port,omitempty"`
+	StatusCode int               `json:"statusCode,omitempty"`
+}
+
+type HTTPURLRewrite struct {
+	Hostname string            `json:"hostname,omitempty"`
+	Path     *HTTPPathModifier `json:"path,omitempty"`
+}
+
+type HTTPRequestMirror struct {
+	BackendRef *ObjectReference `json:"backendRef"`
+}
+
+type LocalObjectReference struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+type HTTPBackendRef struct {
+	ObjectReference `json:",inline"`
+	Weight          *int               `json:"weight,omitempty"`
+	Filters         []*HTTPRouteFilter `json:"filters,omitempty"`
+}
+
+type HTTPRouteStatus struct {
+	Parents []*RouteParentStatus `json:"parents"`
+}
+
+type RouteParentStatus struct {
+	ParentRef      *ParentReference `json:"parentRef"`
+	ControllerName string           `json:"controllerName"`
+	Conditions     []*Condition     `json:"conditions,omitempty"`
+}
+
+type ReferenceGrantList struct {
+	Items []*ReferenceGrantItem `json:"items"`
+}
+
+type ReferenceGrantItem struct {
+	Metadata *Metadata           `json:"metadata"`
+	Spec     *ReferenceGrantSpec `json:"spec"`
+}
+
+type ReferenceGrantSpec struct {
+	From []*ReferenceGrantFrom `json:"from"`
+	To   []*ReferenceGrantTo   `json:"to"`
+}
+
+type ReferenceGrantFrom struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+}
+
+type ReferenceGrantTo struct {
+	Group string  `json:"group"`
+	Kind  string  `json:"kind"`
+	Name  *string `json:"name,omitempty"`
+}
+
+// Condition is the status condition of the Gateway API resources and the
+// RouteGroups.
+type Condition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	ObservedGeneration int64     `json:"observedGeneration,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Reason             string    `json:"reason"`
+	Message            string    `json:"message"`
+}
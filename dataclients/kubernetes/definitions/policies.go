@@ -0,0 +1,297 @@
+package definitions
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Policy resources, that apply ratelimit and authorization filters to the
+// routes of the targeted RouteGroups and Ingresses in their namespace.
+
+const (
+	RatelimitPolicyKind = "RatelimitPolicy"
+	AuthPolicyKind      = "AuthPolicy"
+	IngressKind         = "Ingress"
+	IngressAPIGroup     = "networking.k8s.io"
+
+	// RatelimitTypeService limits the requests of all clients, per
+	// skipper instance, see the ratelimit filter.
+	RatelimitTypeService = "service"
+
+	// RatelimitTypeClient limits the requests of each client, per
+	// skipper instance, see the clientRatelimit filter.
+	RatelimitTypeClient = "client"
+
+	// RatelimitTypeCluster limits the requests of all clients, in the
+	// cluster, see the clusterRatelimit filter.
+	RatelimitTypeCluster = "cluster"
+
+	// RatelimitTypeClusterClient limits the requests of each client, in
+	// the cluster, see the clusterClientRatelimit filter.
+	RatelimitTypeClusterClient = "clusterClient"
+)
+
+var (
+	errPolicyWithoutSpec      = errors.New("policy without spec")
+	errPolicyWithoutTargets   = errors.New("policy without targets")
+	errPolicyWithoutRatelimit = errors.New("policy without ratelimits")
+	errPolicyWithoutTokeninfo = errors.New("policy without tokeninfo rules")
+	errUnnamedTargetRef       = errors.New("unnamed target reference")
+	errEmptyTargetHost        = errors.New("empty target host")
+	errEmptyTokeninfoKey      = errors.New("empty tokeninfo key")
+)
+
+// PolicyTargets select the RouteGroups and Ingresses, in the namespace of
+// the policy, that the policy applies to. When more policies of the same
+// kind target the same route, the one with a target reference has the
+// highest precedence, then the one targeting hosts, and then the one with
+// a label selector. Between policies of the same precedence, the oldest
+// one applies.
+type PolicyTargets struct {
+	// TargetRefs reference RouteGroups and Ingresses by name.
+	TargetRefs []*PolicyTargetRef `json:"targetRefs,omitempty"`
+
+	// Hosts select the routes of the RouteGroups and Ingresses
+	// serving any of the hosts.
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Selector selects RouteGroups and Ingresses by their labels. An
+	// empty selector selects all of them.
+	Selector *LabelSelector `json:"selector,omitempty"`
+}
+
+type PolicyTargetRef struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+type LabelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// PolicyStatus tells whether the policy was accepted, and lists the IDs
+// of the routes it was applied to.
+type PolicyStatus struct {
+	Conditions []*Condition `json:"conditions,omitempty"`
+	Routes     []string     `json:"routes,omitempty"`
+}
+
+type RatelimitPolicyList struct {
+	Items []*RatelimitPolicyItem `json:"items"`
+}
+
+type RatelimitPolicyItem struct {
+	Metadata *Metadata            `json:"metadata"`
+	Spec     *RatelimitPolicySpec `json:"spec"`
+	Status   *PolicyStatus        `json:"status,omitempty"`
+}
+
+type RatelimitPolicySpec struct {
+	PolicyTargets
+
+	// Ratelimits are applied to the targeted routes, in the order
+	// of the list.
+	Ratelimits []*PolicyRatelimit `json:"ratelimits"`
+}
+
+type PolicyRatelimit struct {
+	// Type is one of service, client, cluster or clusterClient.
+	Type string `json:"type"`
+
+	// Group is the name of the cluster ratelimit group, required for
+	// the cluster and clusterClient types.
+	Group string `json:"group,omitempty"`
+
+	// MaxHits is the number of requests allowed in the period.
+	MaxHits int `json:"maxHits"`
+
+	// Period is the time window of the ratelimit, e.g. 1m.
+	Period string `json:"period"`
+
+	// Header identifies the clients of the client and clusterClient
+	// types. Defaults to the X-Forwarded-For header.
+	Header string `json:"header,omitempty"`
+}
+
+type AuthPolicyList struct {
+	Items []*AuthPolicyItem `json:"items"`
+}
+
+type AuthPolicyItem struct {
+	Metadata *Metadata       `json:"metadata"`
+	Spec     *AuthPolicySpec `json:"spec"`
+	Status   *PolicyStatus   `json:"status,omitempty"`
+}
+
+type AuthPolicySpec struct {
+	PolicyTargets
+
+	// Tokeninfo configures the authorization of the requests by the
+	// tokeninfo of their OAuth2 tokens.
+	Tokeninfo *PolicyTokeninfo `json:"tokeninfo"`
+}
+
+// PolicyTokeninfo contains the rules checked by the oauthTokeninfo*
+// filters. Every rule that is set needs to be satisfied.
+type PolicyTokeninfo struct {
+	AnyScope []string    `json:"anyScope,omitempty"`
+	AllScope []string    `json:"allScope,omitempty"`
+	AnyKV    []*PolicyKV `json:"anyKV,omitempty"`
+	AllKV    []*PolicyKV `json:"allKV,omitempty"`
+}
+
+type PolicyKV struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func invalidTargetKind(kind string) error {
+	return fmt.Errorf("invalid target kind: %s", kind)
+}
+
+func invalidTargetGroup(kind, group string) error {
+	return fmt.Errorf("invalid target group of kind %s: %s", kind, group)
+}
+
+func invalidRatelimitType(index int, typ string) error {
+	return fmt.Errorf("invalid ratelimit type at %d: %s", index, typ)
+}
+
+func invalidRatelimit(index int, err error) error {
+	return fmt.Errorf("invalid ratelimit at %d, %w", index, err)
+}
+
+// TargetGroup returns the API group of the targeted kind, when not set
+// explicitly.
+func (r *PolicyTargetRef) TargetGroup() string {
+	if r.Group != "" {
+		return r.Group
+	}
+
+	switch r.Kind {
+	case RouteGroupKind:
+		return RouteGroupAPIGroup
+	case IngressKind:
+		return IngressAPIGroup
+	default:
+		return ""
+	}
+}
+
+func (t *PolicyTargets) validate() error {
+	if len(t.TargetRefs) == 0 && len(t.Hosts) == 0 && t.Selector == nil {
+		return errPolicyWithoutTargets
+	}
+
+	for _, r := range t.TargetRefs {
+		if r == nil || r.Name == "" {
+			return errUnnamedTargetRef
+		}
+
+		if r.Kind != RouteGroupKind && r.Kind != IngressKind {
+			return invalidTargetKind(r.Kind)
+		}
+
+		if r.Group != "" && r.Group != r.TargetGroup() {
+			return invalidTargetGroup(r.Kind, r.Group)
+		}
+	}
+
+	if hasEmpty(t.Hosts) {
+		return errEmptyTargetHost
+	}
+
+	return nil
+}
+
+func (r *PolicyRatelimit) validate(index int) error {
+	if r == nil {
+		return invalidRatelimitType(index, "")
+	}
+
+	switch r.Type {
+	case RatelimitTypeService, RatelimitTypeClient, RatelimitTypeCluster, RatelimitTypeClusterClient:
+	default:
+		return invalidRatelimitType(index, r.Type)
+	}
+
+	if r.MaxHits <= 0 {
+		return invalidRatelimit(index, fmt.Errorf("invalid max hits: %d", r.MaxHits))
+	}
+
+	if d, err := time.ParseDuration(r.Period); err != nil || d <= 0 {
+		return invalidRatelimit(index, fmt.Errorf("invalid period: %q", r.Period))
+	}
+
+	clusterType := r.Type == RatelimitTypeCluster || r.Type == RatelimitTypeClusterClient
+	if clusterType && r.Group == "" {
+		return invalidRatelimit(index, errors.New("missing group"))
+	}
+
+	if !clusterType && r.Group != "" {
+		return invalidRatelimit(index, fmt.Errorf("group not supported by type %s", r.Type))
+	}
+
+	clientType := r.Type == RatelimitTypeClient || r.Type == RatelimitTypeClusterClient
+	if !clientType && r.Header != "" {
+		return invalidRatelimit(index, fmt.Errorf("header not supported by type %s", r.Type))
+	}
+
+	return nil
+}
+
+func (t *PolicyTokeninfo) validate() error {
+	if t == nil || len(t.AnyScope) == 0 && len(t.AllScope) == 0 && len(t.AnyKV) == 0 && len(t.AllKV) == 0 {
+		return errPolicyWithoutTokeninfo
+	}
+
+	for _, kvs := range [][]*PolicyKV{t.AnyKV, t.AllKV} {
+		for _, kv := range kvs {
+			if kv == nil || kv.Key == "" {
+				return errEmptyTokeninfoKey
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateRatelimitPolicy validates the targets and the ratelimits of a
+// RatelimitPolicy.
+func ValidateRatelimitPolicy(p *RatelimitPolicyItem) error {
+	if p.Spec == nil {
+		return errPolicyWithoutSpec
+	}
+
+	if err := p.Spec.validate(); err != nil {
+		return err
+	}
+
+	if len(p.Spec.Ratelimits) == 0 {
+		return errPolicyWithoutRatelimit
+	}
+
+	for i, r := range p.Spec.Ratelimits {
+		if err := r.validate(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateAuthPolicy validates the targets and the tokeninfo rules of an
+// AuthPolicy.
+func ValidateAuthPolicy(p *AuthPolicyItem) error {
+	if p.Spec == nil {
+		return errPolicyWithoutSpec
+	}
+
+	if err := p.Spec.validate(); err != nil {
+		return err
+	}
+
+	return p.Spec.Tokeninfo.validate()
+}
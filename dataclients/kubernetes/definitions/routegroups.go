@@ -20,6 +20,11 @@ const (
 	ServiceBackend = -1
 )
 
+const (
+	RouteGroupAPIGroup = "zalando.org"
+	RouteGroupKind     = "RouteGroup"
+)
+
 var (
 	errRouteGroupWithoutBackend = errors.New("route group without backend")
 	errRouteGroupWithoutName    = errors.New("route group without name")
@@ -30,6 +35,7 @@ var (
 	errMissingBackendReference  = errors.New("missing backend reference")
 	errUnnamedBackend           = errors.New("unnamed backend")
 	errUnnamedBackendReference  = errors.New("unnamed backend reference")
+	errServiceReferenceFields   = errors.New("service namespace and cluster are only allowed in service backends")
 )
 
 type RouteGroupList struct {
@@ -37,8 +43,33 @@ type RouteGroupList struct {
 }
 
 type RouteGroupItem struct {
-	Metadata *Metadata       `json:"metadata"`
-	Spec     *RouteGroupSpec `json:"spec"`
+	Metadata *Metadata         `json:"metadata"`
+	Spec     *RouteGroupSpec   `json:"spec"`
+	Status   *RouteGroupStatus `json:"status,omitempty"`
+}
+
+// RouteGroupStatus is the status of a RouteGroup.
+type RouteGroupStatus struct {
+	// LoadBalancer contains the addresses of the load balancer
+	// serving the RouteGroup, similar to the Ingress status.
+	LoadBalancer *RouteGroupLoadBalancer `json:"loadBalancer,omitempty"`
+
+	// Conditions tell whether the RouteGroup was accepted, and
+	// whether all its routes are valid.
+	Conditions []*Condition `json:"conditions,omitempty"`
+
+	// RouteErrors contains the errors of the invalid routes
+	// created from the RouteGroup.
+	RouteErrors []*RouteGroupRouteError `json:"routeErrors,omitempty"`
+}
+
+type RouteGroupLoadBalancer struct {
+	RouteGroup []IngressLoadBalancerIngress `json:"routegroup"`
+}
+
+type RouteGroupRouteError struct {
+	RouteID string `json:"routeId"`
+	Error   string `json:"error"`
 }
 
 type RouteGroupSpec struct {
@@ -84,10 +115,20 @@ type SkipperBackend struct {
 	// ServicePort is required for Type service
 	ServicePort int
 
+	// ServiceNamespace is optional for Type service. It references a
+	// Service in another namespace, when a ReferenceGrant in the
+	// namespace of the Service allows it.
+	ServiceNamespace string
+
+	// Cluster is optional for Type service. It references a Service
+	// in one of the remote clusters configured in skipper.
+	Cluster string
+
 	// Algorithm is required for Type lb
 	Algorithm loadbalancer.Algorithm
 
-	// Endpoints is required for Type lb
+	// Endpoints is required for Type lb. The endpoints can have an
+	// optional static weight, e.g. https://app1.example.org?weight=2
 	Endpoints []string
 
 	parseError error
@@ -117,6 +158,12 @@ type skipperBackendParser struct {
 
 	// ServicePort is required for Type service
 	ServicePort int `json:"servicePort"`
+
+	// ServiceNamespace is optional for Type service
+	ServiceNamespace string `json:"serviceNamespace"`
+
+	// Cluster is optional for Type service
+	Cluster string `json:"cluster"`
 }
 
 type BackendReference struct {
@@ -209,6 +256,10 @@ func missingEndpoints(backendName string) error {
 	return fmt.Errorf("missing LB endpoints in backend: %s", backendName)
 }
 
+func invalidEndpointWeight(backendName, endpoint string) error {
+	return fmt.Errorf("invalid LB endpoint weight in backend: %s, %s", backendName, endpoint)
+}
+
 func routeGroupError(m *Metadata, err error) error {
 	return fmt.Errorf("error in route group %s/%s: %w", namespaceString(m.Namespace), m.Name, err)
 }
@@ -247,6 +298,8 @@ func (sb *SkipperBackend) UnmarshalJSON(value []byte) error {
 	b.Address = p.Address
 	b.ServiceName = p.ServiceName
 	b.ServicePort = p.ServicePort
+	b.ServiceNamespace = p.ServiceNamespace
+	b.Cluster = p.Cluster
 	b.Algorithm = a
 	b.Endpoints = p.Endpoints
 	b.parseError = perr
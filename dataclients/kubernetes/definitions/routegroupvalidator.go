@@ -3,7 +3,9 @@ package definitions
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/url"
+	"strconv"
 
 	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/routing"
@@ -282,7 +284,34 @@ func (sb *SkipperBackend) validate() error {
 		return invalidServicePort(sb.Name, sb.ServicePort)
 	case sb.Type == eskip.LBBackend && len(sb.Endpoints) == 0:
 		return missingEndpoints(sb.Name)
+	case sb.Type != ServiceBackend && (sb.ServiceNamespace != "" || sb.Cluster != ""):
+		return errServiceReferenceFields
+	}
+
+	if sb.Type == eskip.LBBackend {
+		for _, ep := range sb.Endpoints {
+			if !validEndpointWeight(ep) {
+				return invalidEndpointWeight(sb.Name, ep)
+			}
+		}
 	}
 
 	return nil
 }
+
+// validEndpointWeight checks the optional weight query parameter of an LB
+// endpoint, e.g. https://app1.example.org?weight=2
+func validEndpointWeight(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return true
+	}
+
+	w := u.Query().Get("weight")
+	if w == "" {
+		return true
+	}
+
+	f, err := strconv.ParseFloat(w, 64)
+	return err == nil && f > 0 && !math.IsInf(f, 0)
+}
@@ -958,6 +958,8 @@ func TestValidateIngressLoadBalancerAnnotation(t *testing.T) {
 		{name: "power of random choices", algorithm: "powerOfRandomNChoices", annotated: true},
 		{name: "weighted round robin", algorithm: "weightedRoundRobin", annotated: true},
 		{name: "least requests", algorithm: "leastRequests", annotated: true},
+		{name: "ring hash", algorithm: "ringHash", annotated: true},
+		{name: "maglev", algorithm: "maglev", annotated: true},
 		{name: "filter used as algorithm", algorithm: `consistentHashParam("client_id")`, annotated: true, wantErr: true},
 		{name: "unsupported algorithm", algorithm: "unsupported", annotated: true, wantErr: true},
 	}
@@ -0,0 +1,948 @@
+package kubernetes
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/secrets/certregistry"
+)
+
+// Condition types and reasons of the Gateway API resources, see
+// https://gateway-api.sigs.k8s.io/reference/spec/.
+const (
+	conditionAccepted     = "Accepted"
+	conditionProgrammed   = "Programmed"
+	conditionResolvedRefs = "ResolvedRefs"
+
+	reasonAccepted                   = "Accepted"
+	reasonProgrammed                 = "Programmed"
+	reasonResolvedRefs               = "ResolvedRefs"
+	reasonInvalid                    = "Invalid"
+	reasonListenersNotValid          = "ListenersNotValid"
+	reasonUnsupportedProtocol        = "UnsupportedProtocol"
+	reasonInvalidCertificateRef      = "InvalidCertificateRef"
+	reasonRefNotPermitted            = "RefNotPermitted"
+	reasonInvalidKind                = "InvalidKind"
+	reasonBackendNotFound            = "BackendNotFound"
+	reasonNoMatchingParent           = "NoMatchingParent"
+	reasonNotAllowedByListeners      = "NotAllowedByListeners"
+	reasonNoMatchingListenerHostname = "NoMatchingListenerHostname"
+	reasonUnsupportedValue           = "UnsupportedValue"
+)
+
+const defaultRedirectStatusCode = 302
+
+type gatewayAPI struct {
+	options Options
+}
+
+// gatewayAPIStatus contains the status of the Gateway API resources
+// implemented by skipper, as it was found during the conversion.
+type gatewayAPIStatus struct {
+	gatewayClasses map[string]*definitions.GatewayClassStatus
+	gateways       map[definitions.ResourceID]*definitions.GatewayStatus
+	httpRoutes     map[definitions.ResourceID][]*definitions.RouteParentStatus
+}
+
+type gatewayListener struct {
+	spec     *definitions.GatewayListener
+	status   *definitions.ListenerStatus
+	accepted bool
+	hosts    map[string]struct{}
+}
+
+type httpRouteContext struct {
+	state                *clusterState
+	route                *definitions.HTTPRouteItem
+	logger               *logger
+	defaultFilters       defaultFilters
+	hostRx               string
+	calculateTraffic     func([]*gatewayBackend) map[string]backendTraffic
+	lbAlgorithm          string
+	zone                 string
+	disableZoneAwareness bool
+
+	// refsReason is the reason of the first unresolved
+	// reference, and empty when all the references are resolved.
+	refsReason  string
+	refsMessage string
+}
+
+// gatewayBackend implements definitions.WeightedBackend for the backend
+// references of the HTTPRoute rules.
+type gatewayBackend struct {
+	name   string
+	weight int
+}
+
+type gatewayRouteError struct {
+	reason, message string
+}
+
+func (b *gatewayBackend) GetName() string {
+	return b.name
+}
+
+func (b *gatewayBackend) GetWeight() float64 {
+	return float64(b.weight)
+}
+
+func (e *gatewayRouteError) Error() string {
+	return e.message
+}
+
+func unsupported(format string, args ...any) error {
+	return &gatewayRouteError{reason: reasonUnsupportedValue, message: fmt.Sprintf(format, args...)}
+}
+
+func newGatewayAPI(o Options) *gatewayAPI {
+	return &gatewayAPI{options: o}
+}
+
+func gwRouteID(m *definitions.Metadata, ruleIndex, matchIndex, backendIndex int) string {
+	return fmt.Sprintf(
+		"kube_gw__%s__%s__%d_%d_%d",
+		toSymbol(namespaceString(m.Namespace)),
+		toSymbol(m.Name),
+		ruleIndex,
+		matchIndex,
+		backendIndex,
+	)
+}
+
+func newCondition(typ string, ok bool, reason, message string, m *definitions.Metadata) *definitions.Condition {
+	status := definitions.ConditionFalse
+	if ok {
+		status = definitions.ConditionTrue
+	}
+
+	return &definitions.Condition{
+		Type:               typ,
+		Status:             status,
+		ObservedGeneration: m.Generation,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// hostnameMatches tells whether the hostname, that may be a wildcard
+// hostname itself, is matched by the pattern.
+func hostnameMatches(pattern, hostname string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(hostname, suffix) && len(hostname) > len(suffix)
+	}
+
+	return pattern == hostname
+}
+
+// intersectHostnames returns the hostnames accepted both by the listener
+// and the route. A nil result with true means any hostname.
+func intersectHostnames(listener string, route []string) ([]string, bool) {
+	if listener == "" {
+		return route, true
+	}
+
+	if len(route) == 0 {
+		return []string{listener}, true
+	}
+
+	var result []string
+	for _, h := range route {
+		if hostnameMatches(listener, h) {
+			result = append(result, h)
+		} else if hostnameMatches(h, listener) {
+			result = append(result, listener)
+		}
+	}
+
+	return result, len(result) > 0
+}
+
+// referenceGranted tells whether a ReferenceGrant in the namespace of the
+// referenced resource allows the reference.
+func referenceGranted(state *clusterState, fromGroup, fromKind, fromNamespace, toKind, toNamespace, toName string) bool {
+	for _, rg := range state.referenceGrants {
+		if namespaceString(rg.Metadata.Namespace) != toNamespace {
+			continue
+		}
+
+		var from bool
+		for _, f := range rg.Spec.From {
+			if f.Group == fromGroup && f.Kind == fromKind && f.Namespace == fromNamespace {
+				from = true
+				break
+			}
+		}
+
+		if !from {
+			continue
+		}
+
+		for _, t := range rg.Spec.To {
+			if t.Group == "" && t.Kind == toKind && (t.Name == nil || *t.Name == toName) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func routeAllowed(l *definitions.GatewayListener, gatewayNamespace, routeNamespace string) bool {
+	if l.AllowedRoutes != nil && len(l.AllowedRoutes.Kinds) > 0 {
+		var kind bool
+		for _, k := range l.AllowedRoutes.Kinds {
+			if (k.Group == "" || k.Group == definitions.GatewayAPIGroup) && k.Kind == definitions.HTTPRouteKind {
+				kind = true
+				break
+			}
+		}
+
+		if !kind {
+			return false
+		}
+	}
+
+	from := definitions.NamespacesFromSame
+	if l.AllowedRoutes != nil && l.AllowedRoutes.Namespaces != nil && l.AllowedRoutes.Namespaces.From != "" {
+		from = l.AllowedRoutes.Namespaces.From
+	}
+
+	switch from {
+	case definitions.NamespacesFromAll:
+		return true
+	case definitions.NamespacesFromSame:
+		return gatewayNamespace == routeNamespace
+	default:
+		// namespace selectors are not supported
+		return false
+	}
+}
+
+func (g *gatewayAPI) listenerCertificates(state *clusterState, gw *definitions.GatewayItem, l *definitions.GatewayListener) ([]*secret, *definitions.Condition) {
+	if l.TLS == nil || len(l.TLS.CertificateRefs) == 0 {
+		return nil, newCondition(conditionResolvedRefs, false, reasonInvalidCertificateRef, "missing certificate reference", gw.Metadata)
+	}
+
+	namespace := namespaceString(gw.Metadata.Namespace)
+	var secrets []*secret
+	for _, ref := range l.TLS.CertificateRefs {
+		if ref.Group != "" || ref.Kind != "" && ref.Kind != definitions.SecretKind {
+			return nil, newCondition(conditionResolvedRefs, false, reasonInvalidCertificateRef, fmt.Sprintf("unsupported certificate reference kind: %s", ref.Kind), gw.Metadata)
+		}
+
+		ns := namespace
+		if ref.Namespace != "" && ref.Namespace != namespace {
+			ns = ref.Namespace
+			if !referenceGranted(state, definitions.GatewayAPIGroup, definitions.GatewayKind, namespace, definitions.SecretKind, ns, ref.Name) {
+				return nil, newCondition(conditionResolvedRefs, false, reasonRefNotPermitted, fmt.Sprintf("reference to secret %s/%s not permitted", ns, ref.Name), gw.Metadata)
+			}
+		}
+
+		s, ok := state.secrets[newResourceID(ns, ref.Name)]
+		if !ok {
+			return nil, newCondition(conditionResolvedRefs, false, reasonInvalidCertificateRef, fmt.Sprintf("secret not found: %s/%s", ns, ref.Name), gw.Metadata)
+		}
+
+		secrets = append(secrets, s)
+	}
+
+	return secrets, newCondition(conditionResolvedRefs, true, reasonResolvedRefs, "references resolved", gw.Metadata)
+}
+
+func (g *gatewayAPI) gatewayListeners(state *clusterState, gw *definitions.GatewayItem, cr *certregistry.CertRegistry) ([]*gatewayListener, map[*gatewayListener][]*secret) {
+	var listeners []*gatewayListener
+	certs := make(map[*gatewayListener][]*secret)
+	for _, l := range gw.Spec.Listeners {
+		gl := &gatewayListener{
+			spec:  l,
+			hosts: make(map[string]struct{}),
+			status: &definitions.ListenerStatus{
+				Name:           l.Name,
+				SupportedKinds: []*definitions.RouteKind{},
+			},
+		}
+
+		listeners = append(listeners, gl)
+
+		var resolved *definitions.Condition
+		switch l.Protocol {
+		case "HTTP":
+			resolved = newCondition(conditionResolvedRefs, true, reasonResolvedRefs, "references resolved", gw.Metadata)
+		case "HTTPS":
+			var secrets []*secret
+			secrets, resolved = g.listenerCertificates(state, gw, l)
+			if cr != nil && len(secrets) > 0 {
+				certs[gl] = secrets
+			}
+		default:
+			gl.status.Conditions = []*definitions.Condition{
+				newCondition(conditionAccepted, false, reasonUnsupportedProtocol, fmt.Sprintf("unsupported protocol: %s", l.Protocol), gw.Metadata),
+				newCondition(conditionResolvedRefs, true, reasonResolvedRefs, "references resolved", gw.Metadata),
+				newCondition(conditionProgrammed, false, reasonInvalid, "listener not accepted", gw.Metadata),
+			}
+
+			continue
+		}
+
+		gl.accepted = true
+		gl.status.SupportedKinds = append(gl.status.SupportedKinds, &definitions.RouteKind{
+			Group: definitions.GatewayAPIGroup,
+			Kind:  definitions.HTTPRouteKind,
+		})
+
+		programmed := newCondition(conditionProgrammed, true, reasonProgrammed, "listener programmed", gw.Metadata)
+		if resolved.Status != definitions.ConditionTrue {
+			programmed = newCondition(conditionProgrammed, false, reasonInvalid, "listener references not resolved", gw.Metadata)
+		}
+
+		gl.status.Conditions = []*definitions.Condition{
+			newCondition(conditionAccepted, true, reasonAccepted, "listener accepted", gw.Metadata),
+			resolved,
+			programmed,
+		}
+	}
+
+	return listeners, certs
+}
+
+func gatewayStatus(gw *definitions.GatewayItem, listeners []*gatewayListener) *definitions.GatewayStatus {
+	status := &definitions.GatewayStatus{}
+
+	var accepted bool
+	for _, l := range listeners {
+		status.Listeners = append(status.Listeners, l.status)
+		accepted = accepted || l.accepted
+	}
+
+	if accepted {
+		status.Conditions = []*definitions.Condition{
+			newCondition(conditionAccepted, true, reasonAccepted, "gateway accepted by skipper", gw.Metadata),
+			newCondition(conditionProgrammed, true, reasonProgrammed, "gateway programmed by skipper", gw.Metadata),
+		}
+	} else {
+		status.Conditions = []*definitions.Condition{
+			newCondition(conditionAccepted, false, reasonListenersNotValid, "no valid listener", gw.Metadata),
+			newCondition(conditionProgrammed, false, reasonInvalid, "no valid listener", gw.Metadata),
+		}
+	}
+
+	return status
+}
+
+// attachRoute resolves the parent references of the route to the listeners of the
+// Gateways implemented by skipper. It returns the statuses of the parents, and the
+// hostnames of the route accepted by the listeners, where nil means any hostname.
+func (g *gatewayAPI) attachRoute(hr *definitions.HTTPRouteItem, listeners map[definitions.ResourceID][]*gatewayListener) ([]*definitions.RouteParentStatus, []string, bool) {
+	namespace := namespaceString(hr.Metadata.Namespace)
+	var (
+		parents  []*definitions.RouteParentStatus
+		hosts    []string
+		anyHost  bool
+		attached bool
+	)
+
+	for _, p := range hr.Spec.ParentRefs {
+		if p.Group != "" && p.Group != definitions.GatewayAPIGroup || p.Kind != "" && p.Kind != definitions.GatewayKind {
+			continue
+		}
+
+		gwNamespace := namespace
+		if p.Namespace != "" {
+			gwNamespace = p.Namespace
+		}
+
+		ls, ok := listeners[newResourceID(gwNamespace, p.Name)]
+		if !ok {
+			continue
+		}
+
+		var matched, allowed, hostMatched bool
+		for _, l := range ls {
+			if p.SectionName != "" && p.SectionName != l.spec.Name || p.Port != 0 && p.Port != l.spec.Port {
+				continue
+			}
+
+			matched = true
+			if !l.accepted || !routeAllowed(l.spec, gwNamespace, namespace) {
+				continue
+			}
+
+			allowed = true
+			h, ok := intersectHostnames(l.spec.Hostname, hr.Spec.Hostnames)
+			if !ok {
+				continue
+			}
+
+			hostMatched = true
+			l.status.AttachedRoutes++
+			if h == nil {
+				anyHost = true
+			}
+
+			for _, hi := range h {
+				hosts = append(hosts, hi)
+				if !strings.HasPrefix(hi, "*") {
+					l.hosts[hi] = struct{}{}
+				}
+			}
+		}
+
+		var c *definitions.Condition
+		switch {
+		case !matched:
+			c = newCondition(conditionAccepted, false, reasonNoMatchingParent, "no matching listener", hr.Metadata)
+		case !allowed:
+			c = newCondition(conditionAccepted, false, reasonNotAllowedByListeners, "route not allowed by the listeners", hr.Metadata)
+		case !hostMatched:
+			c = newCondition(conditionAccepted, false, reasonNoMatchingListenerHostname, "no matching listener hostname", hr.Metadata)
+		default:
+			c = newCondition(conditionAccepted, true, reasonAccepted, "route accepted", hr.Metadata)
+			attached = true
+		}
+
+		parents = append(parents, &definitions.RouteParentStatus{
+			ParentRef:      p,
+			ControllerName: g.options.GatewayControllerName,
+			Conditions:     []*definitions.Condition{c},
+		})
+	}
+
+	if !attached || anyHost {
+		return parents, nil, attached
+	}
+
+	sort.Strings(hosts)
+	unique := hosts[:0]
+	for i, h := range hosts {
+		if i == 0 || h != hosts[i-1] {
+			unique = append(unique, h)
+		}
+	}
+
+	return parents, unique, attached
+}
+
+func (ctx *httpRouteContext) unresolved(reason, message string) {
+	ctx.logger.Errorf("Unresolved reference: %s", message)
+	if ctx.refsReason == "" {
+		ctx.refsReason, ctx.refsMessage = reason, message
+	}
+}
+
+// service resolves a Service reference, that is the backend reference or
+// the target of the RequestMirror filter.
+func (ctx *httpRouteContext) service(ref *definitions.ObjectReference) (*service, string, error) {
+	if ref.Group != "" || ref.Kind != "" && ref.Kind != definitions.ServiceKind {
+		return nil, "", &gatewayRouteError{reasonInvalidKind, fmt.Sprintf("unsupported backend kind: %s", ref.Kind)}
+	}
+
+	namespace := namespaceString(ctx.route.Metadata.Namespace)
+	if ref.Namespace != "" && ref.Namespace != namespace {
+		if !referenceGranted(ctx.state, definitions.GatewayAPIGroup, definitions.HTTPRouteKind, namespace, definitions.ServiceKind, ref.Namespace, ref.Name) {
+			return nil, "", &gatewayRouteError{reasonRefNotPermitted, fmt.Sprintf("reference to service %s/%s not permitted", ref.Namespace, ref.Name)}
+		}
+
+		namespace = ref.Namespace
+	}
+
+	s, err := ctx.state.getServiceRG(namespace, ref.Name)
+	if err != nil || s.Spec == nil {
+		return nil, "", &gatewayRouteError{reasonBackendNotFound, fmt.Sprintf("service not found: %s/%s", namespace, ref.Name)}
+	}
+
+	if strings.ToLower(s.Spec.Type) != "clusterip" {
+		return nil, "", &gatewayRouteError{reasonBackendNotFound, notSupportedServiceType(s).Error()}
+	}
+
+	if ref.Port == 0 {
+		return nil, "", &gatewayRouteError{reasonBackendNotFound, fmt.Sprintf("missing port of service %s/%s", namespace, ref.Name)}
+	}
+
+	return s, namespace, nil
+}
+
+func (ctx *httpRouteContext) applyBackend(ref *definitions.ObjectReference, r *eskip.Route) error {
+	s, namespace, err := ctx.service(ref)
+	if err != nil {
+		return err
+	}
+
+	targetPort, ok := s.getTargetPortByValue(ref.Port)
+	if !ok {
+		return &gatewayRouteError{reasonBackendNotFound, targetPortNotFound(ref.Name, ref.Port).Error()}
+	}
+
+	protocol := "http"
+	annotationSet := false
+	if p, ok := ctx.route.Metadata.Annotations[skipperBackendProtocolAnnotationKey]; ok {
+		protocol = p
+		annotationSet = true
+	}
+
+	if f := zoneAwarenessAnnotationFilter(ctx.route.Metadata); f != nil {
+		r.Filters = append([]*eskip.Filter{f}, r.Filters...)
+	}
+
+	zone, name, disableZoneAwareness := ctx.zone, ref.Name, ctx.disableZoneAwareness
+	lookup := &endpointsLookup{
+		service: newResourceID(namespace, name),
+		endpoints: func(cs *clusterState) []skipperEndpoint {
+			return cs.getTargetEndpoints(zone, namespace, name, protocol, annotationSet, targetPort, disableZoneAwareness)
+		},
+	}
+
+	eps := lookup.endpoints(ctx.state)
+	ctx.state.setEndpointsLookup(r, lookup)
+	if len(eps) == 0 {
+		ctx.logger.Tracef("Target endpoints not found, shuntroute for %s:%d", ref.Name, ref.Port)
+	}
+
+	applyEndpoints(r, eps, ctx.lbAlgorithm)
+	if f, err := ctx.defaultFilters.getNamed(namespace, ref.Name); err != nil {
+		ctx.logger.Errorf("Failed to retrieve default filters: %v", err)
+	} else {
+		r.Filters = append(f, r.Filters...)
+	}
+
+	return nil
+}
+
+func appendHeaderFilters(f []*eskip.Filter, h *definitions.HTTPHeaderFilter, set, add, drop string) []*eskip.Filter {
+	if h == nil {
+		return f
+	}
+
+	for _, hi := range h.Set {
+		f = appendFilter(f, set, hi.Name, hi.Value)
+	}
+
+	for _, hi := range h.Add {
+		f = appendFilter(f, add, hi.Name, hi.Value)
+	}
+
+	for _, name := range h.Remove {
+		f = appendFilter(f, drop, name)
+	}
+
+	return f
+}
+
+// replacePrefixFilter creates the filter that replaces the matched path prefix.
+func replacePrefixFilter(f []*eskip.Filter, prefix string, m *definitions.HTTPPathModifier) ([]*eskip.Filter, error) {
+	if prefix == "" {
+		return nil, unsupported("prefix replacement without path prefix match")
+	}
+
+	replacement := strings.ReplaceAll(strings.TrimSuffix(m.ReplacePrefixMatch, "/"), "$", "$$")
+	return appendFilter(
+		f,
+		filters.ModPathName,
+		"^"+regexp.QuoteMeta(strings.TrimSuffix(prefix, "/"))+"(/.*)?$",
+		replacement+"${1}",
+	), nil
+}
+
+// redirectLocation creates the location argument of the redirectTo filter.
+// The filter takes the missing parts of the location from the request.
+func redirectLocation(rr *definitions.HTTPRequestRedirect) (string, error) {
+	u := url.URL{Scheme: rr.Scheme, Host: rr.Hostname}
+	if rr.Port != 0 {
+		if rr.Hostname == "" {
+			return "", unsupported("redirect port without hostname")
+		}
+
+		u.Host = net.JoinHostPort(rr.Hostname, strconv.Itoa(rr.Port))
+	}
+
+	if rr.Path != nil {
+		switch rr.Path.Type {
+		case definitions.ReplaceFullPath:
+			u.Path = rr.Path.ReplaceFullPath
+		case definitions.ReplacePrefixMatch:
+		default:
+			return "", unsupported("unsupported path modifier: %s", rr.Path.Type)
+		}
+	}
+
+	return u.String(), nil
+}
+
+// ruleFilters converts the HTTPRoute filters. It tells whether the filters
+// contain a RequestRedirect filter, that is served by the route itself.
+func (ctx *httpRouteContext) ruleFilters(rf []*definitions.HTTPRouteFilter, prefix string) ([]*eskip.Filter, bool, error) {
+	var (
+		f        []*eskip.Filter
+		redirect bool
+		err      error
+	)
+
+	for _, fi := range rf {
+		switch fi.Type {
+		case definitions.RequestHeaderModifierFilter:
+			f = appendHeaderFilters(f, fi.RequestHeaderModifier, filters.SetRequestHeaderName, filters.AppendRequestHeaderName, filters.DropRequestHeaderName)
+		case definitions.ResponseHeaderModifierFilter:
+			f = appendHeaderFilters(f, fi.ResponseHeaderModifier, filters.SetResponseHeaderName, filters.AppendResponseHeaderName, filters.DropResponseHeaderName)
+		case definitions.RequestRedirectFilter:
+			rr := fi.RequestRedirect
+			if rr == nil {
+				return nil, false, unsupported("missing request redirect")
+			}
+
+			if rr.Path != nil && rr.Path.Type == definitions.ReplacePrefixMatch {
+				if f, err = replacePrefixFilter(f, prefix, rr.Path); err != nil {
+					return nil, false, err
+				}
+			}
+
+			code := rr.StatusCode
+			if code == 0 {
+				code = defaultRedirectStatusCode
+			}
+
+			location, err := redirectLocation(rr)
+			if err != nil {
+				return nil, false, err
+			}
+
+			f = appendFilter(f, filters.RedirectToName, float64(code), location)
+			redirect = true
+		case definitions.URLRewriteFilter:
+			rw := fi.URLRewrite
+			if rw == nil {
+				return nil, false, unsupported("missing url rewrite")
+			}
+
+			if rw.Hostname != "" {
+				f = appendFilter(f, filters.SetRequestHeaderName, "Host", rw.Hostname)
+			}
+
+			if rw.Path != nil {
+				switch rw.Path.Type {
+				case definitions.ReplaceFullPath:
+					f = appendFilter(f, filters.SetPathName, rw.Path.ReplaceFullPath)
+				case definitions.ReplacePrefixMatch:
+					if f, err = replacePrefixFilter(f, prefix, rw.Path); err != nil {
+						return nil, false, err
+					}
+				default:
+					return nil, false, unsupported("unsupported path modifier: %s", rw.Path.Type)
+				}
+			}
+		case definitions.RequestMirrorFilter:
+			if fi.RequestMirror == nil || fi.RequestMirror.BackendRef == nil {
+				return nil, false, unsupported("missing request mirror backend")
+			}
+
+			ref := fi.RequestMirror.BackendRef
+			s, _, err := ctx.service(ref)
+			if err != nil {
+				return nil, false, err
+			}
+
+			f = appendFilter(f, filters.TeeName, "http://"+net.JoinHostPort(s.Spec.ClusterIP, strconv.Itoa(ref.Port)))
+		default:
+			return nil, false, unsupported("unsupported filter: %s", fi.Type)
+		}
+	}
+
+	return f, redirect, nil
+}
+
+// matchPredicates converts the HTTPRoute match. It returns the matched path
+// prefix, that is used by the prefix replacing path modifiers.
+func (ctx *httpRouteContext) matchPredicates(m *definitions.HTTPRouteMatch) ([]*eskip.Predicate, string, error) {
+	var (
+		p      []*eskip.Predicate
+		prefix string
+	)
+
+	path := &definitions.HTTPPathMatch{Type: definitions.PathMatchPrefix, Value: "/"}
+	if m.Path != nil {
+		path = m.Path
+	}
+
+	value := path.Value
+	if value == "" {
+		value = "/"
+	}
+
+	switch path.Type {
+	case definitions.PathMatchExact:
+		p = appendPredicate(p, "Path", value)
+	case definitions.PathMatchPrefix, "":
+		p = appendPredicate(p, "PathSubtree", value)
+		prefix = value
+	case definitions.PathMatchRegexp:
+		p = appendPredicate(p, "PathRegexp", value)
+	default:
+		return nil, "", unsupported("unsupported path match type: %s", path.Type)
+	}
+
+	if ctx.hostRx != "" {
+		p = appendPredicate(p, "Host", ctx.hostRx)
+	}
+
+	for _, h := range m.Headers {
+		switch h.Type {
+		case definitions.HeaderMatchExact, "":
+			p = appendPredicate(p, "Header", h.Name, h.Value)
+		case definitions.HeaderMatchRegexp:
+			p = appendPredicate(p, "HeaderRegexp", h.Name, h.Value)
+		default:
+			return nil, "", unsupported("unsupported header match type: %s", h.Type)
+		}
+	}
+
+	for _, q := range m.QueryParams {
+		switch q.Type {
+		case definitions.HeaderMatchExact, "":
+			p = appendPredicate(p, "QueryParam", q.Name, "^"+regexp.QuoteMeta(q.Value)+"$")
+		case definitions.HeaderMatchRegexp:
+			p = appendPredicate(p, "QueryParam", q.Name, q.Value)
+		default:
+			return nil, "", unsupported("unsupported query param match type: %s", q.Type)
+		}
+	}
+
+	if m.Method != "" {
+		p = appendPredicate(p, "Method", strings.ToUpper(m.Method))
+	}
+
+	return p, prefix, nil
+}
+
+// errorRoute responds with 500, as required for the invalid rules and backends.
+func errorRoute(r *eskip.Route) {
+	r.Filters = []*eskip.Filter{{Name: filters.StatusName, Args: []interface{}{500.0}}}
+	r.BackendType = eskip.ShuntBackend
+	r.Backend = ""
+	r.LBEndpoints = nil
+}
+
+func (g *gatewayAPI) ruleRoutes(ctx *httpRouteContext, ruleIndex int, rule *definitions.HTTPRouteRule) []*eskip.Route {
+	matches := rule.Matches
+	if len(matches) == 0 {
+		matches = []*definitions.HTTPRouteMatch{{}}
+	}
+
+	backends := make([]*gatewayBackend, len(rule.BackendRefs))
+	var sum int
+	for i, b := range rule.BackendRefs {
+		backends[i] = &gatewayBackend{name: strconv.Itoa(i), weight: 1}
+		if b.Weight != nil {
+			backends[i].weight = *b.Weight
+		}
+
+		sum += backends[i].weight
+	}
+
+	traffic := ctx.calculateTraffic(backends)
+
+	var routes []*eskip.Route
+	for matchIndex, m := range matches {
+		r := &eskip.Route{Id: gwRouteID(ctx.route.Metadata, ruleIndex, matchIndex, 0)}
+
+		var (
+			prefix   string
+			redirect bool
+			err      error
+		)
+
+		r.Predicates, prefix, err = ctx.matchPredicates(m)
+		if err == nil {
+			r.Filters, redirect, err = ctx.ruleFilters(rule.Filters, prefix)
+		}
+
+		switch {
+		case err != nil:
+			ctx.logger.Errorf("Invalid rule %d: %v", ruleIndex, err)
+			if ge, ok := err.(*gatewayRouteError); ok && ge.reason != reasonUnsupportedValue {
+				ctx.unresolved(ge.reason, ge.message)
+			}
+
+			errorRoute(r)
+			routes = append(routes, r)
+			continue
+		case redirect:
+			r.BackendType = eskip.ShuntBackend
+			routes = append(routes, r)
+			continue
+		case sum == 0:
+			errorRoute(r)
+			routes = append(routes, r)
+			continue
+		}
+
+		for backendIndex, ref := range rule.BackendRefs {
+			bt := traffic[backends[backendIndex].name]
+			if !bt.allowed() {
+				continue
+			}
+
+			ri := &eskip.Route{
+				Id:         gwRouteID(ctx.route.Metadata, ruleIndex, matchIndex, backendIndex),
+				Predicates: append([]*eskip.Predicate(nil), r.Predicates...),
+				Filters:    append([]*eskip.Filter(nil), r.Filters...),
+			}
+
+			bf, redirect, err := ctx.ruleFilters(ref.Filters, prefix)
+			if err == nil && redirect {
+				err = unsupported("request redirect in backend filters")
+			}
+
+			if err == nil {
+				ri.Filters = append(ri.Filters, bf...)
+				err = ctx.applyBackend(&ref.ObjectReference, ri)
+			}
+
+			if err != nil {
+				if ge, ok := err.(*gatewayRouteError); ok && ge.reason != reasonUnsupportedValue {
+					ctx.unresolved(ge.reason, ge.message)
+				} else {
+					ctx.logger.Errorf("Invalid backend %d of rule %d: %v", backendIndex, ruleIndex, err)
+				}
+
+				errorRoute(ri)
+			}
+
+			bt.apply(ri)
+			routes = append(routes, ri)
+		}
+	}
+
+	return routes
+}
+
+func (g *gatewayAPI) convertHTTPRoute(ctx *httpRouteContext) []*eskip.Route {
+	var routes []*eskip.Route
+	for ruleIndex, rule := range ctx.route.Spec.Rules {
+		routes = append(routes, g.ruleRoutes(ctx, ruleIndex, rule)...)
+	}
+
+	m := ctx.route.Metadata
+	for _, r := range routes {
+		prependApplicationAnnotation(m.Labels, g.options.KubernetesApplicationAnnotationLabelKey, r)
+
+		appendAnnotationPredicates(g.options.KubernetesAnnotationPredicates, m.Annotations, r)
+		appendAnnotationFilters(g.options.KubernetesAnnotationFiltersAppend, m.Annotations, r)
+	}
+
+	return routes
+}
+
+func newGatewayAPIStatus() *gatewayAPIStatus {
+	return &gatewayAPIStatus{
+		gatewayClasses: make(map[string]*definitions.GatewayClassStatus),
+		gateways:       make(map[definitions.ResourceID]*definitions.GatewayStatus),
+		httpRoutes:     make(map[definitions.ResourceID][]*definitions.RouteParentStatus),
+	}
+}
+
+// convert converts the HTTPRoutes attached to the Gateways of the GatewayClasses implemented by skipper,
+// and returns the resulting status of the resources.
+func (g *gatewayAPI) convert(s *clusterState, df defaultFilters, loggingEnabled bool, cr *certregistry.CertRegistry) ([]*eskip.Route, *gatewayAPIStatus) {
+	status := newGatewayAPIStatus()
+
+	classes := make(map[string]bool)
+	for _, gc := range s.gatewayClasses {
+		classes[gc.Metadata.Name] = true
+		status.gatewayClasses[gc.Metadata.Name] = &definitions.GatewayClassStatus{
+			Conditions: []*definitions.Condition{
+				newCondition(conditionAccepted, true, reasonAccepted, "gateway class accepted by skipper", gc.Metadata),
+			},
+		}
+	}
+
+	listeners := make(map[definitions.ResourceID][]*gatewayListener)
+	certs := make(map[*gatewayListener][]*secret)
+	var gateways []*definitions.GatewayItem
+	for _, gw := range s.gateways {
+		if !classes[gw.Spec.GatewayClassName] {
+			continue
+		}
+
+		ls, lc := g.gatewayListeners(s, gw, cr)
+		listeners[newResourceID(namespaceString(gw.Metadata.Namespace), gw.Metadata.Name)] = ls
+		for l, c := range lc {
+			certs[l] = c
+		}
+
+		gateways = append(gateways, gw)
+	}
+
+	var routes []*eskip.Route
+	for _, hr := range s.httpRoutes {
+		parents, hosts, attached := g.attachRoute(hr, listeners)
+		if len(parents) == 0 {
+			continue
+		}
+
+		ctx := &httpRouteContext{
+			state:                s,
+			route:                hr,
+			logger:               newLogger("HTTPRoute", hr.Metadata.Namespace, hr.Metadata.Name, loggingEnabled),
+			defaultFilters:       df,
+			hostRx:               createWildcardHostRx(hosts...),
+			calculateTraffic:     getBackendTrafficCalculator[*gatewayBackend](g.options.BackendTrafficAlgorithm),
+			lbAlgorithm:          g.options.DefaultLoadBalancerAlgorithm,
+			zone:                 g.options.TopologyZone,
+			disableZoneAwareness: hr.Metadata.Annotations[trafficZoneAwareAnnotationKey] == "false",
+		}
+
+		if attached {
+			routes = append(routes, g.convertHTTPRoute(ctx)...)
+		}
+
+		resolved := newCondition(conditionResolvedRefs, true, reasonResolvedRefs, "references resolved", hr.Metadata)
+		if ctx.refsReason != "" {
+			resolved = newCondition(conditionResolvedRefs, false, ctx.refsReason, ctx.refsMessage, hr.Metadata)
+		}
+
+		for _, p := range parents {
+			p.Conditions = append(p.Conditions, resolved)
+		}
+
+		status.httpRoutes[newResourceID(namespaceString(hr.Metadata.Namespace), hr.Metadata.Name)] = parents
+	}
+
+	for _, gw := range gateways {
+		id := newResourceID(namespaceString(gw.Metadata.Namespace), gw.Metadata.Name)
+		ls := listeners[id]
+		status.gateways[id] = gatewayStatus(gw, ls)
+
+		logger := newLogger("Gateway", gw.Metadata.Namespace, gw.Metadata.Name, loggingEnabled)
+		for _, l := range ls {
+			secrets, ok := certs[l]
+			if !ok {
+				continue
+			}
+
+			var hosts []string
+			if l.spec.Hostname != "" && !strings.HasPrefix(l.spec.Hostname, "*") {
+				hosts = append(hosts, l.spec.Hostname)
+			}
+
+			for h := range l.hosts {
+				if h != l.spec.Hostname {
+					hosts = append(hosts, h)
+				}
+			}
+
+			sort.Strings(hosts)
+			for _, s := range secrets {
+				addTLSCertToRegistry(cr, logger, hosts, s)
+			}
+		}
+	}
+
+	return routes, status
+}
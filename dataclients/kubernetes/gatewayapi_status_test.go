@@ -0,0 +1,149 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
+)
+
+func TestIntersectHostnames(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		listener string
+		route    []string
+		expected []string
+		ok       bool
+	}{
+		{name: "any", ok: true},
+		{name: "listener only", listener: "example.org", expected: []string{"example.org"}, ok: true},
+		{name: "route only", route: []string{"example.org"}, expected: []string{"example.org"}, ok: true},
+		{name: "same", listener: "example.org", route: []string{"example.org"}, expected: []string{"example.org"}, ok: true},
+		{name: "different", listener: "example.org", route: []string{"example.com"}},
+		{name: "wildcard listener", listener: "*.example.org", route: []string{"foo.example.org", "foo.bar.example.org", "example.org"}, expected: []string{"foo.example.org", "foo.bar.example.org"}, ok: true},
+		{name: "wildcard route", listener: "foo.example.org", route: []string{"*.example.org"}, expected: []string{"foo.example.org"}, ok: true},
+		{name: "both wildcards", listener: "*.example.org", route: []string{"*.foo.example.org"}, expected: []string{"*.foo.example.org"}, ok: true},
+		{name: "wider route wildcard", listener: "*.foo.example.org", route: []string{"*.example.org"}, expected: []string{"*.foo.example.org"}, ok: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, ok := intersectHostnames(tt.listener, tt.route)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, hosts)
+		})
+	}
+}
+
+func gatewayAPITestState() *clusterState {
+	return &clusterState{
+		gatewayClasses: []*definitions.GatewayClassItem{{
+			Metadata: &definitions.Metadata{Name: "skipper", Generation: 1},
+			Spec:     &definitions.GatewayClassSpec{ControllerName: DefaultGatewayControllerName},
+		}},
+		gateways: []*definitions.GatewayItem{{
+			Metadata: &definitions.Metadata{Name: "gateway", Namespace: "default", Generation: 2},
+			Spec: &definitions.GatewaySpec{
+				GatewayClassName: "skipper",
+				Listeners: []*definitions.GatewayListener{
+					{Name: "http", Port: 80, Protocol: "HTTP"},
+					{Name: "udp", Port: 53, Protocol: "UDP"},
+				},
+			},
+		}},
+		httpRoutes: []*definitions.HTTPRouteItem{{
+			Metadata: &definitions.Metadata{Name: "myapp", Namespace: "default", Generation: 3},
+			Spec: &definitions.HTTPRouteSpec{
+				ParentRefs: []*definitions.ParentReference{{Name: "gateway"}, {Name: "other"}},
+				Rules: []*definitions.HTTPRouteRule{{
+					BackendRefs: []*definitions.HTTPBackendRef{{
+						ObjectReference: definitions.ObjectReference{Name: "missing", Port: 80},
+					}},
+				}},
+			},
+			Status: &definitions.HTTPRouteStatus{
+				Parents: []*definitions.RouteParentStatus{{
+					ParentRef:      &definitions.ParentReference{Name: "other"},
+					ControllerName: "example.org/other",
+				}},
+			},
+		}},
+		services: map[definitions.ResourceID]*service{},
+	}
+}
+
+func TestUpdateGatewayAPIStatus(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		patches map[string][]byte
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mu.Lock()
+		patches[r.URL.Path] = b
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	c := &clusterClient{
+		httpClient:            srv.Client(),
+		apiURL:                srv.URL,
+		gatewayControllerName: DefaultGatewayControllerName,
+	}
+
+	g := newGatewayAPI(Options{GatewayControllerName: DefaultGatewayControllerName})
+	state := gatewayAPITestState()
+
+	patches = make(map[string][]byte)
+	_, status := g.convert(state, nil, false, nil)
+	require.NoError(t, c.updateGatewayAPIStatus(state, status))
+	require.Len(t, patches, 3)
+
+	var gc struct {
+		Status *definitions.GatewayClassStatus
+	}
+	require.NoError(t, json.Unmarshal(patches[GatewayClassesClusterURI+"/skipper/status"], &gc))
+	require.Len(t, gc.Status.Conditions, 1)
+	assert.Equal(t, conditionAccepted, gc.Status.Conditions[0].Type)
+	assert.Equal(t, definitions.ConditionTrue, gc.Status.Conditions[0].Status)
+	assert.Equal(t, int64(1), gc.Status.Conditions[0].ObservedGeneration)
+	assert.False(t, gc.Status.Conditions[0].LastTransitionTime.IsZero())
+
+	var gw struct{ Status *definitions.GatewayStatus }
+	require.NoError(t, json.Unmarshal(patches["/apis/gateway.networking.k8s.io/v1/namespaces/default/gateways/gateway/status"], &gw))
+	require.Len(t, gw.Status.Listeners, 2)
+	assert.Equal(t, 1, gw.Status.Listeners[0].AttachedRoutes)
+	assert.Equal(t, definitions.ConditionTrue, gw.Status.Listeners[0].Conditions[0].Status)
+	assert.Equal(t, reasonUnsupportedProtocol, gw.Status.Listeners[1].Conditions[0].Reason)
+
+	var hr struct{ Status *definitions.HTTPRouteStatus }
+	require.NoError(t, json.Unmarshal(patches["/apis/gateway.networking.k8s.io/v1/namespaces/default/httproutes/myapp/status"], &hr))
+	require.Len(t, hr.Status.Parents, 2)
+	assert.Equal(t, "example.org/other", hr.Status.Parents[0].ControllerName, "the status of other controllers is preserved")
+	assert.Equal(t, DefaultGatewayControllerName, hr.Status.Parents[1].ControllerName)
+	require.Len(t, hr.Status.Parents[1].Conditions, 2)
+	assert.Equal(t, definitions.ConditionTrue, hr.Status.Parents[1].Conditions[0].Status)
+	assert.Equal(t, reasonBackendNotFound, hr.Status.Parents[1].Conditions[1].Reason)
+
+	// the next update with the patched status is a noop, also when
+	// the conversion happens later
+	state.gatewayClasses[0].Status = gc.Status
+	state.gateways[0].Status = gw.Status
+	state.httpRoutes[0].Status = hr.Status
+
+	time.Sleep(time.Second)
+	patches = make(map[string][]byte)
+	_, status = g.convert(state, nil, false, nil)
+	require.NoError(t, c.updateGatewayAPIStatus(state, status))
+	assert.Empty(t, patches)
+}
@@ -24,6 +24,28 @@ func createHostRx(hosts ...string) string {
 	return "^(" + strings.Join(hrx, "|") + ")$"
 }
 
+// createWildcardHostRx is like createHostRx, but it also accepts hostnames
+// with a leading wildcard label, that match one or more labels, e.g.
+// *.example.org matches foo.example.org and foo.bar.example.org, but not
+// example.org.
+func createWildcardHostRx(hosts ...string) string {
+	if len(hosts) == 0 {
+		return ""
+	}
+
+	hrx := make([]string, len(hosts))
+	for i, host := range hosts {
+		var wildcard string
+		if suffix, ok := strings.CutPrefix(host, "*."); ok {
+			wildcard, host = "([^.:]+[.])+", suffix
+		}
+
+		hrx[i] = wildcard + strings.ReplaceAll(host, ".", "[.]") + "[.]?(:[0-9]+)?"
+	}
+
+	return "^(" + strings.Join(hrx, "|") + ")$"
+}
+
 // hostCatchAllRoutes creates catch-all routes for those hosts that only have routes with
 // a Host predicate and at least one additional predicate.
 //
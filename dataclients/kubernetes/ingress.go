@@ -316,6 +316,23 @@ func annotationFilter(m *definitions.Metadata, logger *logger) []*eskip.Filter {
 	return nil
 }
 
+// validateSecretNamespace checks the secrets read by the annotation
+// filters and the filters of the annotation routes.
+func (ic *ingressContext) validateSecretNamespace() error {
+	ns := ic.ingressV1.Metadata.Namespace
+	if err := validateSecretNamespace(ns, ic.annotationFilters); err != nil {
+		return err
+	}
+
+	for _, r := range ic.extraRoutes {
+		if err := validateSecretNamespace(ns, r.Filters); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // parse backend annotation
 func annotationBackend(m *definitions.Metadata) (eskip.BackendType, error) {
 	if s, ok := m.Annotations[definitions.IngressBackendAnnotation]; ok {
@@ -479,7 +496,9 @@ func (ing *ingress) convert(state *clusterState, df defaultFilters, r *certregis
 		ewroutes := make([]*eskip.Route, 0, len(routes))
 		for _, r := range routes {
 			if v, ok := ewIngInfo[r.Id]; ok {
-				ewroutes = append(ewroutes, createEastWestRouteIng(ing.kubernetesEastWestDomain, v[0], v[1], r))
+				ewr := createEastWestRouteIng(ing.kubernetesEastWestDomain, v[0], v[1], r)
+				state.copyEndpointsLookup(r, ewr)
+				ewroutes = append(ewroutes, ewr)
 			}
 		}
 		l := len(routes)
@@ -86,10 +86,9 @@ func convertPathRuleV1(
 		epSlices []skipperEndpoint
 		err      error
 		svc      *service
+		lookup   *endpointsLookup
 	)
 
-	dataclientZone := ic.zone
-
 	var hostRegexp []string
 	if host != "" {
 		hostRegexp = []string{createHostRx(host)}
@@ -126,13 +125,10 @@ func convertPathRuleV1(
 			annotationSet = true
 		}
 
-		if state.enableEndpointSlices {
-			epSlices = state.GetEndpointSlicesByService(dataclientZone, ns, svcName, protocol, annotationSet, servicePort, ic)
-			for _, ep := range epSlices {
-				eps = append(eps, ep.Address)
-			}
-		} else {
-			eps = state.GetEndpointsByService(ns, svcName, protocol, servicePort)
+		lookup = serviceEndpointsLookup(ic, ns, svcName, protocol, annotationSet, servicePort)
+		epSlices = lookup.endpoints(state)
+		for _, ep := range epSlices {
+			eps = append(eps, ep.Address)
 		}
 	}
 	if len(eps) == 0 {
@@ -146,6 +142,7 @@ func convertPathRuleV1(
 		setPathV1(pathMode, r, prule.PathType, prule.Path)
 		traffic.apply(r)
 		shuntRoute(r)
+		state.setEndpointsLookup(r, lookup)
 		return r, nil
 	}
 
@@ -161,6 +158,7 @@ func convertPathRuleV1(
 
 		setPathV1(pathMode, r, prule.PathType, prule.Path)
 		traffic.apply(r)
+		state.setEndpointsLookup(r, lookup)
 		return r, nil
 	}
 
@@ -171,22 +169,14 @@ func convertPathRuleV1(
 		HostRegexps: hostRegexp,
 	}
 
-	if state.enableEndpointSlices {
-		var lbeps []*eskip.LBEndpoint
-		for _, ep := range epSlices {
-			lbeps = append(lbeps, &eskip.LBEndpoint{Address: ep.Address, Zone: ep.Zone})
-		}
-		r.LBEndpoints = lbeps
-	} else {
-		r.LBEndpoints = eskip.NewLBEndpoints(eps)
-	}
-
+	r.LBEndpoints = newLBEndpoints(epSlices)
 	if f := zoneAwarenessAnnotationFilter(metadata); f != nil {
 		r.Filters = append([]*eskip.Filter{f}, r.Filters...)
 	}
 
 	setPathV1(pathMode, r, prule.PathType, prule.Path)
 	traffic.apply(r)
+	state.setEndpointsLookup(r, lookup)
 	return r, nil
 }
 
@@ -247,6 +237,9 @@ func (ing *ingress) addEndpointsRuleV1(ic *ingressContext, host string, prule *d
 	}
 
 	prependApplicationAnnotation(meta.Labels, ing.kubernetesApplicationAnnotationLabel, endpointsRoute)
+	if endpointsRoute.BackendType != eskip.ShuntBackend {
+		ic.state.policies.apply(definitions.IngressKind, meta, []string{host}, endpointsRoute)
+	}
 
 	ic.addHostRoute(host, endpointsRoute)
 
@@ -260,7 +253,9 @@ func (ing *ingress) addEndpointsRuleV1(ic *ingressContext, host string, prule *d
 			ic.addHostRoute(host, createIngressEnableHTTPSRedirect(endpointsRoute, redirect.code))
 			redirect.setHost(host)
 		case redirect.disable:
-			ic.addHostRoute(host, createIngressDisableHTTPSRedirect(endpointsRoute))
+			disableRedirectRoute := createIngressDisableHTTPSRedirect(endpointsRoute)
+			ic.state.copyEndpointsLookup(endpointsRoute, disableRedirectRoute)
+			ic.addHostRoute(host, disableRedirectRoute)
 			redirect.setHostDisabled(host)
 		case redirect.defaultEnabled:
 			ic.addHostRoute(host, createIngressEnableHTTPSRedirect(endpointsRoute, redirect.code))
@@ -276,6 +271,7 @@ func (ing *ingress) addEndpointsRuleV1(ic *ingressContext, host string, prule *d
 
 	if ing.kubernetesEnableEastWest {
 		ewRoute := createEastWestRouteIng(ing.kubernetesEastWestDomain, meta.Name, meta.Namespace, endpointsRoute)
+		ic.state.copyEndpointsLookup(endpointsRoute, ewRoute)
 		ewHost := fmt.Sprintf("%s.%s.%s", meta.Name, meta.Namespace, ing.kubernetesEastWestDomain)
 		ic.addHostRoute(ewHost, ewRoute)
 	}
@@ -380,14 +376,13 @@ func (ing *ingress) convertDefaultBackendV1(
 		eps      []string
 		epSlices []skipperEndpoint
 		err      error
+		lookup   *endpointsLookup
 		ns       = i.Metadata.Namespace
 		name     = i.Metadata.Name
 		svcName  = i.Spec.DefaultBackend.Service.Name
 		svcPort  = i.Spec.DefaultBackend.Service.Port
 	)
 
-	dataclientZone := ic.zone
-
 	svc, err := state.getService(ns, svcName)
 	if err != nil {
 		ic.logger.Errorf("Failed to get service %s, %s", svcName, svcPort)
@@ -416,20 +411,12 @@ func (ing *ingress) convertDefaultBackendV1(
 			annotationSet = true
 		}
 
-		if state.enableEndpointSlices {
-			epSlices = state.GetEndpointSlicesByService(dataclientZone, ns, svcName, protocol, annotationSet, servicePort, ic)
-			for _, ep := range epSlices {
-				eps = append(eps, ep.Address)
-			}
-		} else {
-			eps = state.GetEndpointsByService(
-				ns,
-				svcName,
-				protocol,
-				servicePort,
-			)
-			ic.logger.Debugf("Found %d endpoints for %s: %v", len(eps), svcName, err)
+		lookup = serviceEndpointsLookup(ic, ns, svcName, protocol, annotationSet, servicePort)
+		epSlices = lookup.endpoints(state)
+		for _, ep := range epSlices {
+			eps = append(eps, ep.Address)
 		}
+		ic.logger.Debugf("Found %d endpoints for %s", len(eps), svcName)
 	}
 
 	if len(eps) == 0 {
@@ -439,13 +426,16 @@ func (ing *ingress) convertDefaultBackendV1(
 			Id: routeID(ns, name, "", "", ""),
 		}
 		shuntRoute(r)
+		state.setEndpointsLookup(r, lookup)
 		return r, true, nil
 	} else if len(eps) == 1 {
-		return &eskip.Route{
+		r := &eskip.Route{
 			Id:          routeID(ns, name, "", "", ""),
 			Backend:     eps[0],
 			BackendType: eskip.NetworkBackend,
-		}, true, nil
+		}
+		state.setEndpointsLookup(r, lookup)
+		return r, true, nil
 	}
 
 	r := &eskip.Route{
@@ -454,23 +444,30 @@ func (ing *ingress) convertDefaultBackendV1(
 		LBAlgorithm: getLoadBalancerAlgorithm(i.Metadata, ing.defaultLoadBalancerAlgorithm),
 	}
 
-	if state.enableEndpointSlices {
-		var lbeps []*eskip.LBEndpoint
-		for _, ep := range epSlices {
-			lbeps = append(lbeps, &eskip.LBEndpoint{Address: ep.Address, Zone: ep.Zone})
-		}
-		r.LBEndpoints = lbeps
-	} else {
-		r.LBEndpoints = eskip.NewLBEndpoints(eps)
-	}
-
+	r.LBEndpoints = newLBEndpoints(epSlices)
 	if f := zoneAwarenessAnnotationFilter(i.Metadata); f != nil {
 		r.Filters = append([]*eskip.Filter{f}, r.Filters...)
 	}
 
+	state.setEndpointsLookup(r, lookup)
 	return r, true, nil
 }
 
+// serviceEndpointsLookup looks up the endpoints of the service port from
+// the endpointslices, when enabled, or from the endpoints.
+func serviceEndpointsLookup(ic *ingressContext, ns, svcName, protocol string, annotationSet bool, servicePort *servicePort) *endpointsLookup {
+	return &endpointsLookup{
+		service: newResourceID(ns, svcName),
+		endpoints: func(state *clusterState) []skipperEndpoint {
+			if state.enableEndpointSlices {
+				return state.GetEndpointSlicesByService(ic.zone, ns, svcName, protocol, annotationSet, servicePort, ic)
+			}
+
+			return endpointsFromAddresses(state.GetEndpointsByService(ns, svcName, protocol, servicePort))
+		},
+	}
+}
+
 func serviceNameBackend(svcName, svcNamespace string, servicePort *servicePort) string {
 	scheme := "https"
 	if n, _ := servicePort.TargetPort.Number(); n != 443 {
@@ -517,11 +514,19 @@ func (ing *ingress) ingressV1Route(
 		disableZoneAwareness:     i.Metadata.Annotations[trafficZoneAwareAnnotationKey] == "false",
 	}
 
+	if err := ic.validateSecretNamespace(); err != nil {
+		ic.logger.Errorf("Ignoring ingress: %v", err)
+		return nil, nil
+	}
+
 	var route *eskip.Route
 	if r, ok, err := ing.convertDefaultBackendV1(ic, ing.forceKubernetesService); ok {
 		route = r
 		ic.applyBackend(route)
 		prependApplicationAnnotation(i.Metadata.Labels, ing.kubernetesApplicationAnnotationLabel, r)
+		if r.BackendType != eskip.ShuntBackend {
+			state.policies.apply(definitions.IngressKind, i.Metadata, nil, r)
+		}
 	} else if err != nil {
 		ic.logger.Errorf("Failed to convert default backend: %v", err)
 	}
@@ -2,6 +2,7 @@ package kubernetes
 
 import (
 	"fmt"
+	"maps"
 	"net"
 	"net/http"
 	"os"
@@ -12,14 +13,20 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
 	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/loadbalancer"
+	"github.com/zalando/skipper/secrets"
 	"github.com/zalando/skipper/secrets/certregistry"
 )
 
 const DefaultLoadBalancerAlgorithm = "roundRobin"
 
+// DefaultGatewayControllerName is the controller name of the Gateway API
+// GatewayClasses that are implemented by skipper.
+const DefaultGatewayControllerName = "zalando.org/skipper"
+
 const (
 	defaultIngressClass    = "skipper"
 	defaultRouteGroupClass = "skipper"
@@ -186,7 +193,10 @@ type Options struct {
 	// want to set this to true.
 	ReverseSourcePredicate bool
 
-	// Noop, WIP.
+	// ForceFullUpdatePeriod, when watching is enabled, sets the period after which the routes are
+	// converted again even when no resource change was watched. It picks up the changes of the
+	// default filters and the installation of the RouteGroup and Gateway API CRDs. Defaults to
+	// one minute.
 	ForceFullUpdatePeriod time.Duration
 
 	// WhitelistedHealthcheckCIDR to be appended to the default iprange
@@ -258,6 +268,12 @@ type Options struct {
 
 	CertificateRegistry *certregistry.CertRegistry
 
+	// SecretsMap, when set, is updated with the data of the Kubernetes
+	// secrets selected by SecretsLabelSelectors, with the secret names
+	// in the format namespace/name/key. It enables filters to read
+	// secrets like the JWKS of jwtValidationSecret.
+	SecretsMap *secrets.SecretsMap
+
 	// ForceKubernetesService overrides the default Skipper functionality to route traffic using
 	// Kubernetes Endpoint, instead using Kubernetes Services.
 	ForceKubernetesService bool
@@ -267,7 +283,7 @@ type Options struct {
 
 	// DefaultLoadBalancerAlgorithm sets the default algorithm to be used for load balancing between backend endpoints,
 	// available options: roundRobin, consistentHash, random, powerOfRandomNChoices,
-	// weightedRoundRobin, leastRequests
+	// weightedRoundRobin, leastRequests, ringHash, maglev
 	DefaultLoadBalancerAlgorithm string
 
 	// ForwardBackendURL allows to use <forward> backend via kubernetes, for example routegroup backend `type: forward`.
@@ -276,9 +292,49 @@ type Options struct {
 	// TopologyZone if set to non empty string will be used to filter endpointslice endpoints by this value.
 	TopologyZone string
 
+	// ZoneAwareLoadBalancing disables the filtering of the endpoints by
+	// TopologyZone, and keeps the endpoints of all zones in the routes, so
+	// that the load balancer can prefer the endpoints of its own zone and
+	// fall back to the other zones at runtime.
+	ZoneAwareLoadBalancing bool
+
 	// IngressStatusFromService, when set to <namespace>/<name>, makes skipper update ingress status.loadBalancer.ingress
-	// addresses from the referenced Service object.
+	// addresses from the referenced Service object. The same addresses are used as the status addresses of the
+	// Gateway API Gateways.
 	IngressStatusFromService string
+
+	// EnableGatewayAPI enables the conversion of the Kubernetes Gateway API resources GatewayClass, Gateway,
+	// HTTPRoute and ReferenceGrant to routes, and the update of their status conditions.
+	EnableGatewayAPI bool
+
+	// GatewayControllerName is the controller name of the GatewayClasses implemented by skipper.
+	// Defaults to DefaultGatewayControllerName.
+	GatewayControllerName string
+
+	// EnableWatch makes the client watch the Kubernetes resources instead of listing them on every
+	// poll. The routes are converted again only when a watched resource has changed.
+	EnableWatch bool
+
+	// EnableRouteGroupStatus enables the update of the RouteGroup status with the Accepted and
+	// Programmed conditions, the errors of the invalid routes, and the load balancer addresses
+	// when IngressStatusFromService is set.
+	EnableRouteGroupStatus bool
+
+	// EnableRouteGroupCrossNamespaceBackends allows the RouteGroup service backends to reference
+	// Services in other namespaces with the serviceNamespace field. The reference needs to be
+	// allowed by a ReferenceGrant in the namespace of the Service, from the RouteGroups of the
+	// namespace of the RouteGroup.
+	EnableRouteGroupCrossNamespaceBackends bool
+
+	// RemoteClusters maps cluster names to the paths of kubeconfig files. The RouteGroup service
+	// backends can reference the Services of these clusters with the cluster field, and their
+	// endpoints are resolved from the API server of the referenced cluster.
+	RemoteClusters map[string]string
+
+	// EnablePolicies enables the RatelimitPolicy and AuthPolicy resources. Their ratelimit and
+	// tokeninfo filters are applied to the routes of the targeted RouteGroups and Ingresses, and
+	// their status lists the IDs of these routes.
+	EnablePolicies bool
 }
 
 // Client is a Skipper DataClient implementation used to create routes based on Kubernetes Ingress settings.
@@ -287,11 +343,13 @@ type Client struct {
 	ClusterClient          *clusterClient
 	ingress                *ingress
 	routeGroups            *routeGroups
+	gatewayAPI             *gatewayAPI
 	provideHealthcheck     bool
 	provideHTTPSRedirect   bool
 	reverseSourcePredicate bool
 	httpsRedirectCode      int
 	current                map[string]*eskip.Route
+	endpointsLookups       map[string]*endpointsLookup
 	quit                   chan struct{}
 	defaultFiltersDir      string
 	forwardBackendURL      string
@@ -299,6 +357,17 @@ type Client struct {
 	state                  *clusterState
 	loggingInterval        time.Duration
 	loggingLastEnabled     time.Time
+	forceFullUpdatePeriod  time.Duration
+	lastFullUpdate         time.Time
+	routeGroupStatus       bool
+	routeGroupsStatus      *routeGroupsStatus
+	policies               bool
+
+	// invalidRoutes are received from the routing, and
+	// guarded by their own mutex to not block the routing
+	invalidRoutesMu      sync.Mutex
+	invalidRoutes        map[string]string
+	invalidRoutesChanged bool
 }
 
 // New creates and initializes a Kubernetes DataClient.
@@ -341,6 +410,19 @@ func New(o Options) (*Client, error) {
 		o.HTTPSRedirectCode = http.StatusPermanentRedirect
 	}
 
+	if o.ZoneAwareLoadBalancing {
+		// the zones of the endpoints are handled by the load balancer
+		o.TopologyZone = ""
+	}
+
+	if o.GatewayControllerName == "" {
+		o.GatewayControllerName = DefaultGatewayControllerName
+	}
+
+	if o.ForceFullUpdatePeriod <= 0 {
+		o.ForceFullUpdatePeriod = defaultForceFullUpdatePeriod
+	}
+
 	if o.KubernetesEnableEastWest {
 		if o.KubernetesEastWestDomain == "" {
 			o.KubernetesEastWestDomain = defaultEastWestDomain
@@ -365,10 +447,16 @@ func New(o Options) (*Client, error) {
 	ing := newIngress(o)
 	rg := newRouteGroups(o)
 
+	var gw *gatewayAPI
+	if o.EnableGatewayAPI {
+		gw = newGatewayAPI(o)
+	}
+
 	return &Client{
 		ClusterClient:          clusterClient,
 		ingress:                ing,
 		routeGroups:            rg,
+		gatewayAPI:             gw,
 		provideHealthcheck:     o.ProvideHealthcheck,
 		provideHTTPSRedirect:   o.ProvideHTTPSRedirect,
 		httpsRedirectCode:      o.HTTPSRedirectCode,
@@ -378,6 +466,9 @@ func New(o Options) (*Client, error) {
 		defaultFiltersDir:      o.DefaultFiltersDir,
 		forwardBackendURL:      o.ForwardBackendURL,
 		loggingInterval:        1 * time.Minute,
+		forceFullUpdatePeriod:  o.ForceFullUpdatePeriod,
+		routeGroupStatus:       o.EnableRouteGroupStatus,
+		policies:               o.EnablePolicies,
 		zone:                   o.TopologyZone,
 	}, nil
 }
@@ -455,6 +546,7 @@ func (c *Client) loadAndConvert() ([]*eskip.Route, error) {
 		return nil, err
 	}
 	c.state = state
+	c.lastFullUpdate = time.Now()
 
 	loggingEnabled := log.GetLevel() >= log.DebugLevel || time.Since(c.loggingLastEnabled) >= c.loggingInterval
 	if loggingEnabled {
@@ -469,13 +561,34 @@ func (c *Client) loadAndConvert() ([]*eskip.Route, error) {
 		return nil, err
 	}
 
-	rg, err := c.routeGroups.convert(state, defaultFilters, loggingEnabled, c.ClusterClient.certificateRegistry)
+	rg, rgStatus, err := c.routeGroups.convert(state, defaultFilters, loggingEnabled, c.ClusterClient.certificateRegistry)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.routeGroupStatus {
+		c.mu.Lock()
+		c.routeGroupsStatus = rgStatus
+		c.mu.Unlock()
+
+		c.updateRouteGroupsStatus(state, rgStatus)
+	}
+
 	r := append(ri, rg...)
 
+	if c.gatewayAPI != nil {
+		gw, status := c.gatewayAPI.convert(state, defaultFilters, loggingEnabled, c.ClusterClient.certificateRegistry)
+		r = append(r, gw...)
+
+		if err := c.ClusterClient.updateGatewayAPIStatus(state, status); err != nil {
+			log.Errorf("failed to update Gateway API status: %v", err)
+		}
+	}
+
+	if c.policies {
+		c.updatePoliciesStatus(state, r)
+	}
+
 	if c.provideHealthcheck {
 		r = append(r, healthcheckRoutes(c.reverseSourcePredicate)...)
 	}
@@ -544,12 +657,18 @@ func healthcheckRoutes(reverseSourcePredicate bool) []*eskip.Route {
 
 func (c *Client) LoadAll() ([]*eskip.Route, error) {
 	log.Debug("loading all")
+
+	// all the resources are loaded, the already watched changes are
+	// included
+	c.ClusterClient.hasChanges()
+
 	r, err := c.loadAndConvert()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load cluster state: %w", err)
 	}
 
 	c.current, r = mapRoutes(r)
+	c.updateEndpointsLookups()
 
 	log.Debugf("all routes loaded and mapped: %d", len(r))
 
@@ -559,9 +678,27 @@ func (c *Client) LoadAll() ([]*eskip.Route, error) {
 // LoadUpdate returns all known eskip.Route, a list of route IDs
 // scheduled for delete and an error.
 //
-// TODO: implement a force reset after some time.
+// When watching is enabled, the routes are only converted again when a
+// watched resource has changed, or ForceFullUpdatePeriod has passed. When
+// only Endpoints or EndpointSlices changed, only the endpoints of the
+// routes of the affected services are updated, as long as the backend
+// type of these routes doesn't change.
 func (c *Client) LoadUpdate() ([]*eskip.Route, []string, error) {
 	log.Debugf("polling for updates")
+	services, other := c.ClusterClient.changes()
+	if !other && time.Since(c.lastFullUpdate) < c.forceFullUpdatePeriod {
+		if len(services) == 0 {
+			log.Debugf("no changes watched")
+			c.updateInvalidRoutesStatus()
+			return nil, nil, nil
+		}
+
+		if updatedRoutes, ok := c.updateEndpoints(services); ok {
+			c.updateInvalidRoutesStatus()
+			return updatedRoutes, nil, nil
+		}
+	}
+
 	r, err := c.loadAndConvert()
 	if err != nil {
 		log.Errorf("polling for updates failed: %v", err)
@@ -596,9 +733,133 @@ func (c *Client) LoadUpdate() ([]*eskip.Route, []string, error) {
 	}
 
 	c.current = next
+	c.updateEndpointsLookups()
 	return updatedRoutes, deletedIDs, nil
 }
 
+func (c *Client) updateEndpointsLookups() {
+	c.mu.Lock()
+	state := c.state
+	c.mu.Unlock()
+
+	c.endpointsLookups = state.routeEndpointsLookups(c.current)
+}
+
+// updateEndpoints updates the endpoints of the routes of the services,
+// without converting the other resources again. It returns false, when
+// the routes need to be converted again, because the backend type of a
+// route changes.
+func (c *Client) updateEndpoints(services map[definitions.ResourceID]bool) ([]*eskip.Route, bool) {
+	c.mu.Lock()
+	state := c.state
+	c.mu.Unlock()
+
+	if state == nil {
+		return nil, false
+	}
+
+	next, err := c.ClusterClient.fetchEndpointsState(state)
+	if err != nil {
+		log.Errorf("polling for endpoint updates failed: %v", err)
+		return nil, false
+	}
+
+	var updatedRoutes []*eskip.Route
+	for id, l := range c.endpointsLookups {
+		if !services[l.service] {
+			continue
+		}
+
+		r := c.current[id]
+		eps := l.endpoints(next)
+		if r.BackendType != eskip.LBBackend || len(eps) < 2 {
+			log.Debugf("backend of route %s changes, converting all routes", id)
+			return nil, false
+		}
+
+		ur := *r
+		ur.LBEndpoints = newLBEndpoints(eps)
+		if ur.String() != r.String() {
+			updatedRoutes = append(updatedRoutes, &ur)
+		}
+	}
+
+	for _, r := range updatedRoutes {
+		c.current[r.Id] = r
+	}
+
+	c.mu.Lock()
+	c.state = next
+	c.mu.Unlock()
+
+	if len(updatedRoutes) > 0 {
+		log.Infof("endpoints updated, updates: %d", len(updatedRoutes))
+	}
+
+	return updatedRoutes, true
+}
+
+// ReceiveInvalidRoutes implements routing.InvalidRoutesReceiver. When the
+// RouteGroup status is enabled, the errors are reported in the status of
+// the RouteGroups, that the invalid routes were created from.
+func (c *Client) ReceiveInvalidRoutes(errs map[string]string) {
+	if !c.routeGroupStatus {
+		return
+	}
+
+	c.invalidRoutesMu.Lock()
+	defer c.invalidRoutesMu.Unlock()
+	if !maps.Equal(c.invalidRoutes, errs) {
+		c.invalidRoutes = errs
+		c.invalidRoutesChanged = true
+	}
+}
+
+func (c *Client) updateRouteGroupsStatus(state *clusterState, status *routeGroupsStatus) {
+	c.invalidRoutesMu.Lock()
+	invalidRoutes := c.invalidRoutes
+	c.invalidRoutesChanged = false
+	c.invalidRoutesMu.Unlock()
+
+	if err := c.ClusterClient.updateRouteGroupsStatus(state, status, invalidRoutes); err != nil {
+		log.Errorf("failed to update RouteGroup status: %v", err)
+	}
+}
+
+// updateInvalidRoutesStatus updates the RouteGroup status, when the
+// invalid routes changed without changing the cluster state.
+func (c *Client) updateInvalidRoutesStatus() {
+	if !c.routeGroupStatus {
+		return
+	}
+
+	c.invalidRoutesMu.Lock()
+	changed := c.invalidRoutesChanged
+	c.invalidRoutesMu.Unlock()
+	if !changed {
+		return
+	}
+
+	c.mu.Lock()
+	state, status := c.state, c.routeGroupsStatus
+	c.mu.Unlock()
+
+	c.updateRouteGroupsStatus(state, status)
+}
+
+// updatePoliciesStatus updates the status of the policies with the IDs of
+// the final routes they were applied to.
+func (c *Client) updatePoliciesStatus(state *clusterState, routes []*eskip.Route) {
+	routeIDs := make(map[string]bool)
+	for _, r := range routes {
+		routeIDs[r.Id] = true
+	}
+
+	if err := c.ClusterClient.updatePoliciesStatus(state, routeIDs); err != nil {
+		log.Errorf("failed to update policy status: %v", err)
+	}
+}
+
 func (c *Client) Close() {
 	if c != nil && c.quit != nil {
 		close(c.quit)
@@ -30,6 +30,7 @@ import (
 
 	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
 	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/secrets"
 	"github.com/zalando/skipper/secrets/certregistry"
 )
 
@@ -2958,6 +2959,44 @@ func TestCertificateRegistry(t *testing.T) {
 	})
 }
 
+func TestSecretsMap(t *testing.T) {
+	api := newTestAPI(t, nil, &definitions.IngressV1List{})
+	defer api.Close()
+
+	api.secrets = &secretList{
+		Items: []*secret{
+			testSecret("namespace1", "secret1", nil, "", "Opaque", map[string]string{"jwks": "Zm9v", "pem": "YmFy"}),
+			testSecret("namespace2", "secret2", nil, "", "Opaque", map[string]string{"invalid": "not base64"}),
+		},
+	}
+
+	sm := secrets.NewSecretsMap()
+	dc, err := New(Options{KubernetesURL: api.server.URL, SecretsMap: sm})
+	require.NoError(t, err)
+	defer dc.Close()
+
+	_, err = dc.LoadAll()
+	require.NoError(t, err)
+
+	data, ok := sm.GetSecret("namespace1/secret1/jwks")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("foo"), data)
+
+	data, ok = sm.GetSecret("namespace1/secret1/pem")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("bar"), data)
+
+	_, ok = sm.GetSecret("namespace2/secret2/invalid")
+	assert.False(t, ok)
+
+	api.secrets = &secretList{}
+	_, _, err = dc.LoadUpdate()
+	require.NoError(t, err)
+
+	_, ok = sm.GetSecret("namespace1/secret1/jwks")
+	assert.False(t, ok, "deleted secrets are removed")
+}
+
 type mockSecretProvider string
 
 func (sp mockSecretProvider) GetSecret(string) ([]byte, bool) {
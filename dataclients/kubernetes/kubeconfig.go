@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// kubeconfig contains the fields of a kubeconfig file that are used to
+// access the API server of a remote cluster.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+
+	Clusters []struct {
+		Name    string            `yaml:"name"`
+		Cluster kubeconfigCluster `yaml:"cluster"`
+	} `yaml:"clusters"`
+
+	Contexts []struct {
+		Name    string            `yaml:"name"`
+		Context kubeconfigContext `yaml:"context"`
+	} `yaml:"contexts"`
+
+	Users []struct {
+		Name string         `yaml:"name"`
+		User kubeconfigUser `yaml:"user"`
+	} `yaml:"users"`
+}
+
+type kubeconfigCluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthority     string `yaml:"certificate-authority"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+}
+
+type kubeconfigContext struct {
+	Cluster string `yaml:"cluster"`
+	User    string `yaml:"user"`
+}
+
+type kubeconfigUser struct {
+	Token                 string `yaml:"token"`
+	TokenFile             string `yaml:"tokenFile"`
+	ClientCertificate     string `yaml:"client-certificate"`
+	ClientCertificateData string `yaml:"client-certificate-data"`
+	ClientKey             string `yaml:"client-key"`
+	ClientKeyData         string `yaml:"client-key-data"`
+}
+
+// kubeconfigAccess is the API server address and the credentials of the
+// current context of a kubeconfig.
+type kubeconfigAccess struct {
+	server    string
+	tlsConfig *tls.Config
+	token     string
+	tokenFile string
+}
+
+// loadKubeconfig reads the kubeconfig file and returns the access to the
+// cluster of the current context, or of the first context when the
+// current context is not set. The relative file paths in the kubeconfig
+// are resolved relative to the directory of the kubeconfig file.
+func loadKubeconfig(path string) (*kubeconfigAccess, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kc kubeconfig
+	if err := yaml.Unmarshal(b, &kc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+
+	if len(kc.Contexts) == 0 {
+		return nil, fmt.Errorf("no context in kubeconfig %s", path)
+	}
+
+	context := &kc.Contexts[0].Context
+	if kc.CurrentContext != "" {
+		context = nil
+		for i := range kc.Contexts {
+			if kc.Contexts[i].Name == kc.CurrentContext {
+				context = &kc.Contexts[i].Context
+				break
+			}
+		}
+
+		if context == nil {
+			return nil, fmt.Errorf("context %s not found in kubeconfig %s", kc.CurrentContext, path)
+		}
+	}
+
+	var cluster *kubeconfigCluster
+	for i := range kc.Clusters {
+		if kc.Clusters[i].Name == context.Cluster {
+			cluster = &kc.Clusters[i].Cluster
+			break
+		}
+	}
+
+	if cluster == nil || cluster.Server == "" {
+		return nil, fmt.Errorf("cluster %s not found in kubeconfig %s", context.Cluster, path)
+	}
+
+	var user kubeconfigUser
+	for i := range kc.Users {
+		if kc.Users[i].Name == context.User {
+			user = kc.Users[i].User
+			break
+		}
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+
+		return filepath.Join(dir, p)
+	}
+
+	a := &kubeconfigAccess{
+		server:    cluster.Server,
+		tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+		token:     user.Token,
+		tokenFile: resolve(user.TokenFile),
+	}
+
+	if cluster.InsecureSkipTLSVerify {
+		a.tlsConfig.InsecureSkipVerify = true
+	}
+
+	ca, err := kubeconfigData(cluster.CertificateAuthorityData, resolve(cluster.CertificateAuthority))
+	if err != nil {
+		return nil, err
+	}
+
+	if ca != nil {
+		a.tlsConfig.RootCAs = x509.NewCertPool()
+		if !a.tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errInvalidCertificate
+		}
+	}
+
+	cert, err := kubeconfigData(user.ClientCertificateData, resolve(user.ClientCertificate))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := kubeconfigData(user.ClientKeyData, resolve(user.ClientKey))
+	if err != nil {
+		return nil, err
+	}
+
+	if cert != nil || key != nil {
+		c, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate in kubeconfig %s: %w", path, err)
+		}
+
+		a.tlsConfig.Certificates = []tls.Certificate{c}
+	}
+
+	return a, nil
+}
+
+// kubeconfigData returns the base64 encoded inline data, when set, or
+// the contents of the file.
+func kubeconfigData(data, file string) ([]byte, error) {
+	switch {
+	case data != "":
+		return base64.StdEncoding.DecodeString(data)
+	case file != "":
+		return os.ReadFile(file)
+	default:
+		return nil, nil
+	}
+}
@@ -25,12 +25,18 @@ type TestAPIOptions struct {
 }
 
 type namespace struct {
-	services       []byte
-	ingresses      []byte
-	routeGroups    []byte
-	endpoints      []byte
-	endpointslices []byte
-	secrets        []byte
+	services        []byte
+	ingresses       []byte
+	routeGroups     []byte
+	endpoints       []byte
+	endpointslices  []byte
+	secrets         []byte
+	gatewayClasses  []byte
+	gateways        []byte
+	httpRoutes      []byte
+	referenceGrants []byte
+	ratelimits      []byte
+	authPolicies    []byte
 }
 
 type api struct {
@@ -41,6 +47,7 @@ type api struct {
 	all          namespace
 	pathRx       *regexp.Regexp
 	resourceList []byte
+	gatewayList  []byte
 }
 
 type KubeTestAPI interface {
@@ -52,7 +59,7 @@ func NewAPI(o TestAPIOptions, specs ...io.Reader) (*api, error) {
 		namespaces: make(map[string]namespace),
 		// see https://kubernetes.io/docs/reference/using-api/api-concepts/#resource-uris
 		pathRx: regexp.MustCompile(
-			"(?:/namespaces/([^/]+))?/(services|ingresses|routegroups|endpointslices|endpoints|secrets)(?:/(.+))?",
+			"(?:/namespaces/([^/]+))?/(services|ingresses|routegroups|endpointslices|endpoints|secrets|gatewayclasses|gateways|httproutes|referencegrants|ratelimitpolicies|authpolicies)(?:/(.+))?",
 		),
 	}
 
@@ -71,6 +78,11 @@ func NewAPI(o TestAPIOptions, specs ...io.Reader) (*api, error) {
 
 	a.resourceList = clrb
 
+	gwrl := kubernetes.ClusterResourceList{Items: []*kubernetes.ClusterResource{{Name: kubernetes.GatewayClassesName}}}
+	if a.gatewayList, err = json.Marshal(gwrl); err != nil {
+		return nil, err
+	}
+
 	err = a.update(specs...)
 
 	return a, err
@@ -170,6 +182,13 @@ func (a *api) UpdateSpec(specs ...io.Reader) error {
 func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+
+	// status updates are accepted but not stored
+	if r.Method == "PATCH" && strings.HasSuffix(r.URL.Path, "/status") {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	if r.Method != "GET" {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
@@ -190,6 +209,11 @@ func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Path == kubernetes.GatewayAPIClusterURI {
+		w.Write(a.gatewayList)
+		return
+	}
+
 	parts := a.pathRx.FindStringSubmatch(r.URL.Path)
 	if len(parts) == 0 {
 		w.WriteHeader(http.StatusNotFound)
@@ -215,6 +239,18 @@ func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		serve(w, r, ns.endpointslices, name)
 	case "secrets":
 		serve(w, r, ns.secrets, name)
+	case "gatewayclasses":
+		serve(w, r, ns.gatewayClasses, name)
+	case "gateways":
+		serve(w, r, ns.gateways, name)
+	case "httproutes":
+		serve(w, r, ns.httpRoutes, name)
+	case "referencegrants":
+		serve(w, r, ns.referenceGrants, name)
+	case "ratelimitpolicies":
+		serve(w, r, ns.ratelimits, name)
+	case "authpolicies":
+		serve(w, r, ns.authPolicies, name)
 	default:
 		http.Error(w, fmt.Sprintf("unsupported resource type %s", resourceType), http.StatusBadRequest)
 	}
@@ -329,6 +365,30 @@ func initNamespace(kinds map[string][]interface{}) (ns namespace, err error) {
 		return
 	}
 
+	if err = itemsJSON(&ns.gatewayClasses, kinds["GatewayClass"]); err != nil {
+		return
+	}
+
+	if err = itemsJSON(&ns.gateways, kinds["Gateway"]); err != nil {
+		return
+	}
+
+	if err = itemsJSON(&ns.httpRoutes, kinds["HTTPRoute"]); err != nil {
+		return
+	}
+
+	if err = itemsJSON(&ns.referenceGrants, kinds["ReferenceGrant"]); err != nil {
+		return
+	}
+
+	if err = itemsJSON(&ns.ratelimits, kinds["RatelimitPolicy"]); err != nil {
+		return
+	}
+
+	if err = itemsJSON(&ns.authPolicies, kinds["AuthPolicy"]); err != nil {
+		return
+	}
+
 	return
 }
 
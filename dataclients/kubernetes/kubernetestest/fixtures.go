@@ -57,11 +57,16 @@ type kubeOptionsParser struct {
 	DefaultLoadBalancerAlgorithm                   string                            `yaml:"default-lb-algorithm"`
 	ForwardBackendURL                              string                            `yaml:"forward-backend-url"`
 	TopologyZone                                   string                            `yaml:"topology-zone"`
+	ZoneAwareLoadBalancing                         bool                              `yaml:"zone-aware-load-balancing"`
 	KubernetesAnnotationPredicates                 []kubernetes.AnnotationPredicates `yaml:"kubernetesAnnotationPredicates"`
 	KubernetesAnnotationFiltersAppend              []kubernetes.AnnotationFilters    `yaml:"kubernetesAnnotationFiltersAppend"`
 	KubernetesApplicationAnnotationLabelKey        string                            `yaml:"kubernetes-application-annotation-label"`
 	KubernetesEastWestRangeAnnotationPredicates    []kubernetes.AnnotationPredicates `yaml:"kubernetesEastWestRangeAnnotationPredicates"`
 	KubernetesEastWestRangeAnnotationFiltersAppend []kubernetes.AnnotationFilters    `yaml:"kubernetesEastWestRangeAnnotationFiltersAppend"`
+	EnableGatewayAPI                               bool                              `yaml:"enable-kubernetes-gateway-api"`
+	GatewayControllerName                          string                            `yaml:"kubernetes-gateway-controller-name"`
+	EnableRouteGroupCrossNamespaceBackends         bool                              `yaml:"enable-kubernetes-routegroup-cross-namespace-backends"`
+	EnablePolicies                                 bool                              `yaml:"enable-kubernetes-policies"`
 }
 
 func baseNoExt(n string) string {
@@ -255,6 +260,11 @@ func testFixture(t *testing.T, f fixtureSet) {
 		o.DefaultLoadBalancerAlgorithm = kop.DefaultLoadBalancerAlgorithm
 		o.ForwardBackendURL = kop.ForwardBackendURL
 		o.TopologyZone = kop.TopologyZone
+		o.ZoneAwareLoadBalancing = kop.ZoneAwareLoadBalancing
+		o.EnableGatewayAPI = kop.EnableGatewayAPI
+		o.GatewayControllerName = kop.GatewayControllerName
+		o.EnableRouteGroupCrossNamespaceBackends = kop.EnableRouteGroupCrossNamespaceBackends
+		o.EnablePolicies = kop.EnablePolicies
 
 		o.KubernetesApplicationAnnotationLabelKey = kop.KubernetesApplicationAnnotationLabelKey
 
@@ -0,0 +1,319 @@
+package kubernetes
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+)
+
+// policyLevel is the precedence of a policy targeting a route, the lower
+// level wins.
+type policyLevel int
+
+const (
+	policyLevelTargetRef policyLevel = iota
+	policyLevelHosts
+	policyLevelSelector
+	policyLevelNone
+)
+
+// policy is a RatelimitPolicy or an AuthPolicy, converted to the filters
+// that it applies to the targeted routes.
+type policy struct {
+	kind     string
+	metadata *definitions.Metadata
+	targets  *definitions.PolicyTargets
+	status   *definitions.PolicyStatus
+	filters  []*eskip.Filter
+	err      error
+
+	// routes are the IDs of the routes that the policy was applied to
+	routes []string
+}
+
+// policies contains the valid policies of each kind, ordered by their
+// creation time and name, and the invalid ones only to report their
+// status.
+type policies struct {
+	ratelimit []*policy
+	auth      []*policy
+	invalid   []*policy
+}
+
+// ratelimitFilterNames and authFilterNames are the filters, that when
+// set explicitly on a route, take precedence over the policies of the
+// same kind.
+var (
+	ratelimitFilterNames = map[string]bool{
+		filters.RatelimitName:                   true,
+		filters.ClientRatelimitName:             true,
+		filters.ClusterRatelimitName:            true,
+		filters.ClusterClientRatelimitName:      true,
+		filters.ClusterLeakyBucketRatelimitName: true,
+		filters.ClusterTokenBucketRatelimitName: true,
+		filters.DisableRatelimitName:            true,
+	}
+
+	authFilterNames = map[string]bool{
+		filters.OAuthTokeninfoAnyScopeName: true,
+		filters.OAuthTokeninfoAllScopeName: true,
+		filters.OAuthTokeninfoAnyKVName:    true,
+		filters.OAuthTokeninfoAllKVName:    true,
+		filters.OAuthTokeninfoValidateName: true,
+	}
+)
+
+func ratelimitPolicyFilters(spec *definitions.RatelimitPolicySpec) []*eskip.Filter {
+	var f []*eskip.Filter
+	for _, rl := range spec.Ratelimits {
+		hits := float64(rl.MaxHits)
+		switch rl.Type {
+		case definitions.RatelimitTypeService:
+			f = appendFilter(f, filters.RatelimitName, hits, rl.Period)
+		case definitions.RatelimitTypeClient:
+			if rl.Header == "" {
+				f = appendFilter(f, filters.ClientRatelimitName, hits, rl.Period)
+			} else {
+				f = appendFilter(f, filters.ClientRatelimitName, hits, rl.Period, rl.Header)
+			}
+		case definitions.RatelimitTypeCluster:
+			f = appendFilter(f, filters.ClusterRatelimitName, rl.Group, hits, rl.Period)
+		case definitions.RatelimitTypeClusterClient:
+			if rl.Header == "" {
+				f = appendFilter(f, filters.ClusterClientRatelimitName, rl.Group, hits, rl.Period)
+			} else {
+				f = appendFilter(f, filters.ClusterClientRatelimitName, rl.Group, hits, rl.Period, rl.Header)
+			}
+		}
+	}
+
+	return f
+}
+
+func stringArgs(s []string) []interface{} {
+	args := make([]interface{}, 0, len(s))
+	for _, si := range s {
+		args = append(args, si)
+	}
+
+	return args
+}
+
+func kvArgs(kvs []*definitions.PolicyKV) []interface{} {
+	args := make([]interface{}, 0, 2*len(kvs))
+	for _, kv := range kvs {
+		args = append(args, kv.Key, kv.Value)
+	}
+
+	return args
+}
+
+func authPolicyFilters(spec *definitions.AuthPolicySpec) []*eskip.Filter {
+	var (
+		f  []*eskip.Filter
+		ti = spec.Tokeninfo
+	)
+
+	if len(ti.AnyScope) > 0 {
+		f = appendFilter(f, filters.OAuthTokeninfoAnyScopeName, stringArgs(ti.AnyScope)...)
+	}
+
+	if len(ti.AllScope) > 0 {
+		f = appendFilter(f, filters.OAuthTokeninfoAllScopeName, stringArgs(ti.AllScope)...)
+	}
+
+	if len(ti.AnyKV) > 0 {
+		f = appendFilter(f, filters.OAuthTokeninfoAnyKVName, kvArgs(ti.AnyKV)...)
+	}
+
+	if len(ti.AllKV) > 0 {
+		f = appendFilter(f, filters.OAuthTokeninfoAllKVName, kvArgs(ti.AllKV)...)
+	}
+
+	return f
+}
+
+func sortPolicies(p []*policy) {
+	sort.SliceStable(p, func(i, j int) bool {
+		mi, mj := p[i].metadata, p[j].metadata
+		if !mi.Created.Equal(mj.Created) {
+			return mi.Created.Before(mj.Created)
+		}
+
+		if mi.Namespace != mj.Namespace {
+			return mi.Namespace < mj.Namespace
+		}
+
+		return mi.Name < mj.Name
+	})
+}
+
+func newPolicies(rl []*definitions.RatelimitPolicyItem, auth []*definitions.AuthPolicyItem) *policies {
+	ps := &policies{}
+	for _, i := range rl {
+		p := &policy{kind: definitions.RatelimitPolicyKind, metadata: i.Metadata, status: i.Status}
+		if p.err = definitions.ValidateRatelimitPolicy(i); p.err != nil {
+			log.Errorf("[policy] invalid %s %s/%s: %v", p.kind, i.Metadata.Namespace, i.Metadata.Name, p.err)
+			ps.invalid = append(ps.invalid, p)
+			continue
+		}
+
+		p.targets = &i.Spec.PolicyTargets
+		p.filters = ratelimitPolicyFilters(i.Spec)
+		ps.ratelimit = append(ps.ratelimit, p)
+	}
+
+	for _, i := range auth {
+		p := &policy{kind: definitions.AuthPolicyKind, metadata: i.Metadata, status: i.Status}
+		if p.err = definitions.ValidateAuthPolicy(i); p.err != nil {
+			log.Errorf("[policy] invalid %s %s/%s: %v", p.kind, i.Metadata.Namespace, i.Metadata.Name, p.err)
+			ps.invalid = append(ps.invalid, p)
+			continue
+		}
+
+		p.targets = &i.Spec.PolicyTargets
+		p.filters = authPolicyFilters(i.Spec)
+		ps.auth = append(ps.auth, p)
+	}
+
+	sortPolicies(ps.ratelimit)
+	sortPolicies(ps.auth)
+	return ps
+}
+
+// level returns the precedence, with which the policy targets a resource
+// of the kind, serving the hosts.
+func (p *policy) level(kind string, m *definitions.Metadata, hosts []string) policyLevel {
+	if namespaceString(p.metadata.Namespace) != namespaceString(m.Namespace) {
+		return policyLevelNone
+	}
+
+	for _, ref := range p.targets.TargetRefs {
+		if ref.Kind == kind && ref.Name == m.Name {
+			return policyLevelTargetRef
+		}
+	}
+
+	for _, pattern := range p.targets.Hosts {
+		for _, h := range hosts {
+			if hostnameMatches(strings.ToLower(pattern), strings.ToLower(h)) {
+				return policyLevelHosts
+			}
+		}
+	}
+
+	if s := p.targets.Selector; s != nil {
+		matches := true
+		for k, v := range s.MatchLabels {
+			if lv, ok := m.Labels[k]; !ok || lv != v {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return policyLevelSelector
+		}
+	}
+
+	return policyLevelNone
+}
+
+// selectPolicy returns the policy with the highest precedence, targeting
+// the resource. The policies are expected in the order of their creation.
+func selectPolicy(ps []*policy, kind string, m *definitions.Metadata, hosts []string) *policy {
+	var (
+		selected *policy
+		level    = policyLevelNone
+	)
+
+	for _, p := range ps {
+		if l := p.level(kind, m, hosts); l < level {
+			selected, level = p, l
+		}
+	}
+
+	return selected
+}
+
+func hasFilter(r *eskip.Route, names map[string]bool) bool {
+	for _, f := range r.Filters {
+		if names[f.Name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apply prepends the filters of the policies targeting the resource to
+// the route. The filters set explicitly on the route take precedence over
+// the policies. The auth filters are applied before the ratelimit
+// filters, so that the unauthorized requests are not counted.
+func (ps *policies) apply(kind string, m *definitions.Metadata, hosts []string, r *eskip.Route) {
+	if ps == nil || m == nil {
+		return
+	}
+
+	var f []*eskip.Filter
+	if !hasFilter(r, authFilterNames) {
+		if p := selectPolicy(ps.auth, kind, m, hosts); p != nil {
+			f = append(f, eskip.CopyFilters(p.filters)...)
+			p.routes = append(p.routes, r.Id)
+		}
+	}
+
+	if !hasFilter(r, ratelimitFilterNames) {
+		if p := selectPolicy(ps.ratelimit, kind, m, hosts); p != nil {
+			f = append(f, eskip.CopyFilters(p.filters)...)
+			p.routes = append(p.routes, r.Id)
+		}
+	}
+
+	if len(f) > 0 {
+		r.Filters = append(f, r.Filters...)
+	}
+}
+
+// all returns every policy, the valid and the invalid ones.
+func (ps *policies) all() []*policy {
+	if ps == nil {
+		return nil
+	}
+
+	var all []*policy
+	all = append(all, ps.auth...)
+	all = append(all, ps.ratelimit...)
+	return append(all, ps.invalid...)
+}
+
+// policyStatus returns the next status of a policy, with the IDs of the
+// routes that it was applied to, and that are part of the final routing.
+func policyStatus(p *policy, routeIDs map[string]bool) *definitions.PolicyStatus {
+	if p.err != nil {
+		return &definitions.PolicyStatus{Conditions: []*definitions.Condition{
+			newCondition(conditionAccepted, false, reasonInvalid, p.err.Error(), p.metadata),
+		}}
+	}
+
+	applied := make(map[string]bool)
+	for _, id := range p.routes {
+		if routeIDs[id] {
+			applied[id] = true
+		}
+	}
+
+	return &definitions.PolicyStatus{
+		Conditions: []*definitions.Condition{
+			newCondition(conditionAccepted, true, reasonAccepted, p.kind+" accepted", p.metadata),
+		},
+		Routes: slices.Sorted(maps.Keys(applied)),
+	}
+}
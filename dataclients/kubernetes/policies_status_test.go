@@ -0,0 +1,113 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
+	"github.com/zalando/skipper/eskip"
+)
+
+func TestUpdatePoliciesStatus(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		patches map[string][]byte
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mu.Lock()
+		patches[r.URL.Path] = b
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	c := &clusterClient{httpClient: srv.Client(), apiURL: srv.URL}
+
+	ratelimit := &definitions.RatelimitPolicyItem{
+		Metadata: &definitions.Metadata{Namespace: "default", Name: "app", Generation: 3},
+		Spec: &definitions.RatelimitPolicySpec{
+			PolicyTargets: definitions.PolicyTargets{Hosts: []string{"app.example.org"}},
+			Ratelimits:    []*definitions.PolicyRatelimit{{Type: definitions.RatelimitTypeService, MaxHits: 10, Period: "1s"}},
+		},
+	}
+
+	invalid := &definitions.AuthPolicyItem{
+		Metadata: &definitions.Metadata{Namespace: "default", Name: "invalid", Generation: 1},
+		Spec: &definitions.AuthPolicySpec{
+			PolicyTargets: definitions.PolicyTargets{Selector: &definitions.LabelSelector{}},
+		},
+	}
+
+	state := &clusterState{policies: newPolicies(
+		[]*definitions.RatelimitPolicyItem{ratelimit},
+		[]*definitions.AuthPolicyItem{invalid},
+	)}
+
+	m := &definitions.Metadata{Namespace: "default", Name: "app"}
+	for _, id := range []string{"kube_rg__default__app__r2", "kube_rg__default__app__r1", "kube_rg__default__app__dropped"} {
+		state.policies.apply(definitions.RouteGroupKind, m, []string{"app.example.org"}, &eskip.Route{Id: id})
+	}
+
+	routeIDs := map[string]bool{
+		"kube_rg__default__app__r1": true,
+		"kube_rg__default__app__r2": true,
+	}
+
+	const (
+		ratelimitURI = "/apis/zalando.org/v1/namespaces/default/ratelimitpolicies/app/status"
+		invalidURI   = "/apis/zalando.org/v1/namespaces/default/authpolicies/invalid/status"
+	)
+
+	decode := func(uri string) *definitions.PolicyStatus {
+		var p definitions.RatelimitPolicyItem
+		require.NoError(t, json.Unmarshal(patches[uri], &p))
+		require.NotNil(t, p.Status)
+		return p.Status
+	}
+
+	patches = make(map[string][]byte)
+	require.NoError(t, c.updatePoliciesStatus(state, routeIDs))
+	require.Len(t, patches, 2)
+
+	ratelimitStatus := decode(ratelimitURI)
+	require.Len(t, ratelimitStatus.Conditions, 1)
+	assert.Equal(t, conditionAccepted, ratelimitStatus.Conditions[0].Type)
+	assert.Equal(t, definitions.ConditionTrue, ratelimitStatus.Conditions[0].Status)
+	assert.Equal(t, int64(3), ratelimitStatus.Conditions[0].ObservedGeneration)
+	assert.Equal(t, []string{"kube_rg__default__app__r1", "kube_rg__default__app__r2"}, ratelimitStatus.Routes)
+
+	invalidStatus := decode(invalidURI)
+	require.Len(t, invalidStatus.Conditions, 1)
+	assert.Equal(t, definitions.ConditionFalse, invalidStatus.Conditions[0].Status)
+	assert.Equal(t, reasonInvalid, invalidStatus.Conditions[0].Reason)
+	assert.Equal(t, "policy without tokeninfo rules", invalidStatus.Conditions[0].Message)
+	assert.Empty(t, invalidStatus.Routes)
+
+	// the patched status is not patched again
+	state.policies.ratelimit[0].status = ratelimitStatus
+	state.policies.invalid[0].status = invalidStatus
+
+	patches = make(map[string][]byte)
+	require.NoError(t, c.updatePoliciesStatus(state, routeIDs))
+	assert.Empty(t, patches)
+
+	// the routes removed from the final routing are removed from the status
+	delete(routeIDs, "kube_rg__default__app__r2")
+
+	patches = make(map[string][]byte)
+	require.NoError(t, c.updatePoliciesStatus(state, routeIDs))
+	require.Len(t, patches, 1)
+	assert.Equal(t, []string{"kube_rg__default__app__r1"}, decode(ratelimitURI).Routes)
+	assert.Equal(t, ratelimitStatus.Conditions[0].LastTransitionTime, decode(ratelimitURI).Conditions[0].LastTransitionTime)
+}
@@ -0,0 +1,11 @@
+package kubernetes_test
+
+import (
+	"testing"
+
+	"github.com/zalando/skipper/dataclients/kubernetes/kubernetestest"
+)
+
+func TestPolicies(t *testing.T) {
+	kubernetestest.FixturesToTest(t, "testdata/policies")
+}
@@ -0,0 +1,195 @@
+package kubernetes_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/dataclients/kubernetes"
+	"github.com/zalando/skipper/dataclients/kubernetes/kubernetestest"
+	"github.com/zalando/skipper/eskip"
+)
+
+const remoteClusterLocalSpec = `
+apiVersion: zalando.org/v1
+kind: RouteGroup
+metadata:
+  name: app
+  namespace: default
+spec:
+  hosts:
+  - app.example.org
+  backends:
+  - name: local
+    type: service
+    serviceName: app
+    servicePort: 80
+  - name: remote
+    type: service
+    serviceName: app
+    servicePort: 80
+    cluster: eu-west
+  defaultBackends:
+  - backendName: local
+    weight: 80
+  - backendName: remote
+    weight: 20
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: default
+spec:
+  clusterIP: 10.3.0.1
+  ports:
+  - port: 80
+    targetPort: 8080
+  type: ClusterIP
+---
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: app
+  namespace: default
+subsets:
+- addresses:
+  - ip: 10.2.0.1
+  ports:
+  - port: 8080
+`
+
+const remoteClusterRemoteSpec = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: default
+spec:
+  clusterIP: 10.5.0.1
+  ports:
+  - port: 80
+    targetPort: 9090
+  type: ClusterIP
+---
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: app
+  namespace: default
+subsets:
+- addresses:
+  - ip: 10.4.0.1
+  ports:
+  - port: 9090
+`
+
+const remoteClusterKubeconfigFmt = `
+apiVersion: v1
+kind: Config
+current-context: eu-west
+clusters:
+- name: eu-west
+  cluster:
+    server: %s
+contexts:
+- name: eu-west
+  context:
+    cluster: eu-west
+    user: skipper
+users:
+- name: skipper
+  user:
+    tokenFile: token
+`
+
+func remoteClusterBackends(routes []*eskip.Route) map[string]string {
+	backends := make(map[string]string)
+	for _, r := range routes {
+		if r.Backend != "" {
+			backends[r.Id] = r.Backend
+		}
+	}
+
+	return backends
+}
+
+func TestRemoteClusterBackends(t *testing.T) {
+	local, err := kubernetestest.NewAPI(kubernetestest.TestAPIOptions{}, strings.NewReader(remoteClusterLocalSpec))
+	require.NoError(t, err)
+
+	localServer := httptest.NewServer(local)
+	defer localServer.Close()
+
+	remote, err := kubernetestest.NewAPI(kubernetestest.TestAPIOptions{}, strings.NewReader(remoteClusterRemoteSpec))
+	require.NoError(t, err)
+
+	var authorized atomic.Bool
+	remoteServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorized.Store(r.Header.Get("Authorization") == "Bearer remote-token")
+		remote.ServeHTTP(w, r)
+	}))
+	defer remoteServer.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("remote-token"), 0o600))
+
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeconfig, fmt.Appendf(nil, remoteClusterKubeconfigFmt, remoteServer.URL), 0o600))
+
+	c, err := kubernetes.New(kubernetes.Options{
+		KubernetesURL:  localServer.URL,
+		RemoteClusters: map[string]string{"eu-west": kubeconfig},
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	expected := map[string]string{
+		"kube_rg__default__app__all__0_0": "http://10.2.0.1:8080",
+		"kube_rg__default__app__all__0_1": "http://10.4.0.1:9090",
+	}
+
+	routes, err := c.LoadAll()
+	require.NoError(t, err)
+	assert.Equal(t, expected, remoteClusterBackends(routes))
+	assert.True(t, authorized.Load(), "uses the token of the kubeconfig")
+
+	// the last state of an unreachable remote cluster is kept
+	remoteServer.Close()
+
+	routes, err = c.LoadAll()
+	require.NoError(t, err)
+	assert.Equal(t, expected, remoteClusterBackends(routes))
+}
+
+func TestRemoteClusterNotConfigured(t *testing.T) {
+	local, err := kubernetestest.NewAPI(kubernetestest.TestAPIOptions{}, strings.NewReader(remoteClusterLocalSpec))
+	require.NoError(t, err)
+
+	localServer := httptest.NewServer(local)
+	defer localServer.Close()
+
+	c, err := kubernetes.New(kubernetes.Options{KubernetesURL: localServer.URL})
+	require.NoError(t, err)
+	defer c.Close()
+
+	routes, err := c.LoadAll()
+	require.NoError(t, err)
+	assert.Empty(t, remoteClusterBackends(routes))
+}
+
+func TestRemoteClusterInvalidKubeconfig(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte("contexts: []"), 0o600))
+
+	_, err := kubernetes.New(kubernetes.Options{RemoteClusters: map[string]string{"eu-west": kubeconfig}})
+	assert.ErrorContains(t, err, "eu-west")
+}
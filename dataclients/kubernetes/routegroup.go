@@ -20,6 +20,14 @@ type routeGroups struct {
 	options Options
 }
 
+// routeGroupsStatus contains the results of the conversion reported in
+// the status of the RouteGroups: the IDs of the routes created from each
+// RouteGroup, and the conversion errors.
+type routeGroupsStatus struct {
+	routeIDs map[definitions.ResourceID][]string
+	errors   map[definitions.ResourceID]error
+}
+
 type routeGroupContext struct {
 	state                        *clusterState
 	routeGroup                   *definitions.RouteGroupItem
@@ -45,6 +53,7 @@ type routeGroupContext struct {
 	zone                         string
 	disableZoneAwareness         bool
 	applicationAnnotationLabel   string
+	crossNamespaceBackends       bool
 }
 
 type routeContext struct {
@@ -67,6 +76,14 @@ func targetPortNotFound(serviceName string, servicePort int) error {
 	return fmt.Errorf("target port not found: %s:%d", serviceName, servicePort)
 }
 
+func remoteClusterNotFound(backendName, cluster string) error {
+	return fmt.Errorf("remote cluster not found in backend: %s, %s", backendName, cluster)
+}
+
+func crossNamespaceNotAllowed(backendName, namespace, serviceName string) error {
+	return fmt.Errorf("reference to service %s/%s not allowed in backend: %s", namespace, serviceName, backendName)
+}
+
 func newRouteGroups(o Options) *routeGroups {
 	return &routeGroups{options: o}
 }
@@ -158,11 +175,48 @@ func mapBackends(backends []*definitions.SkipperBackend) map[string]*definitions
 	return m
 }
 
-func getBackendService(ctx *routeGroupContext, backend *definitions.SkipperBackend) (*service, error) {
-	s, err := ctx.state.getServiceRG(
-		namespaceString(ctx.routeGroup.Metadata.Namespace),
-		backend.ServiceName,
-	)
+// backendNamespace returns the namespace of the service backend, the
+// namespace of the RouteGroup unless set explicitly.
+func backendNamespace(ctx *routeGroupContext, backend *definitions.SkipperBackend) string {
+	if backend.ServiceNamespace != "" {
+		return backend.ServiceNamespace
+	}
+
+	return namespaceString(ctx.routeGroup.Metadata.Namespace)
+}
+
+// backendClusterState returns the state of the cluster of the service
+// backend, and checks whether a reference to another namespace is
+// allowed by a ReferenceGrant of that cluster.
+func backendClusterState(ctx *routeGroupContext, backend *definitions.SkipperBackend) (*clusterState, error) {
+	state := ctx.state
+	if backend.Cluster != "" {
+		state = ctx.state.remoteClusters[backend.Cluster]
+		if state == nil {
+			return nil, remoteClusterNotFound(backend.Name, backend.Cluster)
+		}
+	}
+
+	namespace := namespaceString(ctx.routeGroup.Metadata.Namespace)
+	if ns := backendNamespace(ctx, backend); ns != namespace {
+		if !ctx.crossNamespaceBackends || !referenceGranted(
+			state,
+			definitions.RouteGroupAPIGroup,
+			definitions.RouteGroupKind,
+			namespace,
+			definitions.ServiceKind,
+			ns,
+			backend.ServiceName,
+		) {
+			return nil, crossNamespaceNotAllowed(backend.Name, ns, backend.ServiceName)
+		}
+	}
+
+	return state, nil
+}
+
+func getBackendService(state *clusterState, namespace string, backend *definitions.SkipperBackend) (*service, error) {
+	s, err := state.getServiceRG(namespace, backend.ServiceName)
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +240,13 @@ func applyServiceBackend(ctx *routeGroupContext, backend *definitions.SkipperBac
 		r.Filters = append([]*eskip.Filter{f}, r.Filters...)
 	}
 
-	s, err := getBackendService(ctx, backend)
+	state, err := backendClusterState(ctx, backend)
+	if err != nil {
+		return err
+	}
+
+	namespace := backendNamespace(ctx, backend)
+	s, err := getBackendService(state, namespace, backend)
 	if err != nil {
 		return err
 	}
@@ -196,64 +256,58 @@ func applyServiceBackend(ctx *routeGroupContext, backend *definitions.SkipperBac
 		return targetPortNotFound(backend.ServiceName, backend.ServicePort)
 	}
 
-	dataclientZone := ctx.zone
-
-	var eps []string
-	var epSlices []skipperEndpoint
-	if ctx.state.enableEndpointSlices {
-		epSlices = ctx.state.GetEndpointSlicesByTarget(dataclientZone, namespaceString(ctx.routeGroup.Metadata.Namespace),
-			s.Meta.Name,
-			"TCP",
-			protocol,
-			annotationSet,
-			targetPort,
-			ctx)
-		for _, epSlice := range epSlices {
-			eps = append(eps, epSlice.Address)
-		}
-	} else {
-		eps = ctx.state.GetEndpointsByTarget(
-			namespaceString(ctx.routeGroup.Metadata.Namespace),
-			s.Meta.Name,
-			"TCP",
-			protocol,
-			targetPort,
-		)
+	zone, name, disableZoneAwareness := ctx.zone, s.Meta.Name, ctx.disableZoneAwareness
+	lookup := &endpointsLookup{
+		service: newResourceID(namespace, name),
+		endpoints: func(cs *clusterState) []skipperEndpoint {
+			return cs.getTargetEndpoints(
+				zone,
+				namespace,
+				name,
+				protocol,
+				annotationSet,
+				targetPort,
+				disableZoneAwareness,
+			)
+		},
 	}
 
+	eps := lookup.endpoints(state)
+	state.setEndpointsLookup(r, lookup)
 	if len(eps) == 0 {
 		ctx.logger.Tracef("Target endpoints not found, shuntroute for %s:%d", backend.ServiceName, backend.ServicePort)
-
-		shuntRoute(r)
-		return nil
-	}
-
-	if len(eps) == 1 {
-		r.BackendType = eskip.NetworkBackend
-		r.Backend = eps[0]
-		return nil
 	}
 
-	r.BackendType = eskip.LBBackend
-	if ctx.state.enableEndpointSlices {
-		for _, ep := range epSlices {
-			r.LBEndpoints = append(r.LBEndpoints, &eskip.LBEndpoint{Address: ep.Address, Zone: ep.Zone})
-		}
-	} else {
-		r.LBEndpoints = eskip.NewLBEndpoints(eps)
-	}
-	r.LBAlgorithm = ctx.defaultLoadBalancerAlgorithm
+	algorithm := ctx.defaultLoadBalancerAlgorithm
 	if backend.Algorithm != loadbalancer.None {
-		r.LBAlgorithm = backend.Algorithm.String()
+		algorithm = backend.Algorithm.String()
 	}
 
+	applyEndpoints(r, eps, algorithm)
 	return nil
 }
 
-func applyDefaultFilters(ctx *routeGroupContext, serviceName string, r *eskip.Route) error {
-	f, err := ctx.defaultFilters.getNamed(namespaceString(ctx.routeGroup.Metadata.Namespace), serviceName)
+// applyEndpoints sets the endpoints as the backend of the route. Routes
+// without endpoints are shunted, and a single endpoint is used as
+// network backend.
+func applyEndpoints(r *eskip.Route, eps []skipperEndpoint, algorithm string) {
+	switch len(eps) {
+	case 0:
+		shuntRoute(r)
+	case 1:
+		r.BackendType = eskip.NetworkBackend
+		r.Backend = eps[0].Address
+	default:
+		r.BackendType = eskip.LBBackend
+		r.LBEndpoints = newLBEndpoints(eps)
+		r.LBAlgorithm = algorithm
+	}
+}
+
+func applyDefaultFilters(ctx *routeGroupContext, backend *definitions.SkipperBackend, r *eskip.Route) error {
+	f, err := ctx.defaultFilters.getNamed(backendNamespace(ctx, backend), backend.ServiceName)
 	if err != nil {
-		return defaultFiltersError(ctx.routeGroup.Metadata, serviceName, err)
+		return defaultFiltersError(ctx.routeGroup.Metadata, backend.ServiceName, err)
 	}
 
 	// safe to prepend as defaultFilters.get() copies the slice:
@@ -337,6 +391,7 @@ func appendEastWest(ctx *routeGroupContext, routes []*eskip.Route, current *eski
 		current,
 	)
 
+	ctx.state.copyEndpointsLookup(current, ewr)
 	return append(routes, ewr)
 }
 
@@ -372,11 +427,12 @@ func implicitGroupRoutes(ctx *routeGroupContext) ([]*eskip.Route, error) {
 
 		ctx.defaultBackendTraffic[beref.BackendName].apply(ri)
 		if be.Type == definitions.ServiceBackend {
-			if err := applyDefaultFilters(ctx, be.ServiceName, ri); err != nil {
+			if err := applyDefaultFilters(ctx, be, ri); err != nil {
 				ctx.logger.Errorf("Failed to retrieve default filters: %v", err)
 			}
 		}
 		prependApplicationAnnotation(rg.Metadata.Labels, ctx.applicationAnnotationLabel, ri)
+		ctx.state.policies.apply(definitions.RouteGroupKind, rg.Metadata, ctx.hosts, ri)
 		storeHostRoute(ctx, ri)
 		routes = append(routes, ri)
 		routes = appendEastWest(ctx, routes, ri)
@@ -428,6 +484,10 @@ func transformExplicitGroupRoute(ctx *routeContext) (*eskip.Route, error) {
 		f = append(f, ffi...)
 	}
 
+	if err := validateSecretNamespace(ctx.group.routeGroup.Metadata.Namespace, f); err != nil {
+		return nil, err
+	}
+
 	r.Filters = f
 	err := applyBackend(ctx.group, ctx.backend, r)
 	if err != nil {
@@ -435,7 +495,7 @@ func transformExplicitGroupRoute(ctx *routeContext) (*eskip.Route, error) {
 	}
 
 	if ctx.backend.Type == definitions.ServiceBackend {
-		if err := applyDefaultFilters(ctx.group, ctx.backend.ServiceName, r); err != nil {
+		if err := applyDefaultFilters(ctx.group, ctx.backend, r); err != nil {
 			ctx.group.logger.Errorf("Failed to retrieve default filters: %v", err)
 		}
 	}
@@ -486,6 +546,7 @@ nextRoute:
 
 				backendTraffic[bref.BackendName].apply(r)
 				prependApplicationAnnotation(rg.Metadata.Labels, ctx.applicationAnnotationLabel, r)
+				ctx.state.policies.apply(definitions.RouteGroupKind, rg.Metadata, ctx.hosts, r)
 				storeHostRoute(ctx, r)
 				routes = append(routes, r)
 				routes = appendEastWest(ctx, routes, r)
@@ -552,12 +613,17 @@ func (r *routeGroups) addRouteGroupTLS(ctx *routeGroupContext, tls *definitions.
 
 }
 
-func (r *routeGroups) convert(s *clusterState, df defaultFilters, loggingEnabled bool, cr *certregistry.CertRegistry) ([]*eskip.Route, error) {
+func (r *routeGroups) convert(s *clusterState, df defaultFilters, loggingEnabled bool, cr *certregistry.CertRegistry) ([]*eskip.Route, *routeGroupsStatus, error) {
 	var rs []*eskip.Route
 	redirect := createRedirectInfo(r.options.ProvideHTTPSRedirect, r.options.HTTPSRedirectCode)
+	status := &routeGroupsStatus{
+		routeIDs: make(map[definitions.ResourceID][]string),
+		errors:   make(map[definitions.ResourceID]error),
+	}
 
 	for _, rg := range s.routeGroups {
 		logger := newLogger("RouteGroup", rg.Metadata.Namespace, rg.Metadata.Name, loggingEnabled)
+		id := rg.Metadata.ToResourceID()
 
 		redirect.initCurrent(rg.Metadata)
 
@@ -609,11 +675,13 @@ func (r *routeGroups) convert(s *clusterState, df defaultFilters, loggingEnabled
 				zone:                         r.options.TopologyZone,
 				disableZoneAwareness:         rg.Metadata.Annotations[trafficZoneAwareAnnotationKey] == "false",
 				applicationAnnotationLabel:   r.options.KubernetesApplicationAnnotationLabelKey,
+				crossNamespaceBackends:       r.options.EnableRouteGroupCrossNamespaceBackends,
 			}
 
 			ri, err := transformRouteGroup(ctx)
 			if err != nil {
 				ctx.logger.Errorf("Error transforming external hosts: %v", err)
+				status.errors[id] = err
 				continue
 			}
 
@@ -637,6 +705,9 @@ func (r *routeGroups) convert(s *clusterState, df defaultFilters, loggingEnabled
 			}
 
 			rs = append(rs, ri...)
+			for _, route := range ri {
+				status.routeIDs[id] = append(status.routeIDs[id], route.Id)
+			}
 		}
 
 		// Internal hosts
@@ -658,11 +729,15 @@ func (r *routeGroups) convert(s *clusterState, df defaultFilters, loggingEnabled
 				forwardBackendURL:            r.options.ForwardBackendURL,
 				certificateRegistry:          cr,
 				applicationAnnotationLabel:   r.options.KubernetesApplicationAnnotationLabelKey,
+				crossNamespaceBackends:       r.options.EnableRouteGroupCrossNamespaceBackends,
 			}
 
 			internalRi, err := transformRouteGroup(internalCtx)
 			if err != nil {
 				internalCtx.logger.Errorf("Error transforming internal hosts: %v", err)
+				if status.errors[id] == nil {
+					status.errors[id] = err
+				}
 
 				continue
 			}
@@ -688,8 +763,11 @@ func (r *routeGroups) convert(s *clusterState, df defaultFilters, loggingEnabled
 			}
 
 			rs = append(rs, internalRi...)
+			for _, route := range internalRi {
+				status.routeIDs[id] = append(status.routeIDs[id], route.Id)
+			}
 		}
 	}
 
-	return rs, nil
+	return rs, status, nil
 }
@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
+)
+
+func TestUpdateRouteGroupsStatus(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		patches map[string][]byte
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mu.Lock()
+		patches[r.URL.Path] = b
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	c := &clusterClient{
+		httpClient:               srv.Client(),
+		apiURL:                   srv.URL,
+		ingressStatusFromService: "kube-system/skipper",
+	}
+
+	app := &definitions.RouteGroupItem{Metadata: &definitions.Metadata{Namespace: "default", Name: "app", Generation: 2}}
+	broken := &definitions.RouteGroupItem{Metadata: &definitions.Metadata{Namespace: "default", Name: "broken", Generation: 1}}
+	state := &clusterState{
+		routeGroups:        []*definitions.RouteGroupItem{app},
+		invalidRouteGroups: []*invalidRouteGroup{{item: broken, err: errors.New("route group without backend")}},
+		services: map[definitions.ResourceID]*service{
+			newResourceID("kube-system", "skipper"): {Spec: &serviceSpec{Type: "ClusterIP", ClusterIP: "10.0.0.9"}},
+		},
+	}
+
+	status := &routeGroupsStatus{
+		routeIDs: map[definitions.ResourceID][]string{app.Metadata.ToResourceID(): {"kube_rg__default__app__r1", "kube_rg__default__app__r2"}},
+		errors:   map[definitions.ResourceID]error{},
+	}
+
+	invalidRoutes := map[string]string{
+		"kube_rg__default__app__r2": `failed to create filter "foo"`,
+		"kube_rg__default__other":   `failed to create filter "bar"`,
+	}
+
+	const (
+		appURI    = "/apis/zalando.org/v1/namespaces/default/routegroups/app/status"
+		brokenURI = "/apis/zalando.org/v1/namespaces/default/routegroups/broken/status"
+	)
+
+	decode := func(uri string) *definitions.RouteGroupStatus {
+		var rg definitions.RouteGroupItem
+		require.NoError(t, json.Unmarshal(patches[uri], &rg))
+		require.NotNil(t, rg.Status)
+		return rg.Status
+	}
+
+	patches = make(map[string][]byte)
+	require.NoError(t, c.updateRouteGroupsStatus(state, status, invalidRoutes))
+	require.Len(t, patches, 2)
+
+	appStatus := decode(appURI)
+	require.Len(t, appStatus.Conditions, 2)
+	assert.Equal(t, conditionAccepted, appStatus.Conditions[0].Type)
+	assert.Equal(t, definitions.ConditionTrue, appStatus.Conditions[0].Status)
+	assert.Equal(t, int64(2), appStatus.Conditions[0].ObservedGeneration)
+	assert.Equal(t, conditionProgrammed, appStatus.Conditions[1].Type)
+	assert.Equal(t, definitions.ConditionFalse, appStatus.Conditions[1].Status)
+	assert.Equal(t, reasonInvalidRoutes, appStatus.Conditions[1].Reason)
+	assert.Equal(t, "1 of 2 routes are invalid", appStatus.Conditions[1].Message)
+	assert.Equal(t, []*definitions.RouteGroupRouteError{{
+		RouteID: "kube_rg__default__app__r2",
+		Error:   `failed to create filter "foo"`,
+	}}, appStatus.RouteErrors)
+	assert.Equal(t, &definitions.RouteGroupLoadBalancer{
+		RouteGroup: []definitions.IngressLoadBalancerIngress{{IP: "10.0.0.9"}},
+	}, appStatus.LoadBalancer)
+
+	brokenStatus := decode(brokenURI)
+	require.Len(t, brokenStatus.Conditions, 1)
+	assert.Equal(t, definitions.ConditionFalse, brokenStatus.Conditions[0].Status)
+	assert.Equal(t, reasonInvalid, brokenStatus.Conditions[0].Reason)
+	assert.Equal(t, "route group without backend", brokenStatus.Conditions[0].Message)
+
+	// the patched status is not patched again
+	app.Status = appStatus
+	broken.Status = brokenStatus
+
+	patches = make(map[string][]byte)
+	require.NoError(t, c.updateRouteGroupsStatus(state, status, invalidRoutes))
+	assert.Empty(t, patches)
+
+	// the load balancer addresses of other controllers are preserved
+	c.ingressStatusFromService = ""
+	app.Status.LoadBalancer = &definitions.RouteGroupLoadBalancer{
+		RouteGroup: []definitions.IngressLoadBalancerIngress{{Hostname: "lb.example.org"}},
+	}
+
+	patches = make(map[string][]byte)
+	require.NoError(t, c.updateRouteGroupsStatus(state, status, invalidRoutes))
+	assert.Empty(t, patches)
+
+	patches = make(map[string][]byte)
+	require.NoError(t, c.updateRouteGroupsStatus(state, status, nil))
+	require.Len(t, patches, 1)
+	assert.NotContains(t, string(patches[appURI]), "loadBalancer")
+
+	appStatus = decode(appURI)
+	assert.Equal(t, definitions.ConditionTrue, appStatus.Conditions[1].Status)
+	assert.Empty(t, appStatus.RouteErrors)
+
+	// conversion errors are reported as not accepted
+	status.errors[app.Metadata.ToResourceID()] = errors.New("backend not found")
+
+	patches = make(map[string][]byte)
+	require.NoError(t, c.updateRouteGroupsStatus(state, status, nil))
+	appStatus = decode(appURI)
+	require.Len(t, appStatus.Conditions, 1)
+	assert.Equal(t, definitions.ConditionFalse, appStatus.Conditions[0].Status)
+	assert.Equal(t, "backend not found", appStatus.Conditions[0].Message)
+}
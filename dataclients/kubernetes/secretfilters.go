@@ -0,0 +1,35 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+)
+
+// secretFilters are the filters, that read a Kubernetes secret with the
+// name in the first argument in the format namespace/name/key.
+var secretFilters = map[string]bool{
+	filters.JwtValidationSecretName: true,
+}
+
+// validateSecretNamespace returns an error when one of the filters reads a
+// Kubernetes secret of a namespace other than the namespace of the
+// resource. Names starting with / are files of the -credentials-paths.
+func validateSecretNamespace(namespace string, fs []*eskip.Filter) error {
+	for _, f := range fs {
+		if !secretFilters[f.Name] || len(f.Args) == 0 {
+			continue
+		}
+
+		name, ok := f.Args[0].(string)
+		if !ok || strings.HasPrefix(name, "/") || strings.HasPrefix(name, namespace+"/") {
+			continue
+		}
+
+		return fmt.Errorf("secret %s of filter %s not allowed in namespace %s", name, f.Name, namespace)
+	}
+
+	return nil
+}
@@ -0,0 +1,431 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/dataclients/kubernetes/definitions"
+)
+
+const (
+	watchEventAdded    = "ADDED"
+	watchEventModified = "MODIFIED"
+	watchEventDeleted  = "DELETED"
+	watchEventBookmark = "BOOKMARK"
+	watchEventError    = "ERROR"
+
+	// the API server closes the watch requests after this timeout, and
+	// the watch continues from the last seen resource version
+	defaultWatchTimeout = 5 * time.Minute
+	defaultWatchBackoff = time.Second
+	maxWatchBackoff     = 30 * time.Second
+
+	defaultForceFullUpdatePeriod = time.Minute
+)
+
+// errResourceGone is returned when the resource version of a watch is too
+// old, and the resources need to be listed again.
+var errResourceGone = errors.New("resource version gone")
+
+type watchList struct {
+	Metadata *definitions.Metadata `json:"metadata,omitempty"`
+	Items    []json.RawMessage     `json:"items"`
+}
+
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+type watchObject struct {
+	Metadata *definitions.Metadata `json:"metadata"`
+}
+
+// watchStatus is the object of the ERROR events.
+type watchStatus struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// watchItem is a watched object. It is decoded into the item type of the
+// resource list only once, when the list is loaded the first time after
+// the object has changed.
+type watchItem struct {
+	raw     json.RawMessage
+	decoded reflect.Value
+}
+
+// watchChanges are the changes of a watched resource list since they were
+// taken the last time.
+type watchChanges struct {
+	// objects are the metadata of the changed objects, both before
+	// and after the change
+	objects []*definitions.Metadata
+
+	// relisted tells that the resources were listed again, and any of
+	// them may have changed
+	relisted bool
+}
+
+// watcher keeps the objects of a resource list in sync with the API
+// server. It lists the resources once, and then watches the changes
+// starting from the resource version of the list, relisting when the
+// resource version is not available anymore.
+type watcher struct {
+	client *clusterClient
+	uri    string
+
+	timeout time.Duration
+	backoff time.Duration
+
+	mu              sync.Mutex
+	synced          bool
+	resourceVersion string
+	items           map[definitions.ResourceID]*watchItem
+	changes         *watchChanges
+}
+
+// watches holds the watchers of the resource lists loaded by the cluster
+// client. The watchers are started on the first load of a resource list.
+type watches struct {
+	client  *clusterClient
+	ctx     context.Context
+	timeout time.Duration
+	backoff time.Duration
+
+	mu       sync.Mutex
+	watchers map[string]*watcher
+}
+
+func newWatches(c *clusterClient, quit <-chan struct{}) *watches {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-quit
+		cancel()
+	}()
+
+	return &watches{
+		client:   c,
+		ctx:      ctx,
+		timeout:  defaultWatchTimeout,
+		backoff:  defaultWatchBackoff,
+		watchers: make(map[string]*watcher),
+	}
+}
+
+// get returns the watcher of a resource list. When the list is not
+// watched yet, it lists the resources, and starts watching them.
+func (ws *watches) get(uri string) (*watcher, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if w, ok := ws.watchers[uri]; ok {
+		return w, nil
+	}
+
+	w := &watcher{
+		client:  ws.client,
+		uri:     uri,
+		timeout: ws.timeout,
+		backoff: ws.backoff,
+	}
+
+	if err := w.list(); err != nil {
+		return nil, err
+	}
+
+	ws.watchers[uri] = w
+	go w.run(ws.ctx)
+
+	return w, nil
+}
+
+// takeChanges returns the changes of the watched resource lists by URI
+// since the last call.
+func (ws *watches) takeChanges() map[string]*watchChanges {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	changes := make(map[string]*watchChanges)
+	for uri, w := range ws.watchers {
+		w.mu.Lock()
+		if w.changes != nil {
+			changes[uri] = w.changes
+			w.changes = nil
+		}
+		w.mu.Unlock()
+	}
+
+	return changes
+}
+
+// changed records the change of an object, or of all the objects when m
+// is nil. It expects the lock to be held.
+func (w *watcher) changed(m *definitions.Metadata) {
+	if w.changes == nil {
+		w.changes = &watchChanges{}
+	}
+
+	if m == nil {
+		w.changes.relisted = true
+		w.changes.objects = nil
+		return
+	}
+
+	if !w.changes.relisted {
+		w.changes.objects = append(w.changes.objects, m)
+	}
+}
+
+func (w *watcher) list() error {
+	var l watchList
+	if err := w.client.getJSON(w.uri, &l); err != nil {
+		w.mu.Lock()
+		w.synced = false
+		w.mu.Unlock()
+		return err
+	}
+
+	items := make(map[definitions.ResourceID]*watchItem, len(l.Items))
+	for _, item := range l.Items {
+		var o watchObject
+		if err := json.Unmarshal(item, &o); err != nil || o.Metadata == nil {
+			continue
+		}
+
+		items[o.Metadata.ToResourceID()] = &watchItem{raw: item}
+	}
+
+	w.mu.Lock()
+	w.items = items
+	w.synced = true
+	w.resourceVersion = ""
+	if l.Metadata != nil {
+		w.resourceVersion = l.Metadata.ResourceVersion
+	}
+	w.mu.Unlock()
+
+	log.Debugf("watch: listed %d resources from %s", len(items), w.uri)
+	return nil
+}
+
+// decode sets the items of the resource list a, a pointer to a list type
+// with an Items field of item pointers, to the current watched objects. It
+// returns false when the watcher is not in sync with the API server. The
+// decoded items are shared by the subsequent loads, until the objects
+// change, and must not be modified.
+func (w *watcher) decode(a interface{}) (bool, error) {
+	list := reflect.ValueOf(a).Elem().FieldByName("Items")
+	itemType := list.Type().Elem().Elem()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.synced {
+		return false, nil
+	}
+
+	ids := make([]definitions.ResourceID, 0, len(w.items))
+	for id := range w.items {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].Namespace != ids[j].Namespace {
+			return ids[i].Namespace < ids[j].Namespace
+		}
+
+		return ids[i].Name < ids[j].Name
+	})
+
+	items := reflect.MakeSlice(list.Type(), 0, len(ids))
+	for _, id := range ids {
+		item := w.items[id]
+		if !item.decoded.IsValid() {
+			v := reflect.New(itemType)
+			if err := json.Unmarshal(item.raw, v.Interface()); err != nil {
+				return true, err
+			}
+
+			item.decoded = v
+		}
+
+		items = reflect.Append(items, item.decoded)
+	}
+
+	list.Set(items)
+	return true, nil
+}
+
+func (w *watcher) watchURI() string {
+	w.mu.Lock()
+	rv := w.resourceVersion
+	w.mu.Unlock()
+
+	q := url.Values{}
+	q.Set("watch", "true")
+	q.Set("allowWatchBookmarks", "true")
+	q.Set("timeoutSeconds", fmt.Sprint(int(w.timeout.Seconds())))
+	if rv != "" {
+		q.Set("resourceVersion", rv)
+	}
+
+	sep := "?"
+	if strings.Contains(w.uri, "?") {
+		sep = "&"
+	}
+
+	return w.uri + sep + q.Encode()
+}
+
+// run watches the resource list until the context is done. Failed
+// watches are retried from the last seen resource version, and when it's
+// gone, the resources are listed again.
+func (w *watcher) run(ctx context.Context) {
+	backoff := w.backoff
+	relist := false
+	for {
+		var err error
+		if relist {
+			err = w.list()
+			if err == nil {
+				relist = false
+				w.mu.Lock()
+				w.changed(nil)
+				w.mu.Unlock()
+			}
+		} else {
+			err = w.watch(ctx)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case err == nil:
+			backoff = w.backoff
+			continue
+		case errors.Is(err, errResourceGone):
+			log.Infof("watch: resource version of %s gone, listing again", w.uri)
+			relist = true
+			continue
+		case relist:
+			log.Errorf("watch: failed to list %s: %v", w.uri, err)
+		default:
+			log.Errorf("watch: failed to watch %s: %v", w.uri, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		backoff = min(2*backoff, maxWatchBackoff)
+	}
+}
+
+func (w *watcher) watch(ctx context.Context) error {
+	req, err := w.client.createRequest(w.watchURI(), nil)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := w.client.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+	case http.StatusGone:
+		return errResourceGone
+	case http.StatusNotFound:
+		return errResourceNotFound
+	default:
+		return fmt.Errorf("watch request to %s failed, status: %d, %s", w.uri, rsp.StatusCode, rsp.Status)
+	}
+
+	d := json.NewDecoder(rsp.Body)
+	for {
+		var e watchEvent
+		if err := d.Decode(&e); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := w.apply(&e); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *watcher) apply(e *watchEvent) error {
+	if e.Type == watchEventError {
+		var s watchStatus
+		if err := json.Unmarshal(e.Object, &s); err != nil {
+			return fmt.Errorf("invalid watch error event: %w", err)
+		}
+
+		if s.Code == http.StatusGone {
+			return errResourceGone
+		}
+
+		return fmt.Errorf("watch error event, status: %d, %s: %s", s.Code, s.Reason, s.Message)
+	}
+
+	var o watchObject
+	if err := json.Unmarshal(e.Object, &o); err != nil || o.Metadata == nil {
+		return fmt.Errorf("invalid object in watch event %s", e.Type)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if o.Metadata.ResourceVersion != "" {
+		w.resourceVersion = o.Metadata.ResourceVersion
+	}
+
+	id := o.Metadata.ToResourceID()
+	previous := w.items[id]
+	switch e.Type {
+	case watchEventAdded, watchEventModified:
+		w.items[id] = &watchItem{raw: bytes.Clone(e.Object)}
+	case watchEventDeleted:
+		delete(w.items, id)
+	case watchEventBookmark:
+		return nil
+	default:
+		return fmt.Errorf("unknown watch event type: %s", e.Type)
+	}
+
+	log.Debugf("watch: %s %s/%s in %s", e.Type, id.Namespace, id.Name, w.uri)
+	w.changed(o.Metadata)
+	if previous != nil {
+		// the labels of the previous version may differ, e.g. the
+		// service name of an endpointslice
+		var po watchObject
+		if err := json.Unmarshal(previous.raw, &po); err == nil && po.Metadata != nil {
+			w.changed(po.Metadata)
+		}
+	}
+
+	return nil
+}
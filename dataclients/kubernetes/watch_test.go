@@ -0,0 +1,359 @@
+package kubernetes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+// watchTestAPI serves the lists of the configured paths, and responds
+// to the watch requests with the bodies sent to the watch channels of
+// the paths.
+type watchTestAPI struct {
+	mu              sync.Mutex
+	lists           map[string]string
+	watch           map[string]chan string
+	listRequests    int
+	resourceVersion map[string][]string
+}
+
+func newWatchTestAPI(lists map[string]string) *watchTestAPI {
+	a := &watchTestAPI{
+		lists:           lists,
+		watch:           make(map[string]chan string),
+		resourceVersion: make(map[string][]string),
+	}
+
+	for p := range lists {
+		a.watch[p] = make(chan string, 1)
+	}
+
+	return a
+}
+
+func (a *watchTestAPI) setList(path, list string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lists[path] = list
+}
+
+func (a *watchTestAPI) listCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.listRequests
+}
+
+func (a *watchTestAPI) watchedVersions(path string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.resourceVersion[path]...)
+}
+
+func (a *watchTestAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	list, ok := a.lists[r.URL.Path]
+	watch := a.watch[r.URL.Path]
+	if !ok {
+		a.mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Query().Get("watch") != "true" {
+		a.listRequests++
+		a.mu.Unlock()
+		w.Write([]byte(list))
+		return
+	}
+
+	a.resourceVersion[r.URL.Path] = append(a.resourceVersion[r.URL.Path], r.URL.Query().Get("resourceVersion"))
+	a.mu.Unlock()
+
+	w.(http.Flusher).Flush()
+	select {
+	case body := <-watch:
+		w.Write([]byte(body))
+	case <-r.Context().Done():
+	}
+}
+
+func watchedService(name, resourceVersion, clusterIP string) string {
+	return fmt.Sprintf(
+		`{"metadata": {"namespace": "default", "name": %q, "resourceVersion": %q}, "spec": {"clusterIP": %q}}`,
+		name, resourceVersion, clusterIP,
+	)
+}
+
+func watchedEvent(eventType, object string) string {
+	return fmt.Sprintf(`{"type": %q, "object": %s}`, eventType, object)
+}
+
+func watchedList(resourceVersion string, items ...string) string {
+	return fmt.Sprintf(`{"metadata": {"resourceVersion": %q}, "items": [%s]}`, resourceVersion, strings.Join(items, ","))
+}
+
+func clusterIPs(t *testing.T, w *watcher) map[string]string {
+	var sl serviceList
+	ok, err := w.decode(&sl)
+	require.True(t, ok)
+	require.NoError(t, err)
+
+	ips := make(map[string]string)
+	for _, s := range sl.Items {
+		ips[s.Meta.Name] = s.Spec.ClusterIP
+	}
+
+	return ips
+}
+
+func TestWatcher(t *testing.T) {
+	api := newWatchTestAPI(map[string]string{
+		ServicesClusterURI: watchedList("1", watchedService("a", "1", "10.3.0.1"), watchedService("b", "1", "10.3.0.2")),
+	})
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	quit := make(chan struct{})
+	defer close(quit)
+
+	c := &clusterClient{httpClient: server.Client(), apiURL: server.URL}
+	c.watches = newWatches(c, quit)
+	c.watches.backoff = time.Millisecond
+
+	w, err := c.watches.get(ServicesClusterURI)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "10.3.0.1", "b": "10.3.0.2"}, clusterIPs(t, w))
+	assert.False(t, c.hasChanges(), "the initial list is not a change")
+
+	api.watch[ServicesClusterURI] <- strings.Join([]string{
+		watchedEvent(watchEventAdded, watchedService("c", "2", "10.3.0.3")),
+		watchedEvent(watchEventModified, watchedService("a", "3", "10.3.0.4")),
+		watchedEvent(watchEventDeleted, watchedService("b", "4", "10.3.0.2")),
+		watchedEvent(watchEventBookmark, `{"metadata": {"resourceVersion": "5"}}`),
+	}, "\n")
+
+	require.Eventually(t, func() bool {
+		return len(api.watchedVersions(ServicesClusterURI)) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"1", "5"}, api.watchedVersions(ServicesClusterURI), "continues from the bookmark")
+	assert.Equal(t, map[string]string{"a": "10.3.0.4", "c": "10.3.0.3"}, clusterIPs(t, w))
+	assert.True(t, c.hasChanges())
+	assert.False(t, c.hasChanges())
+
+	api.setList(ServicesClusterURI, watchedList("10", watchedService("d", "10", "10.3.0.5")))
+	api.watch[ServicesClusterURI] <- watchedEvent(watchEventError, `{"code": 410, "reason": "Expired"}`)
+
+	require.Eventually(t, func() bool {
+		return len(api.watchedVersions(ServicesClusterURI)) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"1", "5", "10"}, api.watchedVersions(ServicesClusterURI), "relists when the version is gone")
+	assert.Equal(t, 2, api.listCount())
+	assert.Equal(t, map[string]string{"d": "10.3.0.5"}, clusterIPs(t, w))
+	assert.True(t, c.hasChanges())
+}
+
+func TestWatcherDecodesChangedObjects(t *testing.T) {
+	api := newWatchTestAPI(map[string]string{
+		ServicesClusterURI: watchedList("1", watchedService("a", "1", "10.3.0.1"), watchedService("b", "1", "10.3.0.2")),
+	})
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	quit := make(chan struct{})
+	defer close(quit)
+
+	c := &clusterClient{httpClient: server.Client(), apiURL: server.URL}
+	c.watches = newWatches(c, quit)
+
+	w, err := c.watches.get(ServicesClusterURI)
+	require.NoError(t, err)
+
+	decode := func() []*service {
+		var sl serviceList
+		ok, err := w.decode(&sl)
+		require.True(t, ok)
+		require.NoError(t, err)
+		require.Len(t, sl.Items, 2)
+		return sl.Items
+	}
+
+	first := decode()
+	second := decode()
+	assert.Same(t, first[0], second[0], "unchanged objects are decoded once")
+	assert.Same(t, first[1], second[1], "unchanged objects are decoded once")
+
+	api.watch[ServicesClusterURI] <- watchedEvent(watchEventModified, watchedService("a", "2", "10.3.0.3"))
+	require.Eventually(t, c.hasChanges, time.Second, 10*time.Millisecond)
+
+	third := decode()
+	assert.NotSame(t, first[0], third[0])
+	assert.Equal(t, "10.3.0.3", third[0].Spec.ClusterIP)
+	assert.Same(t, first[1], third[1])
+}
+
+func TestLoadUpdateWatchedChanges(t *testing.T) {
+	const (
+		ingress = `{
+			"metadata": {"namespace": "default", "name": "app", "resourceVersion": "1"},
+			"spec": {"rules": [{"host": "app.example.org", "http": {"paths": [{
+				"path": "/", "pathType": "Prefix",
+				"backend": {"service": {"name": "app", "port": {"number": 80}}}
+			}]}}]}
+		}`
+		service = `{
+			"metadata": {"namespace": "default", "name": "app", "resourceVersion": "1"},
+			"spec": {"clusterIP": "10.3.0.1", "ports": [{"name": "http", "port": 80, "targetPort": 8080}]}
+		}`
+		endpointsFmt = `{
+			"metadata": {"namespace": "default", "name": "app", "resourceVersion": %q},
+			"subsets": [{"addresses": [{"ip": %q}], "ports": [{"name": "http", "port": 8080}]}]
+		}`
+	)
+
+	api := newWatchTestAPI(map[string]string{
+		IngressesV1ClusterURI: watchedList("1", ingress),
+		ServicesClusterURI:    watchedList("1", service),
+		EndpointsClusterURI:   watchedList("1", fmt.Sprintf(endpointsFmt, "1", "10.2.0.1")),
+	})
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	k, err := New(Options{KubernetesURL: server.URL, EnableWatch: true})
+	require.NoError(t, err)
+	defer k.Close()
+
+	backend := func(routes []*eskip.Route) string {
+		for _, r := range routes {
+			if r.Backend != "" {
+				return r.Backend
+			}
+		}
+		return ""
+	}
+
+	r, err := k.LoadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "http://10.2.0.1:8080", backend(r))
+	assert.Equal(t, 3, api.listCount())
+
+	r, deleted, err := k.LoadUpdate()
+	require.NoError(t, err)
+	assert.Empty(t, r)
+	assert.Empty(t, deleted)
+	assert.Equal(t, 3, api.listCount(), "no lists without watched changes")
+
+	api.watch[EndpointsClusterURI] <- watchedEvent(watchEventModified, fmt.Sprintf(endpointsFmt, "2", "10.2.0.2"))
+
+	require.Eventually(t, func() bool {
+		r, _, err = k.LoadUpdate()
+		return err == nil && len(r) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "http://10.2.0.2:8080", backend(r))
+	assert.Equal(t, 3, api.listCount(), "the changes are loaded from the watched resources")
+}
+
+func TestLoadUpdateWatchedEndpointsOnly(t *testing.T) {
+	const (
+		ingressFmt = `{
+			"metadata": {"namespace": "default", "name": %[1]q, "resourceVersion": "1"},
+			"spec": {"rules": [{"host": "%[1]s.example.org", "http": {"paths": [{
+				"path": "/", "pathType": "ImplementationSpecific",
+				"backend": {"service": {"name": %[1]q, "port": {"number": 80}}}
+			}]}}]}
+		}`
+		serviceFmt = `{
+			"metadata": {"namespace": "default", "name": %q, "resourceVersion": "1"},
+			"spec": {"clusterIP": "10.3.0.1", "ports": [{"name": "http", "port": 80, "targetPort": 8080}]}
+		}`
+		endpointsFmt = `{
+			"metadata": {"namespace": "default", "name": %q, "resourceVersion": %q},
+			"subsets": [{"addresses": [%s], "ports": [{"name": "http", "port": 8080}]}]
+		}`
+	)
+
+	endpoints := func(name, resourceVersion string, ips ...string) string {
+		addresses := make([]string, len(ips))
+		for i, ip := range ips {
+			addresses[i] = fmt.Sprintf(`{"ip": %q}`, ip)
+		}
+
+		return fmt.Sprintf(endpointsFmt, name, resourceVersion, strings.Join(addresses, ","))
+	}
+
+	api := newWatchTestAPI(map[string]string{
+		IngressesV1ClusterURI: watchedList("1", fmt.Sprintf(ingressFmt, "app1"), fmt.Sprintf(ingressFmt, "app2")),
+		ServicesClusterURI:    watchedList("1", fmt.Sprintf(serviceFmt, "app1"), fmt.Sprintf(serviceFmt, "app2")),
+		EndpointsClusterURI: watchedList(
+			"1",
+			endpoints("app1", "1", "10.2.0.1", "10.2.0.2"),
+			endpoints("app2", "1", "10.2.1.1", "10.2.1.2"),
+		),
+	})
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	k, err := New(Options{KubernetesURL: server.URL, EnableWatch: true})
+	require.NoError(t, err)
+	defer k.Close()
+
+	_, err = k.LoadAll()
+	require.NoError(t, err)
+	lastFullUpdate := k.lastFullUpdate
+
+	api.watch[EndpointsClusterURI] <- watchedEvent(watchEventModified, endpoints("app1", "2", "10.2.0.1", "10.2.0.3"))
+
+	var (
+		r       []*eskip.Route
+		deleted []string
+	)
+	require.Eventually(t, func() bool {
+		r, deleted, err = k.LoadUpdate()
+		return err == nil && len(r) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	require.Len(t, r, 1, "only the route of the changed endpoints is updated")
+	assert.Empty(t, deleted)
+	assert.Equal(t, "kube_default__app1__app1_example_org_____app1", r[0].Id)
+	assert.Equal(t, []*eskip.LBEndpoint{{Address: "http://10.2.0.1:8080"}, {Address: "http://10.2.0.3:8080"}}, r[0].LBEndpoints)
+	assert.Equal(t, lastFullUpdate, k.lastFullUpdate, "the routes are not converted again")
+	assert.Equal(t, []string{"http://10.2.0.1:8080", "http://10.2.0.3:8080"}, addresses(k.current[r[0].Id]))
+	assert.Equal(t, []string{"10.2.0.1", "10.2.0.3"}, k.GetEndpointAddresses("", "default", "app1"))
+
+	api.watch[EndpointsClusterURI] <- watchedEvent(watchEventModified, endpoints("app2", "3", "10.2.1.1"))
+
+	require.Eventually(t, func() bool {
+		r, deleted, err = k.LoadUpdate()
+		return err == nil && len(r) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	require.Len(t, r, 1)
+	assert.Empty(t, deleted)
+	assert.Equal(t, "kube_default__app2__app2_example_org_____app2", r[0].Id)
+	assert.Equal(t, "http://10.2.1.1:8080", r[0].Backend, "the routes are converted again when the backend type changes")
+	assert.NotEqual(t, lastFullUpdate, k.lastFullUpdate)
+}
+
+func addresses(r *eskip.Route) []string {
+	var a []string
+	for _, ep := range r.LBEndpoints {
+		a = append(a, ep.Address)
+	}
+
+	return a
+}
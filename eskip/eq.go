@@ -46,7 +46,7 @@ func eqLBEndpoints(left, right []*LBEndpoint) bool {
 	}
 
 	for i := range left {
-		if left[i].Address != right[i].Address || left[i].Zone != right[i].Zone {
+		if left[i].Address != right[i].Address || left[i].Zone != right[i].Zone || left[i].Weight != right[i].Weight {
 			return false
 		}
 	}
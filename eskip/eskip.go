@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"math"
 	"math/rand/v2"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -16,7 +18,10 @@ import (
 )
 
 const duplicateHeaderPredicateErrorFmt = "duplicate header predicate: %s"
-const zoneQueryParam = "zone"
+const (
+	zoneQueryParam   = "zone"
+	weightQueryParam = "weight"
+)
 
 var (
 	errDuplicatePathTreePredicate = errors.New("duplicate path tree predicate")
@@ -237,7 +242,10 @@ func (fb *fwdBackend) Do(routes []*Route) []*Route {
 	return routes
 }
 
-var errMixedProtocols = errors.New("loadbalancer endpoints cannot have mixed protocols")
+var (
+	errMixedProtocols          = errors.New("loadbalancer endpoints cannot have mixed protocols")
+	errInvalidLBEndpointWeight = errors.New("loadbalancer endpoint weight must be a positive number")
+)
 
 // Route definition used during the parser processes the raw routing
 // document.
@@ -384,6 +392,11 @@ func LBEndpointString(eps []*LBEndpoint) []string {
 type LBEndpoint struct {
 	Address string
 	Zone    string
+
+	// Weight is the static weight of the endpoint relative to the
+	// other endpoints of the route. Zero means that it was not set,
+	// and the endpoint has the default weight of 1.
+	Weight float64
 }
 
 func (ep LBEndpoint) String() string {
@@ -399,10 +412,27 @@ func (ep LBEndpoint) StringWithZone() string {
 	return ep.Address + "?" + zoneQueryParam + "=" + url.QueryEscape(ep.Zone)
 }
 
+// StringWithParams returns the endpoint address with the availability zone
+// and the weight encoded as the "zone" and "weight" query parameters.
+func (ep LBEndpoint) StringWithParams() string {
+	s := ep.StringWithZone()
+	if ep.Weight <= 0 {
+		return s
+	}
+
+	sep := "?"
+	if ep.Zone != "" {
+		sep = "&"
+	}
+
+	return s + sep + weightQueryParam + "=" + strconv.FormatFloat(ep.Weight, 'g', -1, 64)
+}
+
 // newLBEndpoint builds an LBEndpoint from a endpoint string, extracting the
-// availability zone from the "zone" query parameter if present. Only the "zone"
-// key is stripped; the remaining address is kept clean. Address must not carry
-// the zone param so that it can be used as it was originally defined
+// availability zone and the weight from the "zone" and "weight" query
+// parameters if present. Only these keys are stripped; the remaining address
+// is kept clean. Address must not carry the params so that it can be used as
+// it was originally defined
 func newLBEndpoint(s string) *LBEndpoint {
 	u, err := url.Parse(s)
 	if err != nil {
@@ -411,13 +441,37 @@ func newLBEndpoint(s string) *LBEndpoint {
 
 	q := u.Query()
 	zone := q.Get(zoneQueryParam)
-	if zone == "" {
+	weight, err := parseLBEndpointWeight(q.Get(weightQueryParam))
+	if err != nil {
+		weight = 0
+	}
+
+	if zone == "" && weight == 0 {
 		return &LBEndpoint{Address: s}
 	}
 
 	q.Del(zoneQueryParam)
+	if weight > 0 {
+		q.Del(weightQueryParam)
+	}
+
 	u.RawQuery = q.Encode()
-	return &LBEndpoint{Address: u.String(), Zone: zone}
+	return &LBEndpoint{Address: u.String(), Zone: zone, Weight: weight}
+}
+
+// parseLBEndpointWeight parses the value of the "weight" query parameter of
+// an endpoint. It returns zero when the value is empty.
+func parseLBEndpointWeight(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	w, err := strconv.ParseFloat(s, 64)
+	if err != nil || w <= 0 || math.IsInf(w, 0) {
+		return 0, errInvalidLBEndpointWeight
+	}
+
+	return w, nil
 }
 
 type RoutePredicate func(*Route) bool
@@ -651,6 +705,10 @@ func newRouteDefinition(r *parsedRoute) (*Route, error) {
 				return nil, errMixedProtocols
 			}
 
+			if _, err := parseLBEndpointWeight(eu.Query().Get(weightQueryParam)); err != nil {
+				return nil, err
+			}
+
 			scheme = eu.Scheme
 		}
 	}
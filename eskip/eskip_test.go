@@ -938,12 +938,60 @@ func TestLBEndpointStringWithZone(t *testing.T) {
 	}
 }
 
+func TestLBEndpointStringWithParams(t *testing.T) {
+	for _, ti := range []struct {
+		name string
+		ep   LBEndpoint
+		want string
+	}{{
+		name: "test lb endpoint without params",
+		ep:   LBEndpoint{Address: "http://10.0.0.1:8080"},
+		want: "http://10.0.0.1:8080",
+	}, {
+		name: "test lb endpoint with weight",
+		ep:   LBEndpoint{Address: "http://10.0.0.1:8080", Weight: 0.5},
+		want: "http://10.0.0.1:8080?weight=0.5",
+	}, {
+		name: "test lb endpoint with zone and weight",
+		ep:   LBEndpoint{Address: "http://10.0.0.1:8080", Zone: "eu-central-1a", Weight: 2},
+		want: "http://10.0.0.1:8080?zone=eu-central-1a&weight=2",
+	}} {
+		t.Run(ti.name, func(t *testing.T) {
+			if got := ti.ep.StringWithParams(); got != ti.want {
+				t.Errorf("StringWithParams() = %q, want %q", got, ti.want)
+			}
+		})
+	}
+}
+
+func TestParseLBEndpointWeight(t *testing.T) {
+	r, err := Parse(`* -> <roundRobin, "http://10.0.0.1:8080?weight=3", "http://10.0.0.2:8080">`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := r[0].LBEndpoints[0].Weight; w != 3 {
+		t.Errorf("Weight = %v, want 3", w)
+	}
+
+	if s := r[0].String(); s != `* -> <roundRobin, "http://10.0.0.1:8080?weight=3", "http://10.0.0.2:8080">` {
+		t.Errorf("unexpected route string: %s", s)
+	}
+
+	for _, w := range []string{"0", "-1", "foo", "+Inf"} {
+		if _, err := Parse(`* -> <roundRobin, "http://10.0.0.1:8080?weight=` + w + `", "http://10.0.0.2:8080">`); err == nil {
+			t.Errorf("expected error for weight %s", w)
+		}
+	}
+}
+
 func TestNewLBEndpoint(t *testing.T) {
 	for _, ti := range []struct {
-		name     string
-		in       string
-		wantAddr string
-		wantZone string
+		name       string
+		in         string
+		wantAddr   string
+		wantZone   string
+		wantWeight float64
 	}{{
 		name:     "test lb endpoint with zone",
 		in:       "http://10.0.0.1:8080?zone=eu-central-1a",
@@ -959,6 +1007,21 @@ func TestNewLBEndpoint(t *testing.T) {
 		in:       "not a url",
 		wantAddr: "not a url",
 		wantZone: "",
+	}, {
+		name:       "test lb endpoint with weight",
+		in:         "http://10.0.0.1:8080?weight=2.5",
+		wantAddr:   "http://10.0.0.1:8080",
+		wantWeight: 2.5,
+	}, {
+		name:       "test lb endpoint with zone and weight",
+		in:         "http://10.0.0.1:8080/foo?weight=3&zone=eu-central-1a",
+		wantAddr:   "http://10.0.0.1:8080/foo",
+		wantZone:   "eu-central-1a",
+		wantWeight: 3,
+	}, {
+		name:     "test lb endpoint with invalid weight",
+		in:       "http://10.0.0.1:8080?weight=-1",
+		wantAddr: "http://10.0.0.1:8080?weight=-1",
 	}} {
 		t.Run(ti.name, func(t *testing.T) {
 			ep := newLBEndpoint(ti.in)
@@ -968,6 +1031,9 @@ func TestNewLBEndpoint(t *testing.T) {
 			if ep.Zone != ti.wantZone {
 				t.Errorf("Zone = %q, want %q", ep.Zone, ti.wantZone)
 			}
+			if ep.Weight != ti.wantWeight {
+				t.Errorf("Weight = %v, want %v", ep.Weight, ti.wantWeight)
+			}
 		})
 	}
 }
@@ -35,7 +35,7 @@ func newJSONRoute(r *Route) *jsonRoute {
 	if cr.BackendType != NetworkBackend || cr.Backend != "" {
 		eps := make([]string, len(cr.LBEndpoints))
 		for i, ep := range cr.LBEndpoints {
-			eps[i] = ep.StringWithZone()
+			eps[i] = ep.StringWithParams()
 		}
 		jr.Backend = &jsonBackend{
 			Type:      cr.BackendType.String(),
@@ -167,7 +167,7 @@ func lbBackendString(r *Route) string {
 			b.WriteString(", ")
 		}
 		b.WriteByte('"')
-		b.WriteString(ep.StringWithZone())
+		b.WriteString(ep.StringWithParams())
 		b.WriteByte('"')
 	}
 	b.WriteByte('>')
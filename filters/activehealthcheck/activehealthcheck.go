@@ -0,0 +1,232 @@
+package activehealthcheck
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	DefaultInterval           = 10 * time.Second
+	DefaultTimeout            = time.Second
+	DefaultExpectedStatus     = http.StatusOK
+	DefaultHealthyThreshold   = 2
+	DefaultUnhealthyThreshold = 3
+)
+
+// Settings configure how the endpoints of an LB route are probed.
+type Settings struct {
+	// Path is requested from every endpoint with a GET request.
+	Path string
+
+	// Interval is the time between two probes of the same endpoint.
+	Interval time.Duration
+
+	// Timeout of a single probe request.
+	Timeout time.Duration
+
+	// ExpectedStatus is the status code of a successful probe.
+	ExpectedStatus int
+
+	// HealthyThreshold is the number of consecutive successful probes
+	// required to mark an unhealthy endpoint as healthy again.
+	HealthyThreshold int
+
+	// UnhealthyThreshold is the number of consecutive failed probes
+	// required to mark a healthy endpoint as unhealthy.
+	UnhealthyThreshold int
+}
+
+type (
+	spec   struct{}
+	filter struct {
+		settings Settings
+	}
+)
+
+// DefaultSettings returns the settings used for the values that are
+// neither set globally nor by the filter.
+func DefaultSettings() Settings {
+	return Settings{
+		Interval:           DefaultInterval,
+		Timeout:            DefaultTimeout,
+		ExpectedStatus:     DefaultExpectedStatus,
+		HealthyThreshold:   DefaultHealthyThreshold,
+		UnhealthyThreshold: DefaultUnhealthyThreshold,
+	}
+}
+
+// merge returns the settings with the zero fields taken from the
+// defaults.
+func (s Settings) merge(defaults Settings) Settings {
+	if s.Path == "" {
+		s.Path = defaults.Path
+	}
+	if s.Interval == 0 {
+		s.Interval = defaults.Interval
+	}
+	if s.Timeout == 0 {
+		s.Timeout = defaults.Timeout
+	}
+	if s.ExpectedStatus == 0 {
+		s.ExpectedStatus = defaults.ExpectedStatus
+	}
+	if s.HealthyThreshold == 0 {
+		s.HealthyThreshold = defaults.HealthyThreshold
+	}
+	if s.UnhealthyThreshold == 0 {
+		s.UnhealthyThreshold = defaults.UnhealthyThreshold
+	}
+	return s
+}
+
+// InitActiveHealthChecker parses the global active health check
+// configuration. It returns false when no configuration was provided,
+// in which case only the routes with the activeHealthCheck filter are
+// probed.
+func InitActiveHealthChecker(o map[string]string) (bool, Settings, error) {
+	result := DefaultSettings()
+	if len(o) == 0 {
+		return false, result, nil
+	}
+
+	for key, value := range o {
+		switch key {
+		case "path":
+			result.Path = value
+		case "interval":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return false, Settings{}, fmt.Errorf("active health check: invalid interval value: %s", value)
+			}
+			result.Interval = d
+		case "timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return false, Settings{}, fmt.Errorf("active health check: invalid timeout value: %s", value)
+			}
+			result.Timeout = d
+		case "expected-status":
+			code, err := strconv.Atoi(value)
+			if err != nil || !validStatus(code) {
+				return false, Settings{}, fmt.Errorf("active health check: invalid expected-status value: %s", value)
+			}
+			result.ExpectedStatus = code
+		case "healthy-threshold":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return false, Settings{}, fmt.Errorf("active health check: invalid healthy-threshold value: %s", value)
+			}
+			result.HealthyThreshold = n
+		case "unhealthy-threshold":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return false, Settings{}, fmt.Errorf("active health check: invalid unhealthy-threshold value: %s", value)
+			}
+			result.UnhealthyThreshold = n
+		default:
+			return false, Settings{}, fmt.Errorf("active health check: invalid parameter: key=%s,value=%s", key, value)
+		}
+	}
+
+	if result.Path == "" {
+		return false, Settings{}, fmt.Errorf("active health check: missing required parameter path")
+	}
+
+	return true, result, nil
+}
+
+func validStatus(code int) bool {
+	return code >= 100 && code <= 599
+}
+
+func getIntArg(a interface{}) (int, error) {
+	switch v := a.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	default:
+		return 0, filters.ErrInvalidFilterParameters
+	}
+}
+
+func getDurationArg(a interface{}) (time.Duration, error) {
+	if s, ok := a.(string); ok {
+		return time.ParseDuration(s)
+	}
+
+	i, err := getIntArg(a)
+	return time.Duration(i) * time.Millisecond, err
+}
+
+// NewActiveHealthCheck creates a filter spec for the activeHealthCheck
+// filter. The filter enables the active health check of the endpoints of
+// an LB route, and it overrides the global settings:
+//
+//	activeHealthCheck("/healthz")
+//	activeHealthCheck("/healthz", "5s", "500ms", 204, 2, 3)
+//
+// The arguments are the path, the interval and the timeout (milliseconds or
+// duration string), the expected status code, and the healthy and unhealthy
+// thresholds. Only the path is mandatory.
+func NewActiveHealthCheck() filters.Spec {
+	return spec{}
+}
+
+func (spec) Name() string { return filters.ActiveHealthCheckName }
+
+func (spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 || len(args) > 6 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var (
+		f   filter
+		ok  bool
+		err error
+	)
+
+	f.settings.Path, ok = args[0].(string)
+	if !ok || f.settings.Path == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if len(args) > 1 {
+		if f.settings.Interval, err = getDurationArg(args[1]); err != nil || f.settings.Interval <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 2 {
+		if f.settings.Timeout, err = getDurationArg(args[2]); err != nil || f.settings.Timeout <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 3 {
+		if f.settings.ExpectedStatus, err = getIntArg(args[3]); err != nil || !validStatus(f.settings.ExpectedStatus) {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 4 {
+		if f.settings.HealthyThreshold, err = getIntArg(args[4]); err != nil || f.settings.HealthyThreshold < 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 5 {
+		if f.settings.UnhealthyThreshold, err = getIntArg(args[5]); err != nil || f.settings.UnhealthyThreshold < 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func (filter) Request(filters.FilterContext)  {}
+func (filter) Response(filters.FilterContext) {}
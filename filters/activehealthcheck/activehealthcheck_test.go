@@ -0,0 +1,261 @@
+package activehealthcheck
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/loadbalancer"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCreateFilter(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		args   []interface{}
+		expect Settings
+		fail   bool
+	}{{
+		name: "no args",
+		fail: true,
+	}, {
+		name: "too many args",
+		args: []interface{}{"/health", "1s", "1s", 200, 1, 1, 1},
+		fail: true,
+	}, {
+		name: "empty path",
+		args: []interface{}{""},
+		fail: true,
+	}, {
+		name: "invalid interval",
+		args: []interface{}{"/health", "foo"},
+		fail: true,
+	}, {
+		name: "invalid status",
+		args: []interface{}{"/health", "1s", "1s", 42},
+		fail: true,
+	}, {
+		name: "invalid threshold",
+		args: []interface{}{"/health", "1s", "1s", 200, 0},
+		fail: true,
+	}, {
+		name:   "path only",
+		args:   []interface{}{"/health"},
+		expect: Settings{Path: "/health"},
+	}, {
+		name: "all args",
+		args: []interface{}{"/health", "5s", 500, 204.0, 2, 4},
+		expect: Settings{
+			Path:               "/health",
+			Interval:           5 * time.Second,
+			Timeout:            500 * time.Millisecond,
+			ExpectedStatus:     http.StatusNoContent,
+			HealthyThreshold:   2,
+			UnhealthyThreshold: 4,
+		},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewActiveHealthCheck().CreateFilter(tt.args)
+			if tt.fail {
+				assert.ErrorIs(t, err, filters.ErrInvalidFilterParameters)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expect, f.(filter).settings)
+		})
+	}
+}
+
+func TestInitActiveHealthChecker(t *testing.T) {
+	enabled, _, err := InitActiveHealthChecker(nil)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	for _, o := range []map[string]string{
+		{"interval": "1s"},
+		{"path": "/health", "interval": "foo"},
+		{"path": "/health", "timeout": "-1s"},
+		{"path": "/health", "expected-status": "600"},
+		{"path": "/health", "unhealthy-threshold": "0"},
+		{"path": "/health", "foo": "bar"},
+	} {
+		_, _, err := InitActiveHealthChecker(o)
+		assert.Error(t, err, "%v", o)
+	}
+
+	enabled, settings, err := InitActiveHealthChecker(map[string]string{
+		"path":              "/health",
+		"interval":          "3s",
+		"healthy-threshold": "1",
+	})
+	require.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, Settings{
+		Path:               "/health",
+		Interval:           3 * time.Second,
+		Timeout:            DefaultTimeout,
+		ExpectedStatus:     DefaultExpectedStatus,
+		HealthyThreshold:   1,
+		UnhealthyThreshold: DefaultUnhealthyThreshold,
+	}, settings)
+}
+
+func createRoutes(t *testing.T, doc string) []*routing.Route {
+	t.Helper()
+
+	defs, err := eskip.Parse(doc)
+	require.NoError(t, err)
+
+	spec := NewActiveHealthCheck()
+	var routes []*routing.Route
+	for _, def := range defs {
+		r := &routing.Route{Route: *def}
+		for _, f := range def.Filters {
+			if f.Name != spec.Name() {
+				continue
+			}
+
+			fi, err := spec.CreateFilter(f.Args)
+			require.NoError(t, err)
+			r.Filters = append(r.Filters, &routing.RouteFilter{Filter: fi, Name: f.Name})
+		}
+		routes = append(routes, r)
+	}
+
+	return loadbalancer.NewAlgorithmProvider().Do(routes)
+}
+
+func TestProber(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer backend.Close()
+
+	registry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer registry.Close()
+
+	m := &metricstest.MockMetrics{}
+	p := NewProber(Options{EndpointRegistry: registry, Metrics: m})
+	defer p.Close()
+
+	routes := createRoutes(t, `
+		probed: Path("/probed") -> activeHealthCheck("/health", "10ms", "100ms", 204, 2, 2) -> <roundRobin, "`+backend.URL+`">;
+		notProbed: Path("/not-probed") -> <roundRobin, "http://127.0.0.1:1">;
+	`)
+	p.Do(routes)
+
+	host := backend.Listener.Addr().String()
+	epMetrics := registry.GetMetrics(host)
+
+	state := p.State()
+	require.Len(t, state, 1)
+	assert.Equal(t, backend.URL, state[0].Endpoint)
+	assert.Equal(t, []string{"probed"}, state[0].Routes)
+
+	healthy.Store(false)
+	require.Eventually(t, epMetrics.ActiveHealthCheckFailed, time.Second, 10*time.Millisecond)
+
+	healthy.Store(true)
+	require.Eventually(t, func() bool { return !epMetrics.ActiveHealthCheckFailed() }, time.Second, 10*time.Millisecond)
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(1), counters["active-health-check.endpoints.marked-unhealthy"])
+		assert.Equal(t, int64(1), counters["active-health-check.endpoints.marked-healthy"])
+	})
+
+	rsp := httptest.NewRecorder()
+	p.ServeHTTP(rsp, httptest.NewRequest("GET", "/active-health-check", nil))
+	require.Equal(t, http.StatusOK, rsp.Code)
+
+	var served []EndpointState
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &served))
+	require.Len(t, served, 1)
+	assert.True(t, served[0].Healthy)
+	assert.Equal(t, "/health", served[0].Path)
+
+	// removing the route stops probing and resets the endpoint state
+	healthy.Store(false)
+	require.Eventually(t, epMetrics.ActiveHealthCheckFailed, time.Second, 10*time.Millisecond)
+
+	p.Do(routes[1:])
+	assert.Empty(t, p.State())
+	assert.False(t, epMetrics.ActiveHealthCheckFailed())
+}
+
+func TestProberGlobal(t *testing.T) {
+	registry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer registry.Close()
+
+	p := NewProber(Options{
+		Enabled:          true,
+		Settings:         Settings{Path: "/health", Interval: time.Hour},
+		EndpointRegistry: registry,
+		Metrics:          &metricstest.MockMetrics{},
+	})
+	defer p.Close()
+
+	p.Do(createRoutes(t, `
+		r1: * -> <roundRobin, "http://127.0.0.1:1", "http://127.0.0.1:2">;
+		r2: * -> activeHealthCheck("/ready") -> "http://127.0.0.1:3";
+		r3: * -> activeHealthCheck("/ready") -> <random, "http://127.0.0.1:2">;
+		r4: * -> <random, "fastcgi://127.0.0.1:4", "fastcgi://127.0.0.1:5">;
+	`))
+
+	state := p.State()
+	require.Len(t, state, 2)
+	assert.Equal(t, "http://127.0.0.1:1", state[0].Endpoint)
+	assert.Equal(t, "/health", state[0].Path)
+	assert.Equal(t, "http://127.0.0.1:2", state[1].Endpoint)
+	assert.Equal(t, []string{"r1", "r3"}, state[1].Routes)
+}
+
+func TestProberConflictingSettings(t *testing.T) {
+	registry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer registry.Close()
+
+	p := NewProber(Options{
+		EndpointRegistry: registry,
+		Metrics:          &metricstest.MockMetrics{},
+	})
+	defer p.Close()
+
+	for _, doc := range []string{`
+		r2: * -> activeHealthCheck("/b", "1h") -> <roundRobin, "http://127.0.0.1:1">;
+		r1: * -> activeHealthCheck("/a", "1h") -> <roundRobin, "http://127.0.0.1:1">;
+	`, `
+		r1: * -> activeHealthCheck("/a", "1h") -> <roundRobin, "http://127.0.0.1:1">;
+		r2: * -> activeHealthCheck("/b", "1h") -> <roundRobin, "http://127.0.0.1:1">;
+	`} {
+		p.Do(createRoutes(t, doc))
+
+		state := p.State()
+		require.Len(t, state, 1)
+		assert.Equal(t, "/a", state[0].Path)
+		assert.ElementsMatch(t, []string{"r1", "r2"}, state[0].Routes)
+		assert.Equal(t, map[string]bool{"http://127.0.0.1:1": true}, p.conflicts)
+	}
+
+	p.Do(createRoutes(t, `
+		r1: * -> activeHealthCheck("/a", "1h") -> <roundRobin, "http://127.0.0.1:1">;
+		r2: * -> activeHealthCheck("/a", "1h") -> <roundRobin, "http://127.0.0.1:1">;
+	`))
+
+	assert.Empty(t, p.conflicts)
+}
@@ -0,0 +1,26 @@
+/*
+Package activehealthcheck provides the active health checking of the
+endpoints of load balanced routes.
+
+The Prober periodically requests a health path from every endpoint of
+the probed LB routes, and marks the endpoints in the routing.EndpointRegistry
+as unhealthy after a configured number of consecutive failed probes. It
+marks them as healthy again after a configured number of consecutive
+successful probes. The proxy skips the endpoints marked as unhealthy before
+applying the load balancing algorithm, unless all the endpoints of the route
+are unhealthy.
+
+Probing can be enabled globally for all LB routes, or per route with the
+activeHealthCheck filter, which also overrides the global settings:
+
+	activeHealthCheck("/healthz")
+	activeHealthCheck("/healthz", "5s", "500ms", 204, 2, 3)
+
+The arguments of the filter are the health path, the probe interval, the
+probe timeout, the expected status code and the healthy and unhealthy
+thresholds. All arguments but the path are optional.
+
+The current probe state is exposed as JSON by the Prober, which implements
+http.Handler, and it can be registered on the support listener.
+*/
+package activehealthcheck
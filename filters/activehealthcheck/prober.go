@@ -0,0 +1,395 @@
+package activehealthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/routing"
+)
+
+// maxProbeBodySize limits how much of the probe response body is
+// consumed before closing it.
+const maxProbeBodySize = 4096
+
+// Options configure the Prober.
+type Options struct {
+	// Enabled enables the probing of all LB routes using Settings.
+	// When false, only the routes with the activeHealthCheck filter
+	// are probed.
+	Enabled bool
+
+	// Settings are the global settings used for all the probed
+	// routes. The activeHealthCheck filter overrides them per route.
+	Settings Settings
+
+	// EndpointRegistry receives the results of the probes. Required.
+	EndpointRegistry *routing.EndpointRegistry
+
+	// Metrics collector. If not specified metrics.Default is used.
+	Metrics metrics.Metrics
+
+	// Transport is used to execute the probe requests. If not
+	// specified, a clone of http.DefaultTransport is used.
+	Transport http.RoundTripper
+}
+
+// Prober is a routing.PostProcessor that maintains the set of probed
+// endpoints based on the current LB routes, and probes them in the
+// background. It implements http.Handler to expose the probe state.
+type Prober struct {
+	enabled  bool
+	settings Settings
+	registry *routing.EndpointRegistry
+	metrics  metrics.Metrics
+	client   *http.Client
+
+	mu        sync.Mutex
+	targets   map[string]*target
+	conflicts map[string]bool
+	closed    bool
+}
+
+type target struct {
+	endpoint string
+	host     string
+	url      string
+	settings Settings
+	quit     chan struct{}
+
+	// settingsRoute is the ID of the route whose settings are used
+	// when multiple routes probe the same endpoint
+	settingsRoute string
+
+	mu     sync.Mutex
+	routes []string
+	state  probeState
+}
+
+type probeState struct {
+	healthy              bool
+	consecutiveSuccesses int
+	consecutiveFailures  int
+	lastStatus           int
+	lastError            string
+	lastProbe            time.Time
+}
+
+// EndpointState is the JSON representation of the probe state of an
+// endpoint.
+type EndpointState struct {
+	Endpoint             string    `json:"endpoint"`
+	Routes               []string  `json:"routes"`
+	Path                 string    `json:"path"`
+	Interval             string    `json:"interval"`
+	Timeout              string    `json:"timeout"`
+	ExpectedStatus       int       `json:"expected_status"`
+	Healthy              bool      `json:"healthy"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	LastStatus           int       `json:"last_status,omitempty"`
+	LastError            string    `json:"last_error,omitempty"`
+	LastProbe            time.Time `json:"last_probe"`
+}
+
+var (
+	_ routing.PostProcessor = &Prober{}
+	_ http.Handler          = &Prober{}
+)
+
+// NewProber creates a Prober. It needs to be added to the routing
+// post-processors after the LB algorithm provider.
+func NewProber(o Options) *Prober {
+	m := o.Metrics
+	if m == nil {
+		m = metrics.Default
+	}
+
+	tr := o.Transport
+	if tr == nil {
+		tr = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	return &Prober{
+		enabled:  o.Enabled,
+		settings: o.Settings.merge(DefaultSettings()),
+		registry: o.EndpointRegistry,
+		metrics:  m,
+		client: &http.Client{
+			Transport: tr,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		targets: make(map[string]*target),
+	}
+}
+
+func (p *Prober) routeSettings(r *routing.Route) (Settings, bool) {
+	for _, f := range r.Filters {
+		if fi, ok := f.Filter.(filter); ok {
+			return fi.settings.merge(p.settings), true
+		}
+	}
+
+	return p.settings, p.enabled
+}
+
+// Do implements routing.PostProcessor.
+//
+// An endpoint is probed only once, even when it is shared by multiple
+// routes. When these routes configure different settings, the settings
+// of the route with the lowest ID, in lexical order, are used.
+func (p *Prober) Do(routes []*routing.Route) []*routing.Route {
+	desired := make(map[string]*target)
+	conflicts := make(map[string]bool)
+	for _, r := range routes {
+		if r.BackendType != eskip.LBBackend {
+			continue
+		}
+
+		settings, ok := p.routeSettings(r)
+		if !ok || settings.Path == "" {
+			continue
+		}
+
+		for _, ep := range r.LBEndpoints {
+			scheme := ep.Scheme
+			switch scheme {
+			case "http", "https":
+			case "h2c":
+				scheme = "http"
+			default:
+				continue
+			}
+
+			key := ep.Scheme + "://" + ep.Host
+			if t, ok := desired[key]; ok {
+				t.routes = append(t.routes, r.Id)
+				if settings != t.settings {
+					conflicts[key] = true
+				}
+
+				if r.Id < t.settingsRoute {
+					t.url = scheme + "://" + ep.Host + settings.Path
+					t.settings = settings
+					t.settingsRoute = r.Id
+				}
+
+				continue
+			}
+
+			desired[key] = &target{
+				endpoint:      key,
+				host:          ep.Host,
+				url:           scheme + "://" + ep.Host + settings.Path,
+				settings:      settings,
+				settingsRoute: r.Id,
+				routes:        []string{r.Id},
+				state:         probeState{healthy: true},
+			}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return routes
+	}
+
+	for key := range conflicts {
+		if !p.conflicts[key] {
+			log.Warnf(
+				"Active health check: routes of %q configure different settings, using the settings of route %s",
+				key,
+				desired[key].settingsRoute,
+			)
+		}
+	}
+
+	p.conflicts = conflicts
+
+	for key, t := range p.targets {
+		if dt, ok := desired[key]; ok && dt.settings == t.settings {
+			t.mu.Lock()
+			t.routes = dt.routes
+			t.mu.Unlock()
+			delete(desired, key)
+			continue
+		}
+
+		// the quit channel is closed and the flag reset under the lock
+		// of the target, so that a running probe can't set it again
+		t.mu.Lock()
+		close(t.quit)
+		p.registry.GetMetrics(t.host).SetActiveHealthCheckFailed(false)
+		t.mu.Unlock()
+		delete(p.targets, key)
+	}
+
+	for key, t := range desired {
+		t.quit = make(chan struct{})
+		p.targets[key] = t
+		go p.run(t)
+	}
+
+	return routes
+}
+
+func (p *Prober) run(t *target) {
+	ticker := time.NewTicker(t.settings.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.probe(t)
+
+		select {
+		case <-t.quit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Prober) probe(t *target) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.settings.Timeout)
+	defer cancel()
+
+	var status int
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url, nil)
+	if err == nil {
+		var rsp *http.Response
+		rsp, err = p.client.Do(req)
+		if err == nil {
+			io.Copy(io.Discard, io.LimitReader(rsp.Body, maxProbeBodySize))
+			rsp.Body.Close()
+			status = rsp.StatusCode
+		}
+	}
+
+	success := err == nil && status == t.settings.ExpectedStatus
+	p.metrics.IncCounter("active-health-check.probes")
+	if !success {
+		p.metrics.IncCounter("active-health-check.probes.failed")
+	}
+
+	t.mu.Lock()
+	s := &t.state
+	wasHealthy := s.healthy
+	s.lastProbe = time.Now()
+	s.lastStatus = status
+	s.lastError = ""
+	if err != nil {
+		s.lastError = err.Error()
+	}
+
+	if success {
+		s.consecutiveSuccesses++
+		s.consecutiveFailures = 0
+		if !s.healthy && s.consecutiveSuccesses >= t.settings.HealthyThreshold {
+			s.healthy = true
+		}
+	} else {
+		s.consecutiveFailures++
+		s.consecutiveSuccesses = 0
+		if s.healthy && s.consecutiveFailures >= t.settings.UnhealthyThreshold {
+			s.healthy = false
+		}
+	}
+	healthy := s.healthy
+
+	select {
+	case <-t.quit:
+		// the target was removed or replaced while probing
+		t.mu.Unlock()
+		return
+	default:
+	}
+
+	p.registry.GetMetrics(t.host).SetActiveHealthCheckFailed(!healthy)
+	t.mu.Unlock()
+
+	switch {
+	case wasHealthy && !healthy:
+		log.Infof("Active health check: marking %q as unhealthy, status: %d, error: %v", t.endpoint, status, err)
+		p.metrics.IncCounter("active-health-check.endpoints.marked-unhealthy")
+	case !wasHealthy && healthy:
+		log.Infof("Active health check: marking %q as healthy", t.endpoint)
+		p.metrics.IncCounter("active-health-check.endpoints.marked-healthy")
+	}
+}
+
+// State returns the current probe state of all probed endpoints,
+// ordered by the endpoint address.
+func (p *Prober) State() []EndpointState {
+	p.mu.Lock()
+	targets := make([]*target, 0, len(p.targets))
+	for _, t := range p.targets {
+		targets = append(targets, t)
+	}
+	p.mu.Unlock()
+
+	result := make([]EndpointState, 0, len(targets))
+	for _, t := range targets {
+		t.mu.Lock()
+		result = append(result, EndpointState{
+			Endpoint:             t.endpoint,
+			Routes:               slices.Clone(t.routes),
+			Path:                 t.settings.Path,
+			Interval:             t.settings.Interval.String(),
+			Timeout:              t.settings.Timeout.String(),
+			ExpectedStatus:       t.settings.ExpectedStatus,
+			Healthy:              t.state.healthy,
+			ConsecutiveSuccesses: t.state.consecutiveSuccesses,
+			ConsecutiveFailures:  t.state.consecutiveFailures,
+			LastStatus:           t.state.lastStatus,
+			LastError:            t.state.lastError,
+			LastProbe:            t.state.lastProbe,
+		})
+		t.mu.Unlock()
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Endpoint < result[j].Endpoint })
+	return result
+}
+
+// ServeHTTP responds with the probe state of all probed endpoints
+// as JSON.
+func (p *Prober) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p.State()); err != nil {
+		log.Errorf("Active health check: failed to encode state: %v", err)
+	}
+}
+
+// Close stops probing all endpoints.
+func (p *Prober) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+
+	for key, t := range p.targets {
+		close(t.quit)
+		delete(p.targets, key)
+	}
+	p.client.CloseIdleConnections()
+}
@@ -53,6 +53,8 @@ const (
 
 	authHeaderName   = "Authorization"
 	authHeaderPrefix = "Bearer "
+	// dpopAuthHeaderPrefix defined at https://www.rfc-editor.org/rfc/rfc9449#section-7.1
+	dpopAuthHeaderPrefix = "DPoP "
 	// tokenKey defined at https://tools.ietf.org/html/rfc7662#section-2.1
 	tokenKey = "token"
 	scopeKey = "scope"
@@ -98,6 +100,28 @@ func getToken(r *http.Request) (string, bool) {
 	return h[len(authHeaderPrefix):], true
 }
 
+// getDPoPToken returns the access token of the DPoP authorization
+// scheme, used by sender-constrained tokens.
+func getDPoPToken(r *http.Request) (string, bool) {
+	h := r.Header.Get(authHeaderName)
+	if !strings.HasPrefix(h, dpopAuthHeaderPrefix) {
+		return "", false
+	}
+
+	return h[len(dpopAuthHeaderPrefix):], true
+}
+
+// getAccessToken returns the access token of the Bearer or the DPoP
+// authorization scheme, for the filters validating the access token
+// before the dpop filter validates the proof.
+func getAccessToken(r *http.Request) (string, bool) {
+	if token, ok := getToken(r); ok {
+		return token, true
+	}
+
+	return getDPoPToken(r)
+}
+
 func reject(
 	ctx filters.FilterContext,
 	status int,
@@ -0,0 +1,447 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	dpopHeaderName = "DPoP"
+	dpopTokenType  = "dpop+jwt"
+
+	defaultDPoPMaxAge = time.Minute
+	defaultDPoPLeeway = 5 * time.Second
+
+	dpopInvalidProof = `DPoP error="invalid_dpop_proof"`
+	dpopInvalidToken = `DPoP error="invalid_token"`
+
+	missingDPoPProof rejectReason = "missing-dpop-proof"
+	invalidDPoPProof rejectReason = "invalid-dpop-proof"
+	invalidDPoPToken rejectReason = "invalid-dpop-token"
+)
+
+var (
+	errDPoPInvalidType    = errors.New("invalid typ")
+	errDPoPInvalidJWK     = errors.New("invalid jwk")
+	errDPoPInvalidMethod  = errors.New("htm does not match the request method")
+	errDPoPInvalidURI     = errors.New("htu does not match the request uri")
+	errDPoPInvalidIat     = errors.New("iat is not fresh")
+	errDPoPInvalidAth     = errors.New("ath does not match the access token")
+	errDPoPMissingJti     = errors.New("missing jti")
+	errDPoPReplay         = errors.New("proof was used before")
+	errDPoPInvalidBinding = errors.New("cnf.jkt does not match the proof key")
+	errDPoPUnboundToken   = errors.New("access token is not bound to a key")
+	errDPoPMissingClaims  = errors.New("access token was not validated")
+	errDPoPInvalidScheme  = errors.New("access token is not sent with the DPoP scheme")
+)
+
+var (
+	defaultDPoPAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+	dpopHMACAlgorithms    = []string{"HS256", "HS384", "HS512"}
+)
+
+type (
+	dpopSpec struct {
+		replayCache DPoPReplayCache
+		config      yamlConfigParser[dpopConfig]
+	}
+
+	dpopFilter struct {
+		replayCache DPoPReplayCache
+		config      *dpopConfig
+		now         func() time.Time
+	}
+
+	// dpopConfig implements [yamlConfig],
+	// make sure it is not modified after initialization.
+	dpopConfig struct {
+		// Algorithms are the allowed signing algorithms of the proof,
+		// defaults to all asymmetric algorithms.
+		Algorithms []string `json:"algorithms,omitempty"`
+
+		// MaxAge is the maximum age of the iat claim of the proof,
+		// defaults to 1m.
+		MaxAge string `json:"maxAge,omitempty"`
+
+		// Leeway is the allowed clock skew to validate the iat claim,
+		// defaults to 5s.
+		Leeway string `json:"leeway,omitempty"`
+
+		// TrustForwardedProto enables taking the scheme of the request
+		// URI from the X-Forwarded-Proto header, when the request was
+		// not received over TLS. It must be enabled only behind a
+		// trusted proxy, that sets the header.
+		TrustForwardedProto bool `json:"trustForwardedProto,omitempty"`
+
+		maxAge time.Duration
+		leeway time.Duration
+	}
+)
+
+// NewDPoP creates a filter spec to validate DPoP proofs (RFC 9449) of
+// sender-constrained access tokens. The filter has to follow a filter,
+// that validates the access token, e.g. jwtValidation or
+// oauthTokenintrospectionAnyClaims, and checks that the proof of the DPoP
+// header is signed by the key bound to the access token with the cnf.jkt
+// claim, matches the request method and URI, is fresh and was not used
+// before.
+//
+// The optional argument is a YAML config:
+//
+//	dpop("{algorithms: [ES256], maxAge: 30s, leeway: 2s, trustForwardedProto: true}")
+func NewDPoP(replayCache DPoPReplayCache) filters.Spec {
+	return &dpopSpec{
+		replayCache: replayCache,
+		config:      newYamlConfigParser[dpopConfig](64),
+	}
+}
+
+func (s *dpopSpec) Name() string {
+	return filters.DPoPName
+}
+
+func (s *dpopSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) > 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	sargs, err := getStrings(args)
+	if err != nil {
+		return nil, err
+	}
+
+	config := "{}"
+	if len(sargs) == 1 {
+		config = sargs[0]
+	}
+
+	c, err := s.config.parse(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dpop config: %w", err)
+	}
+
+	return &dpopFilter{
+		replayCache: s.replayCache,
+		config:      c,
+		now:         time.Now,
+	}, nil
+}
+
+func (c *dpopConfig) initialize() error {
+	if len(c.Algorithms) == 0 {
+		c.Algorithms = defaultDPoPAlgorithms
+	}
+	for _, alg := range c.Algorithms {
+		if alg == jwt.SigningMethodNone.Alg() || slices.Contains(dpopHMACAlgorithms, alg) || jwt.GetSigningMethod(alg) == nil {
+			return fmt.Errorf("unsupported signing algorithm: %s", alg)
+		}
+	}
+
+	var err error
+	c.maxAge, err = parseDPoPDuration(c.MaxAge, defaultDPoPMaxAge)
+	if err != nil {
+		return fmt.Errorf("invalid maxAge: %w", err)
+	}
+
+	c.leeway, err = parseDPoPDuration(c.Leeway, defaultDPoPLeeway)
+	if err != nil {
+		return fmt.Errorf("invalid leeway: %w", err)
+	}
+
+	return nil
+}
+
+func parseDPoPDuration(s string, defaultValue time.Duration) (time.Duration, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %s", s)
+	}
+	return d, nil
+}
+
+func (f *dpopFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+
+	// sender-constrained tokens must not be accepted with the Bearer
+	// scheme, see https://www.rfc-editor.org/rfc/rfc9449#section-7.1
+	token, ok := getDPoPToken(r)
+	if !ok || token == "" {
+		unauthorized(ctx, "", invalidDPoPToken, dpopInvalidToken, errDPoPInvalidScheme.Error())
+		return
+	}
+
+	claims, ok := accessTokenClaims(ctx)
+	if !ok {
+		unauthorized(ctx, "", invalidDPoPToken, dpopInvalidToken, errDPoPMissingClaims.Error())
+		return
+	}
+
+	jkt, ok := tokenThumbprint(claims)
+	if !ok {
+		unauthorized(ctx, "", invalidDPoPToken, dpopInvalidToken, errDPoPUnboundToken.Error())
+		return
+	}
+
+	proofs := r.Header.Values(dpopHeaderName)
+	if len(proofs) != 1 || proofs[0] == "" {
+		unauthorized(ctx, "", missingDPoPProof, dpopInvalidProof, "")
+		return
+	}
+
+	proofJkt, jti, err := f.validateProof(r, proofs[0], token)
+	if err != nil {
+		unauthorized(ctx, "", invalidDPoPProof, dpopInvalidProof, err.Error())
+		return
+	}
+
+	if proofJkt != jkt {
+		unauthorized(ctx, "", invalidDPoPToken, dpopInvalidToken, errDPoPInvalidBinding.Error())
+		return
+	}
+
+	// the proofs are valid for maxAge plus the clock skew in both directions
+	added, err := f.replayCache.Add(r.Context(), hashDPoPID(jkt, jti), f.config.maxAge+2*f.config.leeway)
+	if err != nil {
+		ctx.Logger().Errorf("Failed to check the replay of the DPoP proof: %v.", err)
+		unauthorized(ctx, "", invalidDPoPProof, dpopInvalidProof, err.Error())
+		return
+	}
+	if !added {
+		unauthorized(ctx, "", invalidDPoPProof, dpopInvalidProof, errDPoPReplay.Error())
+		return
+	}
+}
+
+func (*dpopFilter) Response(filters.FilterContext) {}
+
+// accessTokenClaims returns the claims of the access token stored by the
+// jwtValidation or oauthTokenintrospection filters.
+func accessTokenClaims(ctx filters.FilterContext) (map[string]interface{}, bool) {
+	switch v := ctx.StateBag()[oidcClaimsCacheKey].(type) {
+	case tokenContainer:
+		return v.Claims, v.Claims != nil
+	}
+	switch v := ctx.StateBag()[tokenintrospectionCacheKey].(type) {
+	case tokenIntrospectionInfo:
+		return v, true
+	}
+	return nil, false
+}
+
+func tokenThumbprint(claims map[string]interface{}) (string, bool) {
+	cnf, ok := claims["cnf"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	jkt, ok := cnf["jkt"].(string)
+	return jkt, ok && jkt != ""
+}
+
+// validateProof validates the proof JWT and returns the thumbprint of its
+// key and its jti claim.
+func (f *dpopFilter) validateProof(r *http.Request, proof, accessToken string) (string, string, error) {
+	var jkt string
+	keyfunc := func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, dpopTokenType) {
+			return nil, errDPoPInvalidType
+		}
+
+		jwk, ok := token.Header["jwk"].(map[string]interface{})
+		if !ok {
+			return nil, errDPoPInvalidJWK
+		}
+
+		key, thumbprint, err := parseDPoPJWK(jwk)
+		if err != nil {
+			return nil, err
+		}
+		jkt = thumbprint
+		return key, nil
+	}
+
+	var claims jwt.MapClaims
+	parser := jwt.NewParser(jwt.WithValidMethods(f.config.Algorithms), jwt.WithoutClaimsValidation())
+	if _, err := parser.ParseWithClaims(proof, &claims, keyfunc); err != nil {
+		return "", "", err
+	}
+
+	if htm, _ := claims["htm"].(string); htm != r.Method {
+		return "", "", errDPoPInvalidMethod
+	}
+
+	if htu, _ := claims["htu"].(string); !matchDPoPURI(r, htu, f.config.TrustForwardedProto) {
+		return "", "", errDPoPInvalidURI
+	}
+
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return "", "", errDPoPInvalidIat
+	}
+	now := f.now()
+	issued := time.Unix(int64(iat), 0)
+	if issued.After(now.Add(f.config.leeway)) || issued.Before(now.Add(-f.config.maxAge-f.config.leeway)) {
+		return "", "", errDPoPInvalidIat
+	}
+
+	h := sha256.Sum256([]byte(accessToken))
+	if ath, _ := claims["ath"].(string); ath != base64.RawURLEncoding.EncodeToString(h[:]) {
+		return "", "", errDPoPInvalidAth
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return "", "", errDPoPMissingJti
+	}
+
+	return jkt, jti, nil
+}
+
+// matchDPoPURI compares the htu claim with the request URI without query
+// and fragment, see https://www.rfc-editor.org/rfc/rfc9449#section-4.3.
+// The X-Forwarded-Proto header is sent by the client, unless a trusted
+// proxy sets it, so it is used only when trustForwardedProto is set.
+func matchDPoPURI(r *http.Request, htu string, trustForwardedProto bool) bool {
+	u, err := url.Parse(htu)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else if proto := r.Header.Get("X-Forwarded-Proto"); trustForwardedProto && proto != "" {
+		scheme = proto
+	}
+
+	return strings.EqualFold(u.Scheme, scheme) &&
+		normalizeDPoPHost(u.Scheme, u.Host) == normalizeDPoPHost(scheme, r.Host) &&
+		u.EscapedPath() == r.URL.EscapedPath()
+}
+
+func normalizeDPoPHost(scheme, host string) string {
+	host = strings.ToLower(host)
+	switch strings.ToLower(scheme) {
+	case "http":
+		return strings.TrimSuffix(host, ":80")
+	case "https":
+		return strings.TrimSuffix(host, ":443")
+	}
+	return host
+}
+
+func hashDPoPID(jkt, jti string) string {
+	h := sha256.Sum256([]byte(jkt + ":" + jti))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// parseDPoPJWK parses the public key of the jwk header and returns it with
+// its thumbprint, see https://www.rfc-editor.org/rfc/rfc7638.
+func parseDPoPJWK(jwk map[string]interface{}) (interface{}, string, error) {
+	member := func(name string) (string, []byte, error) {
+		s, ok := jwk[name].(string)
+		if !ok || s == "" {
+			return "", nil, fmt.Errorf("%w: missing %s", errDPoPInvalidJWK, name)
+		}
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s: %w", errDPoPInvalidJWK, name, err)
+		}
+		return s, b, nil
+	}
+
+	// a private key must not be sent
+	if _, ok := jwk["d"]; ok {
+		return nil, "", fmt.Errorf("%w: private key", errDPoPInvalidJWK)
+	}
+
+	var key interface{}
+	var canonical string
+	kty, _ := jwk["kty"].(string)
+	switch kty {
+	case "RSA":
+		n, nb, err := member("n")
+		if err != nil {
+			return nil, "", err
+		}
+		e, eb, err := member("e")
+		if err != nil {
+			return nil, "", err
+		}
+		exponent := new(big.Int).SetBytes(eb)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, "", fmt.Errorf("%w: invalid exponent", errDPoPInvalidJWK)
+		}
+		key = &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(exponent.Int64())}
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, e, n)
+
+	case "EC":
+		crv, _ := jwk["crv"].(string)
+		var curve elliptic.Curve
+		switch crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, "", fmt.Errorf("%w: unsupported curve %s", errDPoPInvalidJWK, crv)
+		}
+		x, xb, err := member("x")
+		if err != nil {
+			return nil, "", err
+		}
+		y, yb, err := member("y")
+		if err != nil {
+			return nil, "", err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(xb), Y: new(big.Int).SetBytes(yb)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, "", fmt.Errorf("%w: point is not on curve", errDPoPInvalidJWK)
+		}
+		key = pub
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, crv, x, y)
+
+	case "OKP":
+		crv, _ := jwk["crv"].(string)
+		if crv != "Ed25519" {
+			return nil, "", fmt.Errorf("%w: unsupported curve %s", errDPoPInvalidJWK, crv)
+		}
+		x, xb, err := member("x")
+		if err != nil {
+			return nil, "", err
+		}
+		if len(xb) != ed25519.PublicKeySize {
+			return nil, "", fmt.Errorf("%w: invalid key size", errDPoPInvalidJWK)
+		}
+		key = ed25519.PublicKey(xb)
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, crv, x)
+
+	default:
+		return nil, "", fmt.Errorf("%w: unsupported kty %s", errDPoPInvalidJWK, kty)
+	}
+
+	h := sha256.Sum256([]byte(canonical))
+	return key, base64.RawURLEncoding.EncodeToString(h[:]), nil
+}
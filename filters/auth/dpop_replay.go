@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/valkey-io/valkey-go"
+
+	skpnet "github.com/zalando/skipper/net"
+)
+
+const (
+	// DPoPRedisMetricsPrefix is the metrics prefix of the redis ring
+	// client used by the redis DPoP replay cache.
+	DPoPRedisMetricsPrefix = "dpop.redis."
+
+	// dpopReplayKeyPrefix separates the DPoP proof ids from the other
+	// keys, e.g. the ratelimit counters, stored in the same ring.
+	dpopReplayKeyPrefix = "skipper.dpop:"
+
+	// dpopReplayScript sets the key only when it does not exist and
+	// returns 1 when it was set.
+	dpopReplayScript = `
+if redis.call("SET", KEYS[1], "1", "NX", "PX", ARGV[1]) then
+	return 1
+end
+return 0
+`
+)
+
+// DPoPReplayCache detects the replay of DPoP proofs.
+type DPoPReplayCache interface {
+	// Add adds the id of a proof for the duration of ttl. It returns
+	// false when the id was added before and did not expire yet.
+	Add(ctx context.Context, id string, ttl time.Duration) (bool, error)
+}
+
+type dpopMemoryReplayCache struct {
+	mu        sync.Mutex
+	ids       map[string]time.Time
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+// NewDPoPMemoryReplayCache creates a replay cache, that detects the
+// replay of proofs sent to the same instance.
+func NewDPoPMemoryReplayCache() DPoPReplayCache {
+	return &dpopMemoryReplayCache{
+		ids: make(map[string]time.Time),
+		now: time.Now,
+	}
+}
+
+func (c *dpopMemoryReplayCache) Add(_ context.Context, id string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.After(c.nextSweep) {
+		for k, expires := range c.ids {
+			if now.After(expires) {
+				delete(c.ids, k)
+			}
+		}
+		c.nextSweep = now.Add(ttl)
+	}
+
+	if expires, ok := c.ids[id]; ok && !now.After(expires) {
+		return false, nil
+	}
+
+	c.ids[id] = now.Add(ttl)
+	return true, nil
+}
+
+type dpopRedisReplayCache struct {
+	ring   *skpnet.RedisRingClient
+	script *skpnet.RedisScript
+}
+
+// NewDPoPRedisReplayCache creates a replay cache, that detects the replay
+// of proofs across instances sharing the redis ring.
+func NewDPoPRedisReplayCache(ring *skpnet.RedisRingClient) DPoPReplayCache {
+	return &dpopRedisReplayCache{
+		ring:   ring,
+		script: ring.NewScript(dpopReplayScript),
+	}
+}
+
+func (c *dpopRedisReplayCache) Add(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	r, err := c.ring.RunScript(ctx, c.script, []string{dpopReplayKeyPrefix + id}, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	added, ok := r.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected replay cache result: %v", r)
+	}
+	return added == 1, nil
+}
+
+type dpopValkeyReplayCache struct {
+	ring   *skpnet.ValkeyRingClient
+	script *valkey.Lua
+}
+
+// NewDPoPValkeyReplayCache creates a replay cache, that detects the
+// replay of proofs across instances sharing the valkey ring.
+func NewDPoPValkeyReplayCache(ring *skpnet.ValkeyRingClient) DPoPReplayCache {
+	return &dpopValkeyReplayCache{
+		ring:   ring,
+		script: skpnet.NewScript(dpopReplayScript),
+	}
+}
+
+func (c *dpopValkeyReplayCache) Add(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	msg, err := c.ring.RunScript(ctx, c.script, []string{dpopReplayKeyPrefix + id}, strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return false, err
+	}
+	x, err := msg.ToInt64()
+	if err != nil {
+		return false, err
+	}
+	return x == 1, nil
+}
@@ -0,0 +1,417 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+const dpopTestAccessToken = "access-token"
+
+func dpopTestJWK(key *ecdsa.PrivateKey) map[string]interface{} {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[string]interface{}{
+		"kty": "EC",
+		"crv": key.Curve.Params().Name,
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func dpopTestProof(t *testing.T, key *ecdsa.PrivateKey, header map[string]interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = dpopTestJWK(key)
+	for k, v := range header {
+		if v == nil {
+			delete(token.Header, k)
+		} else {
+			token.Header[k] = v
+		}
+	}
+
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func dpopTestClaims(now time.Time) jwt.MapClaims {
+	ath := sha256.Sum256([]byte(dpopTestAccessToken))
+	return jwt.MapClaims{
+		"jti": "id-" + now.String(),
+		"htm": "POST",
+		"htu": "https://api.example.org/resource",
+		"iat": now.Unix(),
+		"ath": base64.RawURLEncoding.EncodeToString(ath[:]),
+	}
+}
+
+func TestDPoPThumbprint(t *testing.T) {
+	// https://www.rfc-editor.org/rfc/rfc7638#section-3.1
+	_, jkt, err := parseDPoPJWK(map[string]interface{}{
+		"kty": "RSA",
+		"n":   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		"e":   "AQAB",
+		"alg": "RS256",
+		"kid": "2011-04-29",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jkt)
+}
+
+func TestDPoPArgs(t *testing.T) {
+	spec := NewDPoP(NewDPoPMemoryReplayCache())
+	assert.Equal(t, filters.DPoPName, spec.Name())
+
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{
+		{name: "no args", args: nil},
+		{name: "config", args: []interface{}{"{algorithms: [ES256], maxAge: 30s, leeway: 1s}"}},
+		{name: "too many args", args: []interface{}{"{}", "{}"}, wantErr: true},
+		{name: "not a string", args: []interface{}{1}, wantErr: true},
+		{name: "algorithm none", args: []interface{}{"{algorithms: [none]}"}, wantErr: true},
+		{name: "hmac algorithm", args: []interface{}{"{algorithms: [HS256]}"}, wantErr: true},
+		{name: "invalid max age", args: []interface{}{"{maxAge: foo}"}, wantErr: true},
+		{name: "negative leeway", args: []interface{}{"{leeway: -1s}"}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := spec.CreateFilter(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDPoP(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, jkt, err := parseDPoPJWK(dpopTestJWK(key))
+	require.NoError(t, err)
+
+	now := time.Now()
+	boundClaims := map[string]interface{}{"sub": "user1", "cnf": map[string]interface{}{"jkt": jkt}}
+
+	for _, tt := range []struct {
+		name        string
+		claims      map[string]interface{}
+		proof       func(jwt.MapClaims) string
+		noProof     bool
+		scheme      string
+		requestURL  string
+		forwardedTo string
+		config      string
+		want        int
+		wantReason  rejectReason
+	}{
+		{
+			name: "valid proof",
+			want: http.StatusOK,
+		},
+		{
+			name:       "bearer scheme",
+			scheme:     "Bearer ",
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPToken,
+		},
+		{
+			name:       "scheme without separator",
+			scheme:     "DPoP",
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPToken,
+		},
+		{
+			name:       "valid proof with default port and query",
+			requestURL: "https://API.example.org:443/resource?foo=bar",
+			want:       http.StatusOK,
+		},
+		{
+			name:        "valid proof behind tls terminating proxy",
+			requestURL:  "http://api.example.org/resource",
+			forwardedTo: "https",
+			config:      "{trustForwardedProto: true}",
+			want:        http.StatusOK,
+		},
+		{
+			name:        "forwarded proto not trusted",
+			requestURL:  "http://api.example.org/resource",
+			forwardedTo: "https",
+			want:        http.StatusUnauthorized,
+			wantReason:  invalidDPoPProof,
+		},
+		{
+			name:       "access token not validated",
+			claims:     map[string]interface{}{},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPToken,
+		},
+		{
+			name:       "unbound access token",
+			claims:     map[string]interface{}{"sub": "user1"},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPToken,
+		},
+		{
+			name:       "missing proof",
+			noProof:    true,
+			want:       http.StatusUnauthorized,
+			wantReason: missingDPoPProof,
+		},
+		{
+			name: "proof of other key",
+			proof: func(c jwt.MapClaims) string {
+				return dpopTestProof(t, otherKey, nil, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPToken,
+		},
+		{
+			name: "signature of other key",
+			proof: func(c jwt.MapClaims) string {
+				return dpopTestProof(t, otherKey, map[string]interface{}{"jwk": dpopTestJWK(key)}, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "invalid typ",
+			proof: func(c jwt.MapClaims) string {
+				return dpopTestProof(t, key, map[string]interface{}{"typ": "JWT"}, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "missing jwk",
+			proof: func(c jwt.MapClaims) string {
+				return dpopTestProof(t, key, map[string]interface{}{"jwk": nil}, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "private key in jwk",
+			proof: func(c jwt.MapClaims) string {
+				jwk := dpopTestJWK(key)
+				jwk["d"] = base64.RawURLEncoding.EncodeToString(key.D.Bytes())
+				return dpopTestProof(t, key, map[string]interface{}{"jwk": jwk}, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "invalid method",
+			proof: func(c jwt.MapClaims) string {
+				c["htm"] = "GET"
+				return dpopTestProof(t, key, nil, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "invalid uri",
+			proof: func(c jwt.MapClaims) string {
+				c["htu"] = "https://api.example.org/other"
+				return dpopTestProof(t, key, nil, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name:       "invalid scheme",
+			requestURL: "http://api.example.org/resource",
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "expired proof",
+			proof: func(c jwt.MapClaims) string {
+				c["iat"] = now.Add(-2 * time.Minute).Unix()
+				return dpopTestProof(t, key, nil, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "proof from the future",
+			proof: func(c jwt.MapClaims) string {
+				c["iat"] = now.Add(time.Minute).Unix()
+				return dpopTestProof(t, key, nil, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "invalid ath",
+			proof: func(c jwt.MapClaims) string {
+				c["ath"] = "foo"
+				return dpopTestProof(t, key, nil, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+		{
+			name: "missing jti",
+			proof: func(c jwt.MapClaims) string {
+				delete(c, "jti")
+				return dpopTestProof(t, key, nil, c)
+			},
+			want:       http.StatusUnauthorized,
+			wantReason: invalidDPoPProof,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []interface{}
+			if tt.config != "" {
+				args = []interface{}{tt.config}
+			}
+			f, err := NewDPoP(NewDPoPMemoryReplayCache()).CreateFilter(args)
+			require.NoError(t, err)
+
+			requestURL := tt.requestURL
+			if requestURL == "" {
+				requestURL = "https://api.example.org/resource"
+			}
+			req, err := http.NewRequest("POST", requestURL, nil)
+			require.NoError(t, err)
+			if req.URL.Scheme == "https" {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tt.forwardedTo != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.forwardedTo)
+			}
+
+			scheme := tt.scheme
+			if scheme == "" {
+				scheme = "DPoP "
+			}
+			req.Header.Set(authHeaderName, scheme+dpopTestAccessToken)
+
+			if tt.proof != nil {
+				req.Header.Set("DPoP", tt.proof(dpopTestClaims(now)))
+			} else if !tt.noProof {
+				req.Header.Set("DPoP", dpopTestProof(t, key, nil, dpopTestClaims(now)))
+			}
+
+			claims := tt.claims
+			if claims == nil {
+				claims = boundClaims
+			}
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			if len(claims) > 0 {
+				ctx.FStateBag[oidcClaimsCacheKey] = tokenContainer{Claims: claims}
+			}
+
+			f.Request(ctx)
+
+			if tt.want == http.StatusOK {
+				assert.False(t, ctx.FServed)
+				return
+			}
+			require.True(t, ctx.FServed)
+			assert.Equal(t, tt.want, ctx.FResponse.StatusCode)
+			assert.Equal(t, string(tt.wantReason), ctx.FStateBag["auth-reject-reason"])
+			assert.Contains(t, ctx.FResponse.Header.Get("WWW-Authenticate"), "DPoP error=")
+		})
+	}
+}
+
+func TestGetAccessToken(t *testing.T) {
+	for _, tt := range []struct {
+		header    string
+		token     string
+		dpopToken string
+	}{
+		{header: "Bearer foo", token: "foo"},
+		{header: "DPoP foo", token: "foo", dpopToken: "foo"},
+		{header: "Basic foo"},
+		{header: ""},
+	} {
+		r := &http.Request{Header: http.Header{authHeaderName: []string{tt.header}}}
+
+		token, _ := getAccessToken(r)
+		assert.Equal(t, tt.token, token, tt.header)
+
+		dpopToken, _ := getDPoPToken(r)
+		assert.Equal(t, tt.dpopToken, dpopToken, tt.header)
+
+		_, ok := getToken(r)
+		assert.Equal(t, strings.HasPrefix(tt.header, "Bearer "), ok, tt.header)
+	}
+}
+
+func TestDPoPReplay(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, jkt, err := parseDPoPJWK(dpopTestJWK(key))
+	require.NoError(t, err)
+
+	f, err := NewDPoP(NewDPoPMemoryReplayCache()).CreateFilter(nil)
+	require.NoError(t, err)
+
+	proof := dpopTestProof(t, key, nil, dpopTestClaims(time.Now()))
+	serve := func() bool {
+		req, err := http.NewRequest("POST", "https://api.example.org/resource", nil)
+		require.NoError(t, err)
+		req.TLS = &tls.ConnectionState{}
+		req.Header.Set(authHeaderName, "DPoP "+dpopTestAccessToken)
+		req.Header.Set("DPoP", proof)
+
+		ctx := &filtertest.Context{FRequest: req, FStateBag: map[string]interface{}{
+			tokenintrospectionCacheKey: tokenIntrospectionInfo{"active": true, "cnf": map[string]interface{}{"jkt": jkt}},
+		}}
+		f.Request(ctx)
+		return ctx.FServed
+	}
+
+	assert.False(t, serve(), "first use of the proof")
+	assert.True(t, serve(), "replay of the proof")
+}
+
+func TestDPoPMemoryReplayCache(t *testing.T) {
+	now := time.Now()
+	c := NewDPoPMemoryReplayCache().(*dpopMemoryReplayCache)
+	c.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	added, _ := c.Add(ctx, "a", time.Minute)
+	assert.True(t, added)
+
+	added, _ = c.Add(ctx, "a", time.Minute)
+	assert.False(t, added)
+
+	added, _ = c.Add(ctx, "b", time.Minute)
+	assert.True(t, added)
+
+	now = now.Add(2 * time.Minute)
+	added, _ = c.Add(ctx, "a", time.Minute)
+	assert.True(t, added, "expired ids can be added again")
+	assert.Len(t, c.ids, 1, "expired ids are removed")
+}
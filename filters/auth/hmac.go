@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/secrets"
+)
+
+// maxHmacBodySize limits the size of the request bodies buffered to
+// verify the signature.
+const maxHmacBodySize = 10 << 20
+
+const (
+	missingSignature rejectReason = "missing-signature"
+	invalidSignature rejectReason = "invalid-signature"
+)
+
+var errHmacBodyTooLarge = fmt.Errorf("body exceeds %d bytes", maxHmacBodySize)
+
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+type (
+	hmacSignatureSpec struct {
+		secretsReader secrets.SecretsReader
+	}
+
+	hmacSignatureFilter struct {
+		header          string
+		algorithm       string
+		hash            func() hash.Hash
+		secretNames     []string
+		timestampHeader string
+		tolerance       time.Duration
+
+		secretsReader secrets.SecretsReader
+		now           func() time.Time
+	}
+)
+
+// NewVerifyHmacSignature creates a filter spec to verify the HMAC
+// signature of the request body, e.g. of webhooks. The filter takes the
+// name of the signature header, the hash algorithm, a comma separated
+// list of secret names and optionally the name of a timestamp header
+// with the tolerated clock skew. The request is accepted when the
+// signature matches any of the secrets, which allows to rotate them.
+//
+// With a timestamp header, the signed content is the timestamp in Unix
+// seconds, a dot and the body.
+//
+// Example:
+//
+//	verifyHmacSignature("X-Hub-Signature-256", "sha256", "/meta/credentials/webhook/secret")
+//	verifyHmacSignature("X-Signature", "sha512", "current,previous", "X-Timestamp", "5m")
+func NewVerifyHmacSignature(sr secrets.SecretsReader) filters.Spec {
+	return &hmacSignatureSpec{secretsReader: sr}
+}
+
+func (*hmacSignatureSpec) Name() string {
+	return filters.VerifyHmacSignatureName
+}
+
+func (s *hmacSignatureSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 3 && len(args) != 5 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	sargs, err := getStrings(args)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &hmacSignatureFilter{
+		header:        sargs[0],
+		algorithm:     strings.ToLower(sargs[1]),
+		secretsReader: s.secretsReader,
+		now:           time.Now,
+	}
+
+	if f.header == "" {
+		return nil, fmt.Errorf("%w: empty signature header", filters.ErrInvalidFilterParameters)
+	}
+
+	var ok bool
+	f.hash, ok = hmacAlgorithms[f.algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported algorithm %s", filters.ErrInvalidFilterParameters, sargs[1])
+	}
+
+	for _, name := range strings.Split(sargs[2], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			f.secretNames = append(f.secretNames, name)
+		}
+	}
+	if len(f.secretNames) == 0 {
+		return nil, fmt.Errorf("%w: no secret names", filters.ErrInvalidFilterParameters)
+	}
+
+	if len(sargs) == 5 {
+		f.timestampHeader = sargs[3]
+		f.tolerance, err = time.ParseDuration(sargs[4])
+		if err != nil || f.timestampHeader == "" || f.tolerance <= 0 {
+			return nil, fmt.Errorf("%w: invalid timestamp header or tolerance", filters.ErrInvalidFilterParameters)
+		}
+	}
+
+	return f, nil
+}
+
+func (f *hmacSignatureFilter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+
+	signature, ok := f.signature(req.Header.Get(f.header))
+	if !ok {
+		unauthorized(ctx, "", missingSignature, "", "")
+		return
+	}
+
+	var timestamp string
+	if f.timestampHeader != "" {
+		timestamp = req.Header.Get(f.timestampHeader)
+		if !f.validTimestamp(timestamp) {
+			unauthorized(ctx, "", invalidSignature, "", "invalid timestamp")
+			return
+		}
+	}
+
+	body, err := readHmacBody(req)
+	if errors.Is(err, errHmacBodyTooLarge) {
+		ctx.Serve(&http.Response{StatusCode: http.StatusRequestEntityTooLarge})
+		return
+	} else if err != nil {
+		ctx.Logger().Debugf("Failed to read the body to verify the signature: %v", err)
+		ctx.Serve(&http.Response{StatusCode: http.StatusBadRequest})
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	for _, name := range f.secretNames {
+		secret, ok := f.secretsReader.GetSecret(name)
+		if !ok {
+			ctx.Logger().Errorf("Secret %q not found for verifyHmacSignature filter", name)
+			continue
+		}
+
+		mac := hmac.New(f.hash, secret)
+		if timestamp != "" {
+			mac.Write([]byte(timestamp + "."))
+		}
+		mac.Write(body)
+		if hmac.Equal(mac.Sum(nil), signature) {
+			return
+		}
+	}
+
+	unauthorized(ctx, "", invalidSignature, "", "")
+}
+
+func (*hmacSignatureFilter) Response(filters.FilterContext) {}
+
+// signature decodes the hex or base64 encoded signature with an optional
+// prefix of the algorithm, e.g. sha256=<hex>.
+func (f *hmacSignatureFilter) signature(value string) ([]byte, bool) {
+	value = strings.TrimSpace(value)
+	if prefix, rest, ok := strings.Cut(value, "="); ok && strings.EqualFold(prefix, f.algorithm) {
+		value = rest
+	}
+	if value == "" {
+		return nil, false
+	}
+
+	size := f.hash().Size()
+	if b, err := hex.DecodeString(value); err == nil && len(b) == size {
+		return b, true
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(value); err == nil && len(b) == size {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func (f *hmacSignatureFilter) validTimestamp(value string) bool {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	d := f.now().Sub(time.Unix(seconds, 0))
+	return d <= f.tolerance && d >= -f.tolerance
+}
+
+func readHmacBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxHmacBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxHmacBodySize {
+		return nil, errHmacBodyTooLarge
+	}
+	return body, nil
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/secrets"
+)
+
+func TestVerifyHmacSignatureArgs(t *testing.T) {
+	spec := NewVerifyHmacSignature(secrets.StaticSecret("secret"))
+	assert.Equal(t, filters.VerifyHmacSignatureName, spec.Name())
+
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{
+		{name: "valid", args: []interface{}{"X-Signature", "sha256", "secret"}},
+		{name: "valid with timestamp", args: []interface{}{"X-Signature", "SHA512", "current, previous", "X-Timestamp", "5m"}},
+		{name: "too few args", args: []interface{}{"X-Signature", "sha256"}, wantErr: true},
+		{name: "missing tolerance", args: []interface{}{"X-Signature", "sha256", "secret", "X-Timestamp"}, wantErr: true},
+		{name: "not a string", args: []interface{}{"X-Signature", "sha256", 1}, wantErr: true},
+		{name: "empty header", args: []interface{}{"", "sha256", "secret"}, wantErr: true},
+		{name: "unsupported algorithm", args: []interface{}{"X-Signature", "md5", "secret"}, wantErr: true},
+		{name: "no secret names", args: []interface{}{"X-Signature", "sha256", " , "}, wantErr: true},
+		{name: "invalid tolerance", args: []interface{}{"X-Signature", "sha256", "secret", "X-Timestamp", "foo"}, wantErr: true},
+		{name: "negative tolerance", args: []interface{}{"X-Signature", "sha256", "secret", "X-Timestamp", "-1m"}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := spec.CreateFilter(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyHmacSignature(t *testing.T) {
+	const body = `{"event":"push"}`
+
+	sm := secrets.NewSecretsMap()
+	sm.Update(map[string][]byte{
+		"current":  []byte("current-secret"),
+		"previous": []byte("previous-secret"),
+	})
+
+	sign := func(secret, content string) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(content))
+		return mac.Sum(nil)
+	}
+
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	oldTimestamp := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		headers map[string]string
+		body    string
+		want    int
+	}{
+		{
+			name:    "hex signature with prefix",
+			args:    []interface{}{"X-Hub-Signature-256", "sha256", "current"},
+			headers: map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign("current-secret", body))},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "base64 signature",
+			args:    []interface{}{"X-Signature", "sha256", "current"},
+			headers: map[string]string{"X-Signature": base64.StdEncoding.EncodeToString(sign("current-secret", body))},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "rotated secret",
+			args:    []interface{}{"X-Signature", "sha256", "current,previous"},
+			headers: map[string]string{"X-Signature": hex.EncodeToString(sign("previous-secret", body))},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "missing secret is skipped",
+			args:    []interface{}{"X-Signature", "sha256", "missing,current"},
+			headers: map[string]string{"X-Signature": hex.EncodeToString(sign("current-secret", body))},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "unknown secret",
+			args:    []interface{}{"X-Signature", "sha256", "current"},
+			headers: map[string]string{"X-Signature": hex.EncodeToString(sign("previous-secret", body))},
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name:    "modified body",
+			args:    []interface{}{"X-Signature", "sha256", "current"},
+			headers: map[string]string{"X-Signature": hex.EncodeToString(sign("current-secret", body))},
+			body:    `{"event":"delete"}`,
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name:    "missing signature",
+			args:    []interface{}{"X-Signature", "sha256", "current"},
+			headers: map[string]string{},
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name:    "signature of other algorithm",
+			args:    []interface{}{"X-Signature", "sha512", "current"},
+			headers: map[string]string{"X-Signature": hex.EncodeToString(sign("current-secret", body))},
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name: "signature with timestamp",
+			args: []interface{}{"X-Signature", "sha256", "current", "X-Timestamp", "5m"},
+			headers: map[string]string{
+				"X-Signature": hex.EncodeToString(sign("current-secret", timestamp+"."+body)),
+				"X-Timestamp": timestamp,
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "signature without timestamp",
+			args: []interface{}{"X-Signature", "sha256", "current", "X-Timestamp", "5m"},
+			headers: map[string]string{
+				"X-Signature": hex.EncodeToString(sign("current-secret", body)),
+				"X-Timestamp": timestamp,
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "expired timestamp",
+			args: []interface{}{"X-Signature", "sha256", "current", "X-Timestamp", "5m"},
+			headers: map[string]string{
+				"X-Signature": hex.EncodeToString(sign("current-secret", oldTimestamp+"."+body)),
+				"X-Timestamp": oldTimestamp,
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "missing timestamp",
+			args: []interface{}{"X-Signature", "sha256", "current", "X-Timestamp", "5m"},
+			headers: map[string]string{
+				"X-Signature": hex.EncodeToString(sign("current-secret", "."+body)),
+			},
+			want: http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewVerifyHmacSignature(sm).CreateFilter(tt.args)
+			require.NoError(t, err)
+
+			requestBody := tt.body
+			if requestBody == "" {
+				requestBody = body
+			}
+			req, err := http.NewRequest("POST", "https://example.org/webhook", strings.NewReader(requestBody))
+			require.NoError(t, err)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+
+			if tt.want != http.StatusOK {
+				require.True(t, ctx.FServed)
+				assert.Equal(t, tt.want, ctx.FResponse.StatusCode)
+				return
+			}
+
+			require.False(t, ctx.FServed)
+			b, err := io.ReadAll(ctx.FRequest.Body)
+			require.NoError(t, err)
+			assert.Equal(t, requestBody, string(b), "the body is passed to the backend")
+		})
+	}
+}
+
+func TestVerifyHmacSignatureBodyTooLarge(t *testing.T) {
+	f, err := NewVerifyHmacSignature(secrets.StaticSecret("secret")).CreateFilter([]interface{}{"X-Signature", "sha512", "secret"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("POST", "https://example.org/webhook", strings.NewReader(strings.Repeat("a", maxHmacBodySize+1)))
+	require.NoError(t, err)
+	req.Header.Set("X-Signature", hex.EncodeToString(make([]byte, sha512.Size)))
+
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+
+	require.True(t, ctx.FServed)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, ctx.FResponse.StatusCode)
+}
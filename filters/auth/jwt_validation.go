@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -19,14 +22,55 @@ const (
 type (
 	jwtValidationSpec struct {
 		options TokenintrospectionOptions
+		config  yamlConfigParser[jwtValidationConfig]
 	}
 
-	jwtValidationKeysSpec struct{}
+	jwtValidationKeysSpec struct {
+		config yamlConfigParser[jwtValidationConfig]
+	}
 
 	jwtValidationFilter struct {
 		jwksUri    string
+		secretKeys *jwtSecretKeys
 		requireSub bool
+		issuers    []string
+		config     *jwtValidationConfig
 	}
+
+	// jwtValidationConfig implements [yamlConfig],
+	// make sure it is not modified after initialization.
+	jwtValidationConfig struct {
+		// Issuers are the allowed values of the iss claim, jwtValidation
+		// defaults to the issuer of the OpenID configuration.
+		Issuers []string `json:"issuers,omitempty"`
+
+		// Audiences are the allowed values of the aud claim, one of them
+		// has to be contained in the token.
+		Audiences []string `json:"audiences,omitempty"`
+
+		// Algorithms are the allowed signing algorithms of the token.
+		Algorithms []string `json:"algorithms,omitempty"`
+
+		// Claims are alternative sets of claim values, the token has to
+		// contain all claim values of at least one of them. The values
+		// are strings, numbers or booleans.
+		Claims []map[string]any `json:"claims,omitempty"`
+
+		// RequiredClaims have to be present in the token.
+		RequiredClaims []string `json:"requiredClaims,omitempty"`
+
+		// Leeway is the allowed clock skew to validate the exp, nbf and
+		// iat claims, e.g. 30s.
+		Leeway string `json:"leeway,omitempty"`
+
+		leeway time.Duration
+	}
+)
+
+var (
+	errInvalidIssuer   = errors.New("invalid issuer")
+	errInvalidAudience = errors.New("invalid audience")
+	errInvalidClaims   = errors.New("invalid claims")
 )
 
 var refreshInterval = time.Hour
@@ -40,9 +84,17 @@ var (
 	jwksMap map[string]*keyfunc.JWKS = make(map[string]*keyfunc.JWKS)
 )
 
+// NewJwtValidationWithOptions creates a filter spec for JWT validation
+// using the JWKS discovered via the OpenID configuration of the issuer.
+//
+// The optional second argument is a YAML config to validate the issuer,
+// audience, signing algorithm and claims of the token:
+//
+//	jwtValidation("https://accounts.google.com", "{audiences: ['my-client-id'], algorithms: [RS256], leeway: 30s}")
 func NewJwtValidationWithOptions(o TokenintrospectionOptions) filters.Spec {
 	return &jwtValidationSpec{
 		options: o,
+		config:  newYamlConfigParser[jwtValidationConfig](64),
 	}
 }
 
@@ -51,7 +103,7 @@ func (s *jwtValidationSpec) Name() string {
 }
 
 func (s *jwtValidationSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		return nil, filters.ErrInvalidFilterParameters
 	}
 	sargs, err := getStrings(args)
@@ -61,6 +113,14 @@ func (s *jwtValidationSpec) CreateFilter(args []interface{}) (filters.Filter, er
 
 	issuerURL := sargs[0]
 
+	var config *jwtValidationConfig
+	if len(sargs) == 2 {
+		config, err = s.config.parse(sargs[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwtValidation config: %w", err)
+		}
+	}
+
 	cfg, err := getOpenIDConfig(issuerURL, s.options)
 	if err != nil {
 		return nil, err
@@ -74,11 +134,81 @@ func (s *jwtValidationSpec) CreateFilter(args []interface{}) (filters.Filter, er
 	f := &jwtValidationFilter{
 		jwksUri:    cfg.JwksURI,
 		requireSub: true,
+		config:     config,
+	}
+
+	if config != nil {
+		f.issuers = config.Issuers
+		if len(f.issuers) == 0 && cfg.Issuer != "" {
+			f.issuers = []string{cfg.Issuer}
+		}
 	}
 
 	return f, nil
 }
 
+func (c *jwtValidationConfig) initialize() error {
+	if c.Leeway != "" {
+		d, err := time.ParseDuration(c.Leeway)
+		if err != nil {
+			return fmt.Errorf("invalid leeway: %w", err)
+		}
+		if d < 0 {
+			return fmt.Errorf("invalid leeway: %s", c.Leeway)
+		}
+		c.leeway = d
+	}
+
+	for _, alg := range c.Algorithms {
+		if alg == jwt.SigningMethodNone.Alg() || jwt.GetSigningMethod(alg) == nil {
+			return fmt.Errorf("unsupported signing algorithm: %s", alg)
+		}
+	}
+
+	for _, claims := range c.Claims {
+		for name, value := range claims {
+			v, ok := scalarClaim(value)
+			if !ok {
+				return fmt.Errorf("unsupported value of claim %s: %v", name, value)
+			}
+			claims[name] = v
+		}
+	}
+
+	return nil
+}
+
+// scalarClaim returns the string, number or boolean value of a claim,
+// with the numbers converted to float64 like the numbers of the JSON
+// claims of the token, so that the values can be compared.
+func scalarClaim(v any) (any, bool) {
+	switch v := v.(type) {
+	case string, bool, float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return nil, false
+	}
+}
+
+// matchClaims returns true, when the token contains all the scalar claim
+// values.
+func matchClaims(claims, values map[string]any) bool {
+	for name, value := range values {
+		v, ok := scalarClaim(claims[name])
+		if !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
 func hasKeyFunction(url string) bool {
 	jwksMu.RLock()
 	defer jwksMu.RUnlock()
@@ -131,13 +261,13 @@ func (f *jwtValidationFilter) Request(ctx filters.FilterContext) {
 	var info tokenContainer
 	infoTemp, ok := ctx.StateBag()[oidcClaimsCacheKey]
 	if !ok {
-		token, ok := getToken(r)
+		token, ok := getAccessToken(r)
 		if !ok || token == "" {
 			unauthorized(ctx, "", missingToken, "", "")
 			return
 		}
 
-		claims, err := parseToken(token, f.jwksUri)
+		claims, err := f.parseToken(token)
 		if err != nil {
 			ctx.Logger().Errorf("Error while parsing jwt token : %v.", err)
 			unauthorized(ctx, "", invalidToken, "", "")
@@ -149,6 +279,12 @@ func (f *jwtValidationFilter) Request(ctx filters.FilterContext) {
 		info = infoTemp.(tokenContainer)
 	}
 
+	if err := f.validateClaims(info.Claims); err != nil {
+		ctx.Logger().Debugf("Error while validating jwt claims: %v.", err)
+		unauthorized(ctx, "", invalidClaim, "", "")
+		return
+	}
+
 	sub, ok := info.Claims["sub"].(string)
 	if !ok || sub == "" {
 		if f.requireSub {
@@ -176,11 +312,17 @@ func (f *jwtValidationFilter) Response(filters.FilterContext) {}
 // The filter stores token claims into the state bag where they can be used by
 // oidcClaimsQuery, forwardToken or forwardTokenField filters.
 //
+// The optional second argument is a YAML config to validate the token
+// like for jwtValidation, except that the issuer is only validated when
+// configured.
+//
 // Usage:
 //
 //	jwtValidationKeys("https://www.googleapis.com/service_accounts/v1/jwk/chat@system.gserviceaccount.com")
 func NewJwtValidationKeys() filters.Spec {
-	return &jwtValidationKeysSpec{}
+	return &jwtValidationKeysSpec{
+		config: newYamlConfigParser[jwtValidationConfig](64),
+	}
 }
 
 func (s *jwtValidationKeysSpec) Name() string {
@@ -188,7 +330,7 @@ func (s *jwtValidationKeysSpec) Name() string {
 }
 
 func (s *jwtValidationKeysSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		return nil, filters.ErrInvalidFilterParameters
 	}
 
@@ -199,26 +341,122 @@ func (s *jwtValidationKeysSpec) CreateFilter(args []interface{}) (filters.Filter
 
 	jwksURL := sargs[0]
 
+	f := &jwtValidationFilter{
+		jwksUri:    jwksURL,
+		requireSub: false,
+	}
+
+	if len(sargs) == 2 {
+		f.config, err = s.config.parse(sargs[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwtValidationKeys config: %w", err)
+		}
+		f.issuers = f.config.Issuers
+	}
+
 	if err := registerKeyFunction(jwksURL); err != nil {
 		return nil, err
 	}
 
-	return &jwtValidationFilter{
-		jwksUri:    jwksURL,
-		requireSub: false,
-	}, nil
+	return f, nil
+}
+
+// keyfuncs returns the functions to look up the verification keys of
+// the token, which are tried in order.
+func (f *jwtValidationFilter) keyfuncs() ([]jwt.Keyfunc, error) {
+	if f.secretKeys != nil {
+		return f.secretKeys.keyfuncs()
+	}
+	return []jwt.Keyfunc{getKeyFunction(f.jwksUri).Keyfunc}, nil
 }
 
-func parseToken(token string, jwksUri string) (map[string]interface{}, error) {
-	jwks := getKeyFunction(jwksUri)
+func (f *jwtValidationFilter) parseToken(token string) (map[string]interface{}, error) {
+	keyfuncs, err := f.keyfuncs()
+	if err != nil {
+		return nil, err
+	}
+
+	var options []jwt.ParserOption
+	if f.config != nil {
+		if len(f.config.Algorithms) > 0 {
+			options = append(options, jwt.WithValidMethods(f.config.Algorithms))
+		}
+		// the time based claims are validated with leeway
+		options = append(options, jwt.WithoutClaimsValidation())
+	}
 
 	var claims jwt.MapClaims
-	parsedToken, err := jwt.ParseWithClaims(token, &claims, jwks.Keyfunc)
+	parser := jwt.NewParser(options...)
+	for _, keyfunc := range keyfuncs {
+		claims = nil
+		var parsedToken *jwt.Token
+		parsedToken, err = parser.ParseWithClaims(token, &claims, keyfunc)
+		if err == nil && !parsedToken.Valid {
+			err = fmt.Errorf("invalid token")
+		}
+		if err == nil {
+			break
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error while parsing jwt token : %w", err)
-	} else if !parsedToken.Valid {
-		return nil, fmt.Errorf("invalid token")
-	} else {
-		return claims, nil
 	}
+
+	if f.config != nil {
+		if err := validateTime(claims, f.config.leeway); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// validateTime validates the exp, nbf and iat claims allowing the clock
+// skew of leeway.
+func validateTime(claims jwt.MapClaims, leeway time.Duration) error {
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-leeway).Unix(), false) {
+		return jwt.ErrTokenExpired
+	}
+	if !claims.VerifyNotBefore(now.Add(leeway).Unix(), false) {
+		return jwt.ErrTokenNotValidYet
+	}
+	if !claims.VerifyIssuedAt(now.Add(leeway).Unix(), false) {
+		return jwt.ErrTokenUsedBeforeIssued
+	}
+	return nil
+}
+
+// validateClaims validates the issuer, audience and claims of the token
+// against the config of the filter.
+func (f *jwtValidationFilter) validateClaims(claims map[string]interface{}) error {
+	if f.config == nil {
+		return nil
+	}
+
+	if len(f.issuers) > 0 {
+		if iss, ok := claims["iss"].(string); !ok || !slices.Contains(f.issuers, iss) {
+			return errInvalidIssuer
+		}
+	}
+
+	if len(f.config.Audiences) > 0 && !slices.ContainsFunc(f.config.Audiences, func(aud string) bool {
+		return jwt.MapClaims(claims).VerifyAudience(aud, true)
+	}) {
+		return errInvalidAudience
+	}
+
+	for _, name := range f.config.RequiredClaims {
+		if _, ok := claims[name]; !ok {
+			return fmt.Errorf("%w: missing %s", errInvalidClaims, name)
+		}
+	}
+
+	if len(f.config.Claims) > 0 && !slices.ContainsFunc(f.config.Claims, func(c map[string]any) bool {
+		return matchClaims(claims, c)
+	}) {
+		return errInvalidClaims
+	}
+
+	return nil
 }
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/MicahParks/keyfunc"
+	jwt "github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/secrets"
+)
+
+type (
+	jwtValidationSecretSpec struct {
+		secretsReader secrets.SecretsReader
+		config        yamlConfigParser[jwtValidationConfig]
+	}
+
+	// jwtSecretKeys provides the verification keys stored in a secret
+	// and parses them again when the secret changes.
+	jwtSecretKeys struct {
+		name          string
+		secretsReader secrets.SecretsReader
+		current       atomic.Pointer[jwtParsedKeys]
+	}
+
+	jwtParsedKeys struct {
+		data     []byte
+		keyfuncs []jwt.Keyfunc
+	}
+)
+
+var (
+	errSecretNotFound = errors.New("secret not found")
+	errNoKeys         = errors.New("no keys found")
+)
+
+// NewJwtValidationSecret creates a filter spec for JWT validation using
+// keys stored in a secret, that is either a file of the -credentials-paths
+// or a Kubernetes secret in the format namespace/name/key. The secret
+// contains a JWKS or PEM encoded public keys or certificates, and
+// updates of the secret are applied without restart.
+//
+// The optional second argument is a YAML config to validate the token
+// like for jwtValidationKeys.
+//
+// Usage:
+//
+//	jwtValidationSecret("/meta/credentials/jwks.json")
+//	jwtValidationSecret("default/issuer-keys/public.pem", "{issuers: ['https://issuer.example.org']}")
+func NewJwtValidationSecret(sr secrets.SecretsReader) filters.Spec {
+	return &jwtValidationSecretSpec{
+		secretsReader: sr,
+		config:        newYamlConfigParser[jwtValidationConfig](64),
+	}
+}
+
+func (s *jwtValidationSecretSpec) Name() string {
+	return filters.JwtValidationSecretName
+}
+
+func (s *jwtValidationSecretSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	sargs, err := getStrings(args)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &jwtSecretKeys{
+		name:          sargs[0],
+		secretsReader: s.secretsReader,
+	}
+	if _, err := keys.keyfuncs(); err != nil {
+		return nil, fmt.Errorf("invalid jwtValidationSecret secret %s: %w", sargs[0], err)
+	}
+
+	f := &jwtValidationFilter{
+		secretKeys: keys,
+		requireSub: false,
+	}
+
+	if len(sargs) == 2 {
+		f.config, err = s.config.parse(sargs[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwtValidationSecret config: %w", err)
+		}
+		f.issuers = f.config.Issuers
+	}
+
+	return f, nil
+}
+
+// keyfuncs returns the key functions of the current secret data. When the
+// secret changed to invalid keys, the previous keys are kept.
+func (k *jwtSecretKeys) keyfuncs() ([]jwt.Keyfunc, error) {
+	data, ok := k.secretsReader.GetSecret(k.name)
+	if !ok {
+		return nil, errSecretNotFound
+	}
+
+	current := k.current.Load()
+	if current != nil && bytes.Equal(current.data, data) {
+		return current.keyfuncs, nil
+	}
+
+	keyfuncs, err := parseJwtKeys(data)
+	if err != nil {
+		if current != nil {
+			log.Errorf("Failed to parse the keys of secret %s, using the previous keys: %v", k.name, err)
+			return current.keyfuncs, nil
+		}
+		return nil, err
+	}
+
+	k.current.Store(&jwtParsedKeys{data: data, keyfuncs: keyfuncs})
+	return keyfuncs, nil
+}
+
+// parseJwtKeys parses either a JWKS or PEM blocks of public keys and
+// certificates.
+func parseJwtKeys(data []byte) ([]jwt.Keyfunc, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		jwks, err := keyfunc.NewJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		if jwks.Len() == 0 {
+			return nil, errNoKeys
+		}
+		return []jwt.Keyfunc{jwks.Keyfunc}, nil
+	}
+
+	var keyfuncs []jwt.Keyfunc
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var key any
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			cert, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			err = fmt.Errorf("unsupported PEM block type %s", block.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keyfuncs = append(keyfuncs, func(*jwt.Token) (interface{}, error) { return key, nil })
+	}
+
+	if len(keyfuncs) == 0 {
+		return nil, errNoKeys
+	}
+	return keyfuncs, nil
+}
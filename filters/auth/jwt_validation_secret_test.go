@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/secrets"
+)
+
+func publicKeyPEM(t *testing.T, key any) []byte {
+	t.Helper()
+
+	b, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b})
+}
+
+func certificatePEM(t *testing.T, key *rsa.PrivateKey) []byte {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "issuer.example.org"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	b, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b})
+}
+
+func jwksJSON(key *rsa.PrivateKey) []byte {
+	return fmt.Appendf(nil, `{"keys":[{"kty":"RSA", "alg":"RS256", "kid": "%s", "n":"%s","e":"AQAB"}]}`,
+		kid, base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()))
+}
+
+func jwtSecretStatus(t *testing.T, f filters.Filter, token string) int {
+	t.Helper()
+
+	req, err := http.NewRequest("GET", "http://example.org", nil)
+	require.NoError(t, err)
+	req.Header.Set(authHeaderName, authHeaderPrefix+token)
+
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+	if ctx.FServed {
+		return ctx.FResponse.StatusCode
+	}
+	return http.StatusOK
+}
+
+func TestJwtValidationSecretArgs(t *testing.T) {
+	sm := secrets.NewSecretsMap()
+	sm.Update(map[string][]byte{
+		"valid":   publicKeyPEM(t, &privateKey.PublicKey),
+		"invalid": []byte("not a key"),
+		"empty":   []byte(`{"keys":[]}`),
+		"private": pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
+	})
+
+	spec := NewJwtValidationSecret(sm)
+	assert.Equal(t, filters.JwtValidationSecretName, spec.Name())
+
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{
+		{name: "valid", args: []interface{}{"valid"}},
+		{name: "valid with config", args: []interface{}{"valid", "{audiences: [foo]}"}},
+		{name: "no args", args: []interface{}{}, wantErr: true},
+		{name: "too many args", args: []interface{}{"valid", "{}", "foo"}, wantErr: true},
+		{name: "not a string", args: []interface{}{1}, wantErr: true},
+		{name: "missing secret", args: []interface{}{"missing"}, wantErr: true},
+		{name: "invalid secret", args: []interface{}{"invalid"}, wantErr: true},
+		{name: "empty jwks", args: []interface{}{"empty"}, wantErr: true},
+		{name: "private key", args: []interface{}{"private"}, wantErr: true},
+		{name: "invalid config", args: []interface{}{"valid", "{leeway: foo}"}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := spec.CreateFilter(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestJwtValidationSecret(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{"sub": "user1", "exp": time.Now().Add(time.Hour).Unix()}
+	token := createTokenWithKey(t, privateKey, claims)
+	otherToken := createTokenWithKey(t, otherKey, claims)
+	ecToken, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(ecKey)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name   string
+		secret []byte
+		token  string
+		want   int
+	}{
+		{
+			name:   "pem public key",
+			secret: publicKeyPEM(t, &privateKey.PublicKey),
+			token:  token,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "pem rsa public key",
+			secret: pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&privateKey.PublicKey)}),
+			token:  token,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "pem certificate",
+			secret: certificatePEM(t, privateKey),
+			token:  token,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "pem ecdsa public key",
+			secret: publicKeyPEM(t, &ecKey.PublicKey),
+			token:  ecToken,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "pem second key",
+			secret: append(publicKeyPEM(t, &otherKey.PublicKey), publicKeyPEM(t, &privateKey.PublicKey)...),
+			token:  token,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "pem unknown key",
+			secret: publicKeyPEM(t, &privateKey.PublicKey),
+			token:  otherToken,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "jwks",
+			secret: jwksJSON(privateKey),
+			token:  token,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "jwks unknown key",
+			secret: jwksJSON(privateKey),
+			token:  otherToken,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "algorithm none",
+			secret: publicKeyPEM(t, &privateKey.PublicKey),
+			token:  createToken(t, jwt.SigningMethodNone),
+			want:   http.StatusUnauthorized,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := secrets.NewSecretsMap()
+			sm.Update(map[string][]byte{"keys": tt.secret})
+
+			f, err := NewJwtValidationSecret(sm).CreateFilter([]interface{}{"keys"})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, jwtSecretStatus(t, f, tt.token))
+		})
+	}
+}
+
+func TestJwtValidationSecretRotation(t *testing.T) {
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{"sub": "user1", "exp": time.Now().Add(time.Hour).Unix()}
+	oldToken := createTokenWithKey(t, privateKey, claims)
+	newToken := createTokenWithKey(t, newKey, claims)
+
+	sm := secrets.NewSecretsMap()
+	sm.Update(map[string][]byte{"ns/name/keys": publicKeyPEM(t, &privateKey.PublicKey)})
+
+	f, err := NewJwtValidationSecret(sm).CreateFilter([]interface{}{"ns/name/keys"})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, jwtSecretStatus(t, f, oldToken))
+	assert.Equal(t, http.StatusUnauthorized, jwtSecretStatus(t, f, newToken))
+
+	sm.Update(map[string][]byte{"ns/name/keys": jwksJSON(newKey)})
+	assert.Equal(t, http.StatusUnauthorized, jwtSecretStatus(t, f, oldToken))
+	assert.Equal(t, http.StatusOK, jwtSecretStatus(t, f, newToken))
+
+	// invalid updates keep the previous keys
+	sm.Update(map[string][]byte{"ns/name/keys": []byte("invalid")})
+	assert.Equal(t, http.StatusOK, jwtSecretStatus(t, f, newToken))
+
+	sm.Update(nil)
+	assert.Equal(t, http.StatusUnauthorized, jwtSecretStatus(t, f, newToken))
+}
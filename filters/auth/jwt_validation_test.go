@@ -337,3 +337,210 @@ func TestJWTValidationOpenIDConfigTimeout(t *testing.T) {
 		t.Fatalf("OpenID config lookup took too long: %v", elapsed)
 	}
 }
+
+func TestJwtValidationConfigArgs(t *testing.T) {
+	for _, config := range []string{
+		"invalid yaml",
+		"{leeway: foo}",
+		"{leeway: -1s}",
+		"{algorithms: [none]}",
+		"{algorithms: [XY256]}",
+		"{claims: [{groups: [admin]}]}",
+		"{claims: [{cnf: {jkt: foo}}]}",
+		"{claims: [{tid: null}]}",
+	} {
+		t.Run(config, func(t *testing.T) {
+			_, err := NewJwtValidationKeys().CreateFilter([]interface{}{"https://jwks.example.org", config})
+			if err == nil {
+				t.Error("expected error with invalid config")
+			}
+		})
+	}
+}
+
+func TestJWTValidationConfig(t *testing.T) {
+	jwksServer := setupJWKSServer(t, privateKey)
+	defer jwksServer.Close()
+
+	testOidcConfig := getTestOidcConfig()
+	testOidcConfig.JwksURI = jwksServer.URL
+	issuerServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(testOidcConfig); err != nil {
+			t.Errorf("Could not encode testOidcConfig: %v", err)
+		}
+	}))
+	defer issuerServer.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer backend.Close()
+
+	cli := net.NewClient(net.Options{
+		IdleConnTimeout: 2 * time.Second,
+	})
+	defer cli.Close()
+
+	now := time.Now()
+	validClaims := func(claims jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub": "aaa",
+			"iss": testOidcConfig.Issuer,
+			"aud": []string{"foo", "bar"},
+			"exp": now.Add(time.Hour).Unix(),
+			"tid": "tenant1",
+			"lvl": 2,
+			"grp": []string{"admin"},
+		}
+		for k, v := range claims {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	for _, ti := range []struct {
+		name     string
+		config   string
+		claims   jwt.MapClaims
+		expected int
+	}{{
+		name:     "no config does not validate the issuer",
+		claims:   validClaims(jwt.MapClaims{"iss": "https://other.example.org"}),
+		expected: http.StatusOK,
+	}, {
+		name:     "discovered issuer",
+		config:   "{}",
+		claims:   validClaims(nil),
+		expected: http.StatusOK,
+	}, {
+		name:     "invalid discovered issuer",
+		config:   "{}",
+		claims:   validClaims(jwt.MapClaims{"iss": "https://other.example.org"}),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "missing issuer",
+		config:   "{}",
+		claims:   validClaims(jwt.MapClaims{"iss": nil}),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "configured issuer",
+		config:   "{issuers: ['https://other.example.org']}",
+		claims:   validClaims(jwt.MapClaims{"iss": "https://other.example.org"}),
+		expected: http.StatusOK,
+	}, {
+		name:     "audience",
+		config:   "{audiences: [baz, bar]}",
+		claims:   validClaims(nil),
+		expected: http.StatusOK,
+	}, {
+		name:     "single audience",
+		config:   "{audiences: [foo]}",
+		claims:   validClaims(jwt.MapClaims{"aud": "foo"}),
+		expected: http.StatusOK,
+	}, {
+		name:     "invalid audience",
+		config:   "{audiences: [baz]}",
+		claims:   validClaims(nil),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "missing audience",
+		config:   "{audiences: [foo]}",
+		claims:   validClaims(jwt.MapClaims{"aud": nil}),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "allowed algorithm",
+		config:   "{algorithms: [RS256, RS512]}",
+		claims:   validClaims(nil),
+		expected: http.StatusOK,
+	}, {
+		name:     "not allowed algorithm",
+		config:   "{algorithms: [RS512]}",
+		claims:   validClaims(nil),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "required claims",
+		config:   "{requiredClaims: [tid]}",
+		claims:   validClaims(nil),
+		expected: http.StatusOK,
+	}, {
+		name:     "missing required claim",
+		config:   "{requiredClaims: [email]}",
+		claims:   validClaims(nil),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "claims",
+		config:   "{claims: [{tid: tenant2}, {tid: tenant1}]}",
+		claims:   validClaims(nil),
+		expected: http.StatusOK,
+	}, {
+		name:     "invalid claims",
+		config:   "{claims: [{tid: tenant2}]}",
+		claims:   validClaims(nil),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "number claim",
+		config:   "{claims: [{lvl: 2, tid: tenant1}]}",
+		claims:   validClaims(nil),
+		expected: http.StatusOK,
+	}, {
+		name:     "invalid number claim",
+		config:   "{claims: [{lvl: 3}]}",
+		claims:   validClaims(nil),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "list claim in the token",
+		config:   "{claims: [{grp: admin}]}",
+		claims:   validClaims(nil),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "expired",
+		config:   "{}",
+		claims:   validClaims(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "expired within leeway",
+		config:   "{leeway: 30s}",
+		claims:   validClaims(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}),
+		expected: http.StatusOK,
+	}, {
+		name:     "not valid yet",
+		config:   "{}",
+		claims:   validClaims(jwt.MapClaims{"nbf": now.Add(10 * time.Second).Unix()}),
+		expected: http.StatusUnauthorized,
+	}, {
+		name:     "not valid yet within leeway",
+		config:   "{leeway: 30s}",
+		claims:   validClaims(jwt.MapClaims{"nbf": now.Add(10 * time.Second).Unix()}),
+		expected: http.StatusOK,
+	}} {
+		t.Run(ti.name, func(t *testing.T) {
+			args := []interface{}{issuerServer.URL}
+			if ti.config != "" {
+				args = append(args, ti.config)
+			}
+
+			spec := NewJwtValidationWithOptions(TokenintrospectionOptions{})
+			fr := make(filters.Registry)
+			fr.Register(spec)
+			r := &eskip.Route{Filters: []*eskip.Filter{{Name: spec.Name(), Args: args}}, Backend: backend.URL}
+
+			proxy := proxytest.New(fr, r)
+			defer proxy.Close()
+
+			req, _ := http.NewRequest("GET", proxy.URL, nil)
+			req.Header.Set(authHeaderName, authHeaderPrefix+createTokenWithKey(t, privateKey, ti.claims))
+
+			rsp, err := cli.Do(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer rsp.Body.Close()
+
+			if rsp.StatusCode != ti.expected {
+				t.Errorf("unexpected status code: %v != %v", rsp.StatusCode, ti.expected)
+			}
+		})
+	}
+}
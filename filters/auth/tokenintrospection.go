@@ -459,7 +459,7 @@ func (f *tokenintrospectFilter) Request(ctx filters.FilterContext) {
 	var info tokenIntrospectionInfo
 	infoTemp, ok := ctx.StateBag()[tokenintrospectionCacheKey]
 	if !ok {
-		token, ok := getToken(r)
+		token, ok := getAccessToken(r)
 		if !ok || token == "" {
 			unauthorized(ctx, "", missingToken, f.authClient.url.Hostname(), "")
 			return
@@ -6,6 +6,7 @@ package builtin
 import (
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/filters/accesslog"
+	"github.com/zalando/skipper/filters/activehealthcheck"
 	"github.com/zalando/skipper/filters/annotate"
 	"github.com/zalando/skipper/filters/auth"
 	"github.com/zalando/skipper/filters/awssigner/awssigv4"
@@ -16,7 +17,9 @@ import (
 	"github.com/zalando/skipper/filters/diag"
 	"github.com/zalando/skipper/filters/fadein"
 	"github.com/zalando/skipper/filters/flowid"
+	"github.com/zalando/skipper/filters/hedge"
 	logfilter "github.com/zalando/skipper/filters/log"
+	"github.com/zalando/skipper/filters/retry"
 	"github.com/zalando/skipper/filters/rfc"
 	"github.com/zalando/skipper/filters/scheduler"
 	"github.com/zalando/skipper/filters/sed"
@@ -248,6 +251,9 @@ func Filters() []filters.Spec {
 		fadein.NewEndpointCreated(),
 		consistenthash.NewConsistentHashKey(),
 		consistenthash.NewConsistentHashBalanceFactor(),
+		activehealthcheck.NewActiveHealthCheck(),
+		retry.NewRetry(),
+		hedge.NewHedge(),
 		tls.New(),
 		tls.NewMtlsCN(),
 		tls.NewMtlsIssuerDN(),
@@ -0,0 +1,252 @@
+package cache
+
+import (
+	"cmp"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AdminPath is the path prefix of the endpoints served by AdminHandler.
+const AdminPath = "/cache/"
+
+// purgeResult is the response of the purge endpoint.
+type purgeResult struct {
+	Purged int `json:"purged"`
+}
+
+// entryInfo is the metadata of a cached entry returned by the entries
+// endpoint. The header is only returned for a single entry.
+type entryInfo struct {
+	Key                  string      `json:"key"`
+	RouteID              string      `json:"routeId"`
+	URL                  string      `json:"url"`
+	StatusCode           int         `json:"statusCode"`
+	Size                 int         `json:"size"`
+	Age                  string      `json:"age"`
+	TTL                  string      `json:"ttl"`
+	StaleWhileRevalidate string      `json:"staleWhileRevalidate"`
+	StaleIfError         string      `json:"staleIfError"`
+	VaryHeaders          []string    `json:"varyHeaders,omitempty"`
+	SurrogateKeys        []string    `json:"surrogateKeys,omitempty"`
+	Header               http.Header `json:"header,omitempty"`
+}
+
+func newEntryInfo(key string, e *Entry, now time.Time) entryInfo {
+	return entryInfo{
+		Key:                  key,
+		RouteID:              e.RouteID,
+		URL:                  e.URL,
+		StatusCode:           e.StatusCode,
+		Size:                 len(e.Payload),
+		Age:                  now.Sub(e.CreatedAt).Truncate(time.Millisecond).String(),
+		TTL:                  e.TTL.String(),
+		StaleWhileRevalidate: e.StaleWhileRevalidate.String(),
+		StaleIfError:         e.StaleIfError.String(),
+		VaryHeaders:          e.VaryHeaders,
+		SurrogateKeys:        e.SurrogateKeys,
+	}
+}
+
+// AdminHandler serves the administrative endpoints of the cache() filter
+// storage, meant for the support listener. The requests must present the
+// configured token as a bearer token.
+//
+// List the entries with their size, age, TTL, stale windows and Vary
+// headers grouped by route, optionally of a single route, or get the
+// metadata of a single entry:
+//
+//	GET /cache/entries
+//	GET /cache/entries?route=<route ID>
+//	GET /cache/entries?key=<key>
+//
+// Get the hit, miss, stale and revalidation counts and ratios per route:
+//
+//	GET /cache/stats
+//
+// Purge the entries by exact storage key, by route ID, by URL prefix (host,
+// path and query, e.g. "example.org/news/") or by a tag of the
+// Surrogate-Key response header:
+//
+//	POST /cache/purge?key=<key>
+//	POST /cache/purge?route=<route ID>
+//	POST /cache/purge?prefix=<URL prefix>
+//	POST /cache/purge?surrogate-key=<tag>
+type AdminHandler struct {
+	storage Storage
+	stats   *Stats
+	token   string
+}
+
+// NewAdminHandler returns an AdminHandler for the storage and the stats of
+// the cache() filter, authenticating the requests with the token.
+func NewAdminHandler(storage Storage, stats *Stats, token string) *AdminHandler {
+	return &AdminHandler{storage: storage, stats: stats, token: token}
+}
+
+func (h *AdminHandler) authenticated(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && h.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticated(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	switch r.URL.Path {
+	case AdminPath + "entries":
+		h.serveEntries(w, r)
+	case AdminPath + "stats":
+		h.serveStats(w, r)
+	case AdminPath + "purge":
+		h.servePurge(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Cache: failed to encode admin response: %v", err)
+	}
+}
+
+func (h *AdminHandler) serveEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	if key := r.URL.Query().Get("key"); key != "" {
+		e, err := h.storage.Get(r.Context(), key)
+		if err != nil {
+			log.Errorf("Cache: failed to get entry: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if e == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		info := newEntryInfo(key, e, now)
+		info.Header = e.Header
+		writeJSON(w, info)
+		return
+	}
+
+	rg, ok := h.storage.(Ranger)
+	if !ok {
+		http.Error(w, "the cache storage does not support listing the entries", http.StatusNotImplemented)
+		return
+	}
+
+	route, filterRoute := r.URL.Query().Get("route"), r.URL.Query().Has("route")
+	routes := make(map[string][]entryInfo)
+	if err := rg.Range(r.Context(), func(key string, e *Entry) {
+		if !filterRoute || e.RouteID == route {
+			routes[e.RouteID] = append(routes[e.RouteID], newEntryInfo(key, e, now))
+		}
+	}); err != nil {
+		log.Errorf("Cache: failed to list entries: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	for _, entries := range routes {
+		slices.SortFunc(entries, func(a, b entryInfo) int { return cmp.Compare(a.Key, b.Key) })
+	}
+	writeJSON(w, routes)
+}
+
+func (h *AdminHandler) serveStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, h.stats.Routes())
+}
+
+// purgeMatcher returns the predicate selecting the entries to purge, from
+// the single selector of the query.
+func purgeMatcher(r *http.Request) (func(key string, e *Entry) bool, bool) {
+	q := r.URL.Query()
+	if len(q) != 1 {
+		return nil, false
+	}
+
+	switch {
+	case q.Has("route"):
+		route := q.Get("route")
+		return func(_ string, e *Entry) bool { return e.RouteID == route }, route != ""
+	case q.Has("prefix"):
+		prefix := q.Get("prefix")
+		return func(_ string, e *Entry) bool { return strings.HasPrefix(e.URL, prefix) }, prefix != ""
+	case q.Has("surrogate-key"):
+		tag := q.Get("surrogate-key")
+		return func(_ string, e *Entry) bool { return slices.Contains(e.SurrogateKeys, tag) }, tag != ""
+	default:
+		return nil, false
+	}
+}
+
+func (h *AdminHandler) servePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var (
+		purged int
+		err    error
+	)
+	if key := r.URL.Query().Get("key"); key != "" && len(r.URL.Query()) == 1 {
+		purged, err = h.purgeKey(r, key)
+	} else {
+		match, ok := purgeMatcher(r)
+		if !ok {
+			http.Error(w, "exactly one of key, route, prefix or surrogate-key is required", http.StatusBadRequest)
+			return
+		}
+
+		p, ok := h.storage.(Purger)
+		if !ok {
+			http.Error(w, "the cache storage does not support purging by route, prefix or surrogate key", http.StatusNotImplemented)
+			return
+		}
+
+		purged, err = p.Purge(r.Context(), match)
+	}
+
+	if err != nil {
+		log.Errorf("Cache: failed to purge entries: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("Cache: purged %d entries for %s", purged, r.URL.RawQuery)
+	writeJSON(w, purgeResult{Purged: purged})
+}
+
+func (h *AdminHandler) purgeKey(r *http.Request, key string) (int, error) {
+	e, err := h.storage.Get(r.Context(), key)
+	if err != nil || e == nil {
+		return 0, err
+	}
+
+	if err := h.storage.Delete(r.Context(), key); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
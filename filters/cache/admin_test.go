@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+const testAdminToken = "secret"
+
+// storeResponse runs a cache miss through the filter, storing the response
+// with the surrogate keys.
+func storeResponse(t *testing.T, f *cacheFilter, routeID, rawURL, surrogateKey string) string {
+	t.Helper()
+	ctx := newCtxWithRoute("GET", rawURL, "", routeID)
+	f.Request(ctx)
+	if ctx.FServed {
+		t.Fatalf("%s should not be served from cache", rawURL)
+	}
+
+	ctx.FResponse = upstreamResponse(http.StatusOK, rawURL)
+	if surrogateKey != "" {
+		ctx.FResponse.Header.Set(surrogateKeyHeader, surrogateKey)
+	}
+	f.Response(ctx)
+	return ctx.FStateBag[stateBagKey].(string)
+}
+
+func purge(t *testing.T, h http.Handler, method, query, token string) (int, int) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/cache/purge?"+query, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var result purgeResult
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, result.Purged
+}
+
+func TestAdminHandler_Purge(t *testing.T) {
+	f := newTestFilter(t, time.Minute, 15*time.Second, time.Minute)
+	h := NewAdminHandler(f.storage, f.stats, testAdminToken)
+
+	articleKey := storeResponse(t, f, "articles", "https://example.org/news/1", "news article-1")
+	storeResponse(t, f, "articles", "https://example.org/news/2", "news article-2")
+	storeResponse(t, f, "articles", "https://example.org/blog/1", "blog")
+	storeResponse(t, f, "assets", "https://static.example.org/app.js", "")
+
+	cached := func(key string) bool {
+		e, err := f.storage.Get(context.Background(), key)
+		return err == nil && e != nil
+	}
+
+	for _, tt := range []struct {
+		name   string
+		method string
+		query  string
+		token  string
+		status int
+		purged int
+	}{
+		{name: "missing token", method: "POST", query: "route=articles", status: http.StatusUnauthorized},
+		{name: "invalid token", method: "POST", query: "route=articles", token: "invalid", status: http.StatusUnauthorized},
+		{name: "method not allowed", method: "GET", query: "route=articles", token: testAdminToken, status: http.StatusMethodNotAllowed},
+		{name: "missing selector", method: "POST", token: testAdminToken, status: http.StatusBadRequest},
+		{name: "multiple selectors", method: "POST", query: "route=articles&prefix=example.org", token: testAdminToken, status: http.StatusBadRequest},
+		{name: "unknown selector", method: "POST", query: "foo=bar", token: testAdminToken, status: http.StatusBadRequest},
+		{name: "by key", method: "POST", query: "key=" + articleKey, token: testAdminToken, status: http.StatusOK, purged: 1},
+		{name: "by missing key", method: "POST", query: "key=" + articleKey, token: testAdminToken, status: http.StatusOK, purged: 0},
+		{name: "by surrogate key", method: "POST", query: "surrogate-key=news", token: testAdminToken, status: http.StatusOK, purged: 1},
+		{name: "by prefix", method: "POST", query: "prefix=example.org/blog/", token: testAdminToken, status: http.StatusOK, purged: 1},
+		{name: "by route", method: "POST", query: "route=assets", token: testAdminToken, status: http.StatusOK, purged: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status, purged := purge(t, h, tt.method, tt.query, tt.token)
+			if status != tt.status || purged != tt.purged {
+				t.Fatalf("expected %d with %d purged, got %d with %d purged", tt.status, tt.purged, status, purged)
+			}
+		})
+	}
+
+	if cached(articleKey) {
+		t.Fatal("expected the entry to be purged")
+	}
+
+	ctx := newCtxWithRoute("GET", "https://example.org/news/2", "", "articles")
+	f.Request(ctx)
+	if ctx.FServed {
+		t.Fatal("expected the entry with the surrogate key to be purged")
+	}
+}
+
+func getJSON(t *testing.T, h http.Handler, path string, v any) int {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code
+}
+
+func TestAdminHandler_Entries(t *testing.T) {
+	f := newTestFilter(t, time.Minute, 15*time.Second, time.Minute)
+	h := NewAdminHandler(f.storage, f.stats, testAdminToken)
+
+	articleKey := storeResponse(t, f, "articles", "https://example.org/news/1", "news")
+	storeResponse(t, f, "articles", "https://example.org/news/2", "")
+	storeResponse(t, f, "assets", "https://static.example.org/app.js", "")
+
+	var routes map[string][]entryInfo
+	if status := getJSON(t, h, "/cache/entries", &routes); status != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, status)
+	}
+	if len(routes["articles"]) != 2 || len(routes["assets"]) != 1 {
+		t.Fatalf("expected 2 articles and 1 asset, got %v", routes)
+	}
+
+	routes = nil
+	if status := getJSON(t, h, "/cache/entries?route=assets", &routes); status != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, status)
+	}
+	if len(routes) != 1 || len(routes["assets"]) != 1 {
+		t.Fatalf("expected only the asset, got %v", routes)
+	}
+	if e := routes["assets"][0]; e.URL != "static.example.org/app.js" || e.TTL != "1m0s" || e.Size == 0 || e.Header != nil {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+
+	var info entryInfo
+	if status := getJSON(t, h, "/cache/entries?key="+url.QueryEscape(articleKey), &info); status != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, status)
+	}
+	if info.Key != articleKey || info.RouteID != "articles" || info.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected entry: %+v", info)
+	}
+	if len(info.SurrogateKeys) != 1 || info.SurrogateKeys[0] != "news" {
+		t.Fatalf("expected the surrogate key, got %v", info.SurrogateKeys)
+	}
+
+	if status := getJSON(t, h, "/cache/entries?key=missing", &info); status != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, status)
+	}
+}
+
+func TestAdminHandler_Stats(t *testing.T) {
+	f := newTestFilter(t, time.Minute, 15*time.Second, time.Minute)
+	h := NewAdminHandler(f.storage, f.stats, testAdminToken)
+
+	rawURL := "https://example.org/news/1"
+	storeResponse(t, f, "articles", rawURL, "")
+	for range 3 {
+		ctx := newCtxWithRoute("GET", rawURL, "", "articles")
+		f.Request(ctx)
+		if !ctx.FServed {
+			t.Fatal("expected a cache hit")
+		}
+		ctx.FMetrics.(*metricstest.MockMetrics).WithCounters(func(counters map[string]int64) {
+			if counters["cache.status.articles.hit"] != 1 {
+				t.Errorf("expected the route hit counter, got %v", counters)
+			}
+		})
+	}
+
+	var routes map[string]RouteStats
+	if status := getJSON(t, h, "/cache/stats", &routes); status != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, status)
+	}
+
+	got := routes["articles"]
+	if got.Hit != 3 || got.Miss != 1 || got.Stale != 0 || got.HitRatio != 0.75 || got.MissRatio != 0.25 {
+		t.Fatalf("unexpected stats: %+v", got)
+	}
+}
+
+func TestStatsPrunedOnRouteUpdates(t *testing.T) {
+	s := NewStats()
+	s.count("articles", cacheStatusHit)
+	s.count("removed", cacheStatusMiss)
+
+	s.Do([]*routing.Route{{Route: eskip.Route{Id: "articles"}}})
+
+	routes := s.Routes()
+	if len(routes) != 1 || routes["articles"].Hit != 1 {
+		t.Fatalf("unexpected stats: %+v", routes)
+	}
+}
+
+func TestAdminHandler_NotFound(t *testing.T) {
+	h := NewAdminHandler(NewLRUStorage(1<<20, nil), NewStats(), testAdminToken)
+
+	req := httptest.NewRequest("POST", "/cache/foo", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestSurrogateKeys(t *testing.T) {
+	h := http.Header{}
+	h.Add(surrogateKeyHeader, "news  article-1")
+	h.Add(surrogateKeyHeader, "front-page")
+
+	got := surrogateKeys(h)
+	want := []string{"news", "article-1", "front-page"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
@@ -40,6 +40,14 @@ const (
 	cacheStatusMiss   = "MISS"
 	cacheStatusStale  = "STALE"
 
+	// surrogateKeyHeader lists the space separated tags of an upstream
+	// response, that can be used to purge groups of related entries.
+	surrogateKeyHeader = "Surrogate-Key"
+
+	// routeStatusMetricsFormat is the per-route counter of the X-Cache-Status
+	// values, e.g. "cache.status.my_route.hit".
+	routeStatusMetricsFormat = "cache.status.%s.%s"
+
 	// revalQueueSize is the capacity of the per-filter revalidation job queue.
 	// Sized to absorb short bursts; jobs are dropped (with reval_dropped metric)
 	// if the worker cannot keep up.
@@ -64,24 +72,39 @@ const (
 //
 //	-> cache("5m", "15s", "30s", "60s") -> "https://example.org"
 func NewCacheFilter(maxBytes int64, listenAddr string, netOpts skpnet.Options) filters.Spec {
+	return NewCacheFilterWithStorage(NewMemoryStorage(maxBytes), NewStats(), listenAddr, netOpts)
+}
+
+// NewMemoryStorage returns the in-memory LRUStorage used by NewCacheFilter,
+// sized to maxBytes, reporting its evictions and size as metrics.
+func NewMemoryStorage(maxBytes int64) *LRUStorage {
 	var store *LRUStorage
 	store = NewLRUStorage(maxBytes, func() {
 		metrics.Default.IncCounter("lru_eviction")
 		metrics.Default.UpdateGauge("lru_bytes", float64(store.lru.Bytes()))
 	})
+	return store
+}
+
+// NewCacheFilterWithStorage returns a Spec for the cache() filter, like
+// NewCacheFilter, with the cached responses kept in the provided storage,
+// e.g. a TieredStorage backed by a redis or valkey ring to share the cached
+// responses across Skipper instances. The cache statuses of the responses are
+// counted in stats, that can be shared with an AdminHandler.
+func NewCacheFilterWithStorage(storage Storage, stats *Stats, listenAddr string, netOpts skpnet.Options) filters.Spec {
 	return &cacheSpec{
-		maxBytes:   maxBytes,
 		listenAddr: listenAddr,
 		client:     skpnet.NewClient(netOpts),
-		storage:    store,
+		storage:    storage,
+		stats:      stats,
 	}
 }
 
 type cacheSpec struct {
-	maxBytes   int64
 	listenAddr string
 	client     *skpnet.Client
 	storage    Storage // shared across all filter instances
+	stats      *Stats  // shared across all filter instances
 }
 
 func (s *cacheSpec) Name() string { return filterName }
@@ -137,6 +160,7 @@ func (s *cacheSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
 
 	cf := &cacheFilter{
 		storage:      s.storage,
+		stats:        s.stats,
 		listenAddr:   s.listenAddr,
 		ttl:          ttl,
 		errorTTL:     errorTTL,
@@ -175,6 +199,7 @@ type revalJob struct {
 
 type cacheFilter struct {
 	storage      Storage
+	stats        *Stats
 	listenAddr   string
 	ttl          time.Duration
 	errorTTL     time.Duration
@@ -226,6 +251,7 @@ func (f *cacheFilter) Request(ctx filters.FilterContext) {
 	if reqDir.onlyIfCached {
 		entry, err = f.storage.Get(ctx.Request().Context(), key)
 		if err != nil || entry == nil || entry.IsStale(time.Now()) {
+			f.countStatus(ctx, cacheStatusMiss)
 			ctx.Serve(&http.Response{
 				StatusCode: http.StatusGatewayTimeout,
 				Header:     http.Header{cacheStatusHeader: {cacheStatusMiss}},
@@ -274,6 +300,7 @@ func (f *cacheFilter) Request(ctx filters.FilterContext) {
 		rsp.Header.Set(cacheStatusHeader, cacheStatusStale)
 		setAgeHeader(rsp, entry, now)
 		ctx.Metrics().IncCounter("stale")
+		f.countStatus(ctx, cacheStatusStale)
 		method := ctx.Request().Method
 		if (method == http.MethodGet || method == http.MethodHead) && evaluateConditionals(ctx.Request(), entry) {
 			notModified := &http.Response{
@@ -283,11 +310,11 @@ func (f *cacheFilter) Request(ctx filters.FilterContext) {
 				Request:    ctx.Request(), // link response to originating request per net/http convention
 			}
 			ctx.Serve(notModified)
-			f.enqueueRevalidation(key, ctx.Request())
+			f.enqueueRevalidation(ctx.RouteId(), key, ctx.Request())
 			return
 		}
 		ctx.Serve(headBodyOmitted(method, rsp))
-		f.enqueueRevalidation(key, ctx.Request())
+		f.enqueueRevalidation(ctx.RouteId(), key, ctx.Request())
 		return
 	}
 
@@ -311,6 +338,7 @@ func (f *cacheFilter) Request(ctx filters.FilterContext) {
 	rsp.Header.Set(cacheStatusHeader, cacheStatusHit)
 	setAgeHeader(rsp, entry, time.Now())
 	ctx.Metrics().IncCounter("hit")
+	f.countStatus(ctx, cacheStatusHit)
 	method := ctx.Request().Method
 	if (method == http.MethodGet || method == http.MethodHead) && evaluateConditionals(ctx.Request(), entry) {
 		notModified := &http.Response{
@@ -402,6 +430,7 @@ func (f *cacheFilter) coalesce(ctx filters.FilterContext, key string) {
 		}
 		rsp.Header.Set(cacheStatusHeader, cacheStatusMiss)
 		ctx.Metrics().IncCounter("miss")
+		f.countStatus(ctx, cacheStatusMiss)
 		ctx.Serve(headBodyOmitted(ctx.Request().Method, rsp))
 	case <-ctx.Request().Context().Done():
 		// Client disconnected. Remaining waiters still receive their result.
@@ -467,6 +496,7 @@ func (f *cacheFilter) Response(ctx filters.FilterContext) {
 					Body:       io.NopCloser(bytes.NewReader(stored.Payload)),
 				}
 				staleRsp.Header.Set(cacheStatusHeader, cacheStatusStale)
+				f.countStatus(ctx, cacheStatusStale)
 				setAgeHeader(staleRsp, stored, time.Now())
 				ctx.Serve(headBodyOmitted(ctx.Request().Method, staleRsp))
 				return
@@ -477,11 +507,13 @@ func (f *cacheFilter) Response(ctx filters.FilterContext) {
 	if ctx.StateBag()[stateBagNoStore] == true {
 		rsp.Header.Set(cacheStatusHeader, cacheStatusMiss)
 		ctx.Metrics().IncCounter("miss")
+		f.countStatus(ctx, cacheStatusMiss)
 		return
 	}
 
 	rsp.Header.Set(cacheStatusHeader, cacheStatusMiss)
 	ctx.Metrics().IncCounter("miss")
+	f.countStatus(ctx, cacheStatusMiss)
 
 	// Vary: * means every response is unique — never cache.
 	varyHeader := rsp.Header.Get("Vary")
@@ -528,6 +560,9 @@ func (f *cacheFilter) Response(ctx filters.FilterContext) {
 			TTL:                  ttl,
 			StaleWhileRevalidate: f.swrWindow,
 			VaryHeaders:          varyNames,
+			RouteID:              ctx.RouteId(),
+			URL:                  entryURL(ctx.Request()),
+			SurrogateKeys:        surrogateKeys(rsp.Header),
 		}
 		_ = f.storage.Set(ctx.Request().Context(), "vary:"+baseKey, sentinel)
 	}
@@ -559,19 +594,30 @@ func (f *cacheFilter) Response(ctx filters.FilterContext) {
 		VaryHeaders:          varyNames,
 		CorrectedInitialAge:  cia,
 		ResponseTime:         responseTime,
+		RouteID:              ctx.RouteId(),
+		URL:                  entryURL(ctx.Request()),
+		SurrogateKeys:        surrogateKeys(rsp.Header),
 	}
 	_ = f.storage.Set(ctx.Request().Context(), storeKey, entry)
 }
 
+// countStatus records the X-Cache-Status of the response per route, as
+// counter and in the stats.
+func (f *cacheFilter) countStatus(ctx filters.FilterContext, status string) {
+	ctx.Metrics().IncCounter(fmt.Sprintf(routeStatusMetricsFormat, ctx.RouteId(), strings.ToLower(status)))
+	f.stats.count(ctx.RouteId(), status)
+}
+
 // enqueueRevalidation clones the request before sending a job to the background
 // revalidation worker so there is no data race: the clone happens in the calling
 // goroutine while orig is still live, and the worker receives a fully independent copy.
 // Non-blocking send: if the queue is full the revalidation is dropped rather than
 // blocking the request goroutine.
-func (f *cacheFilter) enqueueRevalidation(key string, orig *http.Request) {
+func (f *cacheFilter) enqueueRevalidation(routeID, key string, orig *http.Request) {
 	cloned := orig.Clone(context.Background())
 	select {
 	case f.revalJobs <- revalJob{key: key, req: cloned}:
+		f.stats.countRevalidation(routeID)
 	default:
 		f.metrics.IncCounter("reval_dropped")
 	}
@@ -585,7 +631,11 @@ func (f *cacheFilter) doRevalidate(key string, req *http.Request) {
 		req.URL.Host = f.listenAddr
 		req.RequestURI = ""
 
+		// The route and the URL of the entry are carried over, as the
+		// revalidation request is not routed yet.
+		var routeID, rawURL string
 		if stored, err := f.storage.Get(context.Background(), key); err == nil && stored != nil {
+			routeID, rawURL = stored.RouteID, stored.URL
 			if stored.ETag != "" {
 				req.Header.Set("If-None-Match", stored.ETag)
 			}
@@ -663,6 +713,9 @@ func (f *cacheFilter) doRevalidate(key string, req *http.Request) {
 			LastModified:         responseHeader.Get("Last-Modified"),
 			CorrectedInitialAge:  cia,
 			ResponseTime:         responseTime,
+			RouteID:              routeID,
+			URL:                  rawURL,
+			SurrogateKeys:        surrogateKeys(responseHeader),
 		}
 		_ = f.storage.Set(context.Background(), key, entry)
 		return nil, nil
@@ -740,6 +793,24 @@ func cacheKey(routeID string, r *http.Request, keyHeaders []string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// entryURL returns the host, path and query of the request, used to purge
+// the entries by URL prefix.
+func entryURL(r *http.Request) string {
+	if r.URL.RawQuery == "" {
+		return r.Host + r.URL.Path
+	}
+	return r.Host + r.URL.Path + "?" + r.URL.RawQuery
+}
+
+// surrogateKeys returns the tags listed in the Surrogate-Key header.
+func surrogateKeys(h http.Header) []string {
+	var keys []string
+	for _, v := range h.Values(surrogateKeyHeader) {
+		keys = append(keys, strings.Fields(v)...)
+	}
+	return keys
+}
+
 // parseHTTPTime parses an HTTP date per RFC 9111 §4.2.
 // Rejects dates with non-GMT zone abbreviations that http.ParseTime would silently
 // accept with a wrong offset (RFC 850 format only; RFC 1123 already rejects non-GMT).
@@ -161,6 +161,20 @@ func (s *ShardedByteLRU) Delete(key string) {
 	s.getShard(key).delete(key)
 }
 
+// Range calls fn for every stored item, without changing their recency. The
+// shard of the item is locked while fn runs, so fn must not call the LRU and
+// must not retain data.
+func (s *ShardedByteLRU) Range(fn func(key string, data []byte)) {
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for ele := shard.ll.Front(); ele != nil; ele = ele.Next() {
+			item := ele.Value.(*lruItem)
+			fn(item.key, item.data)
+		}
+		shard.mu.Unlock()
+	}
+}
+
 // Bytes returns the total number of bytes currently stored across all shards.
 func (s *ShardedByteLRU) Bytes() int64 {
 	var total int64
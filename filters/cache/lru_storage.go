@@ -35,14 +35,9 @@ func (s *LRUStorage) Get(_ context.Context, key string) (*Entry, error) {
 		return nil, fmt.Errorf("cache: decode entry: %w", err)
 	}
 
-	// TTL==0 means "always stale but keep for conditional revalidation" (no-cache entries).
-	// Only hard-evict when TTL>0 and the entry has passed its full retention window.
-	if e.TTL > 0 {
-		sieWindow := max(e.StaleIfError, e.StaleWhileRevalidate)
-		if time.Now().After(e.CreatedAt.Add(e.TTL + sieWindow)) {
-			s.lru.Delete(key)
-			return nil, nil
-		}
+	if hardExpired(&e, time.Now()) {
+		s.lru.Delete(key)
+		return nil, nil
 	}
 
 	return &e, nil
@@ -70,3 +65,41 @@ func (s *LRUStorage) Delete(_ context.Context, key string) error {
 	s.lru.Delete(key)
 	return nil
 }
+
+// Purge implements Purger.
+func (s *LRUStorage) Purge(_ context.Context, match func(key string, e *Entry) bool) (int, error) {
+	var keys []string
+	s.lru.Range(func(key string, data []byte) {
+		var e Entry
+		if err := json.Unmarshal(data, &e); err == nil && match(key, &e) {
+			keys = append(keys, key)
+		}
+	})
+
+	for _, key := range keys {
+		s.lru.Delete(key)
+	}
+	return len(keys), nil
+}
+
+// Range implements Ranger.
+func (s *LRUStorage) Range(_ context.Context, fn func(key string, e *Entry)) error {
+	type item struct {
+		key   string
+		entry *Entry
+	}
+
+	// fn is called after the iteration, to not hold the lock of the shards
+	var items []item
+	s.lru.Range(func(key string, data []byte) {
+		var e Entry
+		if err := json.Unmarshal(data, &e); err == nil {
+			items = append(items, item{key: key, entry: &e})
+		}
+	})
+
+	for _, it := range items {
+		fn(it.key, it.entry)
+	}
+	return nil
+}
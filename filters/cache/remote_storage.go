@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/valkey-io/valkey-go"
+
+	skpnet "github.com/zalando/skipper/net"
+)
+
+const (
+	// RedisMetricsPrefix is the metrics prefix of the redis ring client
+	// used by the redis response cache storage.
+	RedisMetricsPrefix = "cache.redis."
+
+	// remoteKeyPrefix separates the cache entries from the other keys, e.g.
+	// the ratelimit counters, stored in the same redis or valkey ring.
+	remoteKeyPrefix = "skipper.cache:"
+
+	// remoteNoTTLRetention is the expiry of the entries without freshness
+	// lifetime (no-cache), that are only kept for conditional revalidation.
+	remoteNoTTLRetention = 24 * time.Hour
+)
+
+// remoteClient is the subset of the redis and valkey ring clients used by
+// RemoteStorage.
+type remoteClient interface {
+	// get returns ("", false, nil) when the key does not exist.
+	get(ctx context.Context, key string) (string, bool, error)
+	set(ctx context.Context, key, value string, expiration time.Duration) error
+	del(ctx context.Context, key string) error
+	scan(ctx context.Context, match string) ([]string, error)
+}
+
+type redisClient struct {
+	ring *skpnet.RedisRingClient
+}
+
+func (c redisClient) get(ctx context.Context, key string) (string, bool, error) {
+	value, err := c.ring.Get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	return value, err == nil, err
+}
+
+func (c redisClient) set(ctx context.Context, key, value string, expiration time.Duration) error {
+	_, err := c.ring.Set(ctx, key, value, expiration)
+	return err
+}
+
+func (c redisClient) del(ctx context.Context, key string) error {
+	_, err := c.ring.Del(ctx, key)
+	return err
+}
+
+func (c redisClient) scan(ctx context.Context, match string) ([]string, error) {
+	return c.ring.Scan(ctx, match)
+}
+
+type valkeyClient struct {
+	ring *skpnet.ValkeyRingClient
+}
+
+func (c valkeyClient) get(ctx context.Context, key string) (string, bool, error) {
+	value, err := c.ring.Get(ctx, key)
+	if valkey.IsValkeyNil(err) {
+		return "", false, nil
+	}
+	return value, err == nil, err
+}
+
+func (c valkeyClient) set(ctx context.Context, key, value string, expiration time.Duration) error {
+	return c.ring.SetWithExpire(ctx, key, value, expiration)
+}
+
+func (c valkeyClient) del(ctx context.Context, key string) error {
+	_, err := c.ring.Del(ctx, key)
+	return err
+}
+
+func (c valkeyClient) scan(ctx context.Context, match string) ([]string, error) {
+	return c.ring.Scan(ctx, match)
+}
+
+// RemoteStorage implements Storage on top of a redis or valkey ring, so that
+// the cached responses are shared by all the Skipper instances connected to
+// the ring. The entries expire in the ring after their full retention window.
+type RemoteStorage struct {
+	client remoteClient
+}
+
+// NewRedisStorage returns a RemoteStorage backed by the redis ring.
+func NewRedisStorage(ring *skpnet.RedisRingClient) *RemoteStorage {
+	return &RemoteStorage{client: redisClient{ring: ring}}
+}
+
+// NewValkeyStorage returns a RemoteStorage backed by the valkey ring.
+func NewValkeyStorage(ring *skpnet.ValkeyRingClient) *RemoteStorage {
+	return &RemoteStorage{client: valkeyClient{ring: ring}}
+}
+
+// remoteExpiration returns the time until the entry may be dropped from the
+// ring, matching the hard-expiry of the LRUStorage. The ring only supports
+// expiry in whole seconds.
+func remoteExpiration(e *Entry, now time.Time) time.Duration {
+	if e.TTL <= 0 {
+		return remoteNoTTLRetention
+	}
+
+	d := e.CreatedAt.Add(e.TTL + max(e.StaleIfError, e.StaleWhileRevalidate)).Sub(now)
+	return max(d.Truncate(time.Second)+time.Second, time.Second)
+}
+
+func (s *RemoteStorage) Get(ctx context.Context, key string) (*Entry, error) {
+	data, ok, err := s.client.get(ctx, remoteKeyPrefix+key)
+	if err != nil {
+		return nil, fmt.Errorf("cache: get remote entry: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	var e Entry
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return nil, fmt.Errorf("cache: decode entry: %w", err)
+	}
+	return &e, nil
+}
+
+func (s *RemoteStorage) Set(ctx context.Context, key string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("cache: encode entry: %w", err)
+	}
+
+	if err := s.client.set(ctx, remoteKeyPrefix+key, string(data), remoteExpiration(entry, time.Now())); err != nil {
+		return fmt.Errorf("cache: set remote entry: %w", err)
+	}
+	return nil
+}
+
+func (s *RemoteStorage) Delete(ctx context.Context, key string) error {
+	if err := s.client.del(ctx, remoteKeyPrefix+key); err != nil {
+		return fmt.Errorf("cache: delete remote entry: %w", err)
+	}
+	return nil
+}
+
+// Range implements Ranger. It scans all the cache entries of the ring, so
+// it is meant for occasional administrative use.
+func (s *RemoteStorage) Range(ctx context.Context, fn func(key string, e *Entry)) error {
+	keys, err := s.client.scan(ctx, remoteKeyPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("cache: scan remote entries: %w", err)
+	}
+
+	for _, remoteKey := range keys {
+		key := strings.TrimPrefix(remoteKey, remoteKeyPrefix)
+		e, err := s.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if e != nil {
+			fn(key, e)
+		}
+	}
+
+	return nil
+}
+
+// Purge implements Purger. Like Range, it scans all the cache entries of
+// the ring.
+func (s *RemoteStorage) Purge(ctx context.Context, match func(key string, e *Entry) bool) (int, error) {
+	var keys []string
+	if err := s.Range(ctx, func(key string, e *Entry) {
+		if match(key, e) {
+			keys = append(keys, key)
+		}
+	}); err != nil {
+		return 0, err
+	}
+
+	for i, key := range keys {
+		if err := s.Delete(ctx, key); err != nil {
+			return i, err
+		}
+	}
+
+	return len(keys), nil
+}
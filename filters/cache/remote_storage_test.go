@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	skpnet "github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/net/redistest"
+	"github.com/zalando/skipper/net/valkeytest"
+)
+
+func TestRemoteExpiration(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		name  string
+		entry *Entry
+		want  time.Duration
+	}{
+		{
+			name:  "no-cache entries are retained",
+			entry: &Entry{CreatedAt: now},
+			want:  remoteNoTTLRetention,
+		},
+		{
+			name:  "ttl",
+			entry: &Entry{CreatedAt: now, TTL: time.Minute},
+			want:  61 * time.Second,
+		},
+		{
+			name:  "stale-if-error extends the retention",
+			entry: &Entry{CreatedAt: now, TTL: time.Minute, StaleWhileRevalidate: 10 * time.Second, StaleIfError: time.Minute},
+			want:  121 * time.Second,
+		},
+		{
+			name:  "rounded to whole seconds",
+			entry: &Entry{CreatedAt: now, TTL: 1500 * time.Millisecond},
+			want:  2 * time.Second,
+		},
+		{
+			name:  "already expired",
+			entry: &Entry{CreatedAt: now.Add(-time.Hour), TTL: time.Minute},
+			want:  time.Second,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := remoteExpiration(tt.entry, now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRemoteStorage(t *testing.T) {
+	redisAddr, done := redistest.NewTestRedis(t)
+	defer done()
+	valkeyAddr, done := valkeytest.NewTestValkey(t)
+	defer done()
+
+	redisRing := skpnet.NewRedisRingClient(&skpnet.RedisOptions{Addrs: []string{redisAddr}})
+	defer redisRing.Close()
+
+	valkeyRing, err := skpnet.NewValkeyRingClient(&skpnet.ValkeyOptions{Addrs: []string{valkeyAddr}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer valkeyRing.Close()
+
+	for name, s := range map[string]*RemoteStorage{
+		"redis":  NewRedisStorage(redisRing),
+		"valkey": NewValkeyStorage(valkeyRing),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			got, err := s.Get(ctx, "missing")
+			if err != nil || got != nil {
+				t.Fatalf("expected (nil, nil) for missing key, got (%v, %v)", got, err)
+			}
+
+			want := makeEntry(`{"id":1}`, time.Minute)
+			want.ETag = `"v1"`
+			if err := s.Set(ctx, "k1", want); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err = s.Get(ctx, "k1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil {
+				t.Fatal("expected entry, got nil")
+			}
+			if string(got.Payload) != string(want.Payload) || got.ETag != want.ETag || got.TTL != want.TTL {
+				t.Fatalf("entry mismatch: got %+v, want %+v", got, want)
+			}
+			if got.Header.Get("Content-Type") != "application/json" {
+				t.Fatalf("header mismatch: got %v", got.Header)
+			}
+
+			if err := s.Delete(ctx, "k1"); err != nil {
+				t.Fatal(err)
+			}
+			got, err = s.Get(ctx, "k1")
+			if err != nil || got != nil {
+				t.Fatalf("expected (nil, nil) after delete, got (%v, %v)", got, err)
+			}
+
+			if err := s.Delete(ctx, "missing"); err != nil {
+				t.Fatalf("expected no error deleting a missing key, got %v", err)
+			}
+
+			for key, routeID := range map[string]string{"r1": "route1", "r2": "route1", "r3": "route2"} {
+				e := makeEntry(key, time.Minute)
+				e.RouteID = routeID
+				if err := s.Set(ctx, key, e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			purged, err := s.Purge(ctx, func(_ string, e *Entry) bool { return e.RouteID == "route1" })
+			if err != nil || purged != 2 {
+				t.Fatalf("expected 2 purged entries, got (%d, %v)", purged, err)
+			}
+			if got, err := s.Get(ctx, "r3"); err != nil || got == nil {
+				t.Fatalf("expected the entry of the other route, got (%v, %v)", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/zalando/skipper/routing"
+)
+
+// Stats counts the cache statuses of the responses and the background
+// revalidations of the cache() filter per route. It is shared by the filter
+// instances and the AdminHandler. As a routing.PostProcessor, it drops the
+// statistics of the routes removed from the routing.
+type Stats struct {
+	mu     sync.RWMutex
+	routes map[string]*routeStats
+}
+
+type routeStats struct {
+	hit, miss, stale, revalidation atomic.Int64
+}
+
+// RouteStats are the statistics of a route. The ratios are relative to the
+// number of responses with a cache status.
+type RouteStats struct {
+	Hit               int64   `json:"hit"`
+	Miss              int64   `json:"miss"`
+	Stale             int64   `json:"stale"`
+	Revalidation      int64   `json:"revalidation"`
+	HitRatio          float64 `json:"hitRatio"`
+	MissRatio         float64 `json:"missRatio"`
+	StaleRatio        float64 `json:"staleRatio"`
+	RevalidationRatio float64 `json:"revalidationRatio"`
+}
+
+var _ routing.PostProcessor = &Stats{}
+
+// NewStats returns empty Stats.
+func NewStats() *Stats {
+	return &Stats{routes: make(map[string]*routeStats)}
+}
+
+// Do implements routing.PostProcessor by dropping the statistics of the
+// routes, that are not in the routing anymore.
+func (s *Stats) Do(routes []*routing.Route) []*routing.Route {
+	ids := make(map[string]struct{}, len(routes))
+	for _, r := range routes {
+		ids[r.Id] = struct{}{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for routeID := range s.routes {
+		if _, ok := ids[routeID]; !ok {
+			delete(s.routes, routeID)
+		}
+	}
+
+	return routes
+}
+
+func (s *Stats) route(routeID string) *routeStats {
+	s.mu.RLock()
+	rs, ok := s.routes[routeID]
+	s.mu.RUnlock()
+	if ok {
+		return rs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rs, ok = s.routes[routeID]; !ok {
+		rs = &routeStats{}
+		s.routes[routeID] = rs
+	}
+	return rs
+}
+
+// count records the cache status of a response of the route.
+func (s *Stats) count(routeID, status string) {
+	rs := s.route(routeID)
+	switch status {
+	case cacheStatusHit:
+		rs.hit.Add(1)
+	case cacheStatusMiss:
+		rs.miss.Add(1)
+	case cacheStatusStale:
+		rs.stale.Add(1)
+	}
+}
+
+// countRevalidation records a background revalidation of the route.
+func (s *Stats) countRevalidation(routeID string) {
+	s.route(routeID).revalidation.Add(1)
+}
+
+// Routes returns the statistics of all the routes.
+func (s *Stats) Routes() map[string]RouteStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]RouteStats, len(s.routes))
+	for routeID, rs := range s.routes {
+		r := RouteStats{
+			Hit:          rs.hit.Load(),
+			Miss:         rs.miss.Load(),
+			Stale:        rs.stale.Load(),
+			Revalidation: rs.revalidation.Load(),
+		}
+
+		if total := float64(r.Hit + r.Miss + r.Stale); total > 0 {
+			r.HitRatio = float64(r.Hit) / total
+			r.MissRatio = float64(r.Miss) / total
+			r.StaleRatio = float64(r.Stale) / total
+			r.RevalidationRatio = float64(r.Revalidation) / total
+		}
+
+		result[routeID] = r
+	}
+
+	return result
+}
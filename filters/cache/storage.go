@@ -41,6 +41,14 @@ type Entry struct {
 	// StaleIfError extends the hard-expiry retention window so the entry remains
 	// retrievable during upstream error periods (RFC 5861 stale-if-error).
 	StaleIfError time.Duration
+	// RouteID is the ID of the route that stored the entry.
+	RouteID string
+	// URL is the host, path and query of the request that stored the entry,
+	// e.g. "example.org/news/?page=2".
+	URL string
+	// SurrogateKeys are the tags of the entry read from the Surrogate-Key
+	// response header, used to purge groups of related entries.
+	SurrogateKeys []string
 }
 
 // IsStale reports whether the entry is past its TTL but still within the
@@ -49,6 +57,18 @@ func (e *Entry) IsStale(now time.Time) bool {
 	return now.After(e.CreatedAt.Add(e.TTL)) && now.Before(e.CreatedAt.Add(e.TTL+e.StaleWhileRevalidate))
 }
 
+// hardExpired reports whether the entry has passed its full retention window
+// and must not be returned by the storage anymore. TTL==0 means "always stale
+// but keep for conditional revalidation" (no-cache entries), these entries are
+// never hard-expired.
+func hardExpired(e *Entry, now time.Time) bool {
+	if e.TTL <= 0 {
+		return false
+	}
+	sieWindow := max(e.StaleIfError, e.StaleWhileRevalidate)
+	return now.After(e.CreatedAt.Add(e.TTL + sieWindow))
+}
+
 // Storage is the backing store abstraction for cached entries.
 // Implementations must be safe for concurrent use.
 type Storage interface {
@@ -61,3 +81,19 @@ type Storage interface {
 	// Delete removes the entry for key. It is not an error if the key does not exist.
 	Delete(ctx context.Context, key string) error
 }
+
+// Purger is implemented by the storages that can remove all the entries
+// matching a predicate. It is used to purge the entries of a route, of a URL
+// prefix or with a surrogate key.
+type Purger interface {
+	// Purge removes the entries for which match returns true, and returns
+	// the number of removed entries.
+	Purge(ctx context.Context, match func(key string, e *Entry) bool) (int, error)
+}
+
+// Ranger is implemented by the storages that can list their entries. It is
+// used to inspect the cached entries.
+type Ranger interface {
+	// Range calls fn for every stored entry.
+	Range(ctx context.Context, fn func(key string, e *Entry)) error
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/zalando/skipper/metrics"
+)
+
+// DefaultL1MaxAge is the default time an entry fetched from the shared
+// storage is served from the local L1 without asking the shared storage.
+const DefaultL1MaxAge = 10 * time.Second
+
+// TieredStorage implements Storage with a local in-memory L1 in front of a
+// shared L2, e.g. a RemoteStorage. Reads are served from the L1 when
+// possible, writes and deletes go to both. Entries are kept in the L1 at most
+// for l1MaxAge, so that the changes made by other instances, e.g. the
+// invalidations by unsafe requests, become visible after that delay.
+type TieredStorage struct {
+	l1       *ShardedByteLRU
+	l2       Storage
+	l1MaxAge time.Duration
+	now      func() time.Time
+}
+
+// l1Entry is the L1 representation of an entry, recording when it was
+// stored in the L1.
+type l1Entry struct {
+	Entry    *Entry
+	StoredAt time.Time
+}
+
+// NewTieredStorage returns a TieredStorage with an L1 sized to l1MaxBytes in
+// front of l2. When l1MaxAge is not positive, DefaultL1MaxAge is used.
+func NewTieredStorage(l1MaxBytes int64, l2 Storage, l1MaxAge time.Duration) *TieredStorage {
+	if l1MaxAge <= 0 {
+		l1MaxAge = DefaultL1MaxAge
+	}
+	s := &TieredStorage{
+		l2:       l2,
+		l1MaxAge: l1MaxAge,
+		now:      time.Now,
+	}
+	s.l1 = NewShardedByteLRU(l1MaxBytes, func() {
+		metrics.Default.IncCounter("lru_eviction")
+		metrics.Default.UpdateGauge("lru_bytes", float64(s.l1.Bytes()))
+	})
+	return s
+}
+
+func (s *TieredStorage) getL1(key string) *Entry {
+	data, ok := s.l1.Get(key)
+	if !ok {
+		return nil
+	}
+
+	var e l1Entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Entry == nil {
+		s.l1.Delete(key)
+		return nil
+	}
+
+	now := s.now()
+	if now.Sub(e.StoredAt) > s.l1MaxAge || hardExpired(e.Entry, now) {
+		s.l1.Delete(key)
+		return nil
+	}
+
+	return e.Entry
+}
+
+// setL1 stores the entry in the L1. Entries that do not fit are only kept in
+// the L2.
+func (s *TieredStorage) setL1(key string, entry *Entry) {
+	data, err := json.Marshal(l1Entry{Entry: entry, StoredAt: s.now()})
+	if err != nil || s.l1.ExceedsShard(data) {
+		s.l1.Delete(key)
+		return
+	}
+	s.l1.Set(key, data)
+}
+
+func (s *TieredStorage) Get(ctx context.Context, key string) (*Entry, error) {
+	if e := s.getL1(key); e != nil {
+		return e, nil
+	}
+
+	e, err := s.l2.Get(ctx, key)
+	if err != nil || e == nil {
+		return nil, err
+	}
+
+	s.setL1(key, e)
+	return e, nil
+}
+
+func (s *TieredStorage) Set(ctx context.Context, key string, entry *Entry) error {
+	s.setL1(key, entry)
+	return s.l2.Set(ctx, key, entry)
+}
+
+func (s *TieredStorage) Delete(ctx context.Context, key string) error {
+	s.l1.Delete(key)
+	return s.l2.Delete(ctx, key)
+}
+
+// Purge implements Purger. The entries are purged from the L1 and, when it
+// implements Purger, from the L2. The L1 of the other instances may serve the
+// purged entries for at most l1MaxAge.
+func (s *TieredStorage) Purge(ctx context.Context, match func(key string, e *Entry) bool) (int, error) {
+	var keys []string
+	s.l1.Range(func(key string, data []byte) {
+		var e l1Entry
+		if err := json.Unmarshal(data, &e); err == nil && e.Entry != nil && match(key, e.Entry) {
+			keys = append(keys, key)
+		}
+	})
+
+	for _, key := range keys {
+		s.l1.Delete(key)
+	}
+
+	p, ok := s.l2.(Purger)
+	if !ok {
+		return len(keys), nil
+	}
+	return p.Purge(ctx, match)
+}
+
+// Range implements Ranger. It lists the entries of the L2 when it implements
+// Ranger, otherwise the entries of the L1.
+func (s *TieredStorage) Range(ctx context.Context, fn func(key string, e *Entry)) error {
+	if r, ok := s.l2.(Ranger); ok {
+		return r.Range(ctx, fn)
+	}
+
+	type item struct {
+		key   string
+		entry *Entry
+	}
+
+	var items []item
+	s.l1.Range(func(key string, data []byte) {
+		var e l1Entry
+		if err := json.Unmarshal(data, &e); err == nil && e.Entry != nil {
+			items = append(items, item{key: key, entry: e.Entry})
+		}
+	})
+
+	for _, it := range items {
+		fn(it.key, it.entry)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingStorage records the calls to the wrapped storage.
+type countingStorage struct {
+	Storage
+	gets int
+	err  error
+}
+
+func (s *countingStorage) Get(ctx context.Context, key string) (*Entry, error) {
+	s.gets++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.Storage.Get(ctx, key)
+}
+
+func TestTieredStorage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	newStorage := func() (*TieredStorage, *countingStorage) {
+		l2 := &countingStorage{Storage: NewLRUStorage(1<<20, nil)}
+		s := NewTieredStorage(1<<20, l2, time.Second)
+		s.now = func() time.Time { return now }
+		return s, l2
+	}
+
+	t.Run("reads from the L2 once", func(t *testing.T) {
+		s, l2 := newStorage()
+		if err := l2.Set(ctx, "k1", makeEntry("v1", time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+
+		for range 3 {
+			got, err := s.Get(ctx, "k1")
+			if err != nil || got == nil || string(got.Payload) != "v1" {
+				t.Fatalf("expected v1, got (%v, %v)", got, err)
+			}
+		}
+		if l2.gets != 1 {
+			t.Fatalf("expected a single L2 read, got %d", l2.gets)
+		}
+	})
+
+	t.Run("writes through to the L2", func(t *testing.T) {
+		s, l2 := newStorage()
+		if err := s.Set(ctx, "k1", makeEntry("v1", time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := l2.Get(ctx, "k1")
+		if err != nil || got == nil || string(got.Payload) != "v1" {
+			t.Fatalf("expected v1 in the L2, got (%v, %v)", got, err)
+		}
+
+		if err := s.Delete(ctx, "k1"); err != nil {
+			t.Fatal(err)
+		}
+		for _, st := range []Storage{s, l2} {
+			if got, err := st.Get(ctx, "k1"); err != nil || got != nil {
+				t.Fatalf("expected (nil, nil) after delete, got (%v, %v)", got, err)
+			}
+		}
+	})
+
+	t.Run("L1 entries expire after the max age", func(t *testing.T) {
+		s, l2 := newStorage()
+		if err := s.Set(ctx, "k1", makeEntry("v1", time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+
+		// another instance updates the entry
+		if err := l2.Set(ctx, "k1", makeEntry("v2", time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.Get(ctx, "k1"); got == nil || string(got.Payload) != "v1" {
+			t.Fatalf("expected v1 from the L1, got %v", got)
+		}
+
+		now = now.Add(2 * time.Second)
+		if got, _ := s.Get(ctx, "k1"); got == nil || string(got.Payload) != "v2" {
+			t.Fatalf("expected v2 from the L2, got %v", got)
+		}
+	})
+
+	t.Run("L2 errors are returned", func(t *testing.T) {
+		s, l2 := newStorage()
+		l2.err = errors.New("connection refused")
+
+		if _, err := s.Get(ctx, "k1"); !errors.Is(err, l2.err) {
+			t.Fatalf("expected the L2 error, got %v", err)
+		}
+	})
+}
@@ -35,6 +35,12 @@ const (
 
 	// BackendRatelimit is the key used in the state bag to configure backend ratelimit in proxy
 	BackendRatelimit = "backend:ratelimit"
+
+	// BackendRetry is the key used in the state bag to configure the backend retry policy in proxy
+	BackendRetry = "backend:retry"
+
+	// BackendHedge is the key used in the state bag to configure request hedging in proxy
+	BackendHedge = "backend:hedge"
 )
 
 // FilterContext object providing state and information that is unique to a request.
@@ -320,6 +326,9 @@ const (
 	GrantClaimsQueryName                       = "grantClaimsQuery"
 	JwtValidationName                          = "jwtValidation"
 	JwtValidationKeysName                      = "jwtValidationKeys"
+	JwtValidationSecretName                    = "jwtValidationSecret"
+	DPoPName                                   = "dpop"
+	VerifyHmacSignatureName                    = "verifyHmacSignature"
 	JwtMetricsName                             = "jwtMetrics"
 	OAuthOidcUserInfoName                      = "oauthOidcUserInfo"
 	OAuthOidcAnyClaimsName                     = "oauthOidcAnyClaims"
@@ -339,8 +348,13 @@ const (
 	ClusterClientRatelimitName                 = "clusterClientRatelimit"
 	ClusterRatelimitName                       = "clusterRatelimit"
 	ClusterLeakyBucketRatelimitName            = "clusterLeakyBucketRatelimit"
+	ClusterTokenBucketRatelimitName            = "clusterTokenBucketRatelimit"
 	BackendRateLimitName                       = "backendRatelimit"
 	RatelimitFailClosedName                    = "ratelimitFailClosed"
+	RatelimitHeadersName                       = "ratelimitHeaders"
+	RatelimitCostName                          = "ratelimitCost"
+	RatelimitResponseCostName                  = "ratelimitResponseCost"
+	RatelimitShadowName                        = "ratelimitShadow"
 	LuaName                                    = "lua"
 	CorsOriginName                             = "corsOrigin"
 	HeaderToQueryName                          = "headerToQuery"
@@ -378,6 +392,9 @@ const (
 	EndpointCreatedName                        = "endpointCreated"
 	ConsistentHashKeyName                      = "consistentHashKey"
 	ConsistentHashBalanceFactorName            = "consistentHashBalanceFactor"
+	ActiveHealthCheckName                      = "activeHealthCheck"
+	RetryName                                  = "retry"
+	HedgeName                                  = "hedge"
 	OpaAuthorizeRequestName                    = "opaAuthorizeRequest"
 	OpaAuthorizeRequestWithBodyName            = "opaAuthorizeRequestWithBody"
 	OpaServeResponseName                       = "opaServeResponse"
@@ -390,6 +407,7 @@ const (
 	MtlsSanURI                                 = "mtlsSanURI"
 	MtlsCN                                     = "mtlsCN"
 	MtlsAuthn                                  = "mtlsAuthn"
+	MtlsRevocation                             = "mtlsRevocation"
 	AWSSigV4Name                               = "awsSigv4"
 	LoopbackIfStatus                           = "loopbackIfStatus"
 	CacheName                                  = "cache"
@@ -0,0 +1,119 @@
+/*
+Package hedge provides the hedge filter, which configures the proxy to send
+duplicate backend requests of slow requests to other endpoints of an LB
+route.
+
+When the backend has not responded within the configured delay, the proxy
+sends the same request to another endpoint selected by the load balancing
+algorithm of the route, up to the configured number of hedged requests. The
+first response is used, and the other requests are cancelled:
+
+	hedge("50ms")
+	hedge("50ms", 2)
+	hedge("50ms", 2, 1048576)
+
+Only requests with an idempotent method are hedged. The request body is
+buffered to send it again, when it is not larger than the maximum body size,
+which defaults to 64KiB. Requests with a larger body are not hedged.
+*/
+package hedge
+
+import (
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	DefaultMaxHedges   = 1
+	DefaultMaxBodySize = 1 << 16
+)
+
+// Policy configures the hedging of the backend requests of a route.
+type Policy struct {
+	// Delay is the time to wait for a response before sending the next
+	// hedged request.
+	Delay time.Duration
+
+	// MaxHedges is the maximum number of hedged requests sent in addition
+	// to the original request.
+	MaxHedges int
+
+	// MaxBodySize is the maximum size of the request body in bytes,
+	// that is buffered to send it with the hedged requests.
+	MaxBodySize int64
+}
+
+type (
+	spec   struct{}
+	filter struct {
+		policy *Policy
+	}
+)
+
+func getIntArg(a interface{}) (int, error) {
+	switch v := a.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	default:
+		return 0, filters.ErrInvalidFilterParameters
+	}
+}
+
+func getDurationArg(a interface{}) (time.Duration, error) {
+	if s, ok := a.(string); ok {
+		return time.ParseDuration(s)
+	}
+
+	i, err := getIntArg(a)
+	return time.Duration(i) * time.Millisecond, err
+}
+
+// NewHedge creates a filter spec for the hedge filter. The arguments are
+// the delay (milliseconds or duration string), the optional maximum
+// number of hedged requests, which defaults to one, and the optional
+// maximum size of the buffered request body in bytes:
+//
+//	hedge("50ms", 2, 1048576)
+func NewHedge() filters.Spec {
+	return spec{}
+}
+
+func (spec) Name() string { return filters.HedgeName }
+
+func (spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 || len(args) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	p := &Policy{MaxHedges: DefaultMaxHedges, MaxBodySize: DefaultMaxBodySize}
+
+	var err error
+	if p.Delay, err = getDurationArg(args[0]); err != nil || p.Delay <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if len(args) > 1 {
+		if p.MaxHedges, err = getIntArg(args[1]); err != nil || p.MaxHedges < 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 2 {
+		size, err := getIntArg(args[2])
+		if err != nil || size < 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+		p.MaxBodySize = int64(size)
+	}
+
+	return filter{policy: p}, nil
+}
+
+func (f filter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.BackendHedge] = f.policy
+}
+
+func (filter) Response(filters.FilterContext) {}
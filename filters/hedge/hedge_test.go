@@ -0,0 +1,71 @@
+package hedge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestCreateFilter(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		args   []interface{}
+		expect Policy
+		fail   bool
+	}{{
+		name: "no args",
+		fail: true,
+	}, {
+		name: "too many args",
+		args: []interface{}{"10ms", 1, 1, 1},
+		fail: true,
+	}, {
+		name: "invalid delay",
+		args: []interface{}{"foo"},
+		fail: true,
+	}, {
+		name: "zero delay",
+		args: []interface{}{0},
+		fail: true,
+	}, {
+		name: "invalid max hedges",
+		args: []interface{}{"10ms", 0},
+		fail: true,
+	}, {
+		name: "invalid max body size",
+		args: []interface{}{"10ms", 1, -1},
+		fail: true,
+	}, {
+		name:   "delay only",
+		args:   []interface{}{"10ms"},
+		expect: Policy{Delay: 10 * time.Millisecond, MaxHedges: 1, MaxBodySize: DefaultMaxBodySize},
+	}, {
+		name:   "delay in milliseconds",
+		args:   []interface{}{50.0, 3},
+		expect: Policy{Delay: 50 * time.Millisecond, MaxHedges: 3, MaxBodySize: DefaultMaxBodySize},
+	}, {
+		name:   "max body size",
+		args:   []interface{}{"10ms", 2, 1024.0},
+		expect: Policy{Delay: 10 * time.Millisecond, MaxHedges: 2, MaxBodySize: 1024},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewHedge().CreateFilter(tt.args)
+			if tt.fail {
+				assert.ErrorIs(t, err, filters.ErrInvalidFilterParameters)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expect, *f.(filter).policy)
+
+			ctx := &filtertest.Context{FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+			assert.Equal(t, f.(filter).policy, ctx.StateBag()[filters.BackendHedge])
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/routing"
+)
+
+// CostStateKey is the state bag key of the number of hits a request
+// costs the ratelimit filters of the route. Filters running before the
+// ratelimit filters can store an int to override the cost configured by
+// the ratelimitCost filter.
+const CostStateKey = "filter.ratelimit.cost"
+
+// chargeStateKey is the state bag key of the ratelimits to charge for
+// the response size.
+const chargeStateKey = "filter.ratelimit.charge"
+
+type costSpec struct{}
+
+type costFilter struct {
+	cost    int
+	methods map[string]int
+}
+
+type responseCostSpec struct{}
+
+type responseCostFilter struct {
+	bytes int64
+}
+
+type CostPostProcessor struct{}
+
+// charge is the ratelimit of an allowed request, that is charged for
+// the response size.
+type charge struct {
+	limit limit
+	key   string
+}
+
+func NewCostPostProcessor() *CostPostProcessor {
+	return &CostPostProcessor{}
+}
+
+// Do is implementing a PostProcessor interface to configure the
+// request and response costs of all ratelimit filters of the routes
+// having the ratelimitCost() or ratelimitResponseCost() filters.
+func (*CostPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	for _, r := range routes {
+		var (
+			cost   *costFilter
+			charge bool
+		)
+		for _, f := range r.Filters {
+			switch cf := f.Filter.(type) {
+			case *costFilter:
+				cost = cf
+			case *responseCostFilter:
+				charge = true
+			}
+		}
+
+		// no config changes detected
+		if cost == nil && !charge {
+			continue
+		}
+
+		for _, f := range r.Filters {
+			if rf, ok := f.Filter.(*filter); ok {
+				rf.cost = cost
+				rf.charge = charge
+			}
+		}
+	}
+	return routes
+}
+
+// NewCost creates a filter Spec, whose instances set the number of hits
+// a request costs the ratelimit filters of the route. The first
+// argument is the cost of all requests, optionally followed by pairs
+// of HTTP method and cost.
+//
+// Example to count a request as five hits:
+//
+//	export: Path("/export") -> ratelimitCost(5) -> clusterClientRatelimit("api", 100, "1m", "Authorization") -> "https://api.example.org";
+//
+// Example to count POST requests as ten hits and all other requests as one:
+//
+//	api: Path("/api") -> ratelimitCost(1, "POST", 10) -> clusterClientRatelimit("api", 100, "1m", "Authorization") -> "https://api.example.org";
+func NewCost() filters.Spec {
+	return &costSpec{}
+}
+
+func (*costSpec) Name() string {
+	return filters.RatelimitCostName
+}
+
+func (*costSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args)%2 != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	cost, err := natural(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	f := &costFilter{cost: cost}
+	for i := 1; i < len(args); i += 2 {
+		method, ok := args[i].(string)
+		if !ok || method == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		cost, err := natural(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		if f.methods == nil {
+			f.methods = make(map[string]int)
+		}
+		f.methods[strings.ToUpper(method)] = cost
+	}
+
+	return f, nil
+}
+
+func (f *costFilter) request(r *http.Request) int {
+	if cost, ok := f.methods[r.Method]; ok {
+		return cost
+	}
+	return f.cost
+}
+
+func (*costFilter) Request(filters.FilterContext) {}
+
+func (*costFilter) Response(filters.FilterContext) {}
+
+// NewResponseCost creates a filter Spec, whose instances charge the
+// ratelimit filters of the route one additional hit for each started
+// number of bytes of the response body, once the response is done.
+// Allowed requests are charged no more hits than the quota has left,
+// so expensive responses reject the next requests of the client.
+//
+// Example to charge one hit per started megabyte of the response:
+//
+//	export: Path("/export") -> ratelimitResponseCost(1048576) -> clusterClientRatelimit("api", 100, "1m", "Authorization") -> "https://api.example.org";
+func NewResponseCost() filters.Spec {
+	return &responseCostSpec{}
+}
+
+func (*responseCostSpec) Name() string {
+	return filters.RatelimitResponseCostName
+}
+
+func (*responseCostSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	bytes, err := natural(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &responseCostFilter{bytes: int64(bytes)}, nil
+}
+
+func (*responseCostFilter) Request(filters.FilterContext) {}
+
+func (f *responseCostFilter) Response(ctx filters.FilterContext) {
+	charges, _ := ctx.StateBag()[chargeStateKey].([]charge)
+	if len(charges) == 0 {
+		return
+	}
+
+	// the request is done before the response body, but the hits
+	// still have to be counted
+	reqCtx := context.WithoutCancel(ctx.Request().Context())
+	chargeAll := func(size int64) {
+		hits := int((size + f.bytes - 1) / f.bytes)
+		if hits <= 0 {
+			return
+		}
+		for _, c := range charges {
+			c.limit.Charge(reqCtx, c.key, hits)
+		}
+	}
+
+	rsp := ctx.Response()
+	switch {
+	case rsp.ContentLength >= 0:
+		chargeAll(rsp.ContentLength)
+	case rsp.Body != nil:
+		rsp.Body = &chargeBody{ReadCloser: rsp.Body, charge: chargeAll}
+	}
+}
+
+// addCharge stores the ratelimit of an allowed request to be charged by
+// the ratelimitResponseCost filter.
+func addCharge(ctx filters.FilterContext, l limit, key string) {
+	charges, _ := ctx.StateBag()[chargeStateKey].([]charge)
+	ctx.StateBag()[chargeStateKey] = append(charges, charge{limit: l, key: key})
+}
+
+// chargeBody counts the bytes of a response body of unknown length and
+// charges them when the body is read or closed.
+type chargeBody struct {
+	io.ReadCloser
+	size   int64
+	once   sync.Once
+	charge func(int64)
+}
+
+func (b *chargeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *chargeBody) Close() error {
+	b.done()
+	return b.ReadCloser.Close()
+}
+
+func (b *chargeBody) done() {
+	b.once.Do(func() { b.charge(b.size) })
+}
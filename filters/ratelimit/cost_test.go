@@ -0,0 +1,212 @@
+package ratelimit_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/builtin"
+	fratelimit "github.com/zalando/skipper/filters/ratelimit"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/proxy/proxytest"
+	"github.com/zalando/skipper/ratelimit"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCostArgs(t *testing.T) {
+	for _, tt := range []struct {
+		spec  filters.Spec
+		args  []interface{}
+		valid bool
+	}{
+		{spec: fratelimit.NewCost(), args: nil},
+		{spec: fratelimit.NewCost(), args: []interface{}{0}},
+		{spec: fratelimit.NewCost(), args: []interface{}{"1"}},
+		{spec: fratelimit.NewCost(), args: []interface{}{1, "POST"}},
+		{spec: fratelimit.NewCost(), args: []interface{}{1, 10, "POST"}},
+		{spec: fratelimit.NewCost(), args: []interface{}{1, "POST", 0}},
+		{spec: fratelimit.NewCost(), args: []interface{}{2}, valid: true},
+		{spec: fratelimit.NewCost(), args: []interface{}{1, "POST", 10, "delete", 5.0}, valid: true},
+		{spec: fratelimit.NewResponseCost(), args: nil},
+		{spec: fratelimit.NewResponseCost(), args: []interface{}{0}},
+		{spec: fratelimit.NewResponseCost(), args: []interface{}{"1MB"}},
+		{spec: fratelimit.NewResponseCost(), args: []interface{}{1024, 1}},
+		{spec: fratelimit.NewResponseCost(), args: []interface{}{1024}, valid: true},
+	} {
+		t.Run(fmt.Sprintf("%s%v", tt.spec.Name(), tt.args), func(t *testing.T) {
+			_, err := tt.spec.CreateFilter(tt.args)
+			if tt.valid && err != nil {
+				t.Errorf("Failed to create filter: %v", err)
+			} else if !tt.valid && err == nil {
+				t.Error("Failed to get an error for invalid args")
+			}
+		})
+	}
+}
+
+type setCostSpec struct{}
+type setCost struct{ cost interface{} }
+
+func (setCostSpec) Name() string { return "setCost" }
+
+func (setCostSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	return &setCost{cost: args[0]}, nil
+}
+
+func (f *setCost) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[fratelimit.CostStateKey] = f.cost
+}
+
+func (*setCost) Response(filters.FilterContext) {}
+
+func TestCost(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		filters  string
+		method   string
+		body     string
+		chunked  bool
+		requests int
+		status   int
+	}{
+		{
+			name:     "one hit by default",
+			filters:  `ratelimit(4, "1m")`,
+			requests: 4,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "fixed cost",
+			filters:  `ratelimitCost(2) -> ratelimit(4, "1m")`,
+			requests: 2,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "fixed cost exceeds the quota",
+			filters:  `ratelimitCost(2) -> ratelimit(4, "1m")`,
+			requests: 3,
+			status:   http.StatusTooManyRequests,
+		},
+		{
+			name:     "configured after the ratelimit filter",
+			filters:  `clientRatelimit(4, "1m", "X-Test") -> ratelimitCost(2)`,
+			requests: 3,
+			status:   http.StatusTooManyRequests,
+		},
+		{
+			name:     "cost of the method",
+			filters:  `ratelimitCost(1, "POST", 4) -> ratelimit(4, "1m")`,
+			method:   "POST",
+			requests: 2,
+			status:   http.StatusTooManyRequests,
+		},
+		{
+			name:     "default cost of other methods",
+			filters:  `ratelimitCost(1, "POST", 4) -> ratelimit(4, "1m")`,
+			requests: 4,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "cost from the state bag",
+			filters:  `ratelimitCost(1) -> setCost(3) -> ratelimit(4, "1m")`,
+			requests: 2,
+			status:   http.StatusTooManyRequests,
+		},
+		{
+			name:     "cost from the state bag set as a number",
+			filters:  `setCost(3.0) -> ratelimit(4, "1m")`,
+			requests: 2,
+			status:   http.StatusTooManyRequests,
+		},
+		{
+			name:     "response cost",
+			filters:  `ratelimitResponseCost(1000) -> ratelimit(10, "1m")`,
+			body:     strings.Repeat("x", 2500),
+			requests: 3,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "response cost exceeds the quota",
+			filters:  `ratelimitResponseCost(1000) -> ratelimit(10, "1m")`,
+			body:     strings.Repeat("x", 2500),
+			requests: 4,
+			status:   http.StatusTooManyRequests,
+		},
+		{
+			name:     "response cost of a response with unknown length",
+			filters:  `ratelimitResponseCost(1000) -> ratelimit(10, "1m")`,
+			body:     strings.Repeat("x", 2500),
+			chunked:  true,
+			requests: 4,
+			status:   http.StatusTooManyRequests,
+		},
+		{
+			name:     "response cost of an empty response",
+			filters:  `ratelimitResponseCost(1000) -> ratelimit(10, "1m")`,
+			requests: 10,
+			status:   http.StatusOK,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := ratelimit.NewRegistry()
+			defer reg.Close()
+
+			provider := fratelimit.NewRatelimitProvider(reg)
+			fr := builtin.MakeRegistry()
+			fr.Register(fratelimit.NewRatelimit(provider))
+			fr.Register(fratelimit.NewClientRatelimit(provider))
+			fr.Register(fratelimit.NewCost())
+			fr.Register(fratelimit.NewResponseCost())
+			fr.Register(setCostSpec{})
+
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.chunked {
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+				}
+				io.WriteString(w, tt.body)
+			}))
+			defer backend.Close()
+
+			r := &eskip.Route{
+				Filters: eskip.MustParseFilters(tt.filters),
+				Backend: backend.URL,
+			}
+
+			p := proxytest.WithParamsAndRoutingOptions(
+				fr,
+				proxy.Params{},
+				routing.Options{
+					PostProcessors: []routing.PostProcessor{
+						fratelimit.NewCostPostProcessor(),
+					},
+				}, r)
+			defer p.Close()
+
+			var rsp *http.Response
+			for range tt.requests {
+				req, err := http.NewRequest(tt.method, p.URL, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("X-Test", "foo")
+
+				rsp, err = p.Client().Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				io.Copy(io.Discard, rsp.Body)
+				rsp.Body.Close()
+			}
+
+			if rsp.StatusCode != tt.status {
+				t.Errorf("Failed to get status %d, got %d", tt.status, rsp.StatusCode)
+			}
+		})
+	}
+}
@@ -41,6 +41,13 @@ func (*FailClosedPostProcessor) Do(routes []*routing.Route) []*routing.Route {
 					lf.failClosed = true
 				}
 
+			// token bucket has no Settings
+			case filters.ClusterTokenBucketRatelimitName:
+				tf, ok := f.Filter.(*tokenBucketFilter)
+				if ok {
+					tf.failClosed = true
+				}
+
 			case filters.BackendRateLimitName:
 				bf, ok := f.Filter.(*BackendRatelimit)
 				if ok {
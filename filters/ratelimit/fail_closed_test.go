@@ -70,6 +70,18 @@ func TestFailureMode(t *testing.T) {
 			wantLimit:       true,
 			limitStatusCode: http.StatusTooManyRequests,
 		},
+		{
+			name:            "test clusterTokenBucketRatelimit fail open",
+			filters:         `clusterTokenBucketRatelimit("t", 1, "1s", 10)`,
+			wantLimit:       false,
+			limitStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:            "test clusterTokenBucketRatelimit fail closed",
+			filters:         `ratelimitFailClosed() -> clusterTokenBucketRatelimit("t", 1, "1s", 10)`,
+			wantLimit:       true,
+			limitStatusCode: http.StatusTooManyRequests,
+		},
 		{
 			name:            "test ratelimitFailClosed applies when placed after ratelimit filter",
 			filters:         `clusterRatelimit("t", 1, "1s") -> ratelimitFailClosed()`,
@@ -87,6 +99,7 @@ func TestFailureMode(t *testing.T) {
 			fr.Register(fratelimit.NewClusterRateLimit(provider))
 			fr.Register(fratelimit.NewClusterClientRateLimit(provider))
 			fr.Register(fratelimit.NewClusterLeakyBucketRatelimit(reg))
+			fr.Register(fratelimit.NewClusterTokenBucketRatelimit(reg))
 			fr.Register(fratelimit.NewBackendRatelimit())
 			fr.Register(fratelimit.NewFailClosed())
 
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/ratelimit"
+	"github.com/zalando/skipper/routing"
+)
+
+// quotaStateKey is the state bag key of the quota reported in the
+// RateLimit-Policy and RateLimit response headers.
+const quotaStateKey = "filter.ratelimit.quota"
+
+type headersSpec struct{}
+type headers struct{}
+type HeadersPostProcessor struct{}
+
+// quota is the state of a ratelimit reported in the response headers.
+type quota struct {
+	maxHits   int
+	window    time.Duration
+	remaining int
+	reset     time.Duration
+}
+
+func (q quota) header() http.Header {
+	return ratelimit.QuotaHeaders(q.maxHits, q.window, q.remaining, q.reset)
+}
+
+// setQuota stores the quota to report, unless another ratelimit filter of
+// the route has already stored a quota with fewer remaining hits.
+func setQuota(ctx filters.FilterContext, q quota) {
+	if current, ok := ctx.StateBag()[quotaStateKey].(quota); ok && current.remaining <= q.remaining {
+		return
+	}
+	ctx.StateBag()[quotaStateKey] = q
+}
+
+// setQuotaHeaders sets the headers of the stored quota on the response.
+func setQuotaHeaders(ctx filters.FilterContext) {
+	q, ok := ctx.StateBag()[quotaStateKey].(quota)
+	if !ok || ctx.Response() == nil {
+		return
+	}
+
+	rsp := ctx.Response()
+	if rsp.Header == nil {
+		rsp.Header = make(http.Header)
+	}
+	maps.Copy(rsp.Header, q.header())
+}
+
+func NewHeadersPostProcessor() *HeadersPostProcessor {
+	return &HeadersPostProcessor{}
+}
+
+// Do is implementing a PostProcessor interface to enable the
+// RateLimit-Policy and RateLimit response headers of all ratelimit
+// filters of the routes having the ratelimitHeaders() filter.
+func (*HeadersPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	for _, r := range routes {
+		var enabled bool
+		for _, f := range r.Filters {
+			if f.Name == filters.RatelimitHeadersName {
+				enabled = true
+				break
+			}
+		}
+
+		if !enabled {
+			continue
+		}
+
+		for _, f := range r.Filters {
+			switch rf := f.Filter.(type) {
+			case *filter:
+				rf.headers = true
+			case *leakyBucketFilter:
+				rf.headers = true
+			case *tokenBucketFilter:
+				rf.headers = true
+			}
+		}
+	}
+	return routes
+}
+
+// NewHeaders creates a filter Spec, whose instances enable the IETF draft
+// RateLimit-Policy and RateLimit response headers for all ratelimit filters
+// of the route, so clients can back off before they get rejected.
+//
+// Example:
+//
+//	api: Path("/api") -> ratelimitHeaders() -> clientRatelimit(100, "1m") -> "https://api.example.org";
+func NewHeaders() filters.Spec {
+	return &headersSpec{}
+}
+
+func (*headersSpec) Name() string {
+	return filters.RatelimitHeadersName
+}
+
+func (*headersSpec) CreateFilter([]interface{}) (filters.Filter, error) {
+	return &headers{}, nil
+}
+
+func (*headers) Request(filters.FilterContext) {}
+
+func (*headers) Response(filters.FilterContext) {}
@@ -0,0 +1,142 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/builtin"
+	fratelimit "github.com/zalando/skipper/filters/ratelimit"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/proxy/proxytest"
+	"github.com/zalando/skipper/ratelimit"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestQuotaHeaders(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		filters  string
+		requests int
+		status   int
+		policy   string
+		quota    string
+	}{
+		{
+			name:     "disabled by default",
+			filters:  `clientRatelimit(3, "1m", "X-Test")`,
+			requests: 1,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "allowed request",
+			filters:  `ratelimitHeaders() -> clientRatelimit(3, "1m", "X-Test")`,
+			requests: 1,
+			status:   http.StatusOK,
+			policy:   "3;w=60",
+			quota:    "limit=3, remaining=2, reset=60",
+		},
+		{
+			name:     "last allowed request",
+			filters:  `ratelimitHeaders() -> ratelimit(3, "1m")`,
+			requests: 3,
+			status:   http.StatusOK,
+			policy:   "3;w=60",
+			quota:    "limit=3, remaining=0, reset=60",
+		},
+		{
+			name:     "rejected request",
+			filters:  `ratelimitHeaders() -> clientRatelimit(3, "1m", "X-Test")`,
+			requests: 4,
+			status:   http.StatusTooManyRequests,
+			policy:   "3;w=60",
+			quota:    "limit=3, remaining=0, reset=60",
+		},
+		{
+			name:     "enabled when placed after the ratelimit filter",
+			filters:  `clientRatelimit(3, "1m", "X-Test") -> ratelimitHeaders()`,
+			requests: 1,
+			status:   http.StatusOK,
+			policy:   "3;w=60",
+			quota:    "limit=3, remaining=2, reset=60",
+		},
+		{
+			name:     "the ratelimit with the fewest remaining hits is reported",
+			filters:  `ratelimitHeaders() -> ratelimit(10, "1m") -> clientRatelimit(3, "10s", "X-Test")`,
+			requests: 2,
+			status:   http.StatusOK,
+			policy:   "3;w=10",
+			quota:    "limit=3, remaining=1, reset=10",
+		},
+		{
+			name:     "cluster ratelimit without backend",
+			filters:  `ratelimitHeaders() -> clusterRatelimit("test", 3, "1m")`,
+			requests: 1,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "no quota of zero max hits",
+			filters:  `ratelimitHeaders() -> ratelimit(0, "1m")`,
+			requests: 1,
+			status:   http.StatusTooManyRequests,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := ratelimit.NewRegistry()
+			defer reg.Close()
+
+			provider := fratelimit.NewRatelimitProvider(reg)
+			fr := builtin.MakeRegistry()
+			fr.Register(fratelimit.NewRatelimit(provider))
+			fr.Register(fratelimit.NewClientRatelimit(provider))
+			fr.Register(fratelimit.NewClusterRateLimit(provider))
+			fr.Register(fratelimit.NewHeaders())
+
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer backend.Close()
+
+			r := &eskip.Route{
+				Filters: eskip.MustParseFilters(tt.filters),
+				Backend: backend.URL,
+			}
+
+			p := proxytest.WithParamsAndRoutingOptions(
+				fr,
+				proxy.Params{},
+				routing.Options{
+					PostProcessors: []routing.PostProcessor{
+						fratelimit.NewHeadersPostProcessor(),
+					},
+				}, r)
+			defer p.Close()
+
+			var rsp *http.Response
+			for range tt.requests {
+				req, err := http.NewRequest("GET", p.URL, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("X-Test", "foo")
+
+				rsp, err = p.Client().Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rsp.Body.Close()
+			}
+
+			if rsp.StatusCode != tt.status {
+				t.Errorf("Failed to get status %d, got %d", tt.status, rsp.StatusCode)
+			}
+			if s := rsp.Header.Get(ratelimit.PolicyHeader); s != tt.policy {
+				t.Errorf("Failed to get %s header value, want %q, got %q", ratelimit.PolicyHeader, tt.policy, s)
+			}
+			if s := rsp.Header.Get(ratelimit.QuotaHeader); s != tt.quota {
+				t.Errorf("Failed to get %s header value, want %q, got %q", ratelimit.QuotaHeader, tt.quota, s)
+			}
+		})
+	}
+}
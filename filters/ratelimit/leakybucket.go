@@ -3,6 +3,7 @@ package ratelimit
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,6 +15,7 @@ import (
 
 type leakyBucket interface {
 	Add(ctx context.Context, label string, increment int) (added bool, retry time.Duration, err error)
+	Remaining(ctx context.Context, label string) (remaining int, reset time.Duration, err error)
 }
 
 type leakyBucketSpec struct {
@@ -23,8 +25,12 @@ type leakyBucketSpec struct {
 type leakyBucketFilter struct {
 	label      *eskip.Template
 	bucket     leakyBucket
+	capacity   int
+	emission   time.Duration
 	increment  int
 	failClosed bool
+	headers    bool
+	shadow     bool
 }
 
 // NewClusterLeakyBucketRatelimit creates a filter Spec, whose instances implement rate limiting using leaky bucket algorithm.
@@ -88,6 +94,8 @@ func (s *leakyBucketSpec) CreateFilter(args []interface{}) (filters.Filter, erro
 	return &leakyBucketFilter{
 		label:     eskip.NewTemplate(label),
 		bucket:    s.create(capacity, emission),
+		capacity:  capacity,
+		emission:  emission,
 		increment: increment,
 	}, nil
 }
@@ -103,7 +111,9 @@ func (f *leakyBucketFilter) Request(ctx filters.FilterContext) {
 	}
 	added, retry, err := f.bucket.Add(ctx.Request().Context(), label, f.increment)
 	if err != nil {
-		if f.failClosed {
+		if f.failClosed && f.shadow {
+			shadowReject(ctx, filters.ClusterLeakyBucketRatelimitName, "", label)
+		} else if f.failClosed {
 			header := http.Header{}
 			header.Set("Retry-After", "60")
 			fail(ctx, header)
@@ -111,18 +121,56 @@ func (f *leakyBucketFilter) Request(ctx filters.FilterContext) {
 		return
 	}
 	if added {
+		if q, ok := f.quota(ctx, label); ok {
+			setQuota(ctx, q)
+		}
 		return // allow if successfully added
 	}
 
+	if f.shadow {
+		shadowReject(ctx, filters.ClusterLeakyBucketRatelimitName, "", label)
+		return
+	}
+
 	header := http.Header{}
 	if retry > 0 {
 		header.Set("Retry-After", strconv.Itoa(int(retry/time.Second)))
 	}
+	if q, ok := f.quota(ctx, label); ok {
+		q.remaining = 0
+		setQuota(ctx, q)
+		maps.Copy(header, q.header())
+	}
 
 	fail(ctx, header)
 }
 
-func (*leakyBucketFilter) Response(filters.FilterContext) {}
+// quota returns the free capacity of the bucket to report in the response
+// headers, where the time window is the time to drain out a full bucket.
+func (f *leakyBucketFilter) quota(ctx filters.FilterContext, label string) (quota, bool) {
+	if !f.headers {
+		return quota{}, false
+	}
+
+	remaining, reset, err := f.bucket.Remaining(ctx.Request().Context(), label)
+	if err != nil {
+		ctx.Logger().Errorf("Failed to get the remaining capacity of the leaky bucket: %v", err)
+		return quota{}, false
+	}
+
+	return quota{
+		maxHits:   f.capacity,
+		window:    time.Duration(f.capacity) * f.emission,
+		remaining: remaining,
+		reset:     reset,
+	}, true
+}
+
+func (f *leakyBucketFilter) Response(ctx filters.FilterContext) {
+	if f.headers {
+		setQuotaHeaders(ctx)
+	}
+}
 
 func natural(arg interface{}) (n int, err error) {
 	n, err = getIntArg(arg)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/ratelimit"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -88,6 +89,10 @@ func (b leakyBucketFunc) Add(ctx context.Context, label string, increment int) (
 	return b(ctx, label, increment)
 }
 
+func (b leakyBucketFunc) Remaining(context.Context, string) (int, time.Duration, error) {
+	panic("unexpected Remaining call")
+}
+
 func TestLeakyBucketFilterRequest(t *testing.T) {
 	for _, test := range []struct {
 		name       string
@@ -183,3 +188,58 @@ func TestLeakyBucketFilterRequest(t *testing.T) {
 		})
 	}
 }
+
+type quotaBucket struct {
+	added     bool
+	remaining int
+}
+
+func (b *quotaBucket) Add(context.Context, string, int) (bool, time.Duration, error) {
+	return b.added, time.Second, nil
+}
+
+func (b *quotaBucket) Remaining(context.Context, string) (int, time.Duration, error) {
+	return b.remaining, 1500 * time.Millisecond, nil
+}
+
+func TestLeakyBucketFilterHeaders(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		bucket *quotaBucket
+		quota  string
+	}{
+		{
+			name:   "added",
+			bucket: &quotaBucket{added: true, remaining: 3},
+			quota:  "limit=4, remaining=3, reset=2",
+		},
+		{
+			name:   "rejected",
+			bucket: &quotaBucket{added: false, remaining: 1},
+			quota:  "limit=4, remaining=0, reset=2",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			spec := &leakyBucketSpec{
+				create: func(int, time.Duration) leakyBucket { return test.bucket },
+			}
+
+			f, err := spec.CreateFilter([]interface{}{"alabel", 2, "1s", 4, 1})
+			require.NoError(t, err)
+			f.(*leakyBucketFilter).headers = true
+
+			ctx := &filtertest.Context{
+				FRequest:  &http.Request{},
+				FStateBag: make(map[string]interface{}),
+			}
+			f.Request(ctx)
+			if !ctx.FServed {
+				ctx.FResponse = &http.Response{StatusCode: http.StatusOK}
+				f.Response(ctx)
+			}
+
+			assert.Equal(t, "4;w=2", ctx.FResponse.Header.Get(ratelimit.PolicyHeader))
+			assert.Equal(t, test.quota, ctx.FResponse.Header.Get(ratelimit.QuotaHeader))
+		})
+	}
+}
@@ -7,6 +7,8 @@ package ratelimit
 
 import (
 	"context"
+	"maps"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -30,7 +32,11 @@ type filter struct {
 	settings   ratelimit.Settings
 	provider   RatelimitProvider
 	statusCode int
-	maxHits    int // overrides settings.MaxHits
+	maxHits    int         // overrides settings.MaxHits
+	headers    bool        // enables the RateLimit-Policy and RateLimit headers
+	cost       *costFilter // sets the hits per request, defaults to one
+	charge     bool        // charges allowed requests for the response size
+	shadow     bool        // records instead of rejecting requests over the limit
 }
 
 // RatelimitProvider returns a limit instance for provided Settings
@@ -42,9 +48,24 @@ type limit interface {
 	// Allow is used to decide if call with context is allowed to pass
 	Allow(context.Context, string) bool
 
+	// AllowN is like Allow, but the call costs n hits
+	AllowN(context.Context, string, int) bool
+
+	// Charge counts up to n more hits for an already allowed call
+	Charge(context.Context, string, int)
+
 	// RetryAfter is used to inform the client how many seconds it
 	// should wait before making a new request
 	RetryAfter(string) int
+
+	// Remaining is used to get the number of calls left in the
+	// current time window and the duration until the full quota
+	// is available again
+	Remaining(context.Context, string) (int, time.Duration)
+
+	// Limit is used to get the maximum number of calls and the
+	// time window, which may be overridden at runtime
+	Limit(string) (int, time.Duration)
 }
 
 // RegistryAdapter adapts ratelimit.Registry to RateLimitProvider interface.
@@ -475,16 +496,92 @@ func (f *filter) Request(ctx filters.FilterContext) {
 		return
 	}
 
-	if !rateLimiter.Allow(ctx.Request().Context(), s) {
-		maxHits := f.settings.MaxHits
-		if f.maxHits != 0 {
-			maxHits = f.maxHits
+	if !rateLimiter.AllowN(ctx.Request().Context(), s, f.requestCost(ctx)) {
+		if f.shadow {
+			shadowReject(ctx, f.settings.Type.String(), f.settings.Group, s)
+			return
 		}
+
+		maxHits, timeWindow := f.limit(rateLimiter, s)
+		header := ratelimit.Headers(maxHits, timeWindow, rateLimiter.RetryAfter(s))
+		if q, ok := f.quota(ctx, rateLimiter, s); ok {
+			q.remaining = 0
+			setQuota(ctx, q)
+			maps.Copy(header, q.header())
+		}
+
 		ctx.Serve(&http.Response{
 			StatusCode: f.statusCode,
-			Header:     ratelimit.Headers(maxHits, f.settings.TimeWindow, rateLimiter.RetryAfter(s)),
+			Header:     header,
 		})
+		return
+	}
+
+	if f.charge {
+		addCharge(ctx, rateLimiter, s)
+	}
+
+	if q, ok := f.quota(ctx, rateLimiter, s); ok {
+		setQuota(ctx, q)
 	}
 }
 
-func (*filter) Response(filters.FilterContext) {}
+// requestCost returns the number of hits the request costs.
+func (f *filter) requestCost(ctx filters.FilterContext) int {
+	switch cost := ctx.StateBag()[CostStateKey].(type) {
+	case int:
+		return max(0, cost)
+	case float64: // e.g. set by the lua filter
+		return max(0, int(cost))
+	}
+
+	if f.cost != nil {
+		return f.cost.request(ctx.Request())
+	}
+	return 1
+}
+
+// limit returns the maximum number of hits and the time window of the
+// client, which may be overridden at runtime.
+func (f *filter) limit(rateLimiter limit, s string) (int, time.Duration) {
+	maxHits, timeWindow := rateLimiter.Limit(s)
+	if f.maxHits != 0 && f.settings.MaxHits != 0 {
+		// sharded cluster ratelimits limit every group shard
+		maxHits = maxHits * f.maxHits / f.settings.MaxHits
+	}
+	return maxHits, timeWindow
+}
+
+// quota returns the quota of the client to report in the response headers.
+// Disabled ratelimits and the ones without a backend never limit, and
+// have no quota to report.
+func (f *filter) quota(ctx filters.FilterContext, rateLimiter limit, s string) (quota, bool) {
+	if !f.headers {
+		return quota{}, false
+	}
+
+	remaining, reset := rateLimiter.Remaining(ctx.Request().Context(), s)
+	maxHits, timeWindow := f.limit(rateLimiter, s)
+	if remaining == math.MaxInt || maxHits <= 0 {
+		return quota{}, false
+	}
+
+	if f.maxHits != 0 && f.settings.MaxHits != 0 {
+		// sharded cluster ratelimits report the remaining hits of a
+		// single group shard
+		remaining = remaining * f.maxHits / f.settings.MaxHits
+	}
+
+	return quota{
+		maxHits:   maxHits,
+		window:    timeWindow,
+		remaining: min(remaining, maxHits),
+		reset:     reset,
+	}, true
+}
+
+func (f *filter) Response(ctx filters.FilterContext) {
+	if f.headers {
+		setQuotaHeaders(ctx)
+	}
+}
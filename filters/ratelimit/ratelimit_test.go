@@ -78,8 +78,16 @@ func (l *testLimit) get(s ratelimit.Settings) limit {
 	return l
 }
 
-func (l *testLimit) Allow(context.Context, string) bool { return false }
-func (l *testLimit) RetryAfter(string) int              { return 31415 }
+func (l *testLimit) Allow(context.Context, string) bool       { return false }
+func (l *testLimit) AllowN(context.Context, string, int) bool { return false }
+func (l *testLimit) Charge(context.Context, string, int)      { panic("unexpected Charge call") }
+func (l *testLimit) RetryAfter(string) int                    { return 31415 }
+func (l *testLimit) Limit(string) (int, time.Duration) {
+	return l.expected.MaxHits, l.expected.TimeWindow
+}
+func (l *testLimit) Remaining(context.Context, string) (int, time.Duration) {
+	panic("unexpected Remaining call")
+}
 
 func TestRateLimit(t *testing.T) {
 	test := func(
@@ -412,8 +420,14 @@ func (n *noLimit) get(ratelimit.Settings) limit {
 	}
 	return n
 }
-func (n *noLimit) Allow(context.Context, string) bool { return true }
-func (n *noLimit) RetryAfter(string) int              { panic("unexpected RetryAfter call") }
+func (n *noLimit) Allow(context.Context, string) bool       { return true }
+func (n *noLimit) AllowN(context.Context, string, int) bool { return true }
+func (n *noLimit) Charge(context.Context, string, int)      { panic("unexpected Charge call") }
+func (n *noLimit) RetryAfter(string) int                    { panic("unexpected RetryAfter call") }
+func (n *noLimit) Limit(string) (int, time.Duration)        { panic("unexpected Limit call") }
+func (n *noLimit) Remaining(context.Context, string) (int, time.Duration) {
+	panic("unexpected Remaining call")
+}
 
 func TestNilLimit(t *testing.T) {
 	f := &filter{provider: &noLimit{nilLimit: true}}
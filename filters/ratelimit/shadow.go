@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/accesslog"
+	"github.com/zalando/skipper/routing"
+)
+
+// ShadowAccessLogKey is the access log field listing the ratelimits,
+// which would have rejected the request in shadow mode.
+const ShadowAccessLogKey = "ratelimit-shadow"
+
+type shadowSpec struct{}
+type shadow struct{}
+type ShadowPostProcessor struct{}
+
+func NewShadowPostProcessor() *ShadowPostProcessor {
+	return &ShadowPostProcessor{}
+}
+
+// Do is implementing a PostProcessor interface to switch all ratelimit
+// filters of the routes having the ratelimitShadow() filter to shadow
+// mode.
+func (*ShadowPostProcessor) Do(routes []*routing.Route) []*routing.Route {
+	for _, r := range routes {
+		var enabled bool
+		for _, f := range r.Filters {
+			if f.Name == filters.RatelimitShadowName {
+				enabled = true
+				break
+			}
+		}
+
+		// no config changes detected
+		if !enabled {
+			continue
+		}
+
+		for _, f := range r.Filters {
+			switch rf := f.Filter.(type) {
+			case *filter:
+				rf.shadow = true
+			case *leakyBucketFilter:
+				rf.shadow = true
+			case *tokenBucketFilter:
+				rf.shadow = true
+			}
+		}
+	}
+	return routes
+}
+
+// NewShadow creates a filter Spec, whose instances switch the ratelimit
+// filters of the route to shadow mode. In shadow mode the ratelimits
+// count the requests as usual, but never reject them. Instead, the
+// requests that would have been rejected are counted in the
+// <filter>.custom.shadow.<route> metric, listed in the ratelimit-shadow
+// field of the access log and tagged on the request span.
+//
+// Example:
+//
+//	api: Path("/api") -> ratelimitShadow() -> clusterClientRatelimit("api", 100, "1m", "Authorization") -> "https://api.example.org";
+func NewShadow() filters.Spec {
+	return &shadowSpec{}
+}
+
+func (*shadowSpec) Name() string {
+	return filters.RatelimitShadowName
+}
+
+func (*shadowSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+	return &shadow{}, nil
+}
+
+func (*shadow) Request(filters.FilterContext) {}
+
+func (*shadow) Response(filters.FilterContext) {}
+
+// shadowReject records a request, that the ratelimit identified by name
+// and group would have rejected for the client key. The key may contain
+// credentials, e.g. the Authorization header, so only its hash is
+// recorded.
+func shadowReject(ctx filters.FilterContext, name, group, key string) {
+	ctx.Metrics().IncCounter("shadow." + ctx.RouteId())
+
+	h := sha256.Sum256([]byte(key))
+	keyHash := hex.EncodeToString(h[:8])
+
+	if span := opentracing.SpanFromContext(ctx.Request().Context()); span != nil {
+		span.SetTag("ratelimit.shadow", name)
+		if group != "" {
+			span.SetTag("ratelimit.shadow.group", group)
+		}
+		span.SetTag("ratelimit.shadow.key", keyHash)
+	}
+
+	entry := map[string]string{"filter": name, "key": keyHash}
+	if group != "" {
+		entry["group"] = group
+	}
+
+	bag := ctx.StateBag()
+	additionalData, ok := bag[accesslog.AccessLogAdditionalDataKey].(map[string]interface{})
+	if !ok {
+		additionalData = make(map[string]interface{})
+		bag[accesslog.AccessLogAdditionalDataKey] = additionalData
+	}
+	entries, _ := additionalData[ShadowAccessLogKey].([]map[string]string)
+	additionalData[ShadowAccessLogKey] = append(entries, entry)
+}
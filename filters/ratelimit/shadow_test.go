@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/accesslog"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/ratelimit"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestShadowArgs(t *testing.T) {
+	_, err := NewShadow().CreateFilter(nil)
+	assert.NoError(t, err)
+
+	_, err = NewShadow().CreateFilter([]interface{}{"foo"})
+	assert.Error(t, err)
+}
+
+func TestShadowPostProcessor(t *testing.T) {
+	shadowFilter, _ := NewShadow().CreateFilter(nil)
+	rf := &filter{}
+	lf := &leakyBucketFilter{}
+	tf := &tokenBucketFilter{}
+	other := &filter{}
+
+	NewShadowPostProcessor().Do([]*routing.Route{
+		{
+			Filters: []*routing.RouteFilter{
+				{Filter: rf, Name: filters.ClusterClientRatelimitName},
+				{Filter: shadowFilter, Name: filters.RatelimitShadowName},
+				{Filter: lf, Name: filters.ClusterLeakyBucketRatelimitName},
+				{Filter: tf, Name: filters.ClusterTokenBucketRatelimitName},
+			},
+		},
+		{
+			Filters: []*routing.RouteFilter{{Filter: other, Name: filters.RatelimitName}},
+		},
+	})
+
+	assert.True(t, rf.shadow)
+	assert.True(t, lf.shadow)
+	assert.True(t, tf.shadow)
+	assert.False(t, other.shadow)
+}
+
+func newShadowContext(t *testing.T, tracer *mocktracer.MockTracer) (*filtertest.Context, opentracing.Span) {
+	t.Helper()
+
+	span := tracer.StartSpan("test")
+	req, err := http.NewRequest("GET", "http://example.org", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "secret")
+
+	return &filtertest.Context{
+		FRequest:  req.WithContext(opentracing.ContextWithSpan(req.Context(), span)),
+		FStateBag: make(map[string]interface{}),
+		FMetrics:  &metricstest.MockMetrics{},
+		FRouteId:  "r1",
+	}, span
+}
+
+func TestShadowRatelimit(t *testing.T) {
+	reg := ratelimit.NewRegistry()
+	defer reg.Close()
+
+	spec := NewClientRatelimit(NewRatelimitProvider(reg))
+	f, err := spec.CreateFilter([]interface{}{1, "1m", "Authorization"})
+	require.NoError(t, err)
+	f.(*filter).shadow = true
+
+	tracer := mocktracer.New()
+	for i := range 3 {
+		ctx, span := newShadowContext(t, tracer)
+		f.Request(ctx)
+		span.Finish()
+
+		assert.False(t, ctx.FServed, "request %d", i)
+
+		additionalData, _ := ctx.FStateBag[accesslog.AccessLogAdditionalDataKey].(map[string]interface{})
+		if i == 0 {
+			assert.Nil(t, additionalData)
+			continue
+		}
+
+		entries := additionalData[ShadowAccessLogKey].([]map[string]string)
+		require.Len(t, entries, 1)
+		assert.Equal(t, filters.ClientRatelimitName, entries[0]["filter"])
+		assert.NotContains(t, entries[0]["key"], "secret")
+		assert.Len(t, entries[0]["key"], 16)
+
+		ctx.FMetrics.(*metricstest.MockMetrics).WithCounters(func(counters map[string]int64) {
+			assert.Equal(t, int64(1), counters["shadow.r1"])
+		})
+	}
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+	assert.Nil(t, spans[0].Tag("ratelimit.shadow"))
+	assert.Equal(t, filters.ClientRatelimitName, spans[1].Tag("ratelimit.shadow"))
+	assert.Equal(t, spans[1].Tag("ratelimit.shadow.key"), spans[2].Tag("ratelimit.shadow.key"))
+}
+
+func TestShadowBuckets(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		filter filters.Filter
+	}{
+		{
+			name: "leaky bucket",
+			filter: &leakyBucketFilter{
+				label:  eskip.NewTemplate("alabel"),
+				bucket: &quotaBucket{added: false},
+				shadow: true,
+			},
+		},
+		{
+			name: "leaky bucket fail closed",
+			filter: &leakyBucketFilter{
+				label: eskip.NewTemplate("alabel"),
+				bucket: leakyBucketFunc(func(context.Context, string, int) (bool, time.Duration, error) {
+					return false, 0, errors.New("test error")
+				}),
+				failClosed: true,
+				shadow:     true,
+			},
+		},
+		{
+			name: "token bucket",
+			filter: &tokenBucketFilter{
+				label:  eskip.NewTemplate("alabel"),
+				bucket: &quotaTokenBucket{taken: false},
+				cost:   1,
+				shadow: true,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, span := newShadowContext(t, mocktracer.New())
+			tt.filter.Request(ctx)
+			span.Finish()
+
+			assert.False(t, ctx.FServed)
+			additionalData := ctx.FStateBag[accesslog.AccessLogAdditionalDataKey].(map[string]interface{})
+			assert.Len(t, additionalData[ShadowAccessLogKey], 1)
+			assert.NotNil(t, span.(*mocktracer.MockSpan).Tag("ratelimit.shadow"))
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/ratelimit"
+)
+
+type tokenBucket interface {
+	Take(ctx context.Context, label string, cost int) (ratelimit.TokenBucketResult, error)
+}
+
+type tokenBucketSpec struct {
+	create func(burst int, refill time.Duration) tokenBucket
+}
+
+type tokenBucketFilter struct {
+	label      *eskip.Template
+	bucket     tokenBucket
+	burst      int
+	refill     time.Duration
+	cost       int
+	failClosed bool
+	headers    bool
+	shadow     bool
+}
+
+// NewClusterTokenBucketRatelimit creates a filter Spec, whose instances implement rate limiting using token bucket algorithm.
+//
+// The token bucket holds up to burst tokens and is refilled at a constant rate. Every request takes cost tokens
+// from the bucket and is rejected if there are not enough tokens left. This allows short bursts of requests,
+// while a sustained rate is enforced.
+// See https://en.wikipedia.org/wiki/Token_bucket
+//
+// Example to allow each unique Authorization header 10 requests per second with bursts of up to 50 requests:
+//
+//	clusterTokenBucketRatelimit("auth-${request.header.Authorization}", 10, "1s", 50)
+//
+// Example to take 5 tokens per request:
+//
+//	clusterTokenBucketRatelimit("auth-${request.header.Authorization}", 10, "1s", 50, 5)
+func NewClusterTokenBucketRatelimit(registry *ratelimit.Registry) filters.Spec {
+	return &tokenBucketSpec{
+		create: func(burst int, refill time.Duration) tokenBucket {
+			return ratelimit.NewClusterTokenBucket(registry, burst, refill)
+		},
+	}
+}
+
+func (s *tokenBucketSpec) Name() string {
+	return filters.ClusterTokenBucketRatelimitName
+}
+
+func (s *tokenBucketSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 4 && len(args) != 5 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	label, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	rate, err := natural(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	period, err := getDurationArg(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if period <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	burst, err := natural(args[3])
+	if err != nil {
+		return nil, err
+	}
+
+	cost := 1
+	if len(args) == 5 {
+		cost, err = natural(args[4])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cost > burst {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	// refill is the reciprocal of the rate
+	refill := period / time.Duration(rate)
+	if refill <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &tokenBucketFilter{
+		label:  eskip.NewTemplate(label),
+		bucket: s.create(burst, refill),
+		burst:  burst,
+		refill: refill,
+		cost:   cost,
+	}, nil
+}
+
+func (f *tokenBucketFilter) Request(ctx filters.FilterContext) {
+	label, ok := f.label.ApplyContext(ctx)
+	if !ok {
+		return // allow on missing placeholders
+	}
+	res, err := f.bucket.Take(ctx.Request().Context(), label, f.cost)
+	if err != nil {
+		if f.failClosed && f.shadow {
+			shadowReject(ctx, filters.ClusterTokenBucketRatelimitName, "", label)
+		} else if f.failClosed {
+			header := http.Header{}
+			header.Set("Retry-After", "60")
+			fail(ctx, header)
+		}
+		return
+	}
+	if res.Taken {
+		if q, ok := f.quota(res); ok {
+			setQuota(ctx, q)
+		}
+		return // allow if tokens were taken
+	}
+
+	if f.shadow {
+		shadowReject(ctx, filters.ClusterTokenBucketRatelimitName, "", label)
+		return
+	}
+
+	header := http.Header{}
+	if res.Retry > 0 {
+		header.Set("Retry-After", strconv.Itoa(int((res.Retry+time.Second-1)/time.Second)))
+	}
+	if q, ok := f.quota(res); ok {
+		q.remaining = 0
+		setQuota(ctx, q)
+		maps.Copy(header, q.header())
+	}
+
+	fail(ctx, header)
+}
+
+// quota returns the requests left in the bucket to report in the response
+// headers, where the time window is the time to refill an empty bucket.
+func (f *tokenBucketFilter) quota(res ratelimit.TokenBucketResult) (quota, bool) {
+	if !f.headers {
+		return quota{}, false
+	}
+
+	return quota{
+		maxHits:   f.burst / f.cost,
+		window:    time.Duration(f.burst) * f.refill,
+		remaining: res.Tokens / f.cost,
+		reset:     res.Reset,
+	}, true
+}
+
+func (f *tokenBucketFilter) Response(ctx filters.FilterContext) {
+	if f.headers {
+		setQuotaHeaders(ctx)
+	}
+}
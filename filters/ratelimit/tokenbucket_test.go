@@ -0,0 +1,257 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketFilterInvalidArgs(t *testing.T) {
+	spec := &tokenBucketSpec{
+		create: func(_ int, _ time.Duration) tokenBucket {
+			t.Fatal("unexpected call to create a bucket")
+			return nil
+		},
+	}
+	assert.Equal(t, filters.ClusterTokenBucketRatelimitName, spec.Name())
+
+	for i, test := range []struct {
+		args []interface{}
+	}{
+		{[]interface{}{"missing args"}},
+		{[]interface{}{"alabel", 1, "1s", 1, 1, "too many args"}},
+		{[]interface{}{123, 1, "1s", 1}},
+		{[]interface{}{"alabel", "invalid rate", "1s", 1}},
+		{[]interface{}{"alabel", 1, "invalid period", 1}},
+		{[]interface{}{"alabel", 1, "1s", "invalid burst"}},
+		{[]interface{}{"alabel", 1, "1s", 1, "invalid cost"}},
+		{[]interface{}{"zero rate", 0, "1s", 1}},
+		{[]interface{}{"zero period", 1, "0s", 1}},
+		{[]interface{}{"zero burst", 1, "1s", 0}},
+		{[]interface{}{"zero cost", 1, "1s", 1, 0}},
+		{[]interface{}{"cost exceeds burst", 1, "1s", 2, 3}},
+		{[]interface{}{"rate exceeds period resolution", 10, "1ns", 1}},
+	} {
+		t.Run(fmt.Sprintf("test#%d", i), func(t *testing.T) {
+			_, err := spec.CreateFilter(test.args)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTokenBucketFilterValidArgs(t *testing.T) {
+	for i, test := range []struct {
+		args         []interface{}
+		expectBurst  int
+		expectRefill time.Duration
+		expectCost   int
+	}{
+		{
+			args:         []interface{}{"alabel", 4, "1s", 10},
+			expectBurst:  10,
+			expectRefill: 250 * time.Millisecond,
+			expectCost:   1,
+		},
+		{
+			args:         []interface{}{"alabel", 4, "1s", 10, 5},
+			expectBurst:  10,
+			expectRefill: 250 * time.Millisecond,
+			expectCost:   5,
+		},
+		{
+			args:         []interface{}{"floatargs", 4.0, "1s", 10.0, 2.0},
+			expectBurst:  10,
+			expectRefill: 250 * time.Millisecond,
+			expectCost:   2,
+		},
+	} {
+		t.Run(fmt.Sprintf("test#%d", i), func(t *testing.T) {
+			spec := &tokenBucketSpec{
+				create: func(burst int, refill time.Duration) tokenBucket {
+					assert.Equal(t, test.expectBurst, burst)
+					assert.Equal(t, test.expectRefill, refill)
+					return nil
+				},
+			}
+
+			f, err := spec.CreateFilter(test.args)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectCost, f.(*tokenBucketFilter).cost)
+		})
+	}
+}
+
+type tokenBucketFunc func(context.Context, string, int) (bool, time.Duration, error)
+
+func (b tokenBucketFunc) Take(ctx context.Context, label string, cost int) (ratelimit.TokenBucketResult, error) {
+	taken, retry, err := b(ctx, label, cost)
+	return ratelimit.TokenBucketResult{Taken: taken, Retry: retry}, err
+}
+
+func TestTokenBucketFilterRequest(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		args       []interface{}
+		failClosed bool
+		take       func(*testing.T, string, int) (bool, time.Duration, error)
+		served     bool
+		status     int
+		retryAfter string
+	}{
+		{
+			name: "allow on missing placeholder",
+			args: []interface{}{"alabel-${missing}", 3, "1s", 2},
+			take: func(t *testing.T, _ string, _ int) (bool, time.Duration, error) {
+				t.Error("unexpected call on missing placeholder")
+				return false, 0, nil
+			},
+		},
+		{
+			name: "allow on error",
+			args: []interface{}{"alabel", 3, "1s", 2},
+			take: func(*testing.T, string, int) (bool, time.Duration, error) {
+				return false, 0, fmt.Errorf("oops")
+			},
+		},
+		{
+			name:       "deny on error when failing closed",
+			args:       []interface{}{"alabel", 3, "1s", 2},
+			failClosed: true,
+			take: func(*testing.T, string, int) (bool, time.Duration, error) {
+				return false, 0, fmt.Errorf("oops")
+			},
+			served:     true,
+			status:     429,
+			retryAfter: "60",
+		},
+		{
+			name: "allow on taken",
+			args: []interface{}{"alabel", 3, "1s", 2},
+			take: func(t *testing.T, label string, cost int) (bool, time.Duration, error) {
+				assert.Equal(t, "alabel", label)
+				assert.Equal(t, 1, cost)
+				return true, 0, nil
+			},
+		},
+		{
+			name: "allow with a placeholder and cost",
+			args: []interface{}{"alabel-${request.header.X-Foo}", 3, "1s", 4, 2},
+			take: func(t *testing.T, label string, cost int) (bool, time.Duration, error) {
+				assert.Equal(t, "alabel-bar", label)
+				assert.Equal(t, 2, cost)
+				return true, 0, nil
+			},
+		},
+		{
+			name: "deny",
+			args: []interface{}{"alabel", 3, "1s", 2},
+			take: func(t *testing.T, label string, cost int) (bool, time.Duration, error) {
+				assert.Equal(t, "alabel", label)
+				assert.Equal(t, 1, cost)
+				return false, 2100 * time.Millisecond, nil
+			},
+			served:     true,
+			status:     429,
+			retryAfter: "3",
+		},
+		{
+			name: "deny without retry when cost exceeds the burst",
+			args: []interface{}{"alabel", 3, "1s", 2},
+			take: func(*testing.T, string, int) (bool, time.Duration, error) {
+				return false, 0, nil
+			},
+			served: true,
+			status: 429,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			spec := &tokenBucketSpec{
+				create: func(burst int, refill time.Duration) tokenBucket {
+					return tokenBucketFunc(func(_ context.Context, label string, cost int) (bool, time.Duration, error) {
+						return test.take(t, label, cost)
+					})
+				},
+			}
+
+			f, err := spec.CreateFilter(test.args)
+			require.NoError(t, err)
+			f.(*tokenBucketFilter).failClosed = test.failClosed
+
+			ctx := &filtertest.Context{
+				FRequest: &http.Request{Header: http.Header{"X-Foo": []string{"bar"}}},
+			}
+
+			f.Request(ctx)
+
+			if test.served {
+				assert.True(t, ctx.FServed)
+				assert.Equal(t, test.status, ctx.FResponse.StatusCode)
+				assert.Equal(t, test.retryAfter, ctx.FResponse.Header.Get("Retry-After"))
+			} else {
+				assert.False(t, ctx.FServed)
+			}
+		})
+	}
+}
+
+type quotaTokenBucket struct {
+	taken     bool
+	remaining int
+}
+
+func (b *quotaTokenBucket) Take(context.Context, string, int) (ratelimit.TokenBucketResult, error) {
+	return ratelimit.TokenBucketResult{Taken: b.taken, Retry: time.Second, Tokens: b.remaining, Reset: 1500 * time.Millisecond}, nil
+}
+
+func TestTokenBucketFilterHeaders(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		bucket *quotaTokenBucket
+		quota  string
+	}{
+		{
+			name:   "taken",
+			bucket: &quotaTokenBucket{taken: true, remaining: 7},
+			quota:  "limit=5, remaining=3, reset=2",
+		},
+		{
+			name:   "rejected",
+			bucket: &quotaTokenBucket{taken: false, remaining: 1},
+			quota:  "limit=5, remaining=0, reset=2",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			spec := &tokenBucketSpec{
+				create: func(int, time.Duration) tokenBucket { return test.bucket },
+			}
+
+			f, err := spec.CreateFilter([]interface{}{"alabel", 2, "1s", 10, 2})
+			require.NoError(t, err)
+			f.(*tokenBucketFilter).headers = true
+
+			ctx := &filtertest.Context{
+				FRequest:  &http.Request{},
+				FStateBag: make(map[string]interface{}),
+			}
+			f.Request(ctx)
+			if !ctx.FServed {
+				ctx.FResponse = &http.Response{StatusCode: http.StatusOK}
+				f.Response(ctx)
+			}
+
+			assert.Equal(t, "5;w=5", ctx.FResponse.Header.Get(ratelimit.PolicyHeader))
+			assert.Equal(t, test.quota, ctx.FResponse.Header.Get(ratelimit.QuotaHeader))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Package retry provides the retry filter, which configures the proxy to
+retry failed backend requests.
+
+The filter stores a Policy in the state bag, and the proxy retries the
+backend request when the response status code or the failure matches the
+configured conditions:
+
+	retry(3)
+	retry(3, "500ms", "25ms", "250ms", 0.2, 502, 503, 504, "reset", "timeout")
+
+The arguments are the maximum number of attempts including the first one,
+the timeout of a single attempt, the base and the maximum of the
+exponential backoff, the retry budget ratio and the retry conditions. All
+arguments but the maximum number of attempts are optional.
+
+Only requests with an idempotent method and without a body are retried.
+Every retry picks a different endpoint of an LB backend when possible.
+
+The retry budget limits the number of retries of a route relative to the
+number of its requests: every request deposits the budget ratio into the
+budget, and every retry withdraws one, up to a small burst allowance.
+This prevents retry storms when the backend is overloaded.
+*/
+package retry
@@ -0,0 +1,240 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	DefaultBackoffBase = 25 * time.Millisecond
+	DefaultBackoffMax  = 250 * time.Millisecond
+	DefaultBudgetRatio = 0.2
+
+	// MaxBudget is the maximum number of retries a route can accumulate
+	// in its retry budget.
+	MaxBudget = 10.0
+
+	ConditionReset   = "reset"
+	ConditionTimeout = "timeout"
+)
+
+// DefaultStatusCodes are the response status codes retried when no
+// condition is configured.
+var DefaultStatusCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// Policy configures the retries of the backend requests of a route.
+type Policy struct {
+	// MaxAttempts is the maximum number of backend requests, including
+	// the first one.
+	MaxAttempts int
+
+	// PerTryTimeout is the timeout of a single backend request. Zero
+	// means no timeout besides the one of the route.
+	PerTryTimeout time.Duration
+
+	// BackoffBase and BackoffMax configure the exponential backoff
+	// between two attempts.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+
+	// BudgetRatio is the number of retries allowed per request of the
+	// route.
+	BudgetRatio float64
+
+	// StatusCodes are the backend response status codes to retry.
+	StatusCodes []int
+
+	// OnReset enables retrying connection failures.
+	OnReset bool
+
+	// OnTimeout enables retrying backend timeouts.
+	OnTimeout bool
+
+	budget *budget
+}
+
+type budget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+type (
+	spec   struct{}
+	filter struct {
+		policy *Policy
+	}
+)
+
+func newBudget(ratio float64) *budget {
+	return &budget{ratio: ratio, tokens: MaxBudget}
+}
+
+func (b *budget) deposit() {
+	b.mu.Lock()
+	b.tokens = min(b.tokens+b.ratio, MaxBudget)
+	b.mu.Unlock()
+}
+
+func (b *budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// RetryStatus tells whether a backend response with the given status code
+// should be retried.
+func (p *Policy) RetryStatus(code int) bool {
+	return slices.Contains(p.StatusCodes, code)
+}
+
+// Backoff returns the time to wait before the given retry, starting from
+// one. It uses exponential backoff with full jitter.
+func (p *Policy) Backoff(retry int) time.Duration {
+	if p.BackoffBase <= 0 {
+		return 0
+	}
+
+	d := p.BackoffMax
+	if retry < 32 {
+		if e := p.BackoffBase << (retry - 1); e > 0 && e < d {
+			d = e
+		}
+	}
+
+	return rand.N(d + 1)
+}
+
+// AllowRetry withdraws one retry from the budget of the route. It returns
+// false when the budget is exhausted.
+func (p *Policy) AllowRetry() bool {
+	if p.budget == nil {
+		return true
+	}
+
+	return p.budget.withdraw()
+}
+
+func getIntArg(a interface{}) (int, error) {
+	switch v := a.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	default:
+		return 0, filters.ErrInvalidFilterParameters
+	}
+}
+
+func getDurationArg(a interface{}) (time.Duration, error) {
+	if s, ok := a.(string); ok {
+		return time.ParseDuration(s)
+	}
+
+	i, err := getIntArg(a)
+	return time.Duration(i) * time.Millisecond, err
+}
+
+// NewRetry creates a filter spec for the retry filter. The filter enables
+// retrying the backend requests of the route:
+//
+//	retry(3)
+//	retry(3, "500ms", "25ms", "250ms", 0.2, 502, 503, 504, "reset", "timeout")
+//
+// The arguments are the maximum number of attempts, the per try timeout,
+// the backoff base and maximum (milliseconds or duration string), the retry
+// budget ratio, and the retry conditions: status codes, "reset" for
+// connection failures and "timeout" for backend timeouts. By default, 502,
+// 503, 504, resets and timeouts are retried.
+func NewRetry() filters.Spec {
+	return spec{}
+}
+
+func (spec) Name() string { return filters.RetryName }
+
+func (spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	p := &Policy{
+		BackoffBase: DefaultBackoffBase,
+		BackoffMax:  DefaultBackoffMax,
+		BudgetRatio: DefaultBudgetRatio,
+	}
+
+	var err error
+	if p.MaxAttempts, err = getIntArg(args[0]); err != nil || p.MaxAttempts < 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if len(args) > 1 {
+		if p.PerTryTimeout, err = getDurationArg(args[1]); err != nil || p.PerTryTimeout < 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 2 {
+		if p.BackoffBase, err = getDurationArg(args[2]); err != nil || p.BackoffBase < 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 3 {
+		if p.BackoffMax, err = getDurationArg(args[3]); err != nil || p.BackoffMax < p.BackoffBase {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	} else if p.BackoffMax < p.BackoffBase {
+		p.BackoffMax = p.BackoffBase
+	}
+
+	if len(args) > 4 {
+		var ok bool
+		p.BudgetRatio, ok = args[4].(float64)
+		if !ok || p.BudgetRatio <= 0 || p.BudgetRatio > 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 5 {
+		for _, a := range args[5:] {
+			switch a {
+			case ConditionReset:
+				p.OnReset = true
+			case ConditionTimeout:
+				p.OnTimeout = true
+			default:
+				code, err := getIntArg(a)
+				if err != nil || code < 100 || code > 599 {
+					return nil, filters.ErrInvalidFilterParameters
+				}
+				p.StatusCodes = append(p.StatusCodes, code)
+			}
+		}
+	} else {
+		p.StatusCodes = slices.Clone(DefaultStatusCodes)
+		p.OnReset = true
+		p.OnTimeout = true
+	}
+
+	p.budget = newBudget(p.BudgetRatio)
+	return filter{policy: p}, nil
+}
+
+func (f filter) Request(ctx filters.FilterContext) {
+	f.policy.budget.deposit()
+	ctx.StateBag()[filters.BackendRetry] = f.policy
+}
+
+func (filter) Response(filters.FilterContext) {}
@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestCreateFilter(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		args   []interface{}
+		expect Policy
+		fail   bool
+	}{{
+		name: "no args",
+		fail: true,
+	}, {
+		name: "invalid attempts",
+		args: []interface{}{0},
+		fail: true,
+	}, {
+		name: "invalid per try timeout",
+		args: []interface{}{3, "foo"},
+		fail: true,
+	}, {
+		name: "backoff max less than base",
+		args: []interface{}{3, 0, "100ms", "10ms"},
+		fail: true,
+	}, {
+		name: "invalid budget ratio",
+		args: []interface{}{3, 0, 0, 0, 1.5},
+		fail: true,
+	}, {
+		name: "invalid condition",
+		args: []interface{}{3, 0, 0, 0, 0.1, "foo"},
+		fail: true,
+	}, {
+		name: "invalid status",
+		args: []interface{}{3, 0, 0, 0, 0.1, 1000},
+		fail: true,
+	}, {
+		name: "attempts only",
+		args: []interface{}{3.0},
+		expect: Policy{
+			MaxAttempts: 3,
+			BackoffBase: DefaultBackoffBase,
+			BackoffMax:  DefaultBackoffMax,
+			BudgetRatio: DefaultBudgetRatio,
+			StatusCodes: DefaultStatusCodes,
+			OnReset:     true,
+			OnTimeout:   true,
+		},
+	}, {
+		name: "all args",
+		args: []interface{}{2, "1s", 10, "1s", 0.5, 500, 503.0, "timeout"},
+		expect: Policy{
+			MaxAttempts:   2,
+			PerTryTimeout: time.Second,
+			BackoffBase:   10 * time.Millisecond,
+			BackoffMax:    time.Second,
+			BudgetRatio:   0.5,
+			StatusCodes:   []int{500, 503},
+			OnTimeout:     true,
+		},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewRetry().CreateFilter(tt.args)
+			if tt.fail {
+				assert.ErrorIs(t, err, filters.ErrInvalidFilterParameters)
+				return
+			}
+
+			require.NoError(t, err)
+
+			p := *f.(filter).policy
+			p.budget = nil
+			assert.Equal(t, tt.expect, p)
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	f, err := NewRetry().CreateFilter([]interface{}{2})
+	require.NoError(t, err)
+
+	ctx := &filtertest.Context{FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+
+	p, ok := ctx.StateBag()[filters.BackendRetry].(*Policy)
+	require.True(t, ok)
+	assert.True(t, p.RetryStatus(http.StatusBadGateway))
+	assert.False(t, p.RetryStatus(http.StatusInternalServerError))
+}
+
+func TestBudget(t *testing.T) {
+	b := newBudget(0.5)
+	for range int(MaxBudget) {
+		assert.True(t, b.withdraw())
+	}
+	assert.False(t, b.withdraw())
+
+	b.deposit()
+	assert.False(t, b.withdraw())
+
+	b.deposit()
+	assert.True(t, b.withdraw())
+	assert.False(t, b.withdraw())
+
+	for range 100 {
+		b.deposit()
+	}
+	assert.Equal(t, MaxBudget, b.tokens)
+}
+
+func TestBackoff(t *testing.T) {
+	p := &Policy{BackoffBase: 10 * time.Millisecond, BackoffMax: 50 * time.Millisecond}
+	for retry, limit := range map[int]time.Duration{
+		1:  10 * time.Millisecond,
+		2:  20 * time.Millisecond,
+		3:  40 * time.Millisecond,
+		4:  50 * time.Millisecond,
+		64: 50 * time.Millisecond,
+	} {
+		for range 100 {
+			d := p.Backoff(retry)
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			assert.LessOrEqual(t, d, limit)
+		}
+	}
+
+	assert.Zero(t, (&Policy{}).Backoff(1))
+}
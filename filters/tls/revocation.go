@@ -0,0 +1,101 @@
+package tls
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/secrets/revocation"
+)
+
+const (
+	failOpen   = "fail-open"
+	failClosed = "fail-closed"
+)
+
+const revoked rejectReason = "revoked"
+
+type revocationSpec struct {
+	checker *revocation.Checker
+}
+
+type revocationFilter struct {
+	checker    *revocation.Checker
+	failClosed bool
+}
+
+// NewMtlsRevocation returns a filter spec for the mtlsRevocation filter,
+// which checks the revocation status of the client certificate chain
+// with the given checker. The checker is nil, when revocation checking
+// is not configured, and then the filter can not be created.
+//
+// The filter takes optionally "fail-open" or "fail-closed" to override
+// the mode of the checker for the route.
+func NewMtlsRevocation(checker *revocation.Checker) filters.Spec {
+	return &revocationSpec{checker: checker}
+}
+
+func (*revocationSpec) Name() string {
+	return filters.MtlsRevocation
+}
+
+func (s *revocationSpec) CreateFilter(args []any) (filters.Filter, error) {
+	if s.checker == nil {
+		return nil, errors.New("mtlsRevocation: revocation checking is not configured")
+	}
+	if len(args) > 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &revocationFilter{
+		checker:    s.checker,
+		failClosed: s.checker.FailClosed(),
+	}
+
+	if len(args) == 1 {
+		mode, ok := args[0].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		switch mode {
+		case failOpen:
+			f.failClosed = false
+		case failClosed:
+			f.failClosed = true
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func (f *revocationFilter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	if req.TLS == nil {
+		reject(ctx, http.StatusUnauthorized, "no tls", missingTLS, req.Host)
+		return
+	}
+
+	if len(req.TLS.PeerCertificates) == 0 && len(req.TLS.VerifiedChains) == 0 {
+		reject(ctx, http.StatusUnauthorized, "no client certificate", missingTLS, req.Host)
+		return
+	}
+
+	// only the verified chain is checked, the status of unverified
+	// certificates is unknown
+	if err := f.checker.CheckConnection(req.Context(), *req.TLS, f.failClosed); err != nil {
+		reject(ctx, http.StatusForbidden, clientSubject(req.TLS)+": "+err.Error(), revoked, req.Host)
+	}
+}
+
+func (*revocationFilter) Response(filters.FilterContext) {}
+
+func clientSubject(cs *tls.ConnectionState) string {
+	if len(cs.VerifiedChains) > 0 {
+		return cs.VerifiedChains[0][0].Subject.String()
+	}
+	return cs.PeerCertificates[0].Subject.String()
+}
@@ -0,0 +1,197 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/secrets/revocation"
+)
+
+// newRevocationChecker creates a CA with a CRL revoking the serial number
+// 3, and returns the checker and the chains of a good, a revoked and an
+// uncovered certificate.
+func newRevocationChecker(t *testing.T, failClosed bool) (*revocation.Checker, []*x509.Certificate, []*x509.Certificate, []*x509.Certificate) {
+	t.Helper()
+
+	newCA := func() (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "test CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		require.NoError(t, err)
+
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert, key
+	}
+
+	issue := func(ca *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64) []*x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "client"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, key.Public(), caKey)
+		require.NoError(t, err)
+
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return []*x509.Certificate{cert, ca}
+	}
+
+	ca, caKey := newCA()
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{{
+			SerialNumber:   big.NewInt(3),
+			RevocationTime: time.Now().Add(-time.Minute),
+		}},
+	}, ca, caKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ca.crl")
+	require.NoError(t, os.WriteFile(path, crl, 0600))
+
+	checker, err := revocation.New(revocation.Options{CRLs: []string{path}, FailClosed: failClosed})
+	require.NoError(t, err)
+	t.Cleanup(checker.Close)
+
+	otherCA, otherKey := newCA()
+	return checker, issue(ca, caKey, 2), issue(ca, caKey, 3), issue(otherCA, otherKey, 2)
+}
+
+func TestMtlsRevocationCreateFilter(t *testing.T) {
+	checker, _, _, _ := newRevocationChecker(t, false)
+
+	spec := NewMtlsRevocation(checker)
+	assert.Equal(t, filters.MtlsRevocation, spec.Name())
+
+	for _, tt := range []struct {
+		name    string
+		args    []any
+		wantErr bool
+	}{
+		{name: "no args", args: nil},
+		{name: "fail-open", args: []any{"fail-open"}},
+		{name: "fail-closed", args: []any{"fail-closed"}},
+		{name: "invalid mode", args: []any{"fail"}, wantErr: true},
+		{name: "not a string", args: []any{true}, wantErr: true},
+		{name: "too many args", args: []any{"fail-open", "fail-closed"}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := spec.CreateFilter(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	_, err := NewMtlsRevocation(nil).CreateFilter(nil)
+	assert.Error(t, err, "revocation checking not configured")
+}
+
+func TestMtlsRevocation(t *testing.T) {
+	checker, good, revoked, uncovered := newRevocationChecker(t, false)
+
+	for _, tt := range []struct {
+		name  string
+		args  []any
+		state *tls.ConnectionState
+		want  int
+	}{
+		{name: "no tls", state: nil, want: http.StatusUnauthorized},
+		{name: "no client certificate", state: &tls.ConnectionState{}, want: http.StatusUnauthorized},
+		{name: "good", state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{good}}, want: http.StatusOK},
+		{name: "revoked", state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{revoked}}, want: http.StatusForbidden},
+		{name: "unverified revoked certificates fail-open", state: &tls.ConnectionState{PeerCertificates: revoked}, want: http.StatusOK},
+		{name: "unverified revoked certificates fail-closed", args: []any{"fail-closed"}, state: &tls.ConnectionState{PeerCertificates: revoked}, want: http.StatusForbidden},
+		{name: "revoked fail-open", args: []any{"fail-open"}, state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{revoked}}, want: http.StatusForbidden},
+		{name: "unknown fail-open", state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{uncovered}}, want: http.StatusOK},
+		{name: "unknown fail-closed", args: []any{"fail-closed"}, state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{uncovered}}, want: http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewMtlsRevocation(checker).CreateFilter(tt.args)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", "https://example.org", nil)
+			require.NoError(t, err)
+			req.TLS = tt.state
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+
+			if tt.want == http.StatusOK {
+				assert.False(t, ctx.FServed)
+			} else {
+				require.True(t, ctx.FServed)
+				assert.Equal(t, tt.want, ctx.FResponse.StatusCode)
+			}
+		})
+	}
+}
+
+func TestMtlsRevocationFailClosedChecker(t *testing.T) {
+	checker, _, _, uncovered := newRevocationChecker(t, true)
+
+	for _, tt := range []struct {
+		name string
+		args []any
+		want int
+	}{
+		{name: "checker mode", want: http.StatusForbidden},
+		{name: "fail-open override", args: []any{"fail-open"}, want: http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewMtlsRevocation(checker).CreateFilter(tt.args)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", "https://example.org", nil)
+			require.NoError(t, err)
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{uncovered}}
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+
+			if tt.want == http.StatusOK {
+				assert.False(t, ctx.FServed)
+			} else {
+				require.True(t, ctx.FServed)
+				assert.Equal(t, tt.want, ctx.FResponse.StatusCode)
+			}
+		})
+	}
+}
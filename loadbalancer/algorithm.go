@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"math"
 	"math/rand/v2"
+	"slices"
 	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,6 +15,7 @@ import (
 	"github.com/cespare/xxhash/v2"
 	log "github.com/sirupsen/logrus"
 	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/metrics"
 	snet "github.com/zalando/skipper/net"
 	"github.com/zalando/skipper/routing"
 )
@@ -44,9 +47,31 @@ const (
 	// factor, defined as inflight requests divided by the endpoint weight.
 	// Ties are broken by smooth weighted round-robin over the tied endpoints.
 	LeastRequests
+
+	// RingHash indicates choice between the backends based on a hash ring, in
+	// which every endpoint is represented proportionally to its weight.
+	RingHash
+
+	// Maglev indicates choice between the backends based on the lookup table
+	// of the Maglev consistent hashing algorithm.
+	Maglev
 )
 
 const powerOfRandomNChoicesDefaultN = 2
+const (
+	// DefaultRingHashEndpointEntries is the default number of entries of
+	// the endpoint with the smallest weight in the hash ring of the
+	// ringHash algorithm.
+	DefaultRingHashEndpointEntries = 1024
+
+	// ringHashMaxRingSize limits the size of the hash ring, about 4MB,
+	// when there are many endpoints or their weights are very different.
+	ringHashMaxRingSize = 1 << 18
+
+	// maglevTableSize is the size of the Maglev lookup table, it needs
+	// to be a prime number.
+	maglevTableSize = 65537
+)
 const (
 	ConsistentHashKey           = "consistentHashKey"
 	ConsistentHashBalanceFactor = "consistentHashBalanceFactor"
@@ -60,12 +85,18 @@ var (
 		PowerOfRandomNChoices: newPowerOfRandomNChoices,
 		WeightedRoundRobin:    newWeightedRoundRobin,
 		LeastRequests:         newLeastRequests,
+		RingHash:              newRingHash,
+		Maglev:                newMaglev,
 	}
 	defaultAlgorithm = newRoundRobin
 )
 
 type roundRobin struct {
 	index int64
+
+	// used only when the endpoints have static weights
+	mu             sync.Mutex
+	currentWeights map[string]float64
 }
 
 func newRoundRobin(endpoints []string) routing.LBAlgorithm {
@@ -74,14 +105,26 @@ func newRoundRobin(endpoints []string) routing.LBAlgorithm {
 	}
 }
 
-// Apply implements routing.LBAlgorithm with a roundrobin algorithm.
+// Apply implements routing.LBAlgorithm with a roundrobin algorithm. When the
+// endpoints have static weights, it uses smooth weighted roundrobin.
 func (r *roundRobin) Apply(ctx *routing.LBContext) routing.LBEndpoint {
 	if len(ctx.LBEndpoints) == 1 {
 		return ctx.LBEndpoints[0]
 	}
 
 	choice := int(atomic.AddInt64(&r.index, 1) % int64(len(ctx.LBEndpoints))) // #nosec
-	return ctx.LBEndpoints[choice]
+	if !hasStaticWeights(ctx.LBEndpoints) {
+		return ctx.LBEndpoints[choice]
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.currentWeights == nil {
+		r.currentWeights = make(map[string]float64, len(ctx.LBEndpoints))
+	}
+
+	return ctx.LBEndpoints[smoothWeightedChoice(r.currentWeights, ctx.LBEndpoints, choice, staticWeight)]
 }
 
 type random struct {
@@ -97,15 +140,94 @@ func newRandom(endpoints []string) routing.LBAlgorithm {
 }
 
 // Apply implements routing.LBAlgorithm with a stateless random algorithm.
+// When the endpoints have static weights, the probability of choosing an
+// endpoint is proportional to its weight.
 func (r *random) Apply(ctx *routing.LBContext) routing.LBEndpoint {
 	if len(ctx.LBEndpoints) == 1 {
 		return ctx.LBEndpoints[0]
 	}
 
 	r.mu.Lock()
-	choice := r.rnd.IntN(len(ctx.LBEndpoints)) // #nosec
-	r.mu.Unlock()
-	return ctx.LBEndpoints[choice]
+	defer r.mu.Unlock()
+
+	return ctx.LBEndpoints[randomChoice(r.rnd, ctx.LBEndpoints)]
+}
+
+// randomChoice returns the index of a random endpoint, chosen with a
+// probability proportional to its static weight.
+func randomChoice(rnd *rand.Rand, endpoints []routing.LBEndpoint) int {
+	if !hasStaticWeights(endpoints) {
+		return rnd.IntN(len(endpoints)) // #nosec
+	}
+
+	total := 0.0
+	for _, e := range endpoints {
+		total += staticWeight(e)
+	}
+
+	x := rnd.Float64() * total // #nosec
+	for i, e := range endpoints {
+		x -= staticWeight(e)
+		if x < 0 {
+			return i
+		}
+	}
+
+	return len(endpoints) - 1
+}
+
+// staticWeight returns the static weight of the endpoint, or 1 when it was
+// not set in the route definition.
+func staticWeight(e routing.LBEndpoint) float64 {
+	if e.Weight > 0 {
+		return e.Weight
+	}
+
+	return 1
+}
+
+func hasStaticWeights(endpoints []routing.LBEndpoint) bool {
+	for _, e := range endpoints {
+		if e.Weight > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// endpointWeight returns the weight of the endpoint as the product of its
+// static weight and the dynamic weight tracked by the endpoint registry.
+func endpointWeight(e routing.LBEndpoint) float64 {
+	return staticWeight(e) * e.Metrics.Weight()
+}
+
+// smoothWeightedChoice returns the index of the endpoint selected by smooth
+// weighted roundrobin, see https://github.com/nginx/nginx/commit/52327e0627f49dbda1e8db695e63a4b0af4448b1.
+// Each endpoint accumulates its weight per round and the endpoint with the
+// highest accumulated weight is selected and reduced by the total weight of
+// the round. Iteration starts at the offset so that ties are not biased
+// towards the first endpoint of the list.
+func smoothWeightedChoice(currentWeights map[string]float64, endpoints []routing.LBEndpoint, offset int, weight func(routing.LBEndpoint) float64) int {
+	ne := len(endpoints)
+	total := 0.0
+	best := 0
+	bestWeight := math.Inf(-1)
+	for k := 0; k < ne; k++ {
+		i := (offset + k) % ne
+		w := weight(endpoints[i])
+		total += w
+
+		cw := currentWeights[endpoints[i].Host] + w
+		currentWeights[endpoints[i].Host] = cw
+		if cw > bestWeight {
+			bestWeight = cw
+			best = i
+		}
+	}
+	currentWeights[endpoints[best].Host] -= total
+
+	return best
 }
 
 type (
@@ -223,13 +345,9 @@ func (ch *consistentHash) Apply(ctx *routing.LBContext) routing.LBEndpoint {
 }
 
 func (ch *consistentHash) chooseConsistentHashEndpoint(ctx *routing.LBContext) int {
-	key, ok := ctx.Params[ConsistentHashKey].(string)
-	if !ok {
-		key = snet.RemoteHost(ctx.Request).String()
-	}
-	balanceFactor, ok := ctx.Params[ConsistentHashBalanceFactor].(float64)
+	key, balanceFactor, bounded := consistentHashParams(ctx)
 	var choice int
-	if !ok {
+	if !bounded {
 		choice = ch.search(key, ctx)
 	} else {
 		choice = ch.boundedLoadSearch(key, balanceFactor, ctx)
@@ -238,6 +356,206 @@ func (ch *consistentHash) chooseConsistentHashEndpoint(ctx *routing.LBContext) i
 	return choice
 }
 
+// consistentHashParams returns the request key and the balance factor set by
+// the consistentHashKey and consistentHashBalanceFactor filters. The key
+// defaults to the remote host of the request.
+func consistentHashParams(ctx *routing.LBContext) (key string, balanceFactor float64, bounded bool) {
+	key, ok := ctx.Params[ConsistentHashKey].(string)
+	if !ok {
+		key = snet.RemoteHost(ctx.Request).String()
+	}
+	balanceFactor, bounded = ctx.Params[ConsistentHashBalanceFactor].(float64)
+	return
+}
+
+// newRingHash creates a ringHash algorithm with equal endpoint weights and
+// the default minimum ring size.
+func newRingHash(endpoints []string) routing.LBAlgorithm {
+	return newRingHashInternal(endpoints, nil, DefaultRingHashEndpointEntries)
+}
+
+// newRingHashInternal creates a hash ring in which every endpoint is
+// represented by a number of hashes proportional to its weight, see
+// https://www.envoyproxy.io/docs/envoy/latest/intro/arch_overview/upstream/load_balancing/load_balancers#ring-hash.
+// The endpoint with the smallest weight has endpointEntries entries, so
+// that the number of entries of an endpoint does not change when other
+// endpoints with the same weight are added or removed, and only the keys
+// of those endpoints are remapped. Above ringHashMaxRingSize entries, the
+// ring is scaled down to that size. Nil weights mean equal weights. The
+// resulting ring is searched the same way as the ring of the
+// consistentHash algorithm.
+func newRingHashInternal(endpoints []string, weights []float64, endpointEntries int) routing.LBAlgorithm {
+	normalized := normalizeWeights(len(endpoints), weights)
+	minWeight := slices.Min(normalized)
+	scale := min(float64(endpointEntries)/minWeight, ringHashMaxRingSize)
+
+	ch := &consistentHash{
+		hashRing: make([]endpointHash, 0, int(scale)+len(endpoints)),
+	}
+
+	var key []byte
+	for i, ep := range endpoints {
+		// the entries are the hashes of "<endpoint>-<j>"
+		key = append(append(key[:0], ep...), '-')
+		prefix := len(key)
+
+		n := int(math.Ceil(scale * normalized[i]))
+		for j := range n {
+			key = strconv.AppendInt(key[:prefix], int64(j), 10)
+			ch.hashRing = append(ch.hashRing, endpointHash{i, xxhash.Sum64(key)})
+		}
+	}
+	sort.Sort(ch)
+	return ch
+}
+
+// normalizeWeights returns the weights scaled to sum up to one. Missing or
+// invalid weights are treated as equal weights.
+func normalizeWeights(n int, weights []float64) []float64 {
+	result := make([]float64, n)
+	sum := 0.0
+	for i := range result {
+		w := 1.0
+		if i < len(weights) && weights[i] > 0 {
+			w = weights[i]
+		}
+		result[i] = w
+		sum += w
+	}
+
+	for i := range result {
+		result[i] /= sum
+	}
+
+	return result
+}
+
+type maglev struct {
+	table []int // index of the endpoint in every slot of the lookup table
+}
+
+// newMaglev creates a maglev algorithm with equal endpoint weights.
+func newMaglev(endpoints []string) routing.LBAlgorithm {
+	return newMaglevInternal(endpoints, nil, maglevTableSize)
+}
+
+// newMaglevInternal populates the lookup table of the Maglev algorithm, see
+// https://research.google/pubs/maglev-a-fast-and-reliable-software-network-load-balancer/.
+// Every endpoint fills the slots of its own permutation of the table in
+// turns, and the endpoints with a smaller weight skip their turn
+// proportionally. The table size needs to be a prime number larger than the
+// number of endpoints.
+func newMaglevInternal(endpoints []string, weights []float64, tableSize int) routing.LBAlgorithm {
+	normalized := normalizeWeights(len(endpoints), weights)
+	maxWeight := slices.Max(normalized)
+
+	offsets := make([]uint64, len(endpoints))
+	skips := make([]uint64, len(endpoints))
+	for i, ep := range endpoints {
+		offsets[i] = hash(ep+"-offset") % uint64(tableSize)
+		skips[i] = hash(ep+"-skip")%uint64(tableSize-1) + 1
+	}
+
+	m := &maglev{table: make([]int, tableSize)}
+	for i := range m.table {
+		m.table[i] = -1
+	}
+
+	next := make([]uint64, len(endpoints))
+	counts := make([]float64, len(endpoints))
+	filled := 0
+	for iteration := 0.0; filled < tableSize; iteration++ {
+		for i := range endpoints {
+			if iteration*normalized[i] < counts[i]*maxWeight {
+				continue
+			}
+
+			slot := (offsets[i] + next[i]*skips[i]) % uint64(tableSize)
+			for m.table[slot] >= 0 {
+				next[i]++
+				slot = (offsets[i] + next[i]*skips[i]) % uint64(tableSize)
+			}
+
+			m.table[slot] = i
+			next[i]++
+			counts[i]++
+			filled++
+			if filled == tableSize {
+				break
+			}
+		}
+	}
+
+	return m
+}
+
+// Apply implements routing.LBAlgorithm with the Maglev consistent hashing
+// algorithm. When the selected endpoint is skipped, e.g. fading in or
+// unhealthy, or it is above the target load of the balance factor, the
+// following slots of the lookup table are checked. Every endpoint is
+// checked only once, and the search stops when all the endpoints of the
+// route were checked.
+func (m *maglev) Apply(ctx *routing.LBContext) routing.LBEndpoint {
+	if len(ctx.LBEndpoints) == 1 {
+		return ctx.LBEndpoints[0]
+	}
+
+	key, balanceFactor, bounded := consistentHashParams(ctx)
+	targetLoad := math.Inf(1)
+	if bounded {
+		targetLoad = computeLoadAverage(ctx) * balanceFactor
+	}
+
+	belowTarget := func(index int) bool {
+		return float64(ctx.Route.LBEndpoints[index].Metrics.InflightRequests()) <= targetLoad
+	}
+
+	slot := hash(key) % uint64(len(m.table))
+	index := m.table[slot]
+	skipped := skipEndpoint(ctx, index)
+	if !skipped && belowTarget(index) {
+		return ctx.Route.LBEndpoints[index]
+	}
+
+	choice := -1
+	if !skipped {
+		choice = index
+	}
+
+	// every endpoint has at least one slot, so all of them are visited
+	// within one round of the table
+	visited := make([]bool, len(ctx.Route.LBEndpoints))
+	visited[index] = true
+	for remaining := len(visited) - 1; remaining > 0; {
+		slot = (slot + 1) % uint64(len(m.table))
+		index = m.table[slot]
+		if visited[index] {
+			continue
+		}
+
+		visited[index] = true
+		remaining--
+		if skipEndpoint(ctx, index) {
+			continue
+		}
+
+		if choice < 0 {
+			choice = index
+		}
+
+		if belowTarget(index) {
+			choice = index
+			break
+		}
+	}
+
+	if choice < 0 {
+		return ctx.LBEndpoints[0]
+	}
+
+	return ctx.Route.LBEndpoints[choice]
+}
+
 type powerOfRandomNChoices struct {
 	mu              sync.Mutex
 	rnd             *rand.Rand
@@ -253,16 +571,16 @@ func newPowerOfRandomNChoices([]string) routing.LBAlgorithm {
 }
 
 // Apply implements routing.LBAlgorithm with power of random N choices algorithm.
+// When the endpoints have static weights, the choices are made proportionally
+// to the weights, and the inflight requests are scaled by the weights.
 func (p *powerOfRandomNChoices) Apply(ctx *routing.LBContext) routing.LBEndpoint {
-	ne := len(ctx.LBEndpoints)
-
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	best := ctx.LBEndpoints[p.rnd.IntN(ne)] // #nosec
+	best := ctx.LBEndpoints[randomChoice(p.rnd, ctx.LBEndpoints)]
 
 	for i := 1; i < p.numberOfChoices; i++ {
-		ce := ctx.LBEndpoints[p.rnd.IntN(ne)] // #nosec
+		ce := ctx.LBEndpoints[randomChoice(p.rnd, ctx.LBEndpoints)]
 
 		if p.getScore(ce) > p.getScore(best) {
 			best = ce
@@ -271,10 +589,11 @@ func (p *powerOfRandomNChoices) Apply(ctx *routing.LBContext) routing.LBEndpoint
 	return best
 }
 
-// getScore returns negative value of inflightrequests count.
-func (p *powerOfRandomNChoices) getScore(e routing.LBEndpoint) int64 {
+// getScore returns negative value of inflightrequests count divided by the
+// static weight of the endpoint.
+func (p *powerOfRandomNChoices) getScore(e routing.LBEndpoint) float64 {
 	// endpoints with higher inflight request should have lower score
-	return -int64(e.Metrics.InflightRequests())
+	return -float64(e.Metrics.InflightRequests()) / staticWeight(e)
 }
 
 type weightedRoundRobin struct {
@@ -294,12 +613,10 @@ func newWeightedRoundRobin(endpoints []string) routing.LBAlgorithm {
 }
 
 // Apply implements routing.LBAlgorithm with a smooth weighted roundrobin
-// algorithm, see https://github.com/nginx/nginx/commit/52327e0627f49dbda1e8db695e63a4b0af4448b1.
-// Each endpoint accumulates its weight per round and the endpoint with the
-// highest accumulated weight is selected and reduced by the total weight of
-// the round. With equal weights it behaves like the roundrobin algorithm.
-// Iteration starts at a random offset so that ties are not biased towards
-// the first endpoint of the list.
+// algorithm, using the dynamic weights of the endpoints multiplied by their
+// static weights. With equal weights it behaves like the roundrobin
+// algorithm. Iteration starts at a random offset so that ties are not biased
+// towards the first endpoint of the list.
 func (w *weightedRoundRobin) Apply(ctx *routing.LBContext) routing.LBEndpoint {
 	ne := len(ctx.LBEndpoints)
 	if ne == 1 {
@@ -309,26 +626,8 @@ func (w *weightedRoundRobin) Apply(ctx *routing.LBContext) routing.LBEndpoint {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	total := 0.0
-	best := 0
-	bestWeight := math.Inf(-1)
 	offset := w.rnd.IntN(ne) // #nosec
-	for k := 0; k < ne; k++ {
-		i := (offset + k) % ne
-		e := &ctx.LBEndpoints[i]
-		weight := e.Metrics.Weight()
-		total += weight
-
-		cw := w.currentWeights[e.Host] + weight
-		w.currentWeights[e.Host] = cw
-		if cw > bestWeight {
-			bestWeight = cw
-			best = i
-		}
-	}
-	w.currentWeights[ctx.LBEndpoints[best].Host] -= total
-
-	return ctx.LBEndpoints[best]
+	return ctx.LBEndpoints[smoothWeightedChoice(w.currentWeights, ctx.LBEndpoints, offset, endpointWeight)]
 }
 
 func newLeastRequests(endpoints []string) routing.LBAlgorithm {
@@ -377,7 +676,7 @@ func (l *leastRequests) Apply(ctx *routing.LBContext) routing.LBEndpoint {
 			continue
 		}
 
-		weight := e.Metrics.Weight()
+		weight := endpointWeight(e)
 		total += weight
 
 		cw := l.currentWeights[e.Host] + weight
@@ -410,18 +709,68 @@ func (l *leastRequests) findBestByLoadFactor(endpoints []routing.LBEndpoint) (i
 }
 
 func getLoadFactor(ep routing.LBEndpoint) float64 {
-	return float64(ep.Metrics.InflightRequests()) / ep.Metrics.Weight()
+	return float64(ep.Metrics.InflightRequests()) / endpointWeight(ep)
+}
+
+// Options configure the algorithm provider.
+type Options struct {
+	// RingHashEndpointEntries is the number of entries of the endpoint with
+	// the smallest weight in the hash ring of the ringHash algorithm.
+	// Defaults to DefaultRingHashEndpointEntries.
+	RingHashEndpointEntries int
+
+	// Zone enables zone aware load balancing when set. The routes with
+	// endpoints both in this zone and in other zones prefer the endpoints
+	// in this zone.
+	Zone string
+
+	// ZoneAwareMinHealthyPercent is the percentage of healthy endpoints in
+	// the local zone, below which a proportional share of the requests is
+	// sent to the other zones. Defaults to DefaultZoneAwareMinHealthyPercent.
+	ZoneAwareMinHealthyPercent float64
+
+	// Metrics is used to report the cross-zone decisions of the zone aware
+	// load balancing. Defaults to metrics.Default.
+	Metrics metrics.Metrics
 }
 
 type (
-	algorithmProvider   struct{}
+	algorithmProvider struct {
+		ringHashEndpointEntries int
+		zone                    string
+		zoneMinHealthy          float64
+		metrics                 metrics.Metrics
+	}
 	initializeAlgorithm func(endpoints []string) routing.LBAlgorithm
 )
 
 // NewAlgorithmProvider creates a routing.PostProcessor used to initialize
 // the algorithm of load balancing routes.
 func NewAlgorithmProvider() routing.PostProcessor {
-	return &algorithmProvider{}
+	return NewAlgorithmProviderWithOptions(Options{})
+}
+
+// NewAlgorithmProviderWithOptions creates a routing.PostProcessor used to
+// initialize the algorithm of load balancing routes with custom options.
+func NewAlgorithmProviderWithOptions(o Options) routing.PostProcessor {
+	if o.RingHashEndpointEntries <= 0 {
+		o.RingHashEndpointEntries = DefaultRingHashEndpointEntries
+	}
+
+	if o.ZoneAwareMinHealthyPercent <= 0 || o.ZoneAwareMinHealthyPercent > 100 {
+		o.ZoneAwareMinHealthyPercent = DefaultZoneAwareMinHealthyPercent
+	}
+
+	if o.Metrics == nil {
+		o.Metrics = metrics.Default
+	}
+
+	return &algorithmProvider{
+		ringHashEndpointEntries: o.RingHashEndpointEntries,
+		zone:                    o.Zone,
+		zoneMinHealthy:          o.ZoneAwareMinHealthyPercent / 100,
+		metrics:                 o.Metrics,
+	}
 }
 
 // AlgorithmFromString parses the string representation of the algorithm definition.
@@ -443,6 +792,10 @@ func AlgorithmFromString(a string) (Algorithm, error) {
 		return WeightedRoundRobin, nil
 	case "leastRequests":
 		return LeastRequests, nil
+	case "ringHash":
+		return RingHash, nil
+	case "maglev":
+		return Maglev, nil
 	default:
 		return None, errors.New("unsupported algorithm")
 	}
@@ -463,6 +816,10 @@ func (a Algorithm) String() string {
 		return "weightedRoundRobin"
 	case LeastRequests:
 		return "leastRequests"
+	case RingHash:
+		return "ringHash"
+	case Maglev:
+		return "maglev"
 	default:
 		return ""
 	}
@@ -480,24 +837,60 @@ func parseEndpoints(r *routing.Route) error {
 			Scheme: scheme,
 			Host:   host,
 			Zone:   e.Zone,
+			Weight: e.Weight,
 		}
 	}
 
 	return nil
 }
 
-func setAlgorithm(r *routing.Route) error {
+// staticWeights returns the static weights of the endpoints, or nil when
+// none of them has a weight.
+func staticWeights(endpoints []*eskip.LBEndpoint) []float64 {
+	var weights []float64
+	for i, e := range endpoints {
+		if e.Weight <= 0 {
+			continue
+		}
+
+		if weights == nil {
+			weights = make([]float64, len(endpoints))
+		}
+
+		weights[i] = e.Weight
+	}
+
+	return weights
+}
+
+func (p *algorithmProvider) setAlgorithm(r *routing.Route) error {
 	t, err := AlgorithmFromString(r.Route.LBAlgorithm)
 	if err != nil {
 		return err
 	}
 
-	initialize := defaultAlgorithm
-	if t != None {
-		initialize = algorithms[t]
+	endpoints := eskip.LBEndpointString(r.Route.LBEndpoints)
+	switch t {
+	case None:
+		r.LBAlgorithm = defaultAlgorithm(endpoints)
+	case RingHash:
+		r.LBAlgorithm = newRingHashInternal(endpoints, staticWeights(r.Route.LBEndpoints), p.ringHashEndpointEntries)
+	case Maglev:
+		r.LBAlgorithm = newMaglevInternal(endpoints, staticWeights(r.Route.LBEndpoints), maglevTableSize)
+	default:
+		r.LBAlgorithm = algorithms[t](endpoints)
+	}
+
+	if p.zone != "" && hasMixedZones(r.Route.LBEndpoints, p.zone) {
+		r.LBAlgorithm = &zoneAware{
+			algorithm:  r.LBAlgorithm,
+			zone:       p.zone,
+			minHealthy: p.zoneMinHealthy,
+			metrics:    p.metrics,
+			now:        time.Now,
+		}
 	}
 
-	r.LBAlgorithm = initialize(eskip.LBEndpointString(r.Route.LBEndpoints))
 	return nil
 }
 
@@ -520,7 +913,7 @@ func (p *algorithmProvider) Do(r []*routing.Route) []*routing.Route {
 			continue
 		}
 
-		if err := setAlgorithm(ri); err != nil {
+		if err := p.setAlgorithm(ri); err != nil {
 			log.Errorf("failed to set LB algorithm implementation for route %s: %v", ri.Id, err)
 			continue
 		}
@@ -986,3 +986,295 @@ func TestConsistentHashBoundedLoadSearchSkipsFilteredEndpoints(t *testing.T) {
 	}
 
 }
+
+func TestRingHashAndMaglev(t *testing.T) {
+	for _, tc := range []struct {
+		algorithm Algorithm
+		// maglev only minimizes the disruption of the lookup table, while
+		// the ring entries of the remaining endpoints don't change
+		maxRemappedRemaining int
+	}{
+		{algorithm: RingHash, maxRemappedRemaining: 0},
+		{algorithm: Maglev, maxRemappedRemaining: 10},
+	} {
+		algorithm := tc.algorithm
+		t.Run(algorithm.String(), func(t *testing.T) {
+			apply := func(key string, endpoints []string) string {
+				r := NewAlgorithmProvider().Do([]*routing.Route{{
+					Route: eskip.Route{
+						BackendType: eskip.LBBackend,
+						LBAlgorithm: algorithm.String(),
+						LBEndpoints: eskip.NewLBEndpoints(endpoints),
+					},
+				}})[0]
+				endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+				defer endpointRegistry.Close()
+				endpointRegistry.Do([]*routing.Route{r})
+
+				ctx := &routing.LBContext{Route: r, LBEndpoints: r.LBEndpoints, Params: map[string]interface{}{ConsistentHashKey: key}}
+				return r.LBAlgorithm.Apply(ctx).Host
+			}
+
+			endpoints := []string{"http://127.0.0.1:8080", "http://127.0.0.2:8080", "http://127.0.0.3:8080", "http://127.0.0.4:8080"}
+			const keys = 1000
+
+			selected := make(map[string]string)
+			for i := range keys {
+				key := fmt.Sprintf("10.0.0.%d", i)
+				selected[key] = apply(key, endpoints)
+			}
+
+			// remove an endpoint, only its keys should be remapped
+			removed := "127.0.0.4:8080"
+			remapped, remappedRemaining := 0, 0
+			for key, host := range selected {
+				if h := apply(key, endpoints[:3]); h != host {
+					remapped++
+					if host != removed {
+						remappedRemaining++
+					}
+				}
+			}
+
+			assert.InDelta(t, keys/4, remapped, keys/10)
+			assert.LessOrEqual(t, remappedRemaining, tc.maxRemappedRemaining, "keys remapped from the remaining endpoints")
+		})
+	}
+}
+
+func TestRingHashAndMaglevSkipEndpoints(t *testing.T) {
+	endpoints := []string{"http://127.0.0.1:8080", "http://127.0.0.2:8080", "http://127.0.0.3:8080"}
+	for _, algorithm := range []Algorithm{RingHash, Maglev} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			route := NewAlgorithmProvider().Do([]*routing.Route{{
+				Route: eskip.Route{
+					BackendType: eskip.LBBackend,
+					LBAlgorithm: algorithm.String(),
+					LBEndpoints: eskip.NewLBEndpoints(endpoints),
+				},
+			}})[0]
+			endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+			defer endpointRegistry.Close()
+			endpointRegistry.Do([]*routing.Route{route})
+
+			for i := range 100 {
+				ctx := &routing.LBContext{
+					Route:       route,
+					LBEndpoints: route.LBEndpoints[1:],
+					Params:      map[string]interface{}{ConsistentHashKey: fmt.Sprintf("key-%d", i)},
+				}
+				assert.NotEqual(t, route.LBEndpoints[0].Host, route.LBAlgorithm.Apply(ctx).Host)
+			}
+		})
+	}
+}
+
+func TestMaglevBoundedLoad(t *testing.T) {
+	endpoints := []string{"http://127.0.0.1:8080", "http://127.0.0.2:8080", "http://127.0.0.3:8080"}
+	route := NewAlgorithmProvider().Do([]*routing.Route{{
+		Route: eskip.Route{
+			BackendType: eskip.LBBackend,
+			LBAlgorithm: Maglev.String(),
+			LBEndpoints: eskip.NewLBEndpoints(endpoints),
+		},
+	}})[0]
+	endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer endpointRegistry.Close()
+	endpointRegistry.Do([]*routing.Route{route})
+
+	ctx := &routing.LBContext{
+		Route:       route,
+		LBEndpoints: route.LBEndpoints,
+		Params:      map[string]interface{}{ConsistentHashKey: "somekey", ConsistentHashBalanceFactor: 1.25},
+	}
+
+	nonBounded := route.LBAlgorithm.Apply(&routing.LBContext{Route: route, LBEndpoints: route.LBEndpoints, Params: map[string]interface{}{ConsistentHashKey: "somekey"}})
+	assert.Equal(t, nonBounded, route.LBAlgorithm.Apply(ctx), "no endpoint is overloaded")
+
+	addInflightRequests(endpointRegistry, nonBounded, 20)
+	failover := route.LBAlgorithm.Apply(ctx)
+	assert.NotEqual(t, nonBounded, failover, "the selected endpoint is overloaded")
+
+	addInflightRequests(endpointRegistry, failover, 20)
+	assert.NotContains(t, []routing.LBEndpoint{nonBounded, failover}, route.LBAlgorithm.Apply(ctx), "only the last endpoint is below the target load")
+}
+
+func TestMaglevBoundedLoadManyEndpoints(t *testing.T) {
+	var endpoints []string
+	for i := range 100 {
+		endpoints = append(endpoints, fmt.Sprintf("http://10.0.%d.%d:8080", i/250, i%250+1))
+	}
+
+	route := NewAlgorithmProvider().Do([]*routing.Route{{
+		Route: eskip.Route{
+			BackendType: eskip.LBBackend,
+			LBAlgorithm: Maglev.String(),
+			LBEndpoints: eskip.NewLBEndpoints(endpoints),
+		},
+	}})[0]
+	endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer endpointRegistry.Close()
+	endpointRegistry.Do([]*routing.Route{route})
+
+	// only the last of the available endpoints is below the target load
+	available := route.LBEndpoints[50:]
+	for _, ep := range available[:len(available)-1] {
+		addInflightRequests(endpointRegistry, ep, 20)
+	}
+
+	for i := range 100 {
+		ctx := &routing.LBContext{
+			Route:       route,
+			LBEndpoints: available,
+			Params:      map[string]interface{}{ConsistentHashKey: fmt.Sprintf("key-%d", i), ConsistentHashBalanceFactor: 1.0},
+		}
+		assert.Equal(t, available[len(available)-1].Host, route.LBAlgorithm.Apply(ctx).Host)
+	}
+}
+
+func TestRingHashAndMaglevWeights(t *testing.T) {
+	endpoints := []string{"http://127.0.0.1:8080", "http://127.0.0.2:8080", "http://127.0.0.3:8080"}
+	weights := []float64{1, 2, 5}
+
+	for _, tt := range []struct {
+		name      string
+		algorithm routing.LBAlgorithm
+	}{
+		{"ringHash", newRingHashInternal(endpoints, weights, DefaultRingHashEndpointEntries)},
+		{"maglev", newMaglevInternal(endpoints, weights, maglevTableSize)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &routing.Route{
+				Route: eskip.Route{
+					BackendType: eskip.LBBackend,
+					LBEndpoints: eskip.NewLBEndpoints(endpoints),
+				},
+			}
+			NewAlgorithmProvider().Do([]*routing.Route{route})
+			endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+			defer endpointRegistry.Close()
+			endpointRegistry.Do([]*routing.Route{route})
+
+			const keys = 8000
+			counts := make(map[string]int)
+			for i := range keys {
+				ctx := &routing.LBContext{
+					Route:       route,
+					LBEndpoints: route.LBEndpoints,
+					Params:      map[string]interface{}{ConsistentHashKey: fmt.Sprintf("key-%d", i)},
+				}
+				counts[tt.algorithm.Apply(ctx).Host]++
+			}
+
+			for i, ep := range route.LBEndpoints {
+				expected := keys * weights[i] / 8
+				assert.InDelta(t, expected, counts[ep.Host], expected/5, "endpoint %s", ep.Host)
+			}
+		})
+	}
+}
+
+func TestRingHashEndpointEntries(t *testing.T) {
+	endpoints := []string{"http://127.0.0.1:8080", "http://127.0.0.2:8080", "http://127.0.0.3:8080"}
+	route := NewAlgorithmProviderWithOptions(Options{RingHashEndpointEntries: 3000}).Do([]*routing.Route{{
+		Route: eskip.Route{
+			BackendType: eskip.LBBackend,
+			LBAlgorithm: RingHash.String(),
+			LBEndpoints: eskip.NewLBEndpoints(endpoints),
+		},
+	}})[0]
+
+	assert.Equal(t, 3*3000, route.LBAlgorithm.(*consistentHash).Len())
+	assert.Equal(t, 3*DefaultRingHashEndpointEntries, newRingHash(endpoints).(*consistentHash).Len())
+}
+
+func TestRingHashMaxRingSize(t *testing.T) {
+	endpoints := []string{"http://127.0.0.1:8080", "http://127.0.0.2:8080"}
+	ch := newRingHashInternal(endpoints, []float64{1, 10000}, DefaultRingHashEndpointEntries).(*consistentHash)
+
+	assert.InDelta(t, ringHashMaxRingSize, ch.Len(), float64(len(endpoints)))
+
+	light := 0
+	for _, eh := range ch.hashRing {
+		if eh.index == 0 {
+			light++
+		}
+	}
+	assert.InDelta(t, ringHashMaxRingSize/10001, light, 1)
+}
+
+func TestRingHashEntries(t *testing.T) {
+	endpoints := []string{"http://127.0.0.1:8080", "http://127.0.0.2:8080"}
+	ch := newRingHashInternal(endpoints, nil, 10).(*consistentHash)
+
+	expected := make(map[uint64]int)
+	for i, ep := range endpoints {
+		for j := range 10 {
+			expected[hash(fmt.Sprintf("%s-%d", ep, j))] = i
+		}
+	}
+
+	require.Equal(t, len(expected), ch.Len())
+	for _, eh := range ch.hashRing {
+		assert.Equal(t, expected[eh.hash], eh.index)
+	}
+}
+
+func TestStaticWeights(t *testing.T) {
+	for _, algorithm := range []Algorithm{RoundRobin, Random, PowerOfRandomNChoices, WeightedRoundRobin, LeastRequests, RingHash, Maglev} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			route := NewAlgorithmProvider().Do([]*routing.Route{{
+				Route: eskip.Route{
+					BackendType: eskip.LBBackend,
+					LBAlgorithm: algorithm.String(),
+					LBEndpoints: []*eskip.LBEndpoint{
+						{Address: "http://127.0.0.1:8080", Weight: 3},
+						{Address: "http://127.0.0.2:8080"},
+					},
+				},
+			}})[0]
+			endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+			defer endpointRegistry.Close()
+			endpointRegistry.Do([]*routing.Route{route})
+
+			require.Equal(t, 3.0, route.LBEndpoints[0].Weight)
+
+			const n = 4000
+			counts := make(map[string]int)
+			for i := range n {
+				ctx := &routing.LBContext{
+					Route:       route,
+					LBEndpoints: route.LBEndpoints,
+					Params:      map[string]interface{}{ConsistentHashKey: fmt.Sprintf("key-%d", i)},
+				}
+				counts[route.LBAlgorithm.Apply(ctx).Host]++
+			}
+
+			assert.InDelta(t, n*3/4, counts["127.0.0.1:8080"], n/20)
+			assert.InDelta(t, n/4, counts["127.0.0.2:8080"], n/20)
+		})
+	}
+}
+
+func TestStaticWeightsLoadFactor(t *testing.T) {
+	route := NewAlgorithmProvider().Do([]*routing.Route{{
+		Route: eskip.Route{
+			BackendType: eskip.LBBackend,
+			LBAlgorithm: LeastRequests.String(),
+			LBEndpoints: []*eskip.LBEndpoint{
+				{Address: "http://127.0.0.1:8080", Weight: 4},
+				{Address: "http://127.0.0.2:8080"},
+			},
+		},
+	}})[0]
+	endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer endpointRegistry.Close()
+	endpointRegistry.Do([]*routing.Route{route})
+
+	// the load factor of the first endpoint is 3/4, lower than 1
+	addInflightRequests(endpointRegistry, route.LBEndpoints[0], 3)
+	addInflightRequests(endpointRegistry, route.LBEndpoints[1], 1)
+
+	ctx := &routing.LBContext{Route: route, LBEndpoints: route.LBEndpoints}
+	assert.Equal(t, route.LBEndpoints[0].Host, route.LBAlgorithm.Apply(ctx).Host)
+}
@@ -45,6 +45,35 @@ leastRequests Algorithm
 	equal weights and no inflight requests it behaves like the
 	roundRobin algorithm.
 
+ringHash Algorithm
+
+	The ringHash algorithm selects the endpoint by consistent hashing
+	like the consistentHash algorithm, but every endpoint is
+	represented on the hash ring by a number of hashes proportional
+	to its weight. The ring has a configurable minimum size, see
+	Options.
+
+maglev Algorithm
+
+	The maglev algorithm selects the endpoint by looking up the hash
+	of the request key in the table of the Maglev consistent hashing
+	algorithm. The table is filled from a different permutation of
+	its slots for every endpoint, which spreads the keys evenly
+	across the endpoints and keeps the remapping low when the
+	endpoints change.
+
+The endpoints can have a static weight, set by the "weight" query parameter
+of the endpoint address in the route definition. The roundRobin, random,
+powerOfRandomNChoices, weightedRoundRobin, leastRequests, ringHash and
+maglev algorithms select the endpoints proportionally to their static
+weights.
+
+When the algorithm provider is created with a zone, see Options, the
+routes with endpoints both in the local and in other zones prefer the
+endpoints in the local zone. When the healthy share of the local endpoints,
+as seen by the endpoint registry, drops below a threshold, a proportional
+share of the requests spills over to the other zones.
+
 The load balancing algorithms also provide fade-in behavior for LB endpoints of routes where the
 fade-in duration was configured. This feature can be used to gradually add traffic to new instances of
 applications that require a certain amount of warm-up time.
@@ -57,6 +86,9 @@ Eskip example:
 	r4: * -> <powerOfRandomNChoices, "http://127.0.0.1:9998", "http://127.0.0.1:9997">;
 	r5: * -> <weightedRoundRobin, "http://127.0.0.1:9998", "http://127.0.0.1:9997">;
 	r6: * -> <leastRequests, "http://127.0.0.1:9998", "http://127.0.0.1:9997">;
+	r7: * -> <ringHash, "http://127.0.0.1:9998", "http://127.0.0.1:9997">;
+	r8: * -> <maglev, "http://127.0.0.1:9998", "http://127.0.0.1:9997">;
+	r9: * -> <roundRobin, "http://127.0.0.1:9998?weight=3", "http://127.0.0.1:9997">;
 
 Package loadbalancer also implements health checking of pool members for
 a group of routes, if backend calls are reported to the loadbalancer.
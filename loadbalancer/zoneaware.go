@@ -0,0 +1,111 @@
+package loadbalancer
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/routing"
+)
+
+// DefaultZoneAwareMinHealthyPercent is the default percentage of healthy
+// endpoints in the local zone, below which the zone aware load balancing
+// spills over a share of the requests to the other zones.
+const DefaultZoneAwareMinHealthyPercent = 70.0
+
+// zoneAware wraps the algorithm of a route that has endpoints both in the
+// local and in other zones. It narrows the endpoints passed to the wrapped
+// algorithm to the local zone, or to the other zones for the share of the
+// requests that the local zone cannot serve.
+type zoneAware struct {
+	algorithm  routing.LBAlgorithm
+	zone       string
+	minHealthy float64
+	metrics    metrics.Metrics
+	now        func() time.Time
+}
+
+// hasMixedZones returns true when some of the endpoints are in the zone and
+// some of them are in other zones.
+func hasMixedZones(endpoints []*eskip.LBEndpoint, zone string) bool {
+	var local, other bool
+	for _, e := range endpoints {
+		if e.Zone == zone {
+			local = true
+		} else {
+			other = true
+		}
+	}
+
+	return local && other
+}
+
+// endpointHealth returns the health of the endpoint as seen by the endpoint
+// registry, between 0 and 1.
+func endpointHealth(m routing.Metrics, now time.Time) float64 {
+	if m == nil {
+		return 1
+	}
+
+	if m.ActiveHealthCheckFailed() || now.Before(routing.EjectedUntil(m)) {
+		return 0
+	}
+
+	return 1 - m.HealthCheckDropProbability()
+}
+
+// localShare returns the share of the requests that should be sent to the
+// local zone. It is 1 when the healthy percentage of the local endpoints is
+// at least the minimum, and it decreases proportionally below it.
+func (z *zoneAware) localShare(endpoints []routing.LBEndpoint) float64 {
+	now := z.now()
+	local, healthy := 0, 0.0
+	for _, e := range endpoints {
+		if e.Zone == z.zone {
+			local++
+			healthy += endpointHealth(e.Metrics, now)
+		}
+	}
+
+	if local == 0 {
+		return 0
+	}
+
+	return min(healthy/float64(local)/z.minHealthy, 1)
+}
+
+// Apply implements routing.LBAlgorithm by applying the wrapped algorithm
+// either to the endpoints in the local zone or to the endpoints in the other
+// zones. When none of the selected endpoints is available, e.g. because they
+// were filtered by the health checks, it falls back to all the endpoints.
+func (z *zoneAware) Apply(ctx *routing.LBContext) routing.LBEndpoint {
+	share := z.localShare(ctx.Route.LBEndpoints)
+	local := share >= 1 || share > 0 && rand.Float64() < share // #nosec
+
+	endpoints := make([]routing.LBEndpoint, 0, len(ctx.LBEndpoints))
+	for _, e := range ctx.LBEndpoints {
+		if (e.Zone == z.zone) == local {
+			endpoints = append(endpoints, e)
+		}
+	}
+
+	if len(endpoints) > 0 {
+		lbctx := *ctx
+		lbctx.LBEndpoints = endpoints
+		ctx = &lbctx
+	}
+
+	e := z.algorithm.Apply(ctx)
+	if e.Zone != z.zone {
+		z.metrics.IncCounter("lb.zone-aware.cross-zone")
+		if local {
+			// the local zone had no available endpoints
+			z.metrics.IncCounter("lb.zone-aware.fallback")
+		} else {
+			z.metrics.IncCounter("lb.zone-aware.spillover")
+		}
+	}
+
+	return e
+}
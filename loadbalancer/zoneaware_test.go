@@ -0,0 +1,104 @@
+package loadbalancer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestZoneAware(t *testing.T) {
+	newRoute := func(t *testing.T, m *metricstest.MockMetrics, endpoints []*eskip.LBEndpoint) *routing.Route {
+		route := NewAlgorithmProviderWithOptions(Options{Zone: "zone-a", Metrics: m}).Do([]*routing.Route{{
+			Route: eskip.Route{
+				BackendType: eskip.LBBackend,
+				LBAlgorithm: RoundRobin.String(),
+				LBEndpoints: endpoints,
+			},
+		}})[0]
+
+		endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+		t.Cleanup(endpointRegistry.Close)
+		endpointRegistry.Do([]*routing.Route{route})
+		return route
+	}
+
+	apply := func(route *routing.Route, endpoints []routing.LBEndpoint, n int) map[string]int {
+		zones := make(map[string]int)
+		for range n {
+			zones[route.LBAlgorithm.Apply(&routing.LBContext{Route: route, LBEndpoints: endpoints}).Zone]++
+		}
+		return zones
+	}
+
+	mixed := func() []*eskip.LBEndpoint {
+		return []*eskip.LBEndpoint{
+			{Address: "http://10.0.0.1:8080", Zone: "zone-a"},
+			{Address: "http://10.0.0.2:8080", Zone: "zone-a"},
+			{Address: "http://10.0.1.1:8080", Zone: "zone-b"},
+			{Address: "http://10.0.1.2:8080", Zone: "zone-b"},
+		}
+	}
+
+	t.Run("only mixed zones are wrapped", func(t *testing.T) {
+		m := &metricstest.MockMetrics{}
+		route := newRoute(t, m, []*eskip.LBEndpoint{
+			{Address: "http://10.0.0.1:8080", Zone: "zone-a"},
+			{Address: "http://10.0.0.2:8080", Zone: "zone-a"},
+		})
+		assert.IsType(t, &roundRobin{}, route.LBAlgorithm)
+
+		route = newRoute(t, m, mixed())
+		assert.IsType(t, &zoneAware{}, route.LBAlgorithm)
+	})
+
+	t.Run("prefers the local zone", func(t *testing.T) {
+		m := &metricstest.MockMetrics{}
+		route := newRoute(t, m, mixed())
+
+		assert.Equal(t, map[string]int{"zone-a": 100}, apply(route, route.LBEndpoints, 100))
+		m.WithCounters(func(counters map[string]int64) {
+			assert.Zero(t, counters["lb.zone-aware.cross-zone"])
+		})
+	})
+
+	t.Run("spills over proportionally", func(t *testing.T) {
+		m := &metricstest.MockMetrics{}
+		route := newRoute(t, m, mixed())
+		route.LBEndpoints[0].Metrics.SetActiveHealthCheckFailed(true)
+
+		// 50% healthy below the 70% minimum
+		const n = 10000
+		zones := apply(route, route.LBEndpoints, n)
+		assert.InDelta(t, n*50/70, zones["zone-a"], n/20)
+
+		m.WithCounters(func(counters map[string]int64) {
+			assert.Equal(t, int64(zones["zone-b"]), counters["lb.zone-aware.cross-zone"])
+			assert.Equal(t, int64(zones["zone-b"]), counters["lb.zone-aware.spillover"])
+		})
+	})
+
+	t.Run("unhealthy local zone", func(t *testing.T) {
+		m := &metricstest.MockMetrics{}
+		route := newRoute(t, m, mixed())
+		route.LBEndpoints[0].Metrics.SetActiveHealthCheckFailed(true)
+		route.LBEndpoints[1].Metrics.SetActiveHealthCheckFailed(true)
+
+		assert.Equal(t, map[string]int{"zone-b": 100}, apply(route, route.LBEndpoints, 100))
+	})
+
+	t.Run("falls back when no local endpoint is available", func(t *testing.T) {
+		m := &metricstest.MockMetrics{}
+		route := newRoute(t, m, mixed())
+
+		require.Equal(t, "zone-b", route.LBEndpoints[2].Zone)
+		assert.Equal(t, map[string]int{"zone-b": 100}, apply(route, route.LBEndpoints[2:], 100))
+		m.WithCounters(func(counters map[string]int64) {
+			assert.Equal(t, int64(100), counters["lb.zone-aware.fallback"])
+		})
+	})
+}
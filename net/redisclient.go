@@ -419,6 +419,30 @@ func (r *RedisRingClient) Set(ctx context.Context, key string, value interface{}
 	return res.Result()
 }
 
+func (r *RedisRingClient) Del(ctx context.Context, key string) (int64, error) {
+	res := r.ring.Del(ctx, key)
+	return res.Val(), res.Err()
+}
+
+// Scan returns the keys matching the pattern from all the shards of the
+// ring.
+func (r *RedisRingClient) Scan(ctx context.Context, match string) ([]string, error) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	err := r.ring.ForEachShard(ctx, func(ctx context.Context, client *redis.Client) error {
+		iter := client.Scan(ctx, 0, match, 100).Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			keys = append(keys, iter.Val())
+			mu.Unlock()
+		}
+		return iter.Err()
+	})
+	return keys, err
+}
+
 func (r *RedisRingClient) ZAdd(ctx context.Context, key string, val int64, score float64) (int64, error) {
 	res := r.ring.ZAdd(ctx, key, redis.Z{Member: val, Score: score})
 	return res.Val(), res.Err()
@@ -302,6 +302,32 @@ func (vr *valkeyRing) SetWithExpire(ctx context.Context, key, val string, expire
 	)
 }
 
+// Scan returns the keys matching the pattern from all known shards
+func (vr *valkeyRing) Scan(ctx context.Context, match string) ([]string, error) {
+	vr.mu.Lock()
+	clients := make([]valkey.Client, 0, len(vr.clientMap))
+	for _, shard := range vr.clientMap {
+		clients = append(clients, shard)
+	}
+	vr.mu.Unlock()
+
+	var keys []string
+	for _, shard := range clients {
+		var cursor uint64
+		for {
+			entry, err := shard.Do(ctx, shard.B().Scan().Cursor(cursor).Match(match).Count(100).Build()).AsScanEntry()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, entry.Elements...)
+			if cursor = entry.Cursor; cursor == 0 {
+				break
+			}
+		}
+	}
+	return keys, nil
+}
+
 func (vr *valkeyRing) ZAdd(ctx context.Context, key, val string, score float64) valkey.ValkeyResult {
 	shard := vr.shardForKey(key)
 	return shard.Do(ctx, shard.B().Zadd().Key(key).ScoreMember().ScoreMember(score, val).Build())
@@ -556,6 +582,10 @@ func (vrc *ValkeyRingClient) SetWithExpire(ctx context.Context, key string, valu
 	return nil
 }
 
+func (vrc *ValkeyRingClient) Scan(ctx context.Context, match string) ([]string, error) {
+	return vrc.ring.Scan(ctx, match)
+}
+
 func (vrc *ValkeyRingClient) ZAdd(ctx context.Context, key, val string, score float64) (int64, error) {
 	res := vrc.ring.ZAdd(ctx, key, val, score)
 	return res.ToInt64()
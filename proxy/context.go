@@ -56,6 +56,8 @@ type context struct {
 	logger               filters.FilterContextLogger
 	proxyRequestElapsed  time.Duration
 	proxyResponseElapsed time.Duration
+	triedEndpoints       *triedEndpoints
+	retries              int
 }
 
 type filterMetrics struct {
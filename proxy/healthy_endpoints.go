@@ -66,3 +66,44 @@ func (h *healthyEndpoints) filterHealthyEndpoints(ctx *context, endpoints []rout
 
 	return filtered
 }
+
+// filterActiveHealthCheckFailed drops the endpoints that were marked as
+// unhealthy by the active health check. When all endpoints are marked,
+// it fails open and returns all of them.
+func filterActiveHealthCheckFailed(ctx *context, endpoints []routing.LBEndpoint, metrics metrics.Metrics) []routing.LBEndpoint {
+	failed := 0
+	for _, e := range endpoints {
+		if e.Metrics.ActiveHealthCheckFailed() {
+			failed++
+		}
+	}
+
+	if failed == 0 {
+		return endpoints
+	}
+
+	span := ot.SpanFromContext(ctx.request.Context())
+	if failed == len(endpoints) {
+		if span != nil {
+			span.SetTag("ahc.endpoints.insufficient", true)
+		}
+		return endpoints
+	}
+
+	filtered := make([]routing.LBEndpoint, 0, len(endpoints)-failed)
+	for _, e := range endpoints {
+		if e.Metrics.ActiveHealthCheckFailed() {
+			ctx.Logger().Debugf("Dropping endpoint %q due to active health check", e.Host)
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+
+	if span != nil {
+		span.SetTag("ahc.endpoints.dropped", true)
+		span.SetTag("ahc.endpoints.dropped.count", failed)
+	}
+	metrics.IncCounterBy("active-health-check.endpoints.dropped", int64(failed))
+
+	return filtered
+}
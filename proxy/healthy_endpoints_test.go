@@ -398,3 +398,30 @@ func TestWeightedRoundRobinWithFailingBackend(t *testing.T) {
 			"healthy backend %d should receive more than twice the traffic of the failing backend", i)
 	}
 }
+
+func TestFilterActiveHealthCheckFailed(t *testing.T) {
+	registry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer registry.Close()
+
+	endpoints := []routing.LBEndpoint{
+		{Host: "10.0.0.1:80", Metrics: registry.GetMetrics("10.0.0.1:80")},
+		{Host: "10.0.0.2:80", Metrics: registry.GetMetrics("10.0.0.2:80")},
+		{Host: "10.0.0.3:80", Metrics: registry.GetMetrics("10.0.0.3:80")},
+	}
+	ctx := &context{request: &http.Request{}}
+	m := &metricstest.MockMetrics{}
+
+	assert.Equal(t, endpoints, filterActiveHealthCheckFailed(ctx, endpoints, m))
+
+	registry.GetMetrics("10.0.0.2:80").SetActiveHealthCheckFailed(true)
+	filtered := filterActiveHealthCheckFailed(ctx, endpoints, m)
+	assert.Equal(t, []routing.LBEndpoint{endpoints[0], endpoints[2]}, filtered)
+
+	registry.GetMetrics("10.0.0.1:80").SetActiveHealthCheckFailed(true)
+	registry.GetMetrics("10.0.0.3:80").SetActiveHealthCheckFailed(true)
+	assert.Equal(t, endpoints, filterActiveHealthCheckFailed(ctx, endpoints, m), "fails open when all endpoints failed")
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(1), counters["active-health-check.endpoints.dropped"])
+	})
+}
@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"bytes"
+	stdlibcontext "context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	hedgefilter "github.com/zalando/skipper/filters/hedge"
+)
+
+type hedgeResult struct {
+	hedge int
+	ctx   *context
+	rsp   *http.Response
+	perr  *proxyError
+}
+
+// hedgePolicy returns the hedging policy set by the hedge filter, or nil
+// when the request cannot be hedged.
+func hedgePolicy(ctx *context) *hedgefilter.Policy {
+	policy, ok := ctx.StateBag()[filters.BackendHedge].(*hedgefilter.Policy)
+	if !ok || ctx.route.BackendType != eskip.LBBackend || ctx.Request() == nil || !idempotentMethod(ctx.Request()) {
+		return nil
+	}
+
+	return policy
+}
+
+// roundTripBackend executes the backend request, hedged when the route is
+// configured for it. The request body is buffered to send it with every
+// hedged request, and requests with a body larger than the maximum body
+// size of the policy are not hedged.
+func (p *Proxy) roundTripBackend(ctx *context, requestContext stdlibcontext.Context) (*http.Response, *proxyError) {
+	if policy := hedgePolicy(ctx); policy != nil {
+		if body, ok := bufferBody(ctx.Request(), policy.MaxBodySize); ok {
+			return p.makeHedgedBackendRequest(ctx, requestContext, policy, body)
+		}
+
+		p.metrics.IncCounter("hedge.skipped.body." + ctx.route.Id)
+	}
+
+	return p.makeBackendRequest(ctx, requestContext)
+}
+
+// splitForHedge creates the context of a hedged request. The contexts share
+// the tried endpoints, so that every hedged request selects a different LB
+// endpoint when one is available. Every hedged request sends the buffered
+// body instead of the tee of the original body.
+func (p *Proxy) splitForHedge(ctx *context, body []byte) (*context, error) {
+	req := ctx.Request()
+	originalBody := req.Body
+	fc, err := ctx.Split()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = originalBody
+	hc := fc.(*context)
+	if body != nil {
+		hc.request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	hc.stateBag = maps.Clone(ctx.stateBag)
+	hc.proxySpan = nil
+	return hc, nil
+}
+
+// makeHedgedBackendRequest sends the backend request, and sends a hedged
+// request to another endpoint every time the delay of the policy passes
+// without a response, or when all the requests in flight failed, up to the
+// maximum number of hedged requests. The first response wins, and the other
+// requests are cancelled.
+func (p *Proxy) makeHedgedBackendRequest(ctx *context, requestContext stdlibcontext.Context, policy *hedgefilter.Policy, body []byte) (*http.Response, *proxyError) {
+	if ctx.triedEndpoints == nil {
+		ctx.triedEndpoints = &triedEndpoints{}
+	}
+
+	results := make(chan hedgeResult, policy.MaxHedges+1)
+	cancels := make([]stdlibcontext.CancelFunc, 0, policy.MaxHedges+1)
+	start := func() error {
+		hc, err := p.splitForHedge(ctx, body)
+		if err != nil {
+			return err
+		}
+
+		hedge := len(cancels)
+		hedgeContext, cancel := stdlibcontext.WithCancel(requestContext)
+		cancels = append(cancels, cancel)
+
+		go func() {
+			rsp, perr := p.makeBackendRequest(hc, hedgeContext)
+			if hedge > 0 {
+				p.tracing.setTag(hc.proxySpan, HedgeTag, hedge)
+			}
+			results <- hedgeResult{hedge: hedge, ctx: hc, rsp: rsp, perr: perr}
+		}()
+
+		return nil
+	}
+
+	if err := start(); err != nil {
+		return nil, &proxyError{err: fmt.Errorf("failed to split request for hedging: %w", err)}
+	}
+
+	timer := time.NewTimer(policy.Delay)
+	defer timer.Stop()
+
+	routeID := ctx.route.Id
+	hedge := func() bool {
+		if len(cancels) > policy.MaxHedges {
+			return false
+		}
+
+		if err := start(); err != nil {
+			ctx.Logger().Errorf("Failed to split request for hedging: %v", err)
+			return false
+		}
+
+		p.metrics.IncCounter("hedge.requests." + routeID)
+		if len(cancels) <= policy.MaxHedges {
+			timer.Reset(policy.Delay)
+		}
+
+		return true
+	}
+
+	inflight := 1
+	var winner hedgeResult
+	for winner.ctx == nil {
+		select {
+		case <-timer.C:
+			if hedge() {
+				inflight++
+			}
+		case r := <-results:
+			inflight--
+			if r.perr != nil {
+				if inflight == 0 && hedge() {
+					// all the requests failed before the delay
+					inflight++
+				}
+
+				if inflight > 0 {
+					// the other requests may still succeed
+					p.finishHedge(r, cancels[r.hedge])
+					continue
+				}
+			}
+
+			winner = r
+		}
+	}
+
+	for i, cancel := range cancels {
+		if i != winner.hedge {
+			cancel()
+		}
+	}
+
+	if inflight > 0 {
+		go p.drainHedges(results, cancels, inflight)
+	}
+
+	if winner.hedge > 0 && winner.perr == nil {
+		p.metrics.IncCounter("hedge.won." + routeID)
+	}
+
+	ctx.proxySpan = winner.ctx.proxySpan
+	ctx.backendZone = winner.ctx.backendZone
+	ctx.proxyRequestElapsed = winner.ctx.proxyRequestElapsed
+	ctx.proxyResponseElapsed = winner.ctx.proxyResponseElapsed
+
+	cancelBackendContext := ctx.cancelBackendContext
+	ctx.cancelBackendContext = func() {
+		cancels[winner.hedge]()
+		if cancelBackendContext != nil {
+			cancelBackendContext()
+		}
+	}
+
+	return winner.rsp, winner.perr
+}
+
+// finishHedge releases the resources of a request that lost the race.
+func (p *Proxy) finishHedge(r hedgeResult, cancel stdlibcontext.CancelFunc) {
+	discardResponse(r.rsp)
+	cancel()
+	if r.ctx.proxySpan != nil {
+		r.ctx.proxySpan.Finish()
+	}
+}
+
+// drainHedges waits for the cancelled requests to return after a response
+// won the race.
+func (p *Proxy) drainHedges(results <-chan hedgeResult, cancels []stdlibcontext.CancelFunc, inflight int) {
+	for range inflight {
+		r := <-results
+		p.tracing.setTag(r.ctx.proxySpan, HedgeCancelledTag, true)
+		p.finishHedge(r, cancels[r.hedge])
+	}
+}
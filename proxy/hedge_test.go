@@ -0,0 +1,181 @@
+package proxy_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestHedge(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte("slow"))
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fast"))
+	}))
+	defer fast.Close()
+
+	m := &metricstest.MockMetrics{}
+	doc := fmt.Sprintf(`hedged: * -> hedge("20ms") -> <roundRobin, "%s", "%s">`, slow.URL, fast.URL)
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{Metrics: m}, eskip.MustParse(doc)...)
+	defer p.Close()
+
+	const n = 6
+	for range n {
+		start := time.Now()
+		rsp, err := p.Client().Get(p.URL)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+		assert.Equal(t, "fast", string(body))
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Greater(t, counters["hedge.requests.hedged"], int64(0))
+		assert.Equal(t, counters["hedge.requests.hedged"], counters["hedge.won.hedged"])
+	})
+}
+
+func TestHedgeBody(t *testing.T) {
+	echo := func(delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				return
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			w.Write(body)
+		}))
+	}
+
+	slow := echo(time.Second)
+	defer slow.Close()
+
+	fast := echo(0)
+	defer fast.Close()
+
+	m := &metricstest.MockMetrics{}
+	doc := fmt.Sprintf(`
+		hedged: Path("/hedged") -> hedge("20ms", 1, 8) -> <roundRobin, "%s", "%s">;
+		large: Path("/large") -> hedge("20ms", 1, 4) -> <roundRobin, "%s">;
+	`, slow.URL, fast.URL, fast.URL)
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{Metrics: m}, eskip.MustParse(doc)...)
+	defer p.Close()
+
+	put := func(path string, body io.Reader) string {
+		req, err := http.NewRequest("PUT", p.URL+path, body)
+		require.NoError(t, err)
+
+		rsp, err := p.Client().Do(req)
+		require.NoError(t, err)
+
+		b, err := io.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+		return string(b)
+	}
+
+	const n = 6
+	for range n {
+		start := time.Now()
+		assert.Equal(t, "hello", put("/hedged", strings.NewReader("hello")))
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	}
+
+	// without content length
+	assert.Equal(t, "hello", put("/hedged", io.MultiReader(strings.NewReader("hello"))))
+	assert.Equal(t, "hello world", put("/hedged", io.MultiReader(strings.NewReader("hello world"))))
+
+	assert.Equal(t, "hello", put("/large", strings.NewReader("hello")))
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Greater(t, counters["hedge.requests.hedged"], int64(0))
+		assert.Equal(t, counters["hedge.requests.hedged"], counters["hedge.won.hedged"])
+		assert.Equal(t, int64(1), counters["hedge.skipped.body.hedged"])
+		assert.Equal(t, int64(1), counters["hedge.skipped.body.large"])
+	})
+}
+
+func TestHedgeNotDelayed(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer backend.Close()
+
+	m := &metricstest.MockMetrics{}
+	doc := fmt.Sprintf(`hedged: * -> hedge("1s", 2) -> <roundRobin, "%s", "%s">`, backend.URL, backend.URL)
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{Metrics: m}, eskip.MustParse(doc)...)
+	defer p.Close()
+
+	rsp, err := p.Client().Get(p.URL)
+	require.NoError(t, err)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Zero(t, counters["hedge.requests.hedged"])
+	})
+}
+
+func TestHedgeFailedBeforeDelay(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	failing.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer backend.Close()
+
+	m := &metricstest.MockMetrics{}
+	doc := fmt.Sprintf(`hedged: * -> hedge("10s") -> <roundRobin, "%s", "%s">`, failing.URL, backend.URL)
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{Metrics: m}, eskip.MustParse(doc)...)
+	defer p.Close()
+
+	const n = 4
+	for range n {
+		start := time.Now()
+		rsp, err := p.Client().Get(p.URL)
+		require.NoError(t, err)
+		rsp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+		assert.Less(t, time.Since(start), 5*time.Second, "the hedged request is sent without waiting for the delay")
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Greater(t, counters["hedge.requests.hedged"], int64(0))
+		assert.Equal(t, counters["hedge.requests.hedged"], counters["hedge.won.hedged"])
+	})
+}
@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	ot "github.com/opentracing/opentracing-go"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	DefaultOutlierDetectionConsecutiveErrors  = 5
+	DefaultOutlierDetectionBaseEjectionTime   = 30 * time.Second
+	DefaultOutlierDetectionMaxEjectionTime    = 300 * time.Second
+	DefaultOutlierDetectionMaxEjectionPercent = 10.0
+)
+
+type OutlierDetection struct {
+	// ConsecutiveErrors is the number of consecutive 5xx responses or failed round trips
+	// after which an endpoint is ejected from load balancing
+	ConsecutiveErrors int64
+
+	// BaseEjectionTime is the ejection time of an endpoint ejected for the first time. The
+	// ejection time grows linearly with the number of ejections of the endpoint
+	BaseEjectionTime time.Duration
+
+	// MaxEjectionTime is the maximum ejection time of an endpoint
+	MaxEjectionTime time.Duration
+
+	// MaxEjectionPercent is the maximum percentage of the endpoints of a route that can be
+	// ejected at the same time. At least one endpoint can always be ejected, unless the
+	// route has a single endpoint
+	MaxEjectionPercent float64
+}
+
+type outlierDetection struct {
+	OutlierDetection
+	now func() time.Time
+}
+
+// InitOutlierDetection parses the outlier detection configuration. It returns
+// false when no configuration was provided.
+func InitOutlierDetection(o map[string]string) (bool, *OutlierDetection, error) {
+	result := &OutlierDetection{
+		ConsecutiveErrors:  DefaultOutlierDetectionConsecutiveErrors,
+		BaseEjectionTime:   DefaultOutlierDetectionBaseEjectionTime,
+		MaxEjectionTime:    DefaultOutlierDetectionMaxEjectionTime,
+		MaxEjectionPercent: DefaultOutlierDetectionMaxEjectionPercent,
+	}
+	if len(o) == 0 {
+		return false, result, nil
+	}
+
+	for key, value := range o {
+		switch key {
+		case "consecutive-errors":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 1 {
+				return false, nil, fmt.Errorf("outlier detection: invalid consecutive-errors value: %s", value)
+			}
+			result.ConsecutiveErrors = n
+		case "base-ejection-time":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return false, nil, fmt.Errorf("outlier detection: invalid base-ejection-time value: %s", value)
+			}
+			result.BaseEjectionTime = d
+		case "max-ejection-time":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return false, nil, fmt.Errorf("outlier detection: invalid max-ejection-time value: %s", value)
+			}
+			result.MaxEjectionTime = d
+		case "max-ejection-percent":
+			p, err := strconv.ParseFloat(value, 64)
+			if err != nil || p < 0 || p > 100 {
+				return false, nil, fmt.Errorf("outlier detection: invalid max-ejection-percent value: %s", value)
+			}
+			result.MaxEjectionPercent = p
+		default:
+			return false, nil, fmt.Errorf("outlier detection: invalid parameter: key=%s,value=%s", key, value)
+		}
+	}
+
+	if result.MaxEjectionTime < result.BaseEjectionTime {
+		return false, nil, fmt.Errorf("outlier detection: max-ejection-time should not be less than base-ejection-time")
+	}
+
+	return true, result, nil
+}
+
+// observe records the result of a backend round trip, and ejects the
+// endpoint after the configured number of consecutive errors.
+func (od *outlierDetection) observe(ctx *context, host string, endpoint routing.Metrics, failed bool, metrics metrics.Metrics) {
+	if od == nil {
+		return
+	}
+
+	if !failed {
+		routing.ResetConsecutiveErrors(endpoint)
+		return
+	}
+
+	if routing.IncConsecutiveErrors(endpoint) != od.ConsecutiveErrors {
+		return
+	}
+
+	d := routing.Eject(endpoint, od.now(), od.BaseEjectionTime, od.MaxEjectionTime)
+	log.Infof("Outlier detection: ejecting %q for %v after %d consecutive errors", host, d, od.ConsecutiveErrors)
+	metrics.IncCounter("outlier-detection.endpoints.ejected")
+	if span := ot.SpanFromContext(ctx.request.Context()); span != nil {
+		span.LogKV("event", "outlier-detection.ejected", "endpoint", host, "duration", d.String())
+	}
+}
+
+// filterEjected drops the endpoints ejected by the outlier detection, up to
+// the maximum ejection percentage of the endpoints of the route.
+func (od *outlierDetection) filterEjected(ctx *context, endpoints []routing.LBEndpoint, metrics metrics.Metrics) []routing.LBEndpoint {
+	if od == nil || len(endpoints) < 2 {
+		return endpoints
+	}
+
+	maxEjected := max(int(float64(len(endpoints))*od.MaxEjectionPercent/100), 1)
+	now := od.now()
+
+	var filtered []routing.LBEndpoint
+	ejected := 0
+	for i, e := range endpoints {
+		if ejected < maxEjected && now.Before(routing.EjectedUntil(e.Metrics)) {
+			if filtered == nil {
+				filtered = make([]routing.LBEndpoint, 0, len(endpoints)-1)
+				filtered = append(filtered, endpoints[:i]...)
+			}
+			ctx.Logger().Debugf("Dropping endpoint %q due to outlier detection", e.Host)
+			ejected++
+			continue
+		}
+
+		if filtered != nil {
+			filtered = append(filtered, e)
+		}
+	}
+
+	if ejected == 0 || len(filtered) == 0 {
+		return endpoints
+	}
+
+	if span := ot.SpanFromContext(ctx.request.Context()); span != nil {
+		span.SetTag("outlier-detection.endpoints.dropped", true)
+		span.SetTag("outlier-detection.endpoints.dropped.count", ejected)
+	}
+	metrics.IncCounterBy("outlier-detection.endpoints.dropped", int64(ejected))
+
+	return filtered
+}
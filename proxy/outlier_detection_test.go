@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestInitOutlierDetection(t *testing.T) {
+	enabled, od, err := InitOutlierDetection(nil)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Equal(t, int64(DefaultOutlierDetectionConsecutiveErrors), od.ConsecutiveErrors)
+
+	for _, o := range []map[string]string{
+		{"consecutive-errors": "0"},
+		{"base-ejection-time": "foo"},
+		{"max-ejection-time": "-1s"},
+		{"max-ejection-percent": "101"},
+		{"base-ejection-time": "1m", "max-ejection-time": "30s"},
+		{"foo": "bar"},
+	} {
+		_, _, err := InitOutlierDetection(o)
+		assert.Error(t, err, "%v", o)
+	}
+
+	enabled, od, err = InitOutlierDetection(map[string]string{
+		"consecutive-errors":   "3",
+		"base-ejection-time":   "10s",
+		"max-ejection-percent": "50",
+	})
+	require.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, &OutlierDetection{
+		ConsecutiveErrors:  3,
+		BaseEjectionTime:   10 * time.Second,
+		MaxEjectionTime:    DefaultOutlierDetectionMaxEjectionTime,
+		MaxEjectionPercent: 50,
+	}, od)
+}
+
+func TestOutlierDetection(t *testing.T) {
+	registry := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer registry.Close()
+
+	var endpoints []routing.LBEndpoint
+	for i := range 4 {
+		host := fmt.Sprintf("10.0.0.%d:80", i+1)
+		endpoints = append(endpoints, routing.LBEndpoint{Host: host, Metrics: registry.GetMetrics(host)})
+	}
+
+	now := time.Now()
+	od := &outlierDetection{
+		OutlierDetection: OutlierDetection{
+			ConsecutiveErrors:  3,
+			BaseEjectionTime:   10 * time.Second,
+			MaxEjectionTime:    time.Minute,
+			MaxEjectionPercent: 50,
+		},
+		now: func() time.Time { return now },
+	}
+	ctx := &context{request: &http.Request{}}
+	m := &metricstest.MockMetrics{}
+
+	observe := func(e routing.LBEndpoint, failed bool) {
+		od.observe(ctx, e.Host, e.Metrics, failed, m)
+	}
+
+	// successful requests reset the consecutive errors
+	observe(endpoints[0], true)
+	observe(endpoints[0], true)
+	observe(endpoints[0], false)
+	observe(endpoints[0], true)
+	assert.Equal(t, endpoints, od.filterEjected(ctx, endpoints, m))
+
+	for range 3 {
+		observe(endpoints[0], true)
+	}
+	assert.Equal(t, endpoints[1:], od.filterEjected(ctx, endpoints, m))
+
+	for range 3 {
+		observe(endpoints[1], true)
+		observe(endpoints[2], true)
+	}
+	assert.Equal(t, endpoints[2:], od.filterEjected(ctx, endpoints, m), "limited by max ejection percent")
+
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, endpoints, od.filterEjected(ctx, endpoints, m), "ejection time passed")
+
+	// the second ejection is longer
+	for range 3 {
+		observe(endpoints[0], true)
+	}
+	assert.Equal(t, now.Add(20*time.Second), routing.EjectedUntil(endpoints[0].Metrics))
+
+	assert.Equal(t, endpoints[:1], od.filterEjected(ctx, endpoints[:1], m), "single endpoint is never ejected")
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(4), counters["outlier-detection.endpoints.ejected"])
+	})
+}
@@ -403,6 +403,12 @@ type Params struct {
 
 	// PassiveHealthCheck defines the parameters for the healthy endpoints checker.
 	PassiveHealthCheck *PassiveHealthCheck
+
+	// EnableOutlierDetection enables ejecting the LB endpoints after consecutive errors
+	EnableOutlierDetection bool
+
+	// OutlierDetection defines the parameters of the outlier detection.
+	OutlierDetection *OutlierDetection
 }
 
 type (
@@ -482,6 +488,7 @@ type Proxy struct {
 	registry                 *routing.EndpointRegistry
 	fadein                   *fadeIn
 	healthyEndpoints         *healthyEndpoints
+	outlierDetection         *outlierDetection
 	roundTripper             http.RoundTripper
 	h2cRoundTripper          http.RoundTripper
 	priorityRoutes           []PriorityRoute
@@ -646,8 +653,11 @@ func setRequestURLForDynamicBackend(u *url.URL, stateBag map[string]interface{})
 func (p *Proxy) selectEndpoint(ctx *context) *routing.LBEndpoint {
 	rt := ctx.route
 	endpoints := rt.LBEndpoints
+	endpoints = filterActiveHealthCheckFailed(ctx, endpoints, p.metrics)
+	endpoints = p.outlierDetection.filterEjected(ctx, endpoints, p.metrics)
 	endpoints = p.fadein.filterFadeIn(endpoints, rt)
 	endpoints = p.healthyEndpoints.filterHealthyEndpoints(ctx, endpoints, p.metrics)
+	endpoints = ctx.triedEndpoints.exclude(endpoints)
 
 	lbctx := &routing.LBContext{
 		Request:     ctx.request,
@@ -681,6 +691,7 @@ func (p *Proxy) mapRequest(ctx *context, requestContext stdlibcontext.Context) (
 		ctx.backendZone = endpoint.Zone
 		u.Scheme = endpoint.Scheme
 		u.Host = endpoint.Host
+		ctx.triedEndpoints.add(endpoint.Host)
 	case eskip.NetworkBackend:
 		endpointMetrics = p.registry.GetMetrics(rt.Host)
 		fallthrough
@@ -909,6 +920,14 @@ func WithParams(p Params) *Proxy {
 			maxUnhealthyEndpointsRatio: p.PassiveHealthCheck.MaxUnhealthyEndpointsRatio,
 		}
 	}
+
+	var outliers *outlierDetection
+	if p.EnableOutlierDetection {
+		outliers = &outlierDetection{
+			OutlierDetection: *p.OutlierDetection,
+			now:              time.Now,
+		}
+	}
 	return &Proxy{
 		routing:  p.Routing,
 		registry: p.EndpointRegistry,
@@ -916,6 +935,7 @@ func WithParams(p Params) *Proxy {
 			rnd: rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0)), // #nosec
 		},
 		healthyEndpoints:         healthyEndpointsChooser,
+		outlierDetection:         outliers,
 		roundTripper:             p.CustomHttpRoundTripperWrap(tr),
 		h2cRoundTripper:          p.CustomHttpRoundTripperWrap(h2cTr),
 		priorityRoutes:           p.PriorityRoutes,
@@ -1152,6 +1172,8 @@ func (p *Proxy) makeBackendRequest(ctx *context, requestContext stdlibcontext.Co
 
 	if endpointMetrics != nil {
 		endpointMetrics.IncRequests(routing.IncRequestsOptions{FailedRoundTrip: err != nil})
+		failed := err != nil && req.Context().Err() == nil || err == nil && response.StatusCode >= http.StatusInternalServerError
+		p.outlierDetection.observe(ctx, req.URL.Host, endpointMetrics, failed, p.metrics)
 	}
 	if p.tracing.clientTraceByTag {
 		ctx.proxySpan.SetTag(ClientTraceHTTPRoundTrip, time.Since(httpRoundtripTime).Microseconds())
@@ -1469,7 +1491,16 @@ func (p *Proxy) do(ctx *context, parentSpan ot.Span) (err error) {
 		}
 
 		requestStopWatch.Stop()
-		rsp, perr := p.makeBackendRequest(ctx, backendContext)
+		var (
+			rsp  *http.Response
+			perr *proxyError
+		)
+		policy := retryPolicy(ctx)
+		if policy != nil {
+			rsp, perr = p.makeBackendRequestWithRetry(ctx, backendContext, policy)
+		} else {
+			rsp, perr = p.roundTripBackend(ctx, backendContext)
+		}
 		requestElapsed += ctx.proxyRequestElapsed
 		responseElapsed += ctx.proxyResponseElapsed
 		if perr != nil {
@@ -1480,7 +1511,7 @@ func (p *Proxy) do(ctx *context, parentSpan ot.Span) (err error) {
 
 			p.metrics.IncErrorsBackend(ctx.route.Id)
 
-			if retryable(ctx, perr) {
+			if policy == nil && retryable(ctx, perr) {
 				if p.tracing.clientTraceByTag {
 					p.tracing.setTag(ctx.proxySpan, "retry", ctx.route.Id)
 				} else {
@@ -1822,6 +1853,12 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 
 			additionalData, _ := ctx.stateBag[al.AccessLogAdditionalDataKey].(map[string]interface{})
+			if ctx.retries > 0 {
+				if additionalData == nil {
+					additionalData = make(map[string]interface{})
+				}
+				additionalData["retries"] = ctx.retries
+			}
 			if p.accessLogger != nil {
 				p.accessLogger.LogAccess(entry, additionalData)
 			}
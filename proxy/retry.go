@@ -0,0 +1,242 @@
+package proxy
+
+import (
+	stdlibcontext "context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	retryfilter "github.com/zalando/skipper/filters/retry"
+	"github.com/zalando/skipper/routing"
+)
+
+// maxRetryDiscardBodySize limits how much of the body of a retried response
+// is consumed before closing it, so that the connection can be reused.
+const maxRetryDiscardBodySize = 4096
+
+// retryPolicy returns the retry policy set by the retry filter, or nil when
+// the request cannot be retried.
+func retryPolicy(ctx *context) *retryfilter.Policy {
+	policy, ok := ctx.StateBag()[filters.BackendRetry].(*retryfilter.Policy)
+	if !ok || policy.MaxAttempts < 2 {
+		return nil
+	}
+
+	if !idempotentRequest(ctx.Request()) {
+		return nil
+	}
+
+	return policy
+}
+
+// idempotentRequest tells whether the request can be sent to the backend
+// multiple times. Requests with a body are not repeated, because the body
+// is streamed to the backend.
+func idempotentRequest(req *http.Request) bool {
+	return req != nil && (req.Body == nil || req.Body == http.NoBody) && idempotentMethod(req)
+}
+
+// idempotentMethod tells whether the method of the request allows to send
+// it to the backend multiple times.
+func idempotentMethod(req *http.Request) bool {
+	if isUpgradeRequest(req) {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryReason returns why the result of a backend request should be
+// retried according to the policy, or an empty string when it should not.
+func retryReason(policy *retryfilter.Policy, rsp *http.Response, perr *proxyError) string {
+	if perr == nil {
+		if rsp != nil && policy.RetryStatus(rsp.StatusCode) {
+			return fmt.Sprintf("status %d", rsp.StatusCode)
+		}
+		return ""
+	}
+
+	switch {
+	case perr.handled || perr.code == 499 || perr.code == http.StatusBadRequest:
+		return ""
+	case perr.code == http.StatusGatewayTimeout:
+		if policy.OnTimeout {
+			return retryfilter.ConditionTimeout
+		}
+	case perr.DialError() || perr.code == 0 || perr.code == http.StatusServiceUnavailable:
+		if policy.OnReset {
+			return retryfilter.ConditionReset
+		}
+	}
+
+	return ""
+}
+
+func discardResponse(rsp *http.Response) {
+	if rsp == nil || rsp.Body == nil {
+		return
+	}
+
+	io.Copy(io.Discard, io.LimitReader(rsp.Body, maxRetryDiscardBodySize))
+	rsp.Body.Close()
+}
+
+// makeBackendAttempt executes a single backend request with the per try
+// timeout applied to receiving the response headers. The returned cancel
+// function releases the context of the response.
+func (p *Proxy) makeBackendAttempt(ctx *context, backendContext stdlibcontext.Context, timeout time.Duration) (*http.Response, *proxyError, stdlibcontext.CancelFunc) {
+	if timeout <= 0 {
+		rsp, perr := p.roundTripBackend(ctx, backendContext)
+		return rsp, perr, func() {}
+	}
+
+	attemptContext, cancel := stdlibcontext.WithCancel(backendContext)
+
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(timeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+
+	rsp, perr := p.roundTripBackend(ctx, attemptContext)
+	timer.Stop()
+
+	if timedOut.Load() && backendContext.Err() == nil {
+		discardResponse(rsp)
+		p.tracing.setTag(ctx.proxySpan, HTTPStatusCodeTag, uint16(http.StatusGatewayTimeout))
+		return nil, &proxyError{
+			err:  fmt.Errorf("per try timeout of %v exceeded", timeout),
+			code: http.StatusGatewayTimeout,
+		}, cancel
+	}
+
+	return rsp, perr, cancel
+}
+
+// makeBackendRequestWithRetry executes the backend request and retries it
+// according to the policy. Every attempt is traced in its own proxy span,
+// and it selects a different LB endpoint, when one is available.
+func (p *Proxy) makeBackendRequestWithRetry(ctx *context, backendContext stdlibcontext.Context, policy *retryfilter.Policy) (*http.Response, *proxyError) {
+	var requestElapsed, responseElapsed time.Duration
+	defer func() {
+		ctx.proxyRequestElapsed = requestElapsed
+		ctx.proxyResponseElapsed = responseElapsed
+	}()
+
+	routeID := ctx.route.Id
+	ctx.triedEndpoints = &triedEndpoints{}
+	for attempt := 1; ; attempt++ {
+		rsp, perr, cancel := p.makeBackendAttempt(ctx, backendContext, policy.PerTryTimeout)
+		requestElapsed += ctx.proxyRequestElapsed
+		responseElapsed += ctx.proxyResponseElapsed
+		if attempt > 1 {
+			p.tracing.setTag(ctx.proxySpan, RetryAttemptTag, attempt-1)
+		}
+
+		reason := retryReason(policy, rsp, perr)
+		if reason != "" && attempt >= policy.MaxAttempts {
+			p.metrics.IncCounter("retry.exhausted." + routeID)
+			reason = ""
+		}
+
+		if reason != "" && !policy.AllowRetry() {
+			p.metrics.IncCounter("retry.budget.exhausted." + routeID)
+			reason = ""
+		}
+
+		if reason != "" {
+			timer := time.NewTimer(policy.Backoff(attempt))
+			select {
+			case <-timer.C:
+			case <-backendContext.Done():
+				timer.Stop()
+				reason = ""
+			}
+		}
+
+		if reason == "" {
+			cancelBackendContext := ctx.cancelBackendContext
+			ctx.cancelBackendContext = func() {
+				cancel()
+				if cancelBackendContext != nil {
+					cancelBackendContext()
+				}
+			}
+
+			return rsp, perr
+		}
+
+		discardResponse(rsp)
+		cancel()
+
+		p.tracing.setTag(ctx.proxySpan, RetryReasonTag, reason)
+		if ctx.proxySpan != nil {
+			ctx.proxySpan.Finish()
+			ctx.proxySpan = nil
+		}
+
+		if perr != nil {
+			ctx.Logger().Debugf("Retrying backend request of route %s, reason: %s, error: %v", ctx.route.Id, reason, perr)
+		} else {
+			ctx.Logger().Debugf("Retrying backend request of route %s, reason: %s", ctx.route.Id, reason)
+		}
+
+		p.metrics.IncCounter("retry.attempts." + routeID)
+		ctx.retries++
+	}
+}
+
+// triedEndpoints records the LB endpoints used by the attempts of a
+// retried or hedged request. The contexts of hedged requests share it.
+type triedEndpoints struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (t *triedEndpoints) add(host string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.hosts = append(t.hosts, host)
+	t.mu.Unlock()
+}
+
+// exclude removes the endpoints used by the previous attempts, unless no
+// endpoint would remain.
+func (t *triedEndpoints) exclude(endpoints []routing.LBEndpoint) []routing.LBEndpoint {
+	if t == nil {
+		return endpoints
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.hosts) == 0 {
+		return endpoints
+	}
+
+	filtered := make([]routing.LBEndpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		if !slices.Contains(t.hosts, e.Host) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	if len(filtered) == 0 {
+		return endpoints
+	}
+
+	return filtered
+}
@@ -0,0 +1,165 @@
+package proxy_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func newStatusBackend(status int, requests *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(status)
+	}))
+}
+
+func TestRetryDifferentEndpoint(t *testing.T) {
+	var failed, succeeded atomic.Int64
+	failing := newStatusBackend(http.StatusServiceUnavailable, &failed)
+	defer failing.Close()
+
+	healthy := newStatusBackend(http.StatusOK, &succeeded)
+	defer healthy.Close()
+
+	m := &metricstest.MockMetrics{}
+	doc := fmt.Sprintf(`retried: * -> retry(2, 0, 0) -> <roundRobin, "%s", "%s">`, failing.URL, healthy.URL)
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{Metrics: m}, eskip.MustParse(doc)...)
+	defer p.Close()
+
+	const n = 10
+	for range n {
+		rsp, err := p.Client().Get(p.URL)
+		require.NoError(t, err)
+		rsp.Body.Close()
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	}
+
+	assert.Equal(t, int64(n), succeeded.Load())
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, failed.Load(), counters["retry.attempts.retried"])
+	})
+}
+
+func TestRetryExhausted(t *testing.T) {
+	var requests atomic.Int64
+	backend := newStatusBackend(http.StatusBadGateway, &requests)
+	defer backend.Close()
+
+	m := &metricstest.MockMetrics{}
+	doc := fmt.Sprintf(`retried: * -> retry(3, 0, 0) -> "%s"`, backend.URL)
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{Metrics: m}, eskip.MustParse(doc)...)
+	defer p.Close()
+
+	rsp, err := p.Client().Get(p.URL)
+	require.NoError(t, err)
+	rsp.Body.Close()
+
+	assert.Equal(t, http.StatusBadGateway, rsp.StatusCode)
+	assert.Equal(t, int64(3), requests.Load())
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(2), counters["retry.attempts.retried"])
+		assert.Equal(t, int64(1), counters["retry.exhausted.retried"])
+	})
+}
+
+func TestRetryBudgetExhausted(t *testing.T) {
+	var requests atomic.Int64
+	backend := newStatusBackend(http.StatusServiceUnavailable, &requests)
+	defer backend.Close()
+
+	m := &metricstest.MockMetrics{}
+	doc := fmt.Sprintf(`
+		retried: Path("/retried") -> retry(2, 0, 0, 0, 0.01) -> "%s";
+		other: * -> "%s";
+	`, backend.URL, backend.URL)
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{Metrics: m}, eskip.MustParse(doc)...)
+	defer p.Close()
+
+	const n = 15
+	for range n {
+		rsp, err := p.Client().Get(p.URL + "/retried")
+		require.NoError(t, err)
+		rsp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(10), counters["retry.attempts.retried"], "the budget allows a burst of 10 retries")
+		assert.Equal(t, int64(10), counters["retry.exhausted.retried"])
+		assert.Equal(t, int64(n-10), counters["retry.budget.exhausted.retried"])
+		assert.Zero(t, counters["retry.budget.exhausted.other"])
+	})
+}
+
+func TestRetryConditions(t *testing.T) {
+	var requests atomic.Int64
+	backend := newStatusBackend(http.StatusInternalServerError, &requests)
+	defer backend.Close()
+
+	doc := fmt.Sprintf(`
+		default: * -> retry(3, 0, 0) -> "%s";
+		on500: Path("/500") -> retry(3, 0, 0, 0, 0.2, 500) -> "%s";
+	`, backend.URL, backend.URL)
+	p := proxytest.New(builtin.MakeRegistry(), eskip.MustParse(doc)...)
+	defer p.Close()
+
+	for _, tt := range []struct {
+		method, path, body string
+		expected           int64
+	}{
+		{"GET", "/", "", 1},
+		{"GET", "/500", "", 3},
+		{"POST", "/500", "", 1},
+		{"PUT", "/500", "body", 1},
+	} {
+		requests.Store(0)
+
+		req, err := http.NewRequest(tt.method, p.URL+tt.path, strings.NewReader(tt.body))
+		require.NoError(t, err)
+		if tt.body == "" {
+			req.Body = http.NoBody
+		}
+
+		rsp, err := p.Client().Do(req)
+		require.NoError(t, err)
+		rsp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, rsp.StatusCode)
+		assert.Equal(t, tt.expected, requests.Load(), "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestRetryPerTryTimeout(t *testing.T) {
+	var requests atomic.Int64
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	doc := fmt.Sprintf(`* -> retry(2, "20ms", 0) -> "%s"`, backend.URL)
+	p := proxytest.New(builtin.MakeRegistry(), eskip.MustParse(doc)...)
+	defer p.Close()
+
+	rsp, err := p.Client().Get(p.URL)
+	require.NoError(t, err)
+	rsp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, int64(2), requests.Load())
+}
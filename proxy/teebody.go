@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 	"net/url"
@@ -64,3 +65,29 @@ func cloneRequestForSplit(u *url.URL, req *http.Request) (*http.Request, io.Read
 
 	return clone, mainBody, nil
 }
+
+// bufferBody reads the request body up to the limit, so that it can be sent
+// multiple times. When the body is larger than the limit or cannot be read,
+// the request body is restored to stream the read part and the rest of the
+// body, and false is returned.
+func bufferBody(req *http.Request, limit int64) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+
+	if req.ContentLength > limit {
+		return nil, false
+	}
+
+	b, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil || int64(len(b)) > limit {
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(b), req.Body), req.Body}
+		return nil, false
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, true
+}
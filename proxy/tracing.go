@@ -40,6 +40,10 @@ const (
 	BackendErrorTag        = "backend.error"
 	RouteLookupErrorTag    = "route_lookup.error"
 	CircuitBreakerTag      = "circuit_breaker"
+	RetryAttemptTag        = "skipper.retry.attempt"
+	RetryReasonTag         = "skipper.retry.reason"
+	HedgeTag               = "skipper.hedge"
+	HedgeCancelledTag      = "skipper.hedge.cancelled"
 
 	FilterStartTagSuffix = ".start"
 	FilterEndTagSuffix   = ".end"
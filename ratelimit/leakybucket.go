@@ -3,6 +3,7 @@ package ratelimit
 import (
 	"context"
 	_ "embed"
+	"strconv"
 	"time"
 )
 
@@ -22,6 +23,10 @@ var leakyBucketScript string
 // LeakyBucketLimiter is the interface for cluster leaky bucket implementations.
 type LeakyBucketLimiter interface {
 	Add(ctx context.Context, label string, increment int) (added bool, retry time.Duration, err error)
+
+	// Remaining returns the free capacity of the bucket identified by
+	// the label in units and the duration until the bucket drains out.
+	Remaining(ctx context.Context, label string) (remaining int, reset time.Duration, err error)
 }
 
 // NewClusterLeakyBucket creates a class of leaky buckets of a given capacity and emission.
@@ -38,3 +43,21 @@ func NewClusterLeakyBucket(r *Registry, capacity int, emission time.Duration) Le
 	}
 	return newClusterLeakyBucketRedis(r.redisRing, capacity, emission, time.Now)
 }
+
+// leakyBucketRemaining returns the free capacity in units and the duration
+// until the bucket drains out, from the stored drain out timestamp in
+// microseconds.
+func leakyBucketRemaining(capacity int, emission time.Duration, emptyAt string, now time.Time) (int, time.Duration, error) {
+	us, err := strconv.ParseFloat(emptyAt, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	drain := time.UnixMicro(int64(us)).Sub(now)
+	if drain <= 0 {
+		return capacity, 0, nil
+	}
+
+	level := int((drain + emission - 1) / emission)
+	return max(0, capacity-level), drain, nil
+}
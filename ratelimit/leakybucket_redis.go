@@ -3,11 +3,13 @@ package ratelimit
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"github.com/redis/go-redis/v9"
 
 	"github.com/zalando/skipper/metrics"
 	"github.com/zalando/skipper/net"
@@ -85,6 +87,20 @@ func (b *ClusterLeakyBucket) add(ctx context.Context, label string, increment in
 	return
 }
 
+// Remaining returns the free capacity of the bucket identified by the label
+// in units and the duration until the bucket drains out.
+func (b *ClusterLeakyBucket) Remaining(ctx context.Context, label string) (remaining int, reset time.Duration, err error) {
+	now := b.now()
+	emptyAt, err := b.ringClient.Get(ctx, b.getBucketId(label))
+	if errors.Is(err, redis.Nil) {
+		return b.capacity, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return leakyBucketRemaining(b.capacity, b.emission, emptyAt, now)
+}
+
 func (b *ClusterLeakyBucket) getBucketId(label string) string {
 	return leakyBucketRedisKeyPrefix + getHashedKey(b.labelPrefix+label)
 }
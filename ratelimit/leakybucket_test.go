@@ -3,6 +3,7 @@ package ratelimit
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
@@ -187,6 +188,11 @@ func TestLeakyBucketStoredNumberPrecision(t *testing.T) {
 
 		expected := now.UnixMicro() + (increment * emission).Microseconds()
 		assert.Equal(t, fmt.Sprintf("%d", expected), v)
+
+		remaining, reset, err := b.Remaining(context.Background(), label)
+		require.NoError(t, err)
+		assert.Equal(t, capacity-increment, remaining)
+		assert.Equal(t, increment*emission, reset)
 	})
 	t.Run("valkey", func(t *testing.T) {
 		valkeyAddr, done := valkeytest.NewTestValkey(t)
@@ -207,5 +213,40 @@ func TestLeakyBucketStoredNumberPrecision(t *testing.T) {
 
 		expected := now.UnixMicro() + (increment * emission).Microseconds()
 		assert.Equal(t, fmt.Sprintf("%d", expected), v)
+
+		remaining, reset, err := b.Remaining(context.Background(), label)
+		require.NoError(t, err)
+		assert.Equal(t, capacity-increment, remaining)
+		assert.Equal(t, increment*emission, reset)
 	})
 }
+
+func TestLeakyBucketRemaining(t *testing.T) {
+	now := time.Now()
+	emptyAt := func(d time.Duration) string {
+		return fmt.Sprintf("%d", now.Add(d).UnixMicro())
+	}
+
+	for _, tt := range []struct {
+		name      string
+		emptyAt   string
+		remaining int
+		reset     time.Duration
+	}{
+		{name: "drained out", emptyAt: emptyAt(-time.Second), remaining: 4},
+		{name: "partially filled", emptyAt: emptyAt(2 * time.Second), remaining: 2, reset: 2 * time.Second},
+		{name: "partial unit is not free", emptyAt: emptyAt(1500 * time.Millisecond), remaining: 2, reset: 1500 * time.Millisecond},
+		{name: "full", emptyAt: emptyAt(4 * time.Second), remaining: 0, reset: 4 * time.Second},
+		{name: "float notation", emptyAt: strconv.FormatFloat(float64(now.Add(time.Hour).UnixMicro()), 'e', -1, 64), remaining: 0, reset: time.Hour},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining, reset, err := leakyBucketRemaining(4, time.Second, tt.emptyAt, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.remaining, remaining)
+			assert.InDelta(t, tt.reset, reset, float64(time.Millisecond))
+		})
+	}
+
+	_, _, err := leakyBucketRemaining(4, time.Second, "invalid", now)
+	assert.Error(t, err)
+}
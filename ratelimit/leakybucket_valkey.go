@@ -88,6 +88,20 @@ func (b *ClusterLeakyBucketValkey) add(ctx context.Context, label string, increm
 	return
 }
 
+// Remaining returns the free capacity of the bucket identified by the label
+// in units and the duration until the bucket drains out.
+func (b *ClusterLeakyBucketValkey) Remaining(ctx context.Context, label string) (remaining int, reset time.Duration, err error) {
+	now := b.now()
+	emptyAt, err := b.ringClient.Get(ctx, b.getBucketId(label))
+	if valkey.IsValkeyNil(err) {
+		return b.capacity, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return leakyBucketRemaining(b.capacity, b.emission, emptyAt, now)
+}
+
 func (b *ClusterLeakyBucketValkey) getBucketId(label string) string {
 	return leakyBucketRedisKeyPrefix + getHashedKey(b.labelPrefix+label)
 }
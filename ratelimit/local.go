@@ -0,0 +1,214 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	circularbuffer "github.com/szuecs/rate-limit-buffer"
+)
+
+// hitBuffer is a circular buffer, that can count several hits at once.
+type hitBuffer struct {
+	mu sync.Mutex
+	*circularbuffer.CircularBuffer
+}
+
+func newHitBuffer(maxHits int, window time.Duration) *hitBuffer {
+	return &hitBuffer{CircularBuffer: circularbuffer.NewCircularBuffer(maxHits, window)}
+}
+
+// addN adds n hits to the buffer, if it has n free slots. With partial
+// set, it adds as many of the n hits as there are free slots.
+func (b *hitBuffer) addN(n int, partial bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n == 1 {
+		return b.Add(time.Now())
+	}
+
+	if free := b.Cap() - b.Len(); n > free {
+		if !partial {
+			return false
+		}
+		n = free
+	}
+
+	now := time.Now()
+	for range n {
+		b.Add(now)
+	}
+	return true
+}
+
+// serviceLimiter is the circular buffer of a service ratelimit, that can
+// also report the remaining quota.
+type serviceLimiter struct {
+	*hitBuffer
+	window time.Duration
+}
+
+func newServiceLimiter(maxHits int, window time.Duration) *serviceLimiter {
+	return &serviceLimiter{
+		hitBuffer: newHitBuffer(maxHits, window),
+		window:    window,
+	}
+}
+
+// Allow adds a hit to the buffer, if it has a free slot.
+func (l *serviceLimiter) Allow(context.Context, string) bool {
+	return l.addN(1, false)
+}
+
+// AllowN adds n hits to the buffer, if it has n free slots.
+func (l *serviceLimiter) AllowN(_ context.Context, _ string, n int) bool {
+	return l.addN(n, false)
+}
+
+// Charge adds up to n hits to the buffer.
+func (l *serviceLimiter) Charge(_ context.Context, _ string, n int) {
+	l.addN(n, true)
+}
+
+// Remaining returns the number of free slots of the buffer and the
+// duration until the newest hit leaves the time window.
+func (l *serviceLimiter) Remaining(context.Context, string) (int, time.Duration) {
+	return l.Cap() - l.Len(), resetAfter(l.Current(""), l.window)
+}
+
+// clientLimiter keeps a circular buffer per client, like
+// circularbuffer.ClientRateLimiter, but can also report the remaining
+// quota of a client.
+type clientLimiter struct {
+	mu      sync.RWMutex
+	bag     map[string]*hitBuffer
+	maxHits int
+	window  time.Duration
+	quit    chan struct{}
+}
+
+func newClientLimiter(maxHits int, window, cleanInterval time.Duration) *clientLimiter {
+	l := &clientLimiter{
+		bag:     make(map[string]*hitBuffer),
+		maxHits: maxHits,
+		window:  window,
+		quit:    make(chan struct{}),
+	}
+	go l.startCleaner(cleanInterval)
+	return l
+}
+
+func (l *clientLimiter) buffer(s string) *hitBuffer {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.bag[s]
+}
+
+// newBuffer returns the buffer of the client and creates it, if the
+// client has none yet.
+func (l *clientLimiter) newBuffer(s string) *hitBuffer {
+	if cb := l.buffer(s); cb != nil {
+		return cb
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cb := l.bag[s]
+	if cb == nil {
+		cb = newHitBuffer(l.maxHits, l.window)
+		l.bag[s] = cb
+	}
+	return cb
+}
+
+// Allow adds a hit to the buffer of the client, if it has a free slot.
+func (l *clientLimiter) Allow(_ context.Context, s string) bool {
+	return l.newBuffer(s).addN(1, false)
+}
+
+// AllowN adds n hits to the buffer of the client, if it has n free
+// slots.
+func (l *clientLimiter) AllowN(_ context.Context, s string, n int) bool {
+	return l.newBuffer(s).addN(n, false)
+}
+
+// Charge adds up to n hits to the buffer of the client.
+func (l *clientLimiter) Charge(_ context.Context, s string, n int) {
+	l.newBuffer(s).addN(n, true)
+}
+
+// Close stops the cleanup goroutine.
+func (l *clientLimiter) Close() {
+	close(l.quit)
+}
+
+func (l *clientLimiter) Delta(s string) time.Duration {
+	cb := l.buffer(s)
+	if cb == nil {
+		return 24 * time.Hour
+	}
+	return cb.Delta(s)
+}
+
+func (l *clientLimiter) Oldest(s string) time.Time {
+	cb := l.buffer(s)
+	if cb == nil {
+		return time.Time{}
+	}
+	return cb.Oldest(s)
+}
+
+func (l *clientLimiter) Resize(s string, n int) {
+	if cb := l.buffer(s); cb != nil {
+		cb.Resize(s, n)
+	}
+}
+
+func (l *clientLimiter) RetryAfter(s string) int {
+	cb := l.buffer(s)
+	if cb == nil {
+		return 0
+	}
+	return cb.RetryAfter(s)
+}
+
+// Remaining returns the number of free slots of the buffer of the client
+// and the duration until its newest hit leaves the time window.
+func (l *clientLimiter) Remaining(_ context.Context, s string) (int, time.Duration) {
+	cb := l.buffer(s)
+	if cb == nil {
+		return l.maxHits, 0
+	}
+	return cb.Cap() - cb.Len(), resetAfter(cb.Current(s), l.window)
+}
+
+func (l *clientLimiter) deleteOld() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for s, cb := range l.bag {
+		if !cb.InUse() {
+			delete(l.bag, s)
+		}
+	}
+}
+
+func (l *clientLimiter) startCleaner(d time.Duration) {
+	for {
+		select {
+		case <-l.quit:
+			return
+		case <-time.After(d):
+			l.deleteOld()
+		}
+	}
+}
+
+// resetAfter returns the duration until the hit at newest leaves the
+// time window.
+func resetAfter(newest time.Time, window time.Duration) time.Duration {
+	if newest.IsZero() {
+		return 0
+	}
+	return max(0, time.Until(newest.Add(window)))
+}
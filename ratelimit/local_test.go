@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalLimiterRemaining(t *testing.T) {
+	const maxHits = 3
+	window := time.Minute
+
+	for _, tt := range []struct {
+		name string
+		l    limiter
+	}{
+		{name: "service", l: newServiceLimiter(maxHits, window)},
+		{name: "client", l: newClientLimiter(maxHits, window, window)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.l.Close()
+			ctx := context.Background()
+
+			remaining, reset := tt.l.Remaining(ctx, "foo")
+			assert.Equal(t, maxHits, remaining)
+			assert.Zero(t, reset)
+
+			for i := range maxHits {
+				assert.True(t, tt.l.Allow(ctx, "foo"))
+
+				remaining, reset = tt.l.Remaining(ctx, "foo")
+				assert.Equal(t, maxHits-i-1, remaining)
+				assert.InDelta(t, window, reset, float64(time.Second))
+			}
+
+			assert.False(t, tt.l.Allow(ctx, "foo"))
+			remaining, _ = tt.l.Remaining(ctx, "foo")
+			assert.Zero(t, remaining)
+		})
+	}
+}
+
+func TestClientLimiterBuckets(t *testing.T) {
+	l := newClientLimiter(1, time.Minute, time.Minute)
+	defer l.Close()
+	ctx := context.Background()
+
+	assert.True(t, l.Allow(ctx, "foo"))
+	assert.False(t, l.Allow(ctx, "foo"))
+	assert.True(t, l.Allow(ctx, "bar"))
+
+	remaining, _ := l.Remaining(ctx, "baz")
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, 0, l.RetryAfter("baz"))
+	assert.Positive(t, l.RetryAfter("foo"))
+
+	l.deleteOld()
+	assert.NotNil(t, l.buffer("foo"), "buffers in use must not be deleted")
+}
+
+func TestLocalLimiterAllowN(t *testing.T) {
+	const maxHits = 5
+	window := time.Minute
+
+	for _, tt := range []struct {
+		name string
+		l    limiter
+	}{
+		{name: "service", l: newServiceLimiter(maxHits, window)},
+		{name: "client", l: newClientLimiter(maxHits, window, window)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.l.Close()
+			ctx := context.Background()
+
+			assert.False(t, tt.l.AllowN(ctx, "foo", maxHits+1), "more hits than the maximum")
+			assert.True(t, tt.l.AllowN(ctx, "foo", 3))
+			assert.False(t, tt.l.AllowN(ctx, "foo", 3), "the hits exceed the remaining quota")
+			assert.True(t, tt.l.AllowN(ctx, "foo", 2))
+			assert.False(t, tt.l.Allow(ctx, "foo"))
+		})
+	}
+}
+
+func TestLocalLimiterCharge(t *testing.T) {
+	const maxHits = 5
+	window := time.Minute
+
+	for _, tt := range []struct {
+		name string
+		l    limiter
+	}{
+		{name: "service", l: newServiceLimiter(maxHits, window)},
+		{name: "client", l: newClientLimiter(maxHits, window, window)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.l.Close()
+			ctx := context.Background()
+
+			tt.l.Charge(ctx, "foo", 2)
+			remaining, _ := tt.l.Remaining(ctx, "foo")
+			assert.Equal(t, 3, remaining)
+
+			tt.l.Charge(ctx, "foo", 10)
+			remaining, _ = tt.l.Remaining(ctx, "foo")
+			assert.Zero(t, remaining)
+			assert.False(t, tt.l.Allow(ctx, "foo"))
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package ratelimit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultOverridesUpdateInterval is the default interval to reread
+// the overrides file.
+const DefaultOverridesUpdateInterval = 10 * time.Second
+
+// Override changes the limit of a cluster ratelimit group, or of a
+// single client of the group, at runtime without a route update.
+type Override struct {
+	// Group is the group of the cluster ratelimit to override.
+	Group string `yaml:"group"`
+
+	// Client is the optional key of a single client to override,
+	// as found by the Lookuper of the ratelimit, for example the
+	// value of the Authorization header or the client IP.
+	Client string `yaml:"client"`
+
+	// MaxHits is the maximum number of hits for the time window.
+	// Zero denies all requests.
+	MaxHits int `yaml:"max-hits"`
+
+	// TimeWindow optionally changes the time window of the
+	// ratelimit.
+	TimeWindow time.Duration `yaml:"time-window"`
+}
+
+type overrideKey struct {
+	group  string
+	client string
+}
+
+// overrides is a snapshot of the overrides, that caches the ratelimits
+// of the overridden settings, so that the requests don't take the lock
+// of the registry.
+type overrides struct {
+	byKey  map[overrideKey]Override
+	limits sync.Map // overrideLimitKey -> *Ratelimit
+}
+
+type overrideLimitKey struct {
+	settings Settings
+	override overrideKey
+}
+
+// ParseOverrides parses a YAML list of overrides, for example:
+//
+//	# lower quota of a single abusive client
+//	- group: api
+//	  client: "Bearer abc"
+//	  max-hits: 10
+//	# higher quota of the whole group
+//	- group: partner
+//	  max-hits: 10000
+//	  time-window: 1m
+func ParseOverrides(data []byte) ([]Override, error) {
+	var list []Override
+	if err := yaml.UnmarshalStrict(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse ratelimit overrides: %w", err)
+	}
+
+	seen := make(map[overrideKey]struct{}, len(list))
+	for _, o := range list {
+		if o.Group == "" {
+			return nil, fmt.Errorf("invalid ratelimit override, missing group: %+v", o)
+		}
+		if o.MaxHits < 0 || o.TimeWindow < 0 {
+			return nil, fmt.Errorf("invalid ratelimit override, negative limit: %+v", o)
+		}
+
+		k := overrideKey{group: o.Group, client: o.Client}
+		if _, ok := seen[k]; ok {
+			return nil, fmt.Errorf("duplicate ratelimit override of group %q and client %q", o.Group, o.Client)
+		}
+		seen[k] = struct{}{}
+	}
+
+	return list, nil
+}
+
+// SetOverrides atomically replaces all overrides of the cluster
+// ratelimits of the registry. Overrides of a single client take
+// precedence over the overrides of the whole group.
+func (r *Registry) SetOverrides(list []Override) {
+	if len(list) == 0 {
+		r.overrides.Store(nil)
+		return
+	}
+
+	m := &overrides{byKey: make(map[overrideKey]Override, len(list))}
+	for _, o := range list {
+		m.byKey[overrideKey{group: o.Group, client: o.Client}] = o
+	}
+	r.overrides.Store(m)
+}
+
+// shardedGroup returns the group and the number of shards of a sharded
+// cluster ratelimit group, named <group>.<shards>, or the group and one
+// shard otherwise.
+func shardedGroup(group string) (string, int) {
+	i := strings.LastIndexByte(group, '.')
+	if i < 0 {
+		return group, 1
+	}
+
+	shards, err := strconv.Atoi(group[i+1:])
+	if err != nil || shards < 2 {
+		return group, 1
+	}
+
+	return group[:i], shards
+}
+
+func (m *overrides) lookup(group, client string) (overrideKey, bool) {
+	for _, k := range [...]overrideKey{{group: group, client: client}, {group: group}} {
+		if _, ok := m.byKey[k]; ok {
+			return k, true
+		}
+	}
+
+	return overrideKey{}, false
+}
+
+// find returns the key of the override of the client in the group, and
+// the number of shards, that the limit of the override is divided by,
+// when only the group of a sharded group is overridden.
+func (m *overrides) find(group, client string) (overrideKey, int, bool) {
+	if k, ok := m.lookup(group, client); ok {
+		return k, 1, true
+	}
+
+	if g, shards := shardedGroup(group); shards > 1 {
+		if k, ok := m.lookup(g, client); ok {
+			return k, shards, true
+		}
+	}
+
+	return overrideKey{}, 0, false
+}
+
+// override returns the ratelimit of the overridden settings for the
+// client s.
+func (r *Registry) override(settings Settings, s string) (*Ratelimit, bool) {
+	m := r.overrides.Load()
+	if m == nil || settings.Group == "" {
+		return nil, false
+	}
+
+	if settings.Type != ClusterServiceRatelimit && settings.Type != ClusterClientRatelimit {
+		return nil, false
+	}
+
+	k, shards, ok := m.find(settings.Group, s)
+	if !ok {
+		return nil, false
+	}
+
+	lk := overrideLimitKey{settings: settings, override: k}
+	if rl, ok := m.limits.Load(lk); ok {
+		return rl.(*Ratelimit), true
+	}
+
+	o := m.byKey[k]
+	settings.MaxHits = o.MaxHits / shards
+	if o.MaxHits > 0 {
+		// every shard allows at least one hit, unless denied explicitly
+		settings.MaxHits = max(1, settings.MaxHits)
+	}
+
+	if o.TimeWindow > 0 {
+		settings.TimeWindow = o.TimeWindow
+		if settings.Type == ClusterClientRatelimit {
+			settings.CleanInterval = 10 * o.TimeWindow
+		}
+	}
+
+	rl := r.get(settings)
+	m.limits.Store(lk, rl)
+	return rl, true
+}
+
+// WatchOverrides reads the overrides from the YAML file at path, see
+// ParseOverrides, and rereads it every d interval until the registry
+// is closed. A Kubernetes ConfigMap can be used as source by mounting
+// it as a volume, in which case the updates of the ConfigMap are only
+// seen after the kubelet synced the volume, typically within a minute.
+// Invalid updates of the file are logged and the previous overrides
+// stay active.
+func (r *Registry) WatchOverrides(path string, d time.Duration) error {
+	if d <= 0 {
+		d = DefaultOverridesUpdateInterval
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read ratelimit overrides: %w", err)
+	}
+
+	list, err := ParseOverrides(data)
+	if err != nil {
+		return err
+	}
+	r.SetOverrides(list)
+	log.Infof("Loaded %d ratelimit overrides from %s", len(list), path)
+
+	r.Lock()
+	if r.quit == nil {
+		r.quit = make(chan struct{})
+	}
+	quit := r.quit
+	r.Unlock()
+
+	go r.watchOverrides(path, d, data, quit)
+	return nil
+}
+
+func (r *Registry) watchOverrides(path string, d time.Duration, last []byte, quit <-chan struct{}) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				log.Errorf("Failed to read ratelimit overrides from %s: %v", path, err)
+				continue
+			}
+			if bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+
+			list, err := ParseOverrides(data)
+			if err != nil {
+				log.Errorf("Failed to update ratelimit overrides from %s: %v", path, err)
+				continue
+			}
+
+			r.SetOverrides(list)
+			log.Infof("Updated %d ratelimit overrides from %s", len(list), path)
+		case <-quit:
+			return
+		}
+	}
+}
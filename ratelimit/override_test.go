@@ -0,0 +1,221 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOverrides(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		data    string
+		want    []Override
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			data: "",
+		},
+		{
+			name: "overrides",
+			data: `
+- group: api
+  client: "Bearer abc"
+  max-hits: 10
+- group: partner
+  max-hits: 0
+  time-window: 1m
+`,
+			want: []Override{
+				{Group: "api", Client: "Bearer abc", MaxHits: 10},
+				{Group: "partner", MaxHits: 0, TimeWindow: time.Minute},
+			},
+		},
+		{
+			name:    "invalid yaml",
+			data:    "group: api",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			data:    "- group: api\n  maxhits: 10",
+			wantErr: true,
+		},
+		{
+			name:    "missing group",
+			data:    "- max-hits: 10",
+			wantErr: true,
+		},
+		{
+			name:    "negative max hits",
+			data:    "- group: api\n  max-hits: -1",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate override",
+			data:    "- group: api\n  max-hits: 1\n- group: api\n  max-hits: 2",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOverrides([]byte(tt.data))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRegistryOverrides(t *testing.T) {
+	r := NewRegistry()
+	defer r.Close()
+
+	ctx := context.Background()
+	settings := Settings{
+		Type:       ClusterClientRatelimit,
+		Group:      "api",
+		MaxHits:    10,
+		TimeWindow: time.Second,
+		Lookuper:   NewHeaderLookuper("Authorization"),
+	}
+
+	// without a swarm the cluster ratelimit allows all requests
+	rl := r.Get(settings)
+	require.True(t, rl.Allow(ctx, "abusive"))
+
+	r.SetOverrides([]Override{
+		{Group: "api", Client: "abusive", MaxHits: 0},
+		{Group: "api", MaxHits: 100, TimeWindow: time.Minute},
+		{Group: "other", MaxHits: 0},
+	})
+
+	assert.False(t, rl.Allow(ctx, "abusive"))
+	assert.True(t, rl.Allow(ctx, "good"))
+
+	maxHits, timeWindow := rl.Limit("abusive")
+	assert.Equal(t, 0, maxHits)
+	assert.Equal(t, time.Second, timeWindow)
+
+	maxHits, timeWindow = rl.Limit("good")
+	assert.Equal(t, 100, maxHits)
+	assert.Equal(t, time.Minute, timeWindow)
+
+	r.SetOverrides(nil)
+
+	assert.True(t, rl.Allow(ctx, "abusive"))
+	maxHits, timeWindow = rl.Limit("abusive")
+	assert.Equal(t, 10, maxHits)
+	assert.Equal(t, time.Second, timeWindow)
+}
+
+func TestRegistryOverridesShardedGroup(t *testing.T) {
+	r := NewRegistry()
+	defer r.Close()
+
+	// a sharded clusterRatelimit("api", 30, "1s") with 3 shards
+	rl := r.Get(Settings{
+		Type:       ClusterServiceRatelimit,
+		Group:      "api.3",
+		MaxHits:    10,
+		TimeWindow: time.Second,
+		Lookuper:   NewRoundRobinLookuper(3),
+	})
+
+	r.SetOverrides([]Override{{Group: "api", MaxHits: 60}})
+	maxHits, _ := rl.Limit("1")
+	assert.Equal(t, 20, maxHits, "the override is divided by the shards")
+
+	r.SetOverrides([]Override{{Group: "api", MaxHits: 1}})
+	maxHits, _ = rl.Limit("1")
+	assert.Equal(t, 1, maxHits, "every shard allows a hit")
+
+	r.SetOverrides([]Override{{Group: "api", MaxHits: 0}})
+	assert.False(t, rl.Allow(context.Background(), "1"))
+
+	r.SetOverrides([]Override{{Group: "api", MaxHits: 60}, {Group: "api.3", MaxHits: 5}})
+	maxHits, _ = rl.Limit("1")
+	assert.Equal(t, 5, maxHits, "the override of the shard takes precedence")
+}
+
+func TestRegistryOverridesCached(t *testing.T) {
+	r := NewRegistry()
+	defer r.Close()
+
+	settings := Settings{
+		Type:       ClusterClientRatelimit,
+		Group:      "api",
+		MaxHits:    10,
+		TimeWindow: time.Second,
+		Lookuper:   NewHeaderLookuper("Authorization"),
+	}
+
+	r.SetOverrides([]Override{{Group: "api", MaxHits: 100}})
+	o1, ok := r.override(settings, "foo")
+	require.True(t, ok)
+	o2, ok := r.override(settings, "bar")
+	require.True(t, ok)
+	assert.Same(t, o1, o2, "the clients of a group override share the ratelimit")
+
+	r.SetOverrides([]Override{{Group: "api", MaxHits: 200}})
+	o3, ok := r.override(settings, "foo")
+	require.True(t, ok)
+	maxHits, _ := o3.Limit("foo")
+	assert.Equal(t, 200, maxHits)
+}
+
+func TestRegistryOverridesLocalRatelimit(t *testing.T) {
+	r := NewRegistry()
+	defer r.Close()
+
+	r.SetOverrides([]Override{{Group: "api", MaxHits: 0}})
+
+	rl := r.Get(Settings{
+		Type:       ClientRatelimit,
+		Group:      "api",
+		MaxHits:    1,
+		TimeWindow: time.Minute,
+	})
+
+	assert.True(t, rl.Allow(context.Background(), "foo"), "local ratelimits are not overridden")
+}
+
+func TestRegistryWatchOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+
+	r := NewRegistry()
+	defer r.Close()
+
+	err := r.WatchOverrides(path, 10*time.Millisecond)
+	assert.Error(t, err, "the file must exist")
+
+	require.NoError(t, os.WriteFile(path, []byte("- group: api\n  max-hits: 0\n"), 0644))
+	require.NoError(t, r.WatchOverrides(path, 10*time.Millisecond))
+
+	rl := r.Get(Settings{
+		Type:       ClusterServiceRatelimit,
+		Group:      "api",
+		MaxHits:    10,
+		TimeWindow: time.Second,
+		Lookuper:   NewSameBucketLookuper(),
+	})
+	assert.False(t, rl.Allow(context.Background(), sameBucket))
+
+	// invalid updates keep the previous overrides
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, rl.Allow(context.Background(), sameBucket))
+
+	require.NoError(t, os.WriteFile(path, []byte("- group: other\n  max-hits: 0\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return rl.Allow(context.Background(), sameBucket)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -6,13 +6,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
-	circularbuffer "github.com/szuecs/rate-limit-buffer"
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/net"
 )
@@ -25,6 +25,15 @@ const (
 	// long a client should wait before making a new request
 	RetryAfterHeader = "Retry-After"
 
+	// PolicyHeader is the name of the IETF draft header advertising the
+	// quota policy, see
+	// https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/
+	PolicyHeader = "RateLimit-Policy"
+
+	// QuotaHeader is the name of the IETF draft header informing about
+	// the limit, the remaining quota and the seconds until the quota resets
+	QuotaHeader = "RateLimit"
+
 	// Deprecated, use filters.RatelimitName instead
 	ServiceRatelimitName = filters.RatelimitName
 
@@ -342,6 +351,16 @@ type limiter interface {
 	// call with context, to pass or to ratelimit
 	Allow(context.Context, string) bool
 
+	// AllowN is like Allow, but counts the call as n hits, for
+	// calls that are more expensive than others. Calls of more
+	// hits than the maximum are never allowed.
+	AllowN(context.Context, string, int) bool
+
+	// Charge counts up to n hits without a decision, for costs
+	// that are only known after the call was allowed. It counts
+	// no more hits than the quota has left.
+	Charge(context.Context, string, int)
+
 	// Close is used to clean up underlying limiter
 	// implementations, if you want to stop a Ratelimiter
 	Close()
@@ -360,6 +379,11 @@ type limiter interface {
 	// RetryAfter is used to inform the client how many seconds it
 	// should wait before making a new request
 	RetryAfter(string) int
+
+	// Remaining is used to get the number of calls left in the
+	// current time window and the duration until the full quota
+	// is available again
+	Remaining(context.Context, string) (int, time.Duration)
 }
 
 // Ratelimit is a proxy object that delegates to limiter
@@ -367,6 +391,18 @@ type limiter interface {
 type Ratelimit struct {
 	settings Settings
 	impl     limiter
+	registry *Registry // provides the overrides of the settings
+}
+
+// limiter returns the ratelimit of the client s, which differs from l,
+// if its settings are overridden at runtime.
+func (l *Ratelimit) limiter(s string) *Ratelimit {
+	if l.registry != nil {
+		if o, ok := l.registry.override(l.settings, s); ok {
+			return o
+		}
+	}
+	return l
 }
 
 // Allow is used to get a decision if you should allow the call
@@ -376,7 +412,25 @@ func (l *Ratelimit) Allow(ctx context.Context, s string) bool {
 		return true
 	}
 
-	return l.impl.Allow(ctx, s)
+	return l.limiter(s).impl.Allow(ctx, s)
+}
+
+// AllowN is like Allow, but the call costs n hits.
+func (l *Ratelimit) AllowN(ctx context.Context, s string, n int) bool {
+	if l == nil {
+		return true
+	}
+
+	return l.limiter(s).impl.AllowN(ctx, s, n)
+}
+
+// Charge counts up to n more hits for an already allowed call.
+func (l *Ratelimit) Charge(ctx context.Context, s string, n int) {
+	if l == nil {
+		return
+	}
+
+	l.limiter(s).impl.Charge(ctx, s, n)
 }
 
 // Close will stop any cleanup goroutines in underlying limiter implementation.
@@ -389,11 +443,32 @@ func (l *Ratelimit) RetryAfter(s string) int {
 	if l == nil {
 		return 0
 	}
-	return l.impl.RetryAfter(s)
+	return l.limiter(s).impl.RetryAfter(s)
+}
+
+// Remaining returns the number of calls left in the current time
+// window and the duration until the full quota is available again.
+// Ratelimits that never limit the calls report math.MaxInt calls.
+func (l *Ratelimit) Remaining(ctx context.Context, s string) (int, time.Duration) {
+	if l == nil {
+		return 0, 0
+	}
+	return l.limiter(s).impl.Remaining(ctx, s)
+}
+
+// Limit returns the maximum number of hits and the time window of
+// the client s, which differ from the settings, if they are
+// overridden at runtime.
+func (l *Ratelimit) Limit(s string) (int, time.Duration) {
+	if l == nil {
+		return 0, 0
+	}
+	o := l.limiter(s)
+	return o.settings.MaxHits, o.settings.TimeWindow
 }
 
 func (l *Ratelimit) Delta(s string) time.Duration {
-	return l.impl.Delta(s)
+	return l.limiter(s).impl.Delta(s)
 }
 
 func (l *Ratelimit) Resize(s string, i int) {
@@ -402,12 +477,19 @@ func (l *Ratelimit) Resize(s string, i int) {
 
 type voidRatelimit struct{}
 
-func (voidRatelimit) Allow(context.Context, string) bool { return true }
-func (voidRatelimit) Close()                             {}
-func (voidRatelimit) Oldest(string) time.Time            { return time.Time{} }
-func (voidRatelimit) RetryAfter(string) int              { return 0 }
-func (voidRatelimit) Delta(string) time.Duration         { return -1 * time.Second }
-func (voidRatelimit) Resize(string, int)                 {}
+func (voidRatelimit) Allow(context.Context, string) bool       { return true }
+func (voidRatelimit) AllowN(context.Context, string, int) bool { return true }
+func (voidRatelimit) Charge(context.Context, string, int)      {}
+func (voidRatelimit) Close()                                   {}
+func (voidRatelimit) Oldest(string) time.Time                  { return time.Time{} }
+func (voidRatelimit) RetryAfter(string) int                    { return 0 }
+func (voidRatelimit) Delta(string) time.Duration               { return -1 * time.Second }
+func (voidRatelimit) Resize(string, int)                       {}
+
+// Remaining reports an unlimited quota, because voidRatelimit never limits
+func (voidRatelimit) Remaining(context.Context, string) (int, time.Duration) {
+	return math.MaxInt, 0
+}
 
 type zeroRatelimit struct{}
 
@@ -420,12 +502,18 @@ const (
 	zeroRetry int           = int(zeroDelta / time.Second)
 )
 
-func (zeroRatelimit) Allow(context.Context, string) bool { return false }
-func (zeroRatelimit) Close()                             {}
-func (zeroRatelimit) Oldest(string) time.Time            { return time.Time{} }
-func (zeroRatelimit) RetryAfter(string) int              { return zeroRetry }
-func (zeroRatelimit) Delta(string) time.Duration         { return zeroDelta }
-func (zeroRatelimit) Resize(string, int)                 {}
+func (zeroRatelimit) Allow(context.Context, string) bool       { return false }
+func (zeroRatelimit) AllowN(context.Context, string, int) bool { return false }
+func (zeroRatelimit) Charge(context.Context, string, int)      {}
+func (zeroRatelimit) Close()                                   {}
+func (zeroRatelimit) Oldest(string) time.Time                  { return time.Time{} }
+func (zeroRatelimit) RetryAfter(string) int                    { return zeroRetry }
+func (zeroRatelimit) Delta(string) time.Duration               { return zeroDelta }
+func (zeroRatelimit) Resize(string, int)                       {}
+
+func (zeroRatelimit) Remaining(context.Context, string) (int, time.Duration) {
+	return 0, zeroDelta
+}
 
 func newRatelimit(s Settings, sw Swarmer, redisRing *net.RedisRingClient, valkeyRing *net.ValkeyRingClient) *Ratelimit {
 	var impl limiter
@@ -434,12 +522,12 @@ func newRatelimit(s Settings, sw Swarmer, redisRing *net.RedisRingClient, valkey
 	} else {
 		switch s.Type {
 		case ServiceRatelimit:
-			impl = circularbuffer.NewRateLimiter(s.MaxHits, s.TimeWindow)
+			impl = newServiceLimiter(s.MaxHits, s.TimeWindow)
 		case LocalRatelimit:
 			log.Warning("LocalRatelimit is deprecated, please use ClientRatelimit instead")
 			fallthrough
 		case ClientRatelimit:
-			impl = circularbuffer.NewClientRateLimiter(s.MaxHits, s.TimeWindow, s.CleanInterval)
+			impl = newClientLimiter(s.MaxHits, s.TimeWindow, s.CleanInterval)
 		case ClusterServiceRatelimit:
 			s.CleanInterval = 0
 			fallthrough
@@ -464,6 +552,24 @@ func Headers(maxHits int, timeWindow time.Duration, retryAfter int) http.Header
 	}
 }
 
+// QuotaHeaders returns the RateLimit-Policy and RateLimit headers of the
+// IETF draft for a quota of maxHits per timeWindow, of which remaining
+// hits are left until the full quota is available again after reset.
+func QuotaHeaders(maxHits int, timeWindow time.Duration, remaining int, reset time.Duration) http.Header {
+	remaining = max(0, min(remaining, maxHits))
+	h := http.Header{}
+	h.Set(PolicyHeader, fmt.Sprintf("%d;w=%d", maxHits, ceilSeconds(timeWindow)))
+	h.Set(QuotaHeader, fmt.Sprintf("limit=%d, remaining=%d, reset=%d", maxHits, remaining, ceilSeconds(reset)))
+	return h
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
+
 func getHashedKey(clearText string) string {
 	h := sha256.Sum256([]byte(clearText))
 	return hex.EncodeToString(h[:])
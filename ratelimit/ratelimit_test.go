@@ -3,6 +3,7 @@ package ratelimit
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"sync"
 	"testing"
@@ -657,3 +658,61 @@ func TestHeaders(t *testing.T) {
 		t.Errorf("Failed to get Retry-After Header value: %s", s)
 	}
 }
+
+func TestQuotaHeaders(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		maxHits   int
+		window    time.Duration
+		remaining int
+		reset     time.Duration
+		policy    string
+		quota     string
+	}{
+		{
+			name:      "remaining quota",
+			maxHits:   100,
+			window:    time.Minute,
+			remaining: 42,
+			reset:     30 * time.Second,
+			policy:    "100;w=60",
+			quota:     "limit=100, remaining=42, reset=30",
+		},
+		{
+			name:      "seconds are rounded up",
+			maxHits:   10,
+			window:    1500 * time.Millisecond,
+			remaining: 1,
+			reset:     100 * time.Millisecond,
+			policy:    "10;w=2",
+			quota:     "limit=10, remaining=1, reset=1",
+		},
+		{
+			name:      "remaining is limited to the quota",
+			maxHits:   10,
+			window:    time.Second,
+			remaining: math.MaxInt,
+			policy:    "10;w=1",
+			quota:     "limit=10, remaining=10, reset=0",
+		},
+		{
+			name:      "negative remaining",
+			maxHits:   10,
+			window:    time.Second,
+			remaining: -1,
+			reset:     -time.Second,
+			policy:    "10;w=1",
+			quota:     "limit=10, remaining=0, reset=0",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := QuotaHeaders(tt.maxHits, tt.window, tt.remaining, tt.reset)
+			if s := h.Get(PolicyHeader); s != tt.policy {
+				t.Errorf("Failed to get %s header value, want %q, got %q", PolicyHeader, tt.policy, s)
+			}
+			if s := h.Get(QuotaHeader); s != tt.quota {
+				t.Errorf("Failed to get %s header value, want %q, got %q", QuotaHeader, tt.quota, s)
+			}
+		})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -23,6 +22,7 @@ type clusterLimitRedis struct {
 	maxHits    int64
 	window     time.Duration
 	ringClient *net.RedisRingClient
+	script     *net.RedisScript
 	metrics    metrics.Metrics
 	sometimes  rate.Sometimes
 }
@@ -31,8 +31,10 @@ const (
 	RedisMetricsPrefix                    = "swarm.redis."
 	redisAllowMetricsFormat               = RedisMetricsPrefix + "query.allow.%s"
 	redisRetryAfterMetricsFormat          = RedisMetricsPrefix + "query.retryafter.%s"
+	redisRemainingMetricsFormat           = RedisMetricsPrefix + "query.remaining.%s"
 	redisAllowMetricsFormatWithGroup      = RedisMetricsPrefix + "query.allow.%s.%s"
 	redisRetryAfterMetricsFormatWithGroup = RedisMetricsPrefix + "query.retryafter.%s.%s"
+	redisRemainingMetricsFormatWithGroup  = RedisMetricsPrefix + "query.remaining.%s.%s"
 
 	redisAllowSpanName       = "redis_allow"
 	redisOldestScoreSpanName = "redis_oldest_score"
@@ -59,6 +61,7 @@ func newClusterRateLimiterRedis(s Settings, r *net.RedisRingClient, group string
 		maxHits:    int64(s.MaxHits),
 		window:     s.TimeWindow,
 		ringClient: r,
+		script:     r.NewScript(slidingWindowScript),
 		metrics:    metrics.Default,
 		sometimes:  rate.Sometimes{First: 3, Interval: 1 * time.Second},
 	}
@@ -104,13 +107,23 @@ func (c *clusterLimitRedis) commonTags() opentracing.Tags {
 //
 // Performance considerations:
 //
-// In case of deny it will use ZREMRANGEBYSCORE and ZCARD commands in
-// one pipeline to remove old items in the list of hits.
-// In case of allow it will additionally use ZADD with a second
-// roundtrip.
+// It runs a lua script in one roundtrip, that removes the old items in
+// the list of hits with ZREMRANGEBYSCORE, counts them with ZCARD and in
+// case of allow adds the hit with ZADD.
 //
 // Uses provided context for creating an OpenTracing span.
 func (c *clusterLimitRedis) Allow(ctx context.Context, clearText string) bool {
+	return c.AllowN(ctx, clearText, 1)
+}
+
+// AllowN is like Allow, but counts the request as n hits. Requests of
+// more hits than the maximum are never allowed.
+//
+// Performance considerations:
+//
+// Like Allow, it uses one roundtrip. In case of allow, the hits are
+// added with one ZADD per thousand hits.
+func (c *clusterLimitRedis) AllowN(ctx context.Context, clearText string, n int) bool {
 	c.metrics.IncCounter(RedisMetricsPrefix + "total")
 	now := time.Now()
 
@@ -120,7 +133,7 @@ func (c *clusterLimitRedis) Allow(ctx context.Context, clearText string) bool {
 		defer span.Finish()
 	}
 
-	allow, err := c.allow(ctx, clearText)
+	allow, err := c.allow(ctx, clearText, n, false)
 	if errors.Is(err, context.Canceled) {
 		return !c.failClosed
 	}
@@ -146,42 +159,52 @@ func (c *clusterLimitRedis) Allow(ctx context.Context, clearText string) bool {
 	return allow
 }
 
-func (c *clusterLimitRedis) allow(ctx context.Context, clearText string) (bool, error) {
-	s := getHashedKey(clearText)
-	key := c.prefixKey(s)
-
-	now := time.Now()
-	nowNanos := now.UnixNano()
-	clearBefore := now.Add(-c.window).UnixNano()
-
-	// drop all elements of the set which occurred before one interval ago.
-	_, err := c.ringClient.ZRemRangeByScore(ctx, key, 0.0, float64(clearBefore))
+// allow adds n hits, if they do not exceed the maximum. With partial
+// set, it adds as many of the n hits as the maximum allows.
+func (c *clusterLimitRedis) allow(ctx context.Context, clearText string, n int, partial bool) (bool, error) {
+	res, err := c.run(ctx, clearText, n, partial)
 	if err != nil {
 		return false, err
 	}
 
-	// get cardinality
-	count, err := c.ringClient.ZCard(ctx, key)
-	if err != nil {
-		return false, err
-	}
+	return res.allowed, nil
+}
 
-	// we increase later with ZAdd, so max-1
-	if count >= c.maxHits {
-		return false, nil
+func (c *clusterLimitRedis) run(ctx context.Context, clearText string, n int, partial bool) (slidingWindowResult, error) {
+	key := c.prefixKey(getHashedKey(clearText))
+	sargs := slidingWindowArgs(c.maxHits, c.window, time.Now(), n, partial)
+	args := make([]interface{}, 0, len(sargs))
+	for _, a := range sargs {
+		args = append(args, a)
 	}
 
-	_, err = c.ringClient.ZAdd(ctx, key, nowNanos, float64(nowNanos))
+	r, err := c.ringClient.RunScript(ctx, c.script, []string{key}, args...)
 	if err != nil {
-		return false, err
+		return slidingWindowResult{}, err
 	}
 
-	_, err = c.ringClient.Expire(ctx, key, c.window+time.Second)
-	if err != nil {
-		return false, err
+	a, ok := r.([]interface{})
+	if !ok || len(a) != 3 {
+		return slidingWindowResult{}, fmt.Errorf("unexpected sliding window result: %v", r)
 	}
 
-	return true, nil
+	allowed, ok1 := a[0].(int64)
+	count, ok2 := a[1].(int64)
+	newest, ok3 := a[2].(string)
+	if !ok1 || !ok2 || !ok3 {
+		return slidingWindowResult{}, fmt.Errorf("unexpected sliding window result: %v", r)
+	}
+
+	return newSlidingWindowResult(allowed, count, newest)
+}
+
+// Charge counts up to n hits across the cluster without a decision,
+// for example for costs that are only known after the request was
+// allowed.
+func (c *clusterLimitRedis) Charge(ctx context.Context, clearText string, n int) {
+	if _, err := c.allow(ctx, clearText, n, true); err != nil {
+		c.logError("Failed to charge hits: %v", err)
+	}
 }
 
 // Close cannot decide to teardown redis ring, because it is not the
@@ -253,13 +276,13 @@ func (c *clusterLimitRedis) oldest(ctx context.Context, clearText string) (time.
 		return time.Time{}, errors.New(msg)
 	}
 
-	oldest, err := strconv.ParseInt(s, 10, 64)
+	oldest, err := parseHitTime(s)
 	if err != nil {
-		redisSetError(span, fmt.Sprintf("failed to convert value to int64: %v", err), redisErrorFailedToConvert)
-		return time.Time{}, fmt.Errorf("failed to convert value to int64: %w", err)
+		redisSetError(span, err.Error(), redisErrorFailedToConvert)
+		return time.Time{}, err
 	}
 
-	return time.Unix(0, oldest), nil
+	return oldest, nil
 }
 
 // Oldest returns the oldest known request time.
@@ -316,3 +339,38 @@ func (c *clusterLimitRedis) RetryAfterContext(ctx context.Context, clearText str
 func (c *clusterLimitRedis) RetryAfter(clearText string) int {
 	return c.RetryAfterContext(context.Background(), clearText)
 }
+
+func (c *clusterLimitRedis) remaining(ctx context.Context, clearText string) (int, time.Duration, error) {
+	res, err := c.run(ctx, clearText, 0, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(max(0, c.maxHits-res.count)), resetAfter(res.newest, c.window), nil
+}
+
+// Remaining returns the number of calls left in the current time
+// window across the cluster and the duration until the newest hit
+// leaves the time window. On failures it reports the full quota when
+// failing open and no quota when failing closed, like Allow decides.
+//
+// Performance considerations:
+//
+// It runs the lua script of Allow without adding hits, in one roundtrip.
+func (c *clusterLimitRedis) Remaining(ctx context.Context, clearText string) (int, time.Duration) {
+	now := time.Now()
+	var queryFailure bool
+	defer c.measureQuery(redisRemainingMetricsFormat, redisRemainingMetricsFormatWithGroup, &queryFailure, now)
+
+	remaining, reset, err := c.remaining(ctx, clearText)
+	if err != nil {
+		c.logError("Failed to get the remaining quota: %v", err)
+		queryFailure = true
+		if c.failClosed {
+			return 0, c.window
+		}
+		return int(c.maxHits), 0
+	}
+
+	return remaining, reset
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -28,6 +29,8 @@ type Registry struct {
 	redisRing  *net.RedisRingClient
 	valkeyRing *net.ValkeyRingClient
 	ownRing    bool // true only when this registry created the ring
+	overrides  atomic.Pointer[overrides]
+	quit       chan struct{} // stops watching the overrides
 }
 
 // NewRegistry initializes a registry with the provided default settings.
@@ -151,6 +154,12 @@ func NewRatelimitRegistryValkey(ring *net.ValkeyRingClient, settings ...Settings
 // Close teardown Registry and dependent resources
 func (r *Registry) Close() {
 	r.once.Do(func() {
+		r.Lock()
+		if r.quit != nil {
+			close(r.quit)
+		}
+		r.Unlock()
+
 		if r.ownRing {
 			if r.redisRing != nil {
 				r.redisRing.Close()
@@ -172,6 +181,7 @@ func (r *Registry) get(s Settings) *Ratelimit {
 	rl, ok := r.lookup[s]
 	if !ok {
 		rl = newRatelimit(s, r.swarm, r.redisRing, r.valkeyRing)
+		rl.registry = r
 		r.lookup[s] = rl
 	}
 
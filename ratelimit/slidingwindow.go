@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	_ "embed"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Implements the sliding window of the cluster ratelimits as a lua
+// script, so that the hits are checked and added atomically in one
+// roundtrip.
+//
+// See https://redis.io/commands/eval
+//
+//go:embed slidingwindow.lua
+var slidingWindowScript string
+
+// slidingWindowResult is the result of the sliding window script.
+type slidingWindowResult struct {
+	allowed bool
+	count   int64
+	newest  time.Time
+}
+
+// slidingWindowArgs returns the arguments of the sliding window script.
+// The id makes the members of the hits unique across requests and
+// skipper instances, that add hits at the same time.
+func slidingWindowArgs(maxHits int64, window time.Duration, now time.Time, n int, partial bool) []string {
+	p := "0"
+	if partial {
+		p = "1"
+	}
+
+	return []string{
+		strconv.FormatInt(maxHits, 10),
+		strconv.FormatInt(now.Add(-window).UnixNano(), 10),
+		strconv.FormatInt(now.UnixNano(), 10),
+		strconv.Itoa(n),
+		p,
+		strconv.FormatUint(rand.Uint64(), 36),
+		strconv.FormatInt((window + time.Second).Milliseconds(), 10),
+	}
+}
+
+func newSlidingWindowResult(allowed, count int64, newest string) (slidingWindowResult, error) {
+	res := slidingWindowResult{allowed: allowed == 1, count: count}
+	if newest == "" {
+		return res, nil
+	}
+
+	t, err := parseHitTime(newest)
+	if err != nil {
+		return slidingWindowResult{}, err
+	}
+
+	res.newest = t
+	return res, nil
+}
+
+// parseHitTime returns the time of a hit from its member in the sorted
+// set. The members start with the time of the hit in nanoseconds.
+func parseHitTime(member string) (time.Time, error) {
+	ns, _, _ := strings.Cut(member, "-")
+	t, err := strconv.ParseInt(ns, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to convert value to int64: %w", err)
+	}
+
+	return time.Unix(0, t), nil
+}
@@ -0,0 +1,45 @@
+local key = KEYS[1]                 -- sorted set of the hits, scored by their time in nanoseconds
+local max_hits = tonumber(ARGV[1])  -- maximum hits in the time window
+local clear_before = ARGV[2]        -- start of the time window in nanoseconds
+local now = ARGV[3]                 -- current time in nanoseconds
+local n = tonumber(ARGV[4])         -- hits to add, zero only queries the set (n >= 0)
+local partial = ARGV[5] == "1"      -- adds as many of the hits as the maximum allows
+local id = ARGV[6]                  -- unique id of the call across the cluster
+local ttl = ARGV[7]                 -- expiration of the set in milliseconds
+
+-- Lua uses double floating-point as a number type which can precisely represent integers only up to 2^53,
+-- so the times in nanoseconds are only passed on as strings.
+redis.call("ZREMRANGEBYSCORE", key, 0, clear_before)
+local count = redis.call("ZCARD", key)
+
+local allowed = 1
+local hits = n
+if count + hits > max_hits then
+    if not partial or count >= max_hits then
+        allowed = 0
+        hits = 0
+    else
+        hits = max_hits - count
+    end
+end
+
+-- The members start with the time of the hit and are unique across the cluster.
+-- The hits are added in batches, because the number of arguments of a call is limited.
+local added = 0
+while added < hits do
+    local batch = math.min(hits - added, 1000)
+    local args = {}
+    for i = 1, batch do
+        args[2 * i - 1] = now
+        args[2 * i] = now .. "-" .. id .. "-" .. (added + i)
+    end
+    redis.call("ZADD", key, unpack(args))
+    added = added + batch
+end
+
+if hits > 0 then
+    redis.call("PEXPIRE", key, ttl)
+end
+
+local newest = redis.call("ZRANGE", key, -1, -1)[1] or ""
+return {allowed, count + hits, newest}
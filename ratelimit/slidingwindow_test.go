@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHitTime(t *testing.T) {
+	const ns = 1700000000123456789
+	for _, member := range []string{
+		"1700000000123456789",
+		"1700000000123456789-3w5e11264sgsf-1",
+	} {
+		got, err := parseHitTime(member)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", member, err)
+		}
+		if !got.Equal(time.Unix(0, ns)) {
+			t.Errorf("Failed to parse %q, got %v", member, got)
+		}
+	}
+
+	if _, err := parseHitTime("invalid-1"); err == nil {
+		t.Error("Failed to get an error for an invalid member")
+	}
+}
+
+func TestSlidingWindowArgsUnique(t *testing.T) {
+	now := time.Now()
+	a := slidingWindowArgs(10, time.Second, now, 1, false)
+	b := slidingWindowArgs(10, time.Second, now, 1, false)
+	if a[5] == b[5] {
+		t.Errorf("Failed to get unique ids: %s", a[5])
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
-	circularbuffer "github.com/szuecs/rate-limit-buffer"
 )
 
 // Swarmer interface defines the requirement for a Swarm, for use as
@@ -51,10 +50,10 @@ func newClusterRateLimiterSwim(s Settings, sw Swarmer, group string) *clusterLim
 	switch s.Type {
 	case ClusterServiceRatelimit:
 		log.Infof("new backend clusterRateLimiter")
-		rl.local = circularbuffer.NewRateLimiter(s.MaxHits, s.TimeWindow)
+		rl.local = newServiceLimiter(s.MaxHits, s.TimeWindow)
 	case ClusterClientRatelimit:
 		log.Infof("new client clusterRateLimiter")
-		rl.local = circularbuffer.NewClientRateLimiter(s.MaxHits, s.TimeWindow, s.CleanInterval)
+		rl.local = newClientLimiter(s.MaxHits, s.TimeWindow, s.CleanInterval)
 	default:
 		log.Errorf("Unknown ratelimit type: %s", s.Type)
 		return nil
@@ -84,6 +83,12 @@ func newClusterRateLimiterSwim(s Settings, sw Swarmer, group string) *clusterLim
 // and use the current cluster information to calculate global rates
 // to decide to allow or not.
 func (c *clusterLimitSwim) Allow(ctx context.Context, clearText string) bool {
+	return c.AllowN(ctx, clearText, 1)
+}
+
+// AllowN is like Allow, but counts the request as n hits of the local
+// share of the cluster ratelimit.
+func (c *clusterLimitSwim) AllowN(ctx context.Context, clearText string, n int) bool {
 	s := getHashedKey(clearText)
 	key := swarmPrefix + c.group + "." + s
 
@@ -93,7 +98,7 @@ func (c *clusterLimitSwim) Allow(ctx context.Context, clearText string) bool {
 	// now - t0
 	t0 := c.Oldest(s).UTC().UnixNano()
 
-	_ = c.local.Allow(ctx, s) // update local rate limit
+	_ = c.local.AllowN(ctx, s, n) // update local rate limit
 
 	if err := c.swarm.ShareValue(key, t0); err != nil {
 		log.Errorf("clusterRatelimit '%s' disabled, failed to share value: %v", c.group, err)
@@ -140,3 +145,15 @@ func (c *clusterLimitSwim) Delta(s string) time.Duration { return c.local.Delta(
 func (c *clusterLimitSwim) Oldest(s string) time.Time    { return c.local.Oldest(s) }
 func (c *clusterLimitSwim) Resize(s string, n int)       { c.local.Resize(s, n) }
 func (c *clusterLimitSwim) RetryAfter(s string) int      { return c.local.RetryAfter(s) }
+
+// Charge counts up to n hits of the local share of the cluster
+// ratelimit, that are shared with the next call to Allow.
+func (c *clusterLimitSwim) Charge(ctx context.Context, clearText string, n int) {
+	c.local.Charge(ctx, getHashedKey(clearText), n)
+}
+
+// Remaining returns the remaining quota of the local share of the
+// cluster ratelimit, because the swarm only exchanges the oldest hits.
+func (c *clusterLimitSwim) Remaining(ctx context.Context, clearText string) (int, time.Duration) {
+	return c.local.Remaining(ctx, getHashedKey(clearText))
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+)
+
+// Implements token bucket algorithm as a Redis lua script.
+// Redis guarantees that a script is executed in an atomic way:
+// no other script or Redis command will be executed while a script is being executed.
+//
+// See https://redis.io/commands/eval
+//
+//go:embed tokenbucket.lua
+var tokenBucketScript string
+
+// TokenBucketLimiter is the interface for cluster token bucket implementations.
+type TokenBucketLimiter interface {
+	// Take takes cost tokens from the bucket identified by the label.
+	// It returns whether the tokens were taken or a time to wait for
+	// the next attempt, and the tokens left in the bucket. It also
+	// returns any error occurred during the attempt.
+	Take(ctx context.Context, label string, cost int) (TokenBucketResult, error)
+
+	// Remaining returns the tokens left in the bucket identified by
+	// the label and the duration until the bucket is full again.
+	Remaining(ctx context.Context, label string) (remaining int, reset time.Duration, err error)
+}
+
+// NewClusterTokenBucket creates a class of token buckets holding up to
+// burst tokens, refilled by one token every refill duration.
+// Prefers Valkey over Redis when both are configured.
+//
+// The token bucket allows bursts of up to burst requests, while it
+// enforces a sustained rate of one request per refill duration.
+// See https://en.wikipedia.org/wiki/Token_bucket
+func NewClusterTokenBucket(r *Registry, burst int, refill time.Duration) TokenBucketLimiter {
+	if r.valkeyRing != nil {
+		return newClusterTokenBucketValkey(r.valkeyRing, burst, refill, time.Now)
+	}
+	return newClusterTokenBucketRedis(r.redisRing, burst, refill, time.Now)
+}
+
+// TokenBucketResult is the result of taking tokens from a token bucket.
+type TokenBucketResult struct {
+	// Taken tells whether the tokens were taken.
+	Taken bool
+
+	// Retry is the time to wait for the next attempt, when the tokens
+	// were not taken.
+	Retry time.Duration
+
+	// Tokens is the number of tokens left in the bucket.
+	Tokens int
+
+	// Reset is the duration until the bucket is full again.
+	Reset time.Duration
+}
+
+func newTokenBucketResult(values []int64) (TokenBucketResult, error) {
+	if len(values) != 4 {
+		return TokenBucketResult{}, fmt.Errorf("unexpected token bucket result: %v", values)
+	}
+
+	return TokenBucketResult{
+		Taken:  values[0] == 1,
+		Retry:  time.Duration(values[1]) * time.Microsecond,
+		Tokens: int(values[2]),
+		Reset:  time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
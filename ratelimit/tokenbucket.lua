@@ -0,0 +1,40 @@
+local bucket_id = KEYS[1]           -- bucket id
+local burst = tonumber(ARGV[1])     -- bucket capacity in tokens (cost <= burst)
+local refill = tonumber(ARGV[2])    -- time to refill one token in microseconds (refill > 0)
+local cost = tonumber(ARGV[3])      -- tokens to take, zero only queries the bucket (cost >= 0)
+local now = tonumber(ARGV[4])       -- current time in microseconds (now >= 0)
+
+-- Redis stores the number of tokens and the timestamp of the last refill in microseconds.
+-- Lua uses double floating-point as a number type which can precisely represent integers only up to 2^53,
+-- the fraction of a token refilled since the last request is kept in the number of tokens.
+-- If bucket does not exist, consider it full now.
+local state = redis.call("HMGET", bucket_id, "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if not tokens or not ts then
+    tokens = burst
+    ts = now
+end
+
+if now > ts then
+    tokens = math.min(burst, tokens + (now - ts) / refill)
+    ts = now
+end
+
+-- If there are not enough tokens then retry is possible after the missing tokens are refilled
+local taken = 0
+local retry = 0
+if tokens >= cost then
+    tokens = tokens - cost
+    taken = 1
+else
+    retry = math.ceil((cost - tokens) * refill)
+end
+
+-- time until the bucket is full again
+local reset = math.ceil((burst - tokens) * refill)
+
+redis.call("HSET", bucket_id, "tokens", tokens, "ts", ts)
+redis.call("PEXPIRE", bucket_id, math.ceil(reset / 1000) + 1)
+
+return {taken, retry, math.floor(tokens), reset}
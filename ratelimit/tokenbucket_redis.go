@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/net"
+)
+
+type ClusterTokenBucket struct {
+	burst       int
+	refill      time.Duration
+	labelPrefix string
+	script      *net.RedisScript
+	ringClient  *net.RedisRingClient
+	metrics     metrics.Metrics
+	now         func() time.Time
+}
+
+const (
+	tokenBucketRedisKeyPrefix = "tkb."
+	tokenBucketMetricPrefix   = "tokenbucket.redis."
+	tokenBucketMetricLatency  = tokenBucketMetricPrefix + "latency"
+	tokenBucketSpanName       = "redis_tokenbucket"
+)
+
+func newClusterTokenBucketRedis(ringClient *net.RedisRingClient, burst int, refill time.Duration, now func() time.Time) *ClusterTokenBucket {
+	return &ClusterTokenBucket{
+		burst:       burst,
+		refill:      refill,
+		labelPrefix: fmt.Sprintf("%d-%v-", burst, refill),
+		script:      ringClient.NewScript(tokenBucketScript),
+		ringClient:  ringClient,
+		metrics:     metrics.Default,
+		now:         now,
+	}
+}
+
+// Take takes cost tokens from the bucket identified by the label.
+// It returns whether the tokens were taken or a time to wait for the next attempt,
+// and the tokens left in the bucket. It also returns any error occurred during the attempt.
+func (b *ClusterTokenBucket) Take(ctx context.Context, label string, cost int) (TokenBucketResult, error) {
+	if cost > b.burst {
+		// not allowed to take more than burst and retry is not possible
+		return TokenBucketResult{}, nil
+	}
+
+	now := b.now()
+	span := b.startSpan(ctx)
+	defer span.Finish()
+	defer b.metrics.MeasureSince(tokenBucketMetricLatency, now)
+
+	res, err := b.run(ctx, label, cost, now)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return TokenBucketResult{}, err
+	}
+	return res, nil
+}
+
+// Remaining returns the tokens left in the bucket identified by the label
+// and the duration until the bucket is full again.
+func (b *ClusterTokenBucket) Remaining(ctx context.Context, label string) (remaining int, reset time.Duration, err error) {
+	res, err := b.run(ctx, label, 0, b.now())
+	if err != nil {
+		return 0, 0, err
+	}
+	return res.Tokens, res.Reset, nil
+}
+
+func (b *ClusterTokenBucket) run(ctx context.Context, label string, cost int, now time.Time) (TokenBucketResult, error) {
+	r, err := b.ringClient.RunScript(ctx, b.script,
+		[]string{b.getBucketId(label)},
+		b.burst,
+		b.refill.Microseconds(),
+		cost,
+		now.UnixMicro(),
+	)
+	if err != nil {
+		return TokenBucketResult{}, err
+	}
+
+	a, ok := r.([]interface{})
+	if !ok {
+		return TokenBucketResult{}, fmt.Errorf("unexpected token bucket result: %v", r)
+	}
+
+	values := make([]int64, 0, len(a))
+	for _, v := range a {
+		i, ok := v.(int64)
+		if !ok {
+			return TokenBucketResult{}, fmt.Errorf("unexpected token bucket result: %v", r)
+		}
+		values = append(values, i)
+	}
+	return newTokenBucketResult(values)
+}
+
+func (b *ClusterTokenBucket) getBucketId(label string) string {
+	return tokenBucketRedisKeyPrefix + getHashedKey(b.labelPrefix+label)
+}
+
+func (b *ClusterTokenBucket) startSpan(ctx context.Context) (span opentracing.Span) {
+	spanOpts := []opentracing.StartSpanOption{opentracing.Tags{
+		string(ext.Component): "skipper",
+		string(ext.SpanKind):  "client",
+	}}
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		spanOpts = append(spanOpts, opentracing.ChildOf(parent.Context()))
+	}
+	return b.ringClient.StartSpan(tokenBucketSpanName, spanOpts...)
+}
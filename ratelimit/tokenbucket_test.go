@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/net/redistest"
+	"github.com/zalando/skipper/net/valkeytest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	verifyTokenBucketAttempts(t, 3, time.Minute, 1, []attempt{
+		// initial burst of three requests empties the bucket
+		{+0, true, 0},
+		{+1, true, 0},
+		{+2, true, 0},
+		// the bucket is empty, one token is refilled per minute
+		{+3, false, 57},
+		{+4, false, 56},
+		// ...
+		{+59, false, 1},
+		// by this point one token has been refilled
+		{+61, true, 0},
+		// the bucket is empty again
+		{+62, false, 58},
+		// ... wait three minutes to refill the whole burst
+		{+300, true, 0},
+		{+301, true, 0},
+		{+302, true, 0},
+		{+303, false, 57},
+	})
+}
+
+func TestTokenBucketTakeCost(t *testing.T) {
+	verifyTokenBucketAttempts(t, 4, time.Second, 2, []attempt{
+		{+0, true, 0},
+		{+0, true, 0},
+		// two tokens are missing
+		{+0, false, 2},
+		{+1, false, 1},
+		{+2, true, 0},
+	})
+}
+
+func TestTokenBucketTakeMoreThanBurst(t *testing.T) {
+	verifyTokenBucketAttempts(t, 1, time.Minute, 2, []attempt{
+		{+0, false, 0},  // not allowed and no retry possible
+		{+61, false, 0}, // even after a minute
+	})
+}
+
+func verifyTokenBucketAttempts(t *testing.T, burst int, refill time.Duration, cost int, attempts []attempt) {
+	t.Helper()
+
+	type backend struct {
+		name      string
+		newBucket func(t *testing.T, now func() time.Time) TokenBucketLimiter
+	}
+
+	backends := []backend{
+		{
+			name: "redis",
+			newBucket: func(t *testing.T, now func() time.Time) TokenBucketLimiter {
+				t.Helper()
+				redisAddr, done := redistest.NewTestRedis(t)
+				t.Cleanup(done)
+				ringClient := net.NewRedisRingClient(&net.RedisOptions{Addrs: []string{redisAddr}})
+				t.Cleanup(ringClient.Close)
+				return newClusterTokenBucketRedis(ringClient, burst, refill, now)
+			},
+		},
+		{
+			name: "valkey",
+			newBucket: func(t *testing.T, now func() time.Time) TokenBucketLimiter {
+				t.Helper()
+				valkeyAddr, done := valkeytest.NewTestValkey(t)
+				t.Cleanup(done)
+				ringClient, err := net.NewValkeyRingClient(&net.ValkeyOptions{Addrs: []string{valkeyAddr}})
+				require.NoError(t, err)
+				t.Cleanup(func() { ringClient.Close() })
+				return newClusterTokenBucketValkey(ringClient, burst, refill, now)
+			},
+		},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			now := time.Now()
+			bucket := b.newBucket(t, func() time.Time { return now })
+			t0 := now
+			for _, a := range attempts {
+				now = t0.Add(time.Duration(a.tplus) * time.Second)
+				res, err := bucket.Take(context.Background(), "alabel", cost)
+				if err != nil {
+					t.Fatal(err)
+				}
+				taken, retry := res.Taken, res.Retry
+				if a.added != taken {
+					t.Errorf("error at %+d: taken mismatch, expected %v, got %v", a.tplus, a.added, taken)
+				}
+				// the fractions of refilled tokens may round the retry up by a microsecond
+				expectedRetry := time.Duration(a.retry) * time.Second
+				if expectedRetry != retry.Truncate(time.Second) {
+					t.Errorf("error at %+d: retry mismatch, expected %v, got %v", a.tplus, expectedRetry, retry)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenBucketRemaining(t *testing.T) {
+	redisAddr, done := redistest.NewTestRedis(t)
+	defer done()
+
+	ringClient := net.NewRedisRingClient(&net.RedisOptions{Addrs: []string{redisAddr}})
+	defer ringClient.Close()
+
+	now := time.Now()
+	b := newClusterTokenBucketRedis(ringClient, 10, time.Second, func() time.Time { return now })
+
+	remaining, reset, err := b.Remaining(context.Background(), "alabel")
+	require.NoError(t, err)
+	assert.Equal(t, 10, remaining)
+	assert.Zero(t, reset)
+
+	for range 2 {
+		_, err := b.Take(context.Background(), "alabel", 1)
+		require.NoError(t, err)
+	}
+
+	res, err := b.Take(context.Background(), "alabel", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 7, res.Tokens)
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	remaining, reset, err = b.Remaining(context.Background(), "alabel")
+	require.NoError(t, err)
+	assert.Equal(t, 7, remaining)
+	assert.Equal(t, 3*time.Second, reset)
+}
+
+func TestTokenBucketError(t *testing.T) {
+	t.Run("redis", func(t *testing.T) {
+		ringClient := net.NewRedisRingClient(&net.RedisOptions{Addrs: []string{"no-such-host.test:123"}})
+		defer ringClient.Close()
+
+		bucket := newClusterTokenBucketRedis(ringClient, 1, time.Minute, time.Now)
+		_, err := bucket.Take(context.Background(), "alabel", 1)
+
+		assert.Error(t, err)
+	})
+	t.Run("valkey", func(t *testing.T) {
+		ringClient, err := net.NewValkeyRingClient(&net.ValkeyOptions{Addrs: []string{"no-such-host.test:123"}})
+		if err != nil {
+			// error at construction time is acceptable for Valkey with unreachable address
+			return
+		}
+		defer ringClient.Close()
+
+		bucket := newClusterTokenBucketValkey(ringClient, 1, time.Minute, time.Now)
+		_, err = bucket.Take(context.Background(), "alabel", 1)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestTokenBucketId(t *testing.T) {
+	const label = "alabel"
+
+	t.Run("redis", func(t *testing.T) {
+		b1 := newClusterTokenBucketRedis(nil, 1, time.Minute, time.Now)
+		b2 := newClusterTokenBucketRedis(nil, 2, time.Minute, time.Now)
+		assert.NotEqual(t, b1.getBucketId(label), b2.getBucketId(label))
+	})
+	t.Run("valkey", func(t *testing.T) {
+		b1 := newClusterTokenBucketValkey(nil, 1, time.Minute, time.Now)
+		b2 := newClusterTokenBucketValkey(nil, 2, time.Minute, time.Now)
+		assert.NotEqual(t, b1.getBucketId(label), b2.getBucketId(label))
+	})
+}
+
+func TestTokenBucketResult(t *testing.T) {
+	res, err := newTokenBucketResult([]int64{1, 0, 2, 3000000})
+	require.NoError(t, err)
+	assert.Equal(t, TokenBucketResult{Taken: true, Tokens: 2, Reset: 3 * time.Second}, res)
+
+	_, err = newTokenBucketResult([]int64{1, 0})
+	assert.Error(t, err)
+}
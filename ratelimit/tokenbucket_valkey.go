@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/valkey-io/valkey-go"
+
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/net"
+)
+
+type ClusterTokenBucketValkey struct {
+	burst       int
+	refill      time.Duration
+	labelPrefix string
+	script      *valkey.Lua
+	ringClient  *net.ValkeyRingClient
+	metrics     metrics.Metrics
+	now         func() time.Time
+}
+
+const (
+	tokenBucketValkeyMetricPrefix  = "tokenbucket.valkey."
+	tokenBucketValkeyMetricLatency = tokenBucketValkeyMetricPrefix + "latency"
+	tokenBucketValkeySpanName      = "valkey_tokenbucket"
+)
+
+func newClusterTokenBucketValkey(ringClient *net.ValkeyRingClient, burst int, refill time.Duration, now func() time.Time) *ClusterTokenBucketValkey {
+	return &ClusterTokenBucketValkey{
+		burst:       burst,
+		refill:      refill,
+		labelPrefix: fmt.Sprintf("%d-%v-", burst, refill),
+		script:      net.NewScript(tokenBucketScript),
+		ringClient:  ringClient,
+		metrics:     metrics.Default,
+		now:         now,
+	}
+}
+
+// Take takes cost tokens from the bucket identified by the label.
+// It returns whether the tokens were taken or a time to wait for the next attempt,
+// and the tokens left in the bucket. It also returns any error occurred during the attempt.
+func (b *ClusterTokenBucketValkey) Take(ctx context.Context, label string, cost int) (TokenBucketResult, error) {
+	if cost > b.burst {
+		// not allowed to take more than burst and retry is not possible
+		return TokenBucketResult{}, nil
+	}
+
+	now := b.now()
+	span := b.startSpan(ctx)
+	defer span.Finish()
+	defer b.metrics.MeasureSince(tokenBucketValkeyMetricLatency, now)
+
+	res, err := b.run(ctx, label, cost, now)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return TokenBucketResult{}, err
+	}
+	return res, nil
+}
+
+// Remaining returns the tokens left in the bucket identified by the label
+// and the duration until the bucket is full again.
+func (b *ClusterTokenBucketValkey) Remaining(ctx context.Context, label string) (remaining int, reset time.Duration, err error) {
+	res, err := b.run(ctx, label, 0, b.now())
+	if err != nil {
+		return 0, 0, err
+	}
+	return res.Tokens, res.Reset, nil
+}
+
+func (b *ClusterTokenBucketValkey) run(ctx context.Context, label string, cost int, now time.Time) (TokenBucketResult, error) {
+	msg, err := b.ringClient.RunScript(ctx, b.script,
+		[]string{b.getBucketId(label)},
+		strconv.Itoa(b.burst),
+		strconv.FormatInt(b.refill.Microseconds(), 10),
+		strconv.Itoa(cost),
+		strconv.FormatInt(now.UnixMicro(), 10),
+	)
+	if err != nil {
+		return TokenBucketResult{}, err
+	}
+
+	a, err := msg.ToArray()
+	if err != nil {
+		return TokenBucketResult{}, err
+	}
+
+	values := make([]int64, 0, len(a))
+	for _, m := range a {
+		i, err := m.ToInt64()
+		if err != nil {
+			return TokenBucketResult{}, err
+		}
+		values = append(values, i)
+	}
+	return newTokenBucketResult(values)
+}
+
+func (b *ClusterTokenBucketValkey) getBucketId(label string) string {
+	return tokenBucketRedisKeyPrefix + getHashedKey(b.labelPrefix+label)
+}
+
+func (b *ClusterTokenBucketValkey) startSpan(ctx context.Context) (span opentracing.Span) {
+	spanOpts := []opentracing.StartSpanOption{opentracing.Tags{
+		string(ext.Component): "skipper",
+		string(ext.SpanKind):  "client",
+	}}
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		spanOpts = append(spanOpts, opentracing.ChildOf(parent.Context()))
+	}
+	return b.ringClient.StartSpan(tokenBucketValkeySpanName, spanOpts...)
+}
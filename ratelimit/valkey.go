@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	log "github.com/sirupsen/logrus"
+	"github.com/valkey-io/valkey-go"
 	"github.com/zalando/skipper/metrics"
 	"github.com/zalando/skipper/net"
 	"golang.org/x/time/rate"
@@ -23,6 +23,7 @@ type clusterLimitValkey struct {
 	maxHits    int64
 	window     time.Duration
 	ringClient *net.ValkeyRingClient
+	script     *valkey.Lua
 	metrics    metrics.Metrics
 	sometimes  rate.Sometimes
 }
@@ -31,8 +32,10 @@ const (
 	ValkeyMetricsPrefix                    = "swarm.valkey."
 	valkeyAllowMetricsFormat               = ValkeyMetricsPrefix + "query.allow.%s"
 	valkeyRetryAfterMetricsFormat          = ValkeyMetricsPrefix + "query.retryafter.%s"
+	valkeyRemainingMetricsFormat           = ValkeyMetricsPrefix + "query.remaining.%s"
 	valkeyAllowMetricsFormatWithGroup      = ValkeyMetricsPrefix + "query.allow.%s.%s"
 	valkeyRetryAfterMetricsFormatWithGroup = ValkeyMetricsPrefix + "query.retryafter.%s.%s"
+	valkeyRemainingMetricsFormatWithGroup  = ValkeyMetricsPrefix + "query.remaining.%s.%s"
 
 	valkeyAllowSpanName       = "valkey_allow"
 	valkeyOldestScoreSpanName = "valkey_oldest_score"
@@ -59,6 +62,7 @@ func newClusterRateLimiterValkey(s Settings, r *net.ValkeyRingClient, group stri
 		maxHits:    int64(s.MaxHits),
 		window:     s.TimeWindow,
 		ringClient: r,
+		script:     net.NewScript(slidingWindowScript),
 		metrics:    metrics.Default,
 		sometimes:  rate.Sometimes{First: 3, Interval: 1 * time.Second},
 	}
@@ -104,13 +108,23 @@ func (c *clusterLimitValkey) commonTags() opentracing.Tags {
 //
 // Performance considerations:
 //
-// In case of deny it will use ZREMRANGEBYSCORE and ZCARD commands in
-// one pipeline to remove old items in the list of hits.
-// In case of allow it will additionally use ZADD with a second
-// roundtrip.
+// It runs a lua script in one roundtrip, that removes the old items in
+// the list of hits with ZREMRANGEBYSCORE, counts them with ZCARD and in
+// case of allow adds the hit with ZADD.
 //
 // Uses provided context for creating an OpenTracing span.
 func (c *clusterLimitValkey) Allow(ctx context.Context, clearText string) bool {
+	return c.AllowN(ctx, clearText, 1)
+}
+
+// AllowN is like Allow, but counts the request as n hits. Requests of
+// more hits than the maximum are never allowed.
+//
+// Performance considerations:
+//
+// Like Allow, it uses one roundtrip. In case of allow, the hits are
+// added with one ZADD per thousand hits.
+func (c *clusterLimitValkey) AllowN(ctx context.Context, clearText string, n int) bool {
 	c.metrics.IncCounter(ValkeyMetricsPrefix + "total")
 	now := time.Now()
 
@@ -120,7 +134,7 @@ func (c *clusterLimitValkey) Allow(ctx context.Context, clearText string) bool {
 		defer span.Finish()
 	}
 
-	allow, err := c.allow(ctx, clearText)
+	allow, err := c.allow(ctx, clearText, n, false)
 	if errors.Is(err, context.Canceled) {
 		return !c.failClosed
 	}
@@ -146,42 +160,59 @@ func (c *clusterLimitValkey) Allow(ctx context.Context, clearText string) bool {
 	return allow
 }
 
-func (c *clusterLimitValkey) allow(ctx context.Context, clearText string) (bool, error) {
-	s := getHashedKey(clearText)
-	key := c.prefixKey(s)
+// allow adds n hits, if they do not exceed the maximum. With partial
+// set, it adds as many of the n hits as the maximum allows.
+func (c *clusterLimitValkey) allow(ctx context.Context, clearText string, n int, partial bool) (bool, error) {
+	res, err := c.run(ctx, clearText, n, partial)
+	if err != nil {
+		return false, err
+	}
 
-	now := time.Now()
-	nowNanos := now.UnixNano()
-	clearBefore := fmt.Sprintf("%d", now.Add(-c.window).UnixNano())
+	return res.allowed, nil
+}
+
+func (c *clusterLimitValkey) run(ctx context.Context, clearText string, n int, partial bool) (slidingWindowResult, error) {
+	key := c.prefixKey(getHashedKey(clearText))
+	args := slidingWindowArgs(c.maxHits, c.window, time.Now(), n, partial)
 
-	// drop all elements of the set which occurred before one interval ago.
-	_, err := c.ringClient.ZRemRangeByScore(ctx, key, "0.0", clearBefore)
+	msg, err := c.ringClient.RunScript(ctx, c.script, []string{key}, args...)
 	if err != nil {
-		return false, err
+		return slidingWindowResult{}, err
 	}
 
-	// get cardinality
-	count, err := c.ringClient.ZCard(ctx, key)
+	a, err := msg.ToArray()
 	if err != nil {
-		return false, err
+		return slidingWindowResult{}, err
+	}
+	if len(a) != 3 {
+		return slidingWindowResult{}, fmt.Errorf("unexpected sliding window result: %v", a)
 	}
 
-	// we increase later with ZAdd, so max-1
-	if count >= c.maxHits {
-		return false, nil
+	allowed, err := a[0].ToInt64()
+	if err != nil {
+		return slidingWindowResult{}, err
 	}
 
-	_, err = c.ringClient.ZAdd(ctx, key, fmt.Sprintf("%d", nowNanos), float64(nowNanos))
+	count, err := a[1].ToInt64()
 	if err != nil {
-		return false, err
+		return slidingWindowResult{}, err
 	}
 
-	_, err = c.ringClient.Expire(ctx, key, c.window+time.Second)
+	newest, err := a[2].ToString()
 	if err != nil {
-		return false, err
+		return slidingWindowResult{}, err
 	}
 
-	return true, nil
+	return newSlidingWindowResult(allowed, count, newest)
+}
+
+// Charge counts up to n hits across the cluster without a decision,
+// for example for costs that are only known after the request was
+// allowed.
+func (c *clusterLimitValkey) Charge(ctx context.Context, clearText string, n int) {
+	if _, err := c.allow(ctx, clearText, n, true); err != nil {
+		c.logError("Failed to charge hits: %v", err)
+	}
 }
 
 // Close cannot decide to teardown valkey ring, because it is not the
@@ -245,14 +276,14 @@ func (c *clusterLimitValkey) oldest(ctx context.Context, clearText string) (time
 		return time.Time{}, nil
 	}
 
-	oldest, err := strconv.ParseInt(res, 10, 64)
+	oldest, err := parseHitTime(res)
 	if err != nil {
 		msg := "failed to convert valkey msg to int64"
 		valkeySetError(span, msg, valkeyErrorFailedToConvert)
 		return time.Time{}, errors.New(msg)
 	}
 
-	return time.Unix(0, oldest), nil
+	return oldest, nil
 }
 
 // Oldest returns the oldest known request time.
@@ -309,3 +340,38 @@ func (c *clusterLimitValkey) RetryAfterContext(ctx context.Context, clearText st
 func (c *clusterLimitValkey) RetryAfter(clearText string) int {
 	return c.RetryAfterContext(context.Background(), clearText)
 }
+
+func (c *clusterLimitValkey) remaining(ctx context.Context, clearText string) (int, time.Duration, error) {
+	res, err := c.run(ctx, clearText, 0, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(max(0, c.maxHits-res.count)), resetAfter(res.newest, c.window), nil
+}
+
+// Remaining returns the number of calls left in the current time
+// window across the cluster and the duration until the newest hit
+// leaves the time window. On failures it reports the full quota when
+// failing open and no quota when failing closed, like Allow decides.
+//
+// Performance considerations:
+//
+// It runs the lua script of Allow without adding hits, in one roundtrip.
+func (c *clusterLimitValkey) Remaining(ctx context.Context, clearText string) (int, time.Duration) {
+	now := time.Now()
+	var queryFailure bool
+	defer c.measureQuery(valkeyRemainingMetricsFormat, valkeyRemainingMetricsFormatWithGroup, &queryFailure, now)
+
+	remaining, reset, err := c.remaining(ctx, clearText)
+	if err != nil {
+		c.logError("Failed to get the remaining quota: %v", err)
+		queryFailure = true
+		if c.failClosed {
+			return 0, c.window
+		}
+		return int(c.maxHits), 0
+	}
+
+	return remaining, reset
+}
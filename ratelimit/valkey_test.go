@@ -444,6 +444,105 @@ func Test_clusterLimitValkey_RetryAfter(t *testing.T) {
 }
 
 /* TODO(sszuecs): fail open on rate limit side
+func Test_clusterLimitValkey_Remaining(t *testing.T) {
+	valkeyAddr, done := valkeytest.NewTestValkey(t)
+	defer done()
+
+	settings := Settings{
+		Type:       ClusterClientRatelimit,
+		Lookuper:   NewHeaderLookuper("X-Test"),
+		MaxHits:    10,
+		TimeWindow: 10 * time.Second,
+		Group:      "R",
+	}
+
+	for _, tt := range []struct {
+		name       string
+		args       string
+		iterations int
+		want       int
+	}{
+		{name: "no hits", args: "clientA", iterations: 0, want: 10},
+		{name: "some hits", args: "clientB", iterations: 3, want: 7},
+		{name: "rejected hits are not counted", args: "clientC", iterations: 12, want: 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ringClient, err := net.NewValkeyRingClient(&net.ValkeyOptions{Addrs: []string{valkeyAddr}})
+			require.NoError(t, err)
+			defer ringClient.Close()
+			c := newClusterRateLimiterValkey(settings, ringClient, settings.Group)
+
+			for range tt.iterations {
+				_ = c.Allow(context.Background(), tt.args)
+			}
+
+			remaining, reset := c.Remaining(context.Background(), tt.args)
+			assert.Equal(t, tt.want, remaining)
+			if tt.iterations == 0 {
+				assert.Zero(t, reset)
+			} else {
+				assert.InDelta(t, settings.TimeWindow, reset, float64(time.Second))
+			}
+		})
+	}
+}
+
+func Test_clusterLimitValkey_AllowN(t *testing.T) {
+	valkeyAddr, done := valkeytest.NewTestValkey(t)
+	defer done()
+
+	settings := Settings{
+		Type:       ClusterClientRatelimit,
+		Lookuper:   NewHeaderLookuper("X-Test"),
+		MaxHits:    10,
+		TimeWindow: 10 * time.Second,
+		Group:      "N",
+	}
+
+	ringClient, err := net.NewValkeyRingClient(&net.ValkeyOptions{Addrs: []string{valkeyAddr}})
+	require.NoError(t, err)
+	defer ringClient.Close()
+	c := newClusterRateLimiterValkey(settings, ringClient, settings.Group)
+	ctx := context.Background()
+
+	assert.False(t, c.AllowN(ctx, "clientA", 11), "more hits than the maximum")
+	assert.True(t, c.AllowN(ctx, "clientA", 6))
+	assert.False(t, c.AllowN(ctx, "clientA", 5), "the hits exceed the remaining quota")
+	assert.True(t, c.AllowN(ctx, "clientA", 4))
+	assert.False(t, c.Allow(ctx, "clientA"))
+
+	remaining, _ := c.Remaining(ctx, "clientA")
+	assert.Equal(t, 0, remaining)
+}
+
+func Test_clusterLimitValkey_Charge(t *testing.T) {
+	valkeyAddr, done := valkeytest.NewTestValkey(t)
+	defer done()
+
+	settings := Settings{
+		Type:       ClusterClientRatelimit,
+		Lookuper:   NewHeaderLookuper("X-Test"),
+		MaxHits:    10,
+		TimeWindow: 10 * time.Second,
+		Group:      "C",
+	}
+
+	ringClient, err := net.NewValkeyRingClient(&net.ValkeyOptions{Addrs: []string{valkeyAddr}})
+	require.NoError(t, err)
+	defer ringClient.Close()
+	c := newClusterRateLimiterValkey(settings, ringClient, settings.Group)
+	ctx := context.Background()
+
+	c.Charge(ctx, "clientA", 3)
+	remaining, _ := c.Remaining(ctx, "clientA")
+	assert.Equal(t, 7, remaining)
+
+	c.Charge(ctx, "clientA", 100)
+	remaining, _ = c.Remaining(ctx, "clientA")
+	assert.Equal(t, 0, remaining)
+	assert.False(t, c.Allow(ctx, "clientA"))
+}
+
 func TestFailOpenOnValkeyError(t *testing.T) {
 	dm := metrics.Default
 	defer func() { metrics.Default = dm }()
@@ -160,16 +160,19 @@ func applyIncoming(defs routeDefs, d *incomingData) routeDefs {
 type mergedDefs struct {
 	routes  []*eskip.Route
 	clients map[DataClient]struct{}
+	owners  map[string]DataClient
 }
 
 // merges the route definitions from multiple data clients by route id
 func mergeDefs(defsByClient map[DataClient]routeDefs) mergedDefs {
 	clients := make(map[DataClient]struct{}, len(defsByClient))
+	owners := make(map[string]DataClient)
 	mergeByID := make(routeDefs)
 	for c, defs := range defsByClient {
 		clients[c] = struct{}{}
 		for id, def := range defs {
 			mergeByID[id] = def
+			owners[id] = c
 		}
 	}
 
@@ -177,7 +180,32 @@ func mergeDefs(defsByClient map[DataClient]routeDefs) mergedDefs {
 	for _, def := range mergeByID {
 		all = append(all, def)
 	}
-	return mergedDefs{routes: all, clients: clients}
+	return mergedDefs{routes: all, clients: clients, owners: owners}
+}
+
+// notifies the data clients implementing InvalidRoutesReceiver about
+// the errors of their invalid routes
+func notifyInvalidRoutes(mdefs mergedDefs, invalidRouteErrors map[string]string) {
+	byClient := make(map[InvalidRoutesReceiver]map[string]string)
+	for c := range mdefs.clients {
+		if r, ok := c.(InvalidRoutesReceiver); ok {
+			byClient[r] = make(map[string]string)
+		}
+	}
+
+	if len(byClient) == 0 {
+		return
+	}
+
+	for id, err := range invalidRouteErrors {
+		if r, ok := mdefs.owners[id].(InvalidRoutesReceiver); ok {
+			byClient[r][id] = err
+		}
+	}
+
+	for r, errs := range byClient {
+		r.ReceiveInvalidRoutes(errs)
+	}
 }
 
 // receives the initial set of the route definitions and their
@@ -680,6 +708,8 @@ func receiveRouteMatcher(o Options, out chan<- *routeTable, quit <-chan struct{}
 				}
 			}
 
+			notifyInvalidRoutes(mdefs, invalidRouteErrors)
+
 			sort.SliceStable(validRoutes, func(i, j int) bool {
 				return validRoutes[i].Id < validRoutes[j].Id
 			})
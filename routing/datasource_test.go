@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/filters/builtin"
 	"github.com/zalando/skipper/logging"
@@ -828,3 +829,60 @@ func waitForMeasure(t *testing.T, m *metricstest.MockMetrics, key string) {
 		}
 	}
 }
+
+type invalidRoutesReceiver struct {
+	*testdataclient.Client
+	received chan map[string]string
+}
+
+func (r *invalidRoutesReceiver) ReceiveInvalidRoutes(errs map[string]string) {
+	r.received <- errs
+}
+
+func TestInvalidRoutesReceiver(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`
+		valid: Path("/valid") -> "https://example.org";
+		invalid: Path("/invalid") -> unknownFilter() -> "https://example.org";
+	`)
+	require.NoError(t, err)
+	defer dc.Close()
+
+	other, err := testdataclient.NewDoc(`otherInvalid: Path("/other") -> unknownFilter() -> "https://example.org";`)
+	require.NoError(t, err)
+	defer other.Close()
+
+	receiver := &invalidRoutesReceiver{Client: dc, received: make(chan map[string]string, 8)}
+	r := routing.New(routing.Options{
+		DataClients:     []routing.DataClient{receiver, other},
+		FilterRegistry:  make(filters.Registry),
+		SignalFirstLoad: true,
+	})
+	defer r.Close()
+	<-r.FirstLoad()
+
+	// the first updates may not contain the routes of both clients
+	var errs map[string]string
+	require.Eventually(t, func() bool {
+		select {
+		case errs = <-receiver.received:
+			_, ok := errs["invalid"]
+			return ok
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	require.Len(t, errs, 1, "only the invalid routes of the client are received")
+	assert.Contains(t, errs["invalid"], `filter "unknownFilter" not found`)
+
+	dc.UpdateDoc(`invalid: Path("/invalid") -> "https://example.org";`, nil)
+
+	require.Eventually(t, func() bool {
+		select {
+		case errs := <-receiver.received:
+			return len(errs) == 0
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+}
@@ -28,6 +28,9 @@ type Metrics interface {
 	IncRequests(o IncRequestsOptions)
 	HealthCheckDropProbability() float64
 	Weight() float64
+
+	ActiveHealthCheckFailed() bool
+	SetActiveHealthCheckFailed(failed bool)
 }
 
 type IncRequestsOptions struct {
@@ -44,6 +47,10 @@ type entry struct {
 	curSlot                    atomic.Int64
 	healthCheckDropProbability atomic.Value // float64
 	weight                     atomic.Value // float64
+	activeHealthCheckFailed    atomic.Bool
+	consecutiveErrors          atomic.Int64
+	ejectionCount              atomic.Int64
+	ejected                    atomic.Value // time.Time
 }
 
 var _ Metrics = &entry{}
@@ -97,6 +104,17 @@ func (e *entry) Weight() float64 {
 	return e.weight.Load().(float64)
 }
 
+// ActiveHealthCheckFailed returns true when the active health check
+// marked the endpoint as unhealthy. Endpoints that are not probed are
+// never marked.
+func (e *entry) ActiveHealthCheckFailed() bool {
+	return e.activeHealthCheckFailed.Load()
+}
+
+func (e *entry) SetActiveHealthCheckFailed(failed bool) {
+	e.activeHealthCheckFailed.Store(failed)
+}
+
 func newEntry() *entry {
 	result := &entry{}
 	result.healthCheckDropProbability.Store(0.0)
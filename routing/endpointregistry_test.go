@@ -407,3 +407,58 @@ func BenchmarkGetDetectedTime(b *testing.B) {
 		benchmarkGetDetectedTime(b, fmt.Sprintf("%d goroutines", goroutines), goroutines)
 	}
 }
+
+func TestEject(t *testing.T) {
+	r := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer r.Close()
+
+	const (
+		base        = 10 * time.Second
+		maxEjection = 25 * time.Second
+	)
+
+	m := r.GetMetrics("some key")
+	assert.Equal(t, time.Time{}, routing.EjectedUntil(m))
+
+	assert.Equal(t, int64(1), routing.IncConsecutiveErrors(m))
+	assert.Equal(t, int64(2), routing.IncConsecutiveErrors(m))
+	routing.ResetConsecutiveErrors(m)
+	assert.Equal(t, int64(1), routing.IncConsecutiveErrors(m))
+
+	now := time.Now()
+	assert.Equal(t, base, routing.Eject(m, now, base, maxEjection))
+	assert.Equal(t, now.Add(base), routing.EjectedUntil(m))
+	assert.Equal(t, int64(1), routing.IncConsecutiveErrors(m), "ejection resets consecutive errors")
+
+	now = now.Add(base)
+	assert.Equal(t, 2*base, routing.Eject(m, now, base, maxEjection))
+
+	now = now.Add(2 * base)
+	assert.Equal(t, maxEjection, routing.Eject(m, now, base, maxEjection), "limited by max ejection time")
+
+	// not ejected for two base ejection times
+	now = now.Add(maxEjection + 2*base)
+	assert.Equal(t, 2*base, routing.Eject(m, now, base, maxEjection))
+}
+
+func TestEjectConcurrent(t *testing.T) {
+	r := routing.NewEndpointRegistry(routing.RegistryOptions{})
+	defer r.Close()
+
+	const base = time.Second
+
+	m := r.GetMetrics("some key")
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			routing.Eject(m, now, base, time.Hour)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 11*base, routing.Eject(m, now, base, time.Hour), "every ejection is counted")
+}
@@ -0,0 +1,69 @@
+package routing
+
+import "time"
+
+// The outlier detection state of the endpoints is kept by the entries of
+// the EndpointRegistry. The functions below have no effect on, and return
+// zero values for, Metrics not returned by the registry.
+
+// IncConsecutiveErrors increments and returns the number of consecutive
+// failed requests to the endpoint, used by the outlier detection.
+func IncConsecutiveErrors(m Metrics) int64 {
+	if e, ok := m.(*entry); ok {
+		return e.consecutiveErrors.Add(1)
+	}
+	return 0
+}
+
+// ResetConsecutiveErrors resets the number of consecutive failed requests
+// to the endpoint.
+func ResetConsecutiveErrors(m Metrics) {
+	if e, ok := m.(*entry); ok {
+		e.consecutiveErrors.Store(0)
+	}
+}
+
+// EjectedUntil returns the time until the endpoint is ejected from load
+// balancing by the outlier detection.
+func EjectedUntil(m Metrics) time.Time {
+	if e, ok := m.(*entry); ok {
+		return e.ejectedUntil()
+	}
+	return time.Time{}
+}
+
+// Eject ejects the endpoint from load balancing and returns the duration
+// of the ejection. The duration is the base ejection time multiplied by the
+// number of ejections of the endpoint, limited by the maximum ejection
+// time. The number of ejections decreases by one for every base ejection
+// time the endpoint was not ejected.
+func Eject(m Metrics, now time.Time, baseEjectionTime, maxEjectionTime time.Duration) time.Duration {
+	if e, ok := m.(*entry); ok {
+		return e.eject(now, baseEjectionTime, maxEjectionTime)
+	}
+	return 0
+}
+
+func (e *entry) ejectedUntil() time.Time {
+	t, _ := e.ejected.Load().(time.Time)
+	return t
+}
+
+func (e *entry) eject(now time.Time, baseEjectionTime, maxEjectionTime time.Duration) time.Duration {
+	if last := e.ejectedUntil(); baseEjectionTime > 0 && now.After(last) {
+		// a concurrent ejection, that already applied the decrease, fails the swap
+		if count, decrease := e.ejectionCount.Load(), int64(now.Sub(last)/baseEjectionTime); count > 0 && decrease > 0 {
+			e.ejectionCount.CompareAndSwap(count, max(count-decrease, 0))
+		}
+	}
+
+	count := e.ejectionCount.Add(1)
+	d := baseEjectionTime * time.Duration(count)
+	if maxEjectionTime > 0 && (d > maxEjectionTime || d < 0) {
+		d = maxEjectionTime
+	}
+
+	e.ejected.Store(now.Add(d))
+	e.consecutiveErrors.Store(0)
+	return d
+}
@@ -62,6 +62,18 @@ type NamedDataClient interface {
 	Name() string
 }
 
+// InvalidRoutesReceiver is an optional interface of the data clients.
+// After every update of the routing table, the data clients implementing
+// it receive the errors of their invalid routes, mapped by the route IDs.
+// The map is empty when all the routes of the client are valid.
+//
+// The method is called from the routing goroutine, and it should not
+// block.
+type InvalidRoutesReceiver interface {
+	DataClient
+	ReceiveInvalidRoutes(errors map[string]string)
+}
+
 // Predicate instances are used as custom user defined route
 // matching predicates.
 type Predicate interface {
@@ -164,6 +176,10 @@ type RouteFilter struct {
 type LBEndpoint struct {
 	Scheme, Host, Zone string
 	Metrics            Metrics
+
+	// Weight is the static weight of the endpoint set in the route
+	// definition. Zero means that it was not set.
+	Weight float64
 }
 
 // LBAlgorithm implementations apply a load balancing algorithm
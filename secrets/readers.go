@@ -1,6 +1,9 @@
 package secrets
 
-import "net/url"
+import (
+	"net/url"
+	"sync/atomic"
+)
 
 // SecretsReader is able to get a secret
 type SecretsReader interface {
@@ -94,3 +97,54 @@ func (hs *HostSecret) GetSecret(s string) ([]byte, bool) {
 func (hs *HostSecret) Close() {
 	hs.sr.Close()
 }
+
+// SecretsMap is a SecretsReader of secrets, that are replaced at
+// runtime, for example by the Kubernetes dataclient.
+type SecretsMap struct {
+	secrets atomic.Pointer[map[string][]byte]
+}
+
+// NewSecretsMap creates an empty SecretsMap.
+func NewSecretsMap() *SecretsMap {
+	return &SecretsMap{}
+}
+
+// Update replaces all secrets with the given map, the map must not
+// be modified afterwards.
+func (sm *SecretsMap) Update(m map[string][]byte) {
+	sm.secrets.Store(&m)
+}
+
+// GetSecret returns the secret stored with the given name.
+func (sm *SecretsMap) GetSecret(s string) ([]byte, bool) {
+	m := sm.secrets.Load()
+	if m == nil {
+		return nil, false
+	}
+	data, ok := (*m)[s]
+	return data, ok
+}
+
+// Close implements SecretsReader.
+func (sm *SecretsMap) Close() {}
+
+// MultiSecret is a SecretsReader, that returns the secret of the first
+// SecretsReader, that has it.
+type MultiSecret []SecretsReader
+
+// GetSecret returns the secret of the first SecretsReader, that has it.
+func (ms MultiSecret) GetSecret(s string) ([]byte, bool) {
+	for _, sr := range ms {
+		if data, ok := sr.GetSecret(s); ok {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// Close closes all wrapped SecretsReaders.
+func (ms MultiSecret) Close() {
+	for _, sr := range ms {
+		sr.Close()
+	}
+}
@@ -58,3 +58,38 @@ func TestHostSecret(t *testing.T) {
 		t.Errorf("Failed to get host secret: %v, got: %s, want: %s", ok, sec, testsecret)
 	}
 }
+
+func TestSecretsMap(t *testing.T) {
+	sm := NewSecretsMap()
+	defer sm.Close()
+
+	if _, ok := sm.GetSecret("foo"); ok {
+		t.Error("Failed to get no secret from an empty map")
+	}
+
+	sm.Update(map[string][]byte{"foo": []byte("bar")})
+	if sec, ok := sm.GetSecret("foo"); string(sec) != "bar" || !ok {
+		t.Errorf("Failed to get secret: %v, got: %s, want: bar", ok, sec)
+	}
+
+	sm.Update(map[string][]byte{"baz": []byte("qux")})
+	if _, ok := sm.GetSecret("foo"); ok {
+		t.Error("Failed to remove secret")
+	}
+}
+
+func TestMultiSecret(t *testing.T) {
+	sm := NewSecretsMap()
+	sm.Update(map[string][]byte{"foo": []byte("bar"), "baz": []byte("qux")})
+
+	ms := MultiSecret{NewStaticDelegateSecret(sm, "baz"), sm}
+	defer ms.Close()
+
+	if sec, ok := ms.GetSecret("foo"); string(sec) != "qux" || !ok {
+		t.Errorf("Failed to get secret of the first reader: %v, got: %s, want: qux", ok, sec)
+	}
+
+	if _, ok := (MultiSecret{sm}).GetSecret("missing"); ok {
+		t.Error("Failed to get no secret")
+	}
+}
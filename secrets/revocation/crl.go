@@ -0,0 +1,241 @@
+package revocation
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type crl struct {
+	list    *x509.RevocationList
+	revoked map[string]struct{}
+
+	// verified caches the result of the signature verification by
+	// the raw issuer certificate
+	verified sync.Map
+}
+
+type crlStore struct {
+	mu sync.RWMutex
+
+	// byIssuer maps the raw issuer name to the CRLs of the sources
+	byIssuer map[string]map[string]*crl
+	known    map[string]struct{}
+
+	// failed maps the failed distribution points to their next retry
+	failed map[string]time.Time
+}
+
+func newCRLStore() *crlStore {
+	return &crlStore{
+		byIssuer: make(map[string]map[string]*crl),
+		known:    make(map[string]struct{}),
+		failed:   make(map[string]time.Time),
+	}
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+func (s *crlStore) addSource(source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.known[source] = struct{}{}
+}
+
+func (s *crlStore) sources() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sources := make([]string, 0, len(s.known))
+	for source := range s.known {
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+func (s *crlStore) hasSource(source string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.known[source]
+	return ok
+}
+
+func (s *crlStore) set(source string, list *x509.RevocationList) {
+	revoked := make(map[string]struct{}, len(list.RevokedCertificateEntries))
+	for _, e := range list.RevokedCertificateEntries {
+		revoked[e.SerialNumber.String()] = struct{}{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the issuer of a source does not change usually, but remove the
+	// previous CRL in case it does
+	for issuer, crls := range s.byIssuer {
+		delete(crls, source)
+		if len(crls) == 0 {
+			delete(s.byIssuer, issuer)
+		}
+	}
+
+	issuer := string(list.RawIssuer)
+	if s.byIssuer[issuer] == nil {
+		s.byIssuer[issuer] = make(map[string]*crl)
+	}
+	s.byIssuer[issuer][source] = &crl{list: list, revoked: revoked}
+	s.known[source] = struct{}{}
+	delete(s.failed, source)
+}
+
+// status returns Revoked or Good, when a valid CRL signed by the issuer
+// covers the certificate.
+func (s *crlStore) status(cert, issuer *x509.Certificate, now time.Time) Status {
+	// the CRLs are copied, because set modifies the map of the issuer
+	s.mu.RLock()
+	crls := make([]*crl, 0, len(s.byIssuer[string(cert.RawIssuer)]))
+	for _, c := range s.byIssuer[string(cert.RawIssuer)] {
+		crls = append(crls, c)
+	}
+	s.mu.RUnlock()
+
+	status := Unknown
+	for _, c := range crls {
+		if !c.list.NextUpdate.IsZero() && now.After(c.list.NextUpdate) {
+			continue
+		}
+		if !c.verify(issuer) {
+			continue
+		}
+		if _, ok := c.revoked[cert.SerialNumber.String()]; ok {
+			return Revoked
+		}
+		status = Good
+	}
+	return status
+}
+
+func (c *crl) verify(issuer *x509.Certificate) bool {
+	key := string(issuer.Raw)
+	if v, ok := c.verified.Load(key); ok {
+		return v.(bool)
+	}
+
+	err := c.list.CheckSignatureFrom(issuer)
+	if err != nil {
+		log.Debugf("CRL of %s is not signed by %s: %v", c.list.Issuer, issuer.Subject, err)
+	}
+	c.verified.Store(key, err == nil)
+	return err == nil
+}
+
+func (c *Checker) loadCRL(source string) error {
+	var data []byte
+	var err error
+	if isURL(source) {
+		data, err = c.fetch(context.Background(), source)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return err
+	}
+
+	list, err := parseCRL(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse CRL %s: %w", source, err)
+	}
+
+	c.crls.set(source, list)
+	return nil
+}
+
+func parseCRL(data []byte) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "X509 CRL" {
+			return nil, fmt.Errorf("unexpected PEM block type %s", block.Type)
+		}
+		data = block.Bytes
+	}
+	return x509.ParseRevocationList(data)
+}
+
+// fetchDistributionPoints loads the CRLs of the http distribution points
+// of the certificate, that were not loaded before. It returns true, when
+// a CRL was loaded.
+func (c *Checker) fetchDistributionPoints(cert *x509.Certificate) bool {
+	loaded := false
+	for _, dp := range cert.CRLDistributionPoints {
+		if !isURL(dp) || c.crls.hasSource(dp) || !c.crls.retry(dp, c.now()) {
+			continue
+		}
+
+		_, err, _ := c.fetchGroup.Do(dp, func() (interface{}, error) {
+			if c.crls.hasSource(dp) {
+				return nil, nil
+			}
+			return nil, c.loadCRL(dp)
+		})
+		if err != nil {
+			log.Errorf("Failed to load CRL distribution point %s: %v", dp, err)
+			c.metrics.IncCounter(crlRefreshFailedMetric)
+			c.crls.setFailed(dp, c.now().Add(failureBackoff))
+			continue
+		}
+		loaded = true
+	}
+	return loaded
+}
+
+func (s *crlStore) retry(source string, now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	next, ok := s.failed[source]
+	return !ok || now.After(next)
+}
+
+func (s *crlStore) setFailed(source string, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed[source] = next
+}
+
+func (c *Checker) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+func (c *Checker) do(req *http.Request) ([]byte, error) {
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", rsp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(rsp.Body, maxRevocationResponseLen+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRevocationResponseLen {
+		return nil, errors.New("response too large")
+	}
+	return data, nil
+}
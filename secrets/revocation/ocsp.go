@@ -0,0 +1,246 @@
+package revocation
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ocsp"
+)
+
+type ocspEntry struct {
+	status  Status
+	expires time.Time
+}
+
+type ocspCache struct {
+	mu      sync.Mutex
+	entries map[string]ocspEntry
+}
+
+func newOCSPCache() *ocspCache {
+	return &ocspCache{entries: make(map[string]ocspEntry)}
+}
+
+func (oc *ocspCache) get(key string, now time.Time) (Status, bool) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	e, ok := oc.entries[key]
+	if !ok || now.After(e.expires) {
+		return Unknown, false
+	}
+	return e.status, true
+}
+
+func (oc *ocspCache) set(key string, status Status, expires time.Time) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	oc.entries[key] = ocspEntry{status: status, expires: expires}
+}
+
+func (oc *ocspCache) removeExpired(now time.Time) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	for key, e := range oc.entries {
+		if now.After(e.expires) {
+			delete(oc.entries, key)
+		}
+	}
+}
+
+// ocspStatus returns the cached or the queried status of the certificate.
+// Failed queries are cached as Unknown for the failure backoff, to not
+// query an unavailable responder on every connection.
+func (c *Checker) ocspStatus(ctx context.Context, cert, issuer *x509.Certificate) Status {
+	key := string(issuer.Raw) + "/" + cert.SerialNumber.String()
+	if s, ok := c.ocsp.get(key, c.now()); ok {
+		return s
+	}
+
+	s, _, _ := c.fetchGroup.Do("ocsp/"+key, func() (interface{}, error) {
+		rsp, _, err := c.queryOCSP(ctx, cert, issuer)
+		if err != nil {
+			log.Errorf("Failed to check OCSP status of %s: %v", cert.Subject, err)
+			c.metrics.IncCounter(ocspFailedMetric)
+			c.ocsp.set(key, Unknown, c.now().Add(failureBackoff))
+			return Unknown, nil
+		}
+
+		s := ocspResponseStatus(rsp)
+		c.ocsp.set(key, s, c.expires(rsp))
+		return s, nil
+	})
+	return s.(Status)
+}
+
+func (c *Checker) expires(rsp *ocsp.Response) time.Time {
+	expires := c.now().Add(c.options.RefreshInterval)
+	if !rsp.NextUpdate.IsZero() && rsp.NextUpdate.Before(expires) {
+		expires = rsp.NextUpdate
+	}
+	return expires
+}
+
+func ocspResponseStatus(rsp *ocsp.Response) Status {
+	switch rsp.Status {
+	case ocsp.Good:
+		return Good
+	case ocsp.Revoked:
+		return Revoked
+	default:
+		return Unknown
+	}
+}
+
+// queryOCSP returns the parsed and the raw response of the first OCSP
+// responder of the certificate, that responds.
+func (c *Checker) queryOCSP(ctx context.Context, cert, issuer *x509.Certificate) (*ocsp.Response, []byte, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var errs []error
+	for _, server := range cert.OCSPServer {
+		if !isURL(server) {
+			continue
+		}
+
+		raw, err := c.postOCSP(ctx, server, req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", server, err))
+			continue
+		}
+
+		rsp, err := ocsp.ParseResponseForCert(raw, cert, issuer)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", server, err))
+			continue
+		}
+		return rsp, raw, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, nil, errors.New("no http OCSP responder")
+	}
+	return nil, nil, errors.Join(errs...)
+}
+
+func (c *Checker) postOCSP(ctx context.Context, server string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", server, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+	return c.do(req)
+}
+
+// Stapler staples OCSP responses to server certificates.
+type Stapler struct {
+	checker *Checker
+	certs   []tls.Certificate
+	stapled atomic.Pointer[[]tls.Certificate]
+	expires []time.Time
+}
+
+// NewStapler creates a Stapler for the certificates and fetches their
+// OCSP responses. The responses are refreshed with the refresh interval
+// of the checker. Certificates without issuer certificate or OCSP
+// responder are served without staple.
+func (c *Checker) NewStapler(certs []tls.Certificate) *Stapler {
+	s := &Stapler{
+		checker: c,
+		certs:   certs,
+		expires: make([]time.Time, len(certs)),
+	}
+
+	stapled := make([]tls.Certificate, len(certs))
+	copy(stapled, certs)
+	s.stapled.Store(&stapled)
+	s.refresh()
+
+	c.mu.Lock()
+	c.staplers = append(c.staplers, s)
+	c.mu.Unlock()
+	return s
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate. It returns the
+// first stapled certificate supported by the client, or nil when none is
+// supported.
+func (s *Stapler) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	stapled := *s.stapled.Load()
+	for i := range stapled {
+		if hello.SupportsCertificate(&stapled[i]) == nil {
+			return &stapled[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// refresh fetches the OCSP responses of the certificates. The previous
+// staple of a certificate is kept on failure, until it expires.
+func (s *Stapler) refresh() {
+	c := s.checker
+	now := c.now()
+
+	previous := *s.stapled.Load()
+	stapled := make([]tls.Certificate, len(s.certs))
+	copy(stapled, s.certs)
+
+	for i, cert := range s.certs {
+		if len(cert.Certificate) < 2 {
+			continue
+		}
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			continue
+		}
+		if len(leaf.OCSPServer) == 0 {
+			continue
+		}
+
+		issuer, err := x509.ParseCertificate(cert.Certificate[1])
+		if err != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.options.Timeout)
+		rsp, raw, err := c.queryOCSP(ctx, leaf, issuer)
+		cancel()
+
+		switch {
+		case err != nil:
+			log.Errorf("Failed to fetch OCSP staple of %s: %v", leaf.Subject, err)
+			c.metrics.IncCounter(ocspStapleFailedMetric)
+		case rsp.Status != ocsp.Good:
+			log.Errorf("OCSP status of %s is %s", leaf.Subject, ocspResponseStatus(rsp))
+			c.metrics.IncCounter(ocspStapleFailedMetric)
+		default:
+			stapled[i].OCSPStaple = raw
+			s.expires[i] = rsp.NextUpdate
+			continue
+		}
+
+		if s.expires[i].IsZero() || now.Before(s.expires[i]) {
+			stapled[i].OCSPStaple = previous[i].OCSPStaple
+		}
+	}
+
+	s.stapled.Store(&stapled)
+}
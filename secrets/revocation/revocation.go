@@ -0,0 +1,307 @@
+/*
+Package revocation implements the revocation checking of X.509
+certificates using CRLs and OCSP, and the OCSP stapling of server
+certificates.
+
+The CRLs are loaded from files and URLs, and optionally from the CRL
+distribution points of the checked certificates, and are refreshed
+periodically. The OCSP responses are cached until their next update, at
+most for the refresh interval.
+
+When the revocation status of a certificate can not be determined, e.g.
+because no CRL covers it and the OCSP responder is not available, the
+certificate is accepted in fail-open mode and rejected in fail-closed
+mode. The status of client certificates, that were not verified, is
+always unknown.
+*/
+package revocation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/zalando/skipper/metrics"
+)
+
+const (
+	// DefaultRefreshInterval is the default interval to refresh the
+	// CRLs and the maximum time to cache OCSP responses.
+	DefaultRefreshInterval = time.Hour
+
+	// DefaultTimeout is the default timeout to fetch CRLs and OCSP
+	// responses.
+	DefaultTimeout = 5 * time.Second
+
+	// failureBackoff is the time to wait before a failed CRL
+	// distribution point or OCSP responder is queried again for the
+	// same certificate.
+	failureBackoff = time.Minute
+
+	revokedMetric            = "revocation.revoked"
+	unknownMetric            = "revocation.unknown"
+	crlRefreshFailedMetric   = "revocation.crl.refresh.failed"
+	ocspFailedMetric         = "revocation.ocsp.failed"
+	ocspStapleFailedMetric   = "revocation.ocsp.staple.failed"
+	maxRevocationResponseLen = 32 << 20
+)
+
+// Status is the revocation status of a certificate.
+type Status int
+
+const (
+	// Unknown status, when no CRL covers the certificate and no OCSP
+	// response is available.
+	Unknown Status = iota
+
+	// Good status, the certificate is not revoked.
+	Good
+
+	// Revoked status, the certificate is revoked.
+	Revoked
+)
+
+func (s Status) String() string {
+	switch s {
+	case Good:
+		return "good"
+	case Revoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	ErrRevoked       = errors.New("certificate is revoked")
+	ErrUnknownStatus = errors.New("revocation status of certificate is unknown")
+)
+
+// Options configures the revocation checking.
+type Options struct {
+	// CRLs are file paths or http(s) URLs of PEM or DER encoded CRLs.
+	CRLs []string
+
+	// FetchDistributionPoints enables to fetch the CRLs from the http
+	// CRL distribution points of the checked certificates.
+	FetchDistributionPoints bool
+
+	// OCSP enables to check the status of the certificates, which
+	// are not covered by a CRL, at their OCSP responders.
+	OCSP bool
+
+	// FailClosed rejects the certificates with unknown revocation
+	// status.
+	FailClosed bool
+
+	// RefreshInterval to refresh the CRLs and the OCSP staples,
+	// defaults to DefaultRefreshInterval.
+	RefreshInterval time.Duration
+
+	// Timeout to fetch CRLs and OCSP responses, defaults to
+	// DefaultTimeout.
+	Timeout time.Duration
+
+	// Metrics defaults to metrics.Default.
+	Metrics metrics.Metrics
+}
+
+// Checker checks the revocation status of certificate chains.
+type Checker struct {
+	options Options
+	client  *http.Client
+	metrics metrics.Metrics
+	now     func() time.Time
+
+	crls       *crlStore
+	ocsp       *ocspCache
+	fetchGroup singleflight.Group
+
+	mu       sync.Mutex
+	staplers []*Stapler
+
+	quit chan struct{}
+	once sync.Once
+}
+
+// New creates a Checker and loads the configured CRLs. It returns an
+// error when a CRL file can not be loaded. The CRLs of URLs, that can
+// not be loaded, are retried on the next refresh.
+func New(o Options) (*Checker, error) {
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = DefaultRefreshInterval
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.Metrics == nil {
+		o.Metrics = metrics.Default
+	}
+
+	c := &Checker{
+		options: o,
+		client:  &http.Client{Timeout: o.Timeout},
+		metrics: o.Metrics,
+		now:     time.Now,
+		crls:    newCRLStore(),
+		ocsp:    newOCSPCache(),
+		quit:    make(chan struct{}),
+	}
+
+	for _, source := range o.CRLs {
+		if err := c.loadCRL(source); err != nil {
+			if !isURL(source) {
+				return nil, err
+			}
+			log.Errorf("Failed to load CRL %s: %v", source, err)
+			c.metrics.IncCounter(crlRefreshFailedMetric)
+			c.crls.addSource(source)
+		}
+	}
+
+	go c.refreshLoop()
+	return c, nil
+}
+
+// Close stops refreshing the CRLs and OCSP staples.
+func (c *Checker) Close() {
+	c.once.Do(func() { close(c.quit) })
+}
+
+// FailClosed returns true when certificates with unknown revocation
+// status are rejected by Check.
+func (c *Checker) FailClosed() bool {
+	return c.options.FailClosed
+}
+
+// Status returns the revocation status of the chain, which is the status
+// of the first revoked or unknown certificate of the chain. The chain
+// starts with the leaf certificate, followed by its issuers. The last
+// certificate is not checked, because it is either the trusted root or
+// its issuer is not known.
+func (c *Checker) Status(ctx context.Context, chain []*x509.Certificate) Status {
+	if len(chain) < 2 {
+		return Unknown
+	}
+
+	for i := 0; i < len(chain)-1; i++ {
+		if s := c.certStatus(ctx, chain[i], chain[i+1]); s != Good {
+			return s
+		}
+	}
+	return Good
+}
+
+// Check returns ErrRevoked when a certificate of the chain is revoked, and
+// in fail-closed mode ErrUnknownStatus when the status of a certificate
+// is not known.
+func (c *Checker) Check(ctx context.Context, chain []*x509.Certificate) error {
+	return c.CheckMode(ctx, chain, c.options.FailClosed)
+}
+
+// CheckMode works like Check, but with the given fail-closed mode
+// instead of the configured one.
+func (c *Checker) CheckMode(ctx context.Context, chain []*x509.Certificate, failClosed bool) error {
+	switch c.Status(ctx, chain) {
+	case Revoked:
+		c.metrics.IncCounter(revokedMetric)
+		return ErrRevoked
+	case Unknown:
+		return c.unknown(failClosed)
+	}
+	return nil
+}
+
+// CheckConnection works like CheckMode for the verified chain of the
+// connection. Without a verified chain, e.g. with
+// tls.RequireAnyClientCert, the status is unknown: the certificates sent
+// by the client are not trusted, so their CRL distribution points and OCSP
+// responders are not queried.
+func (c *Checker) CheckConnection(ctx context.Context, cs tls.ConnectionState, failClosed bool) error {
+	if len(cs.VerifiedChains) == 0 {
+		return c.unknown(failClosed)
+	}
+
+	return c.CheckMode(ctx, cs.VerifiedChains[0], failClosed)
+}
+
+func (c *Checker) unknown(failClosed bool) error {
+	c.metrics.IncCounter(unknownMetric)
+	if failClosed {
+		return ErrUnknownStatus
+	}
+	return nil
+}
+
+// VerifyConnection can be used as tls.Config.VerifyConnection to check
+// the revocation status of client certificates. Connections without
+// client certificates are accepted, they are handled by the ClientAuth
+// policy. Only the verified chain is checked, see CheckConnection.
+func (c *Checker) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 && len(cs.VerifiedChains) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Timeout)
+	defer cancel()
+
+	return c.CheckConnection(ctx, cs, c.options.FailClosed)
+}
+
+func (c *Checker) certStatus(ctx context.Context, cert, issuer *x509.Certificate) Status {
+	now := c.now()
+	if s := c.crls.status(cert, issuer, now); s != Unknown {
+		return s
+	}
+
+	if c.options.FetchDistributionPoints && c.fetchDistributionPoints(cert) {
+		if s := c.crls.status(cert, issuer, now); s != Unknown {
+			return s
+		}
+	}
+
+	if c.options.OCSP && len(cert.OCSPServer) > 0 {
+		return c.ocspStatus(ctx, cert, issuer)
+	}
+
+	return Unknown
+}
+
+func (c *Checker) refreshLoop() {
+	ticker := time.NewTicker(c.options.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-ticker.C:
+			c.refresh()
+		}
+	}
+}
+
+func (c *Checker) refresh() {
+	for _, source := range c.crls.sources() {
+		if err := c.loadCRL(source); err != nil {
+			log.Errorf("Failed to refresh CRL %s: %v", source, err)
+			c.metrics.IncCounter(crlRefreshFailedMetric)
+		}
+	}
+
+	c.ocsp.removeExpired(c.now())
+
+	c.mu.Lock()
+	staplers := c.staplers
+	c.mu.Unlock()
+	for _, s := range staplers {
+		s.refresh()
+	}
+}
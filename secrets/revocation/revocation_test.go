@@ -0,0 +1,491 @@
+package revocation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, ocspServer, crlDistributionPoint string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"example.org"},
+	}
+	if ocspServer != "" {
+		template.OCSPServer = []string{ocspServer}
+	}
+	if crlDistributionPoint != "" {
+		template.CRLDistributionPoints = []string{crlDistributionPoint}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func (ca *testCA) crl(t *testing.T, nextUpdate time.Time, revoked ...int64) []byte {
+	t.Helper()
+
+	template := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: nextUpdate,
+	}
+	for _, serial := range revoked {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca.cert, ca.key)
+	require.NoError(t, err)
+	return der
+}
+
+func (ca *testCA) ocspResponder(t *testing.T, revoked ...int64) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var requests atomic.Int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		template := ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}
+		for _, serial := range revoked {
+			if req.SerialNumber.Int64() == serial {
+				template.Status = ocsp.Revoked
+				template.RevokedAt = time.Now().Add(-time.Minute)
+			}
+		}
+
+		rsp, err := ocsp.CreateResponse(ca.cert, ca.cert, template, ca.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(rsp)
+	}))
+	t.Cleanup(s.Close)
+	return s, &requests
+}
+
+func newTestChecker(t *testing.T, o Options) *Checker {
+	t.Helper()
+
+	c, err := New(o)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestCRLFile(t *testing.T) {
+	ca := newTestCA(t)
+	good, _ := ca.issue(t, 2, "", "")
+	revoked, _ := ca.issue(t, 3, "", "")
+
+	der := ca.crl(t, time.Now().Add(time.Hour), 3)
+	for name, data := range map[string][]byte{
+		"der": der,
+		"pem": pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := &metricstest.MockMetrics{}
+			c := newTestChecker(t, Options{CRLs: []string{writeFile(t, "ca.crl", data)}, Metrics: m})
+
+			assert.Equal(t, Good, c.Status(context.Background(), []*x509.Certificate{good, ca.cert}))
+			assert.Equal(t, Revoked, c.Status(context.Background(), []*x509.Certificate{revoked, ca.cert}))
+
+			assert.NoError(t, c.Check(context.Background(), []*x509.Certificate{good, ca.cert}))
+			assert.ErrorIs(t, c.Check(context.Background(), []*x509.Certificate{revoked, ca.cert}), ErrRevoked)
+
+			m.WithCounters(func(counters map[string]int64) {
+				assert.Equal(t, int64(1), counters[revokedMetric])
+			})
+		})
+	}
+}
+
+func TestCRLFileInvalid(t *testing.T) {
+	_, err := New(Options{CRLs: []string{writeFile(t, "invalid.crl", []byte("invalid"))}})
+	assert.Error(t, err)
+
+	_, err = New(Options{CRLs: []string{filepath.Join(t.TempDir(), "missing.crl")}})
+	assert.Error(t, err)
+}
+
+func TestCRLNotSignedByIssuer(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+	revoked, _ := ca.issue(t, 3, "", "")
+
+	// same subject as the issuing CA, but signed by another key
+	c := newTestChecker(t, Options{CRLs: []string{writeFile(t, "other.crl", other.crl(t, time.Now().Add(time.Hour), 3))}})
+	assert.Equal(t, Unknown, c.Status(context.Background(), []*x509.Certificate{revoked, ca.cert}))
+}
+
+func TestCRLExpired(t *testing.T) {
+	ca := newTestCA(t)
+	good, _ := ca.issue(t, 2, "", "")
+
+	c := newTestChecker(t, Options{CRLs: []string{writeFile(t, "ca.crl", ca.crl(t, time.Now().Add(time.Hour)))}})
+	c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	assert.Equal(t, Unknown, c.Status(context.Background(), []*x509.Certificate{good, ca.cert}))
+}
+
+func TestCRLStoreConcurrentSet(t *testing.T) {
+	ca := newTestCA(t)
+	cert, _ := ca.issue(t, 2, "", "")
+
+	list, err := x509.ParseRevocationList(ca.crl(t, time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+
+	s := newCRLStore()
+	s.set("ca.crl", list)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 1000 {
+			s.set(fmt.Sprintf("ca-%d.crl", i%10), list)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			assert.Equal(t, Good, s.status(cert, ca.cert, time.Now()))
+			return
+		default:
+			assert.Equal(t, Good, s.status(cert, ca.cert, time.Now()))
+		}
+	}
+}
+
+func TestCRLURLRefresh(t *testing.T) {
+	ca := newTestCA(t)
+	cert, _ := ca.issue(t, 2, "", "")
+
+	var crl atomic.Pointer[[]byte]
+	initial := ca.crl(t, time.Now().Add(time.Hour))
+	crl.Store(&initial)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(*crl.Load())
+	}))
+	defer s.Close()
+
+	c := newTestChecker(t, Options{CRLs: []string{s.URL}, RefreshInterval: time.Hour})
+	assert.Equal(t, Good, c.Status(context.Background(), []*x509.Certificate{cert, ca.cert}))
+
+	updated := ca.crl(t, time.Now().Add(time.Hour), 2)
+	crl.Store(&updated)
+	c.refresh()
+
+	assert.Equal(t, Revoked, c.Status(context.Background(), []*x509.Certificate{cert, ca.cert}))
+}
+
+func TestCRLURLUnavailable(t *testing.T) {
+	ca := newTestCA(t)
+	cert, _ := ca.issue(t, 2, "", "")
+
+	available := atomic.Bool{}
+	der := ca.crl(t, time.Now().Add(time.Hour), 2)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(der)
+	}))
+	defer s.Close()
+
+	m := &metricstest.MockMetrics{}
+	c := newTestChecker(t, Options{CRLs: []string{s.URL}, Metrics: m})
+
+	assert.Equal(t, Unknown, c.Status(context.Background(), []*x509.Certificate{cert, ca.cert}))
+	assert.NoError(t, c.Check(context.Background(), []*x509.Certificate{cert, ca.cert}), "fail-open")
+	assert.ErrorIs(t, c.CheckMode(context.Background(), []*x509.Certificate{cert, ca.cert}, true), ErrUnknownStatus)
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(1), counters[crlRefreshFailedMetric])
+		assert.Equal(t, int64(2), counters[unknownMetric])
+	})
+
+	available.Store(true)
+	c.refresh()
+
+	assert.Equal(t, Revoked, c.Status(context.Background(), []*x509.Certificate{cert, ca.cert}))
+}
+
+func TestCRLDistributionPoints(t *testing.T) {
+	ca := newTestCA(t)
+
+	var requests atomic.Int64
+	der := ca.crl(t, time.Now().Add(time.Hour), 3)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write(der)
+	}))
+	defer s.Close()
+
+	good, _ := ca.issue(t, 2, "", s.URL+"/ca.crl")
+	revoked, _ := ca.issue(t, 3, "", s.URL+"/ca.crl")
+
+	t.Run("disabled", func(t *testing.T) {
+		c := newTestChecker(t, Options{})
+		assert.Equal(t, Unknown, c.Status(context.Background(), []*x509.Certificate{revoked, ca.cert}))
+		assert.Equal(t, int64(0), requests.Load())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c := newTestChecker(t, Options{FetchDistributionPoints: true})
+		assert.Equal(t, Good, c.Status(context.Background(), []*x509.Certificate{good, ca.cert}))
+		assert.Equal(t, Revoked, c.Status(context.Background(), []*x509.Certificate{revoked, ca.cert}))
+		assert.Equal(t, int64(1), requests.Load(), "the distribution point is fetched once")
+	})
+}
+
+func TestOCSP(t *testing.T) {
+	ca := newTestCA(t)
+	responder, requests := ca.ocspResponder(t, 3)
+
+	good, _ := ca.issue(t, 2, responder.URL, "")
+	revoked, _ := ca.issue(t, 3, responder.URL, "")
+
+	t.Run("disabled", func(t *testing.T) {
+		c := newTestChecker(t, Options{})
+		assert.Equal(t, Unknown, c.Status(context.Background(), []*x509.Certificate{good, ca.cert}))
+		assert.Equal(t, int64(0), requests.Load())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c := newTestChecker(t, Options{OCSP: true})
+		assert.Equal(t, Good, c.Status(context.Background(), []*x509.Certificate{good, ca.cert}))
+		assert.Equal(t, Revoked, c.Status(context.Background(), []*x509.Certificate{revoked, ca.cert}))
+		assert.Equal(t, int64(2), requests.Load())
+
+		assert.Equal(t, Good, c.Status(context.Background(), []*x509.Certificate{good, ca.cert}))
+		assert.Equal(t, int64(2), requests.Load(), "the response is cached")
+
+		c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		c.refresh()
+		assert.Equal(t, Good, c.Status(context.Background(), []*x509.Certificate{good, ca.cert}))
+		assert.Equal(t, int64(3), requests.Load(), "the expired response is fetched again")
+	})
+
+	t.Run("CRL takes precedence", func(t *testing.T) {
+		c := newTestChecker(t, Options{OCSP: true, CRLs: []string{writeFile(t, "ca.crl", ca.crl(t, time.Now().Add(time.Hour), 2))}})
+		before := requests.Load()
+		assert.Equal(t, Revoked, c.Status(context.Background(), []*x509.Certificate{good, ca.cert}))
+		assert.Equal(t, before, requests.Load())
+	})
+}
+
+func TestOCSPUnavailable(t *testing.T) {
+	ca := newTestCA(t)
+
+	var requests atomic.Int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	cert, _ := ca.issue(t, 2, s.URL, "")
+
+	m := &metricstest.MockMetrics{}
+	c := newTestChecker(t, Options{OCSP: true, FailClosed: true, Metrics: m})
+	assert.True(t, c.FailClosed())
+
+	assert.ErrorIs(t, c.Check(context.Background(), []*x509.Certificate{cert, ca.cert}), ErrUnknownStatus)
+	assert.ErrorIs(t, c.Check(context.Background(), []*x509.Certificate{cert, ca.cert}), ErrUnknownStatus)
+	assert.Equal(t, int64(1), requests.Load(), "the failure is cached")
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(1), counters[ocspFailedMetric])
+		assert.Equal(t, int64(2), counters[unknownMetric])
+	})
+}
+
+func TestVerifyConnection(t *testing.T) {
+	ca := newTestCA(t)
+	good, _ := ca.issue(t, 2, "", "")
+	revoked, _ := ca.issue(t, 3, "", "")
+
+	c := newTestChecker(t, Options{CRLs: []string{writeFile(t, "ca.crl", ca.crl(t, time.Now().Add(time.Hour), 3))}})
+
+	assert.NoError(t, c.VerifyConnection(tls.ConnectionState{}))
+	assert.NoError(t, c.VerifyConnection(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{good, ca.cert}}}))
+	assert.ErrorIs(t, c.VerifyConnection(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{revoked, ca.cert}}}), ErrRevoked)
+	assert.NoError(t, c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{revoked, ca.cert}}), "unverified chain in fail-open mode")
+
+	c = newTestChecker(t, Options{FailClosed: true, CRLs: []string{writeFile(t, "ca.crl", ca.crl(t, time.Now().Add(time.Hour), 3))}})
+	assert.NoError(t, c.VerifyConnection(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{good, ca.cert}}}))
+	assert.ErrorIs(t, c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{good, ca.cert}}), ErrUnknownStatus, "unverified chain in fail-closed mode")
+}
+
+func TestVerifyConnectionUnverifiedChain(t *testing.T) {
+	ca := newTestCA(t)
+
+	var requests atomic.Int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer s.Close()
+
+	cert, _ := ca.issue(t, 2, s.URL+"/ocsp", s.URL+"/ca.crl")
+	c := newTestChecker(t, Options{FetchDistributionPoints: true, OCSP: true})
+
+	assert.NoError(t, c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert, ca.cert}}))
+	assert.Equal(t, int64(0), requests.Load(), "the urls of unverified certificates are not requested")
+	assert.Empty(t, c.crls.sources(), "the distribution points of unverified certificates are not added")
+}
+
+func TestStapler(t *testing.T) {
+	ca := newTestCA(t)
+	responder, _ := ca.ocspResponder(t, 3)
+
+	tlsCert := func(cert *x509.Certificate, key crypto.Signer) tls.Certificate {
+		return tls.Certificate{Certificate: [][]byte{cert.Raw, ca.cert.Raw}, PrivateKey: key}
+	}
+
+	goodCert, goodKey := ca.issue(t, 2, responder.URL, "")
+	revokedCert, revokedKey := ca.issue(t, 3, responder.URL, "")
+	noOCSPCert, noOCSPKey := ca.issue(t, 4, "", "")
+
+	m := &metricstest.MockMetrics{}
+	c := newTestChecker(t, Options{Metrics: m})
+
+	for _, tt := range []struct {
+		name   string
+		cert   tls.Certificate
+		staple bool
+	}{
+		{name: "good", cert: tlsCert(goodCert, goodKey), staple: true},
+		{name: "revoked", cert: tlsCert(revokedCert, revokedKey)},
+		{name: "no OCSP responder", cert: tlsCert(noOCSPCert, noOCSPKey)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := c.NewStapler([]tls.Certificate{tt.cert})
+
+			cert, err := s.GetCertificate(&tls.ClientHelloInfo{
+				ServerName:        "example.org",
+				SupportedVersions: []uint16{tls.VersionTLS13},
+				SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+				SupportedCurves:   []tls.CurveID{tls.CurveP256},
+				SupportedPoints:   []uint8{0},
+				CipherSuites:      []uint16{tls.TLS_AES_128_GCM_SHA256},
+			})
+			require.NoError(t, err)
+			require.NotNil(t, cert)
+
+			if !tt.staple {
+				assert.Empty(t, cert.OCSPStaple)
+				return
+			}
+
+			rsp, err := ocsp.ParseResponseForCert(cert.OCSPStaple, goodCert, ca.cert)
+			require.NoError(t, err)
+			assert.Equal(t, ocsp.Good, rsp.Status)
+		})
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		assert.Equal(t, int64(1), counters[ocspStapleFailedMetric])
+	})
+}
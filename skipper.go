@@ -35,6 +35,7 @@ import (
 	"github.com/zalando/skipper/eskipfile"
 	"github.com/zalando/skipper/etcd"
 	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/activehealthcheck"
 	"github.com/zalando/skipper/filters/apiusagemonitoring"
 	"github.com/zalando/skipper/filters/auth"
 	"github.com/zalando/skipper/filters/block"
@@ -77,6 +78,7 @@ import (
 	"github.com/zalando/skipper/script"
 	"github.com/zalando/skipper/secrets"
 	"github.com/zalando/skipper/secrets/certregistry"
+	"github.com/zalando/skipper/secrets/revocation"
 	"github.com/zalando/skipper/swarm"
 	"github.com/zalando/skipper/tracing"
 	"github.com/zalando/skipper/validation"
@@ -148,6 +150,28 @@ type Options struct {
 	// using a fixed 25% fraction. Set explicitly to override that behaviour.
 	ResponseCacheMaxMemoryBytes int64
 
+	// ResponseCacheStorage selects the storage of the cache() filter. The
+	// default "memory" keeps the cached responses in the local LRU. "redis"
+	// and "valkey" share the cached responses across the Skipper instances
+	// using the ring of the swarm, with the local LRU used as L1.
+	ResponseCacheStorage string
+
+	// ResponseCacheL1MaxAge is the maximum time an entry of the redis or
+	// valkey response cache storage is served from the local L1. Defaults to
+	// 10s.
+	ResponseCacheL1MaxAge time.Duration
+
+	// DPoPReplayStorage selects the storage of the dpop() filter to
+	// detect the replay of DPoP proofs. The default "memory" detects
+	// replays per Skipper instance, "redis" and "valkey" across the
+	// Skipper instances using the ring of the swarm.
+	DPoPReplayStorage string
+
+	// ResponseCacheAdminToken enables the cache admin endpoints on the
+	// support listener, e.g. to purge cached responses. The requests need to
+	// present the token as bearer token.
+	ResponseCacheAdminToken string
+
 	// ReadMemoryLimit, when set, is called by the cache() filter initialiser
 	// to determine the container memory limit. Defaults to reading cgroup files.
 	// Override in tests or on non-standard platforms.
@@ -357,11 +381,42 @@ type Options struct {
 	// KubernetesStatusFromService, when set to <namespace>/<name>, updates ingress status addresses from this Service.
 	KubernetesStatusFromService string
 
+	// KubernetesEnableGatewayAPI enables the Kubernetes Gateway API
+	// resources as routing source.
+	KubernetesEnableGatewayAPI bool
+
+	// KubernetesGatewayControllerName is the controller name of the
+	// GatewayClasses implemented by skipper.
+	KubernetesGatewayControllerName string
+
+	// KubernetesEnableWatch enables watching the Kubernetes resources
+	// instead of listing them on every poll.
+	KubernetesEnableWatch bool
+
+	// KubernetesEnableRouteGroupStatus enables updating the status of
+	// the RouteGroups with their validation and route errors.
+	KubernetesEnableRouteGroupStatus bool
+
+	// KubernetesEnableRouteGroupCrossNamespace allows the RouteGroup
+	// service backends to reference Services in other namespaces,
+	// when a ReferenceGrant allows it.
+	KubernetesEnableRouteGroupCrossNamespace bool
+
+	// KubernetesRemoteClusters maps the names of the remote clusters,
+	// that the RouteGroup service backends can reference, to the paths
+	// of their kubeconfig files.
+	KubernetesRemoteClusters map[string]string
+
+	// KubernetesEnablePolicies enables the RatelimitPolicy and
+	// AuthPolicy resources, that set the ratelimit and tokeninfo
+	// filters of the targeted RouteGroups and Ingresses.
+	KubernetesEnablePolicies bool
+
 	// KubernetesBackendTrafficAlgorithm specifies the algorithm to calculate the backend traffic
 	KubernetesBackendTrafficAlgorithm kubernetes.BackendTrafficAlgorithm
 
 	// KubernetesDefaultLoadBalancerAlgorithm sets the default algorithm to be used for load balancing between backend endpoints,
-	// available options: roundRobin, consistentHash, random, powerOfRandomNChoices, weightedRoundRobin, leastRequests
+	// available options: roundRobin, consistentHash, random, powerOfRandomNChoices, weightedRoundRobin, leastRequests, ringHash, maglev
 	KubernetesDefaultLoadBalancerAlgorithm string
 
 	// File containing static route definitions. Multiple may be given comma separated.
@@ -751,6 +806,38 @@ type Options struct {
 	// VerifyConnection is called after normal certificate verification.
 	VerifyConnection func(tls.ConnectionState) error
 
+	// TLSClientCRLs are file paths or http(s) URLs of CRLs to check
+	// the revocation status of client certificates.
+	TLSClientCRLs []string
+
+	// TLSClientCRLDistributionPoints enables to fetch the CRLs of the
+	// CRL distribution points of the client certificates.
+	TLSClientCRLDistributionPoints bool
+
+	// TLSClientOCSP enables to check the revocation status of client
+	// certificates, which are not covered by a CRL, with OCSP.
+	TLSClientOCSP bool
+
+	// TLSClientRevocationFailClosed rejects client certificates with
+	// unknown revocation status.
+	TLSClientRevocationFailClosed bool
+
+	// TLSClientRevocationRefreshInterval sets the interval to refresh
+	// CRLs and OCSP staples, and the maximum time to cache OCSP
+	// responses.
+	TLSClientRevocationRefreshInterval time.Duration
+
+	// TLSClientRevocationFilterOnly disables the revocation checking
+	// of client certificates on the listener, so that it is only done
+	// by the mtlsRevocation() filter.
+	TLSClientRevocationFilterOnly bool
+
+	// TLSOCSPStapling enables stapling OCSP responses to the server
+	// certificates loaded from CertPathTLS.
+	TLSOCSPStapling bool
+
+	revocationChecker *revocation.Checker
+
 	// ClientCertFile is the path to a PEM-encoded client certificate for mTLS to backends.
 	// Must be set together with ClientKeyFile. When set, certificate rotation is enabled.
 	ClientCertFile string
@@ -929,6 +1016,25 @@ type Options struct {
 	// LoadBalancerHealthCheckInterval is *deprecated* and not in use anymore
 	LoadBalancerHealthCheckInterval time.Duration
 
+	// LoadBalancerRingHashEndpointEntries sets the number of entries of the
+	// endpoint with the smallest weight in the hash ring of the ringHash
+	// load balancing algorithm
+	LoadBalancerRingHashEndpointEntries int
+
+	// LoadBalancerZoneAware enables zone aware load balancing. The LB routes
+	// prefer the endpoints in the zone of skipper, and the kubernetes
+	// dataclient keeps the endpoints of all zones in the routes.
+	LoadBalancerZoneAware bool
+
+	// LoadBalancerZone is the zone of skipper used by the zone aware load
+	// balancing. Defaults to KubernetesTopologyZone.
+	LoadBalancerZone string
+
+	// LoadBalancerZoneAwareMinHealthy is the percentage of healthy endpoints
+	// in the zone of skipper, below which a proportional share of the
+	// requests is sent to the other zones
+	LoadBalancerZoneAwareMinHealthy float64
+
 	// ReverseSourcePredicate enables the automatic use of IP
 	// whitelisting in different places to use the reversed way of
 	// identifying a client IP within the X-Forwarded-For
@@ -1116,9 +1222,24 @@ type Options struct {
 	// ClusterRatelimitMaxGroupShards specifies the maximum number of group shards for the clusterRatelimit filter
 	ClusterRatelimitMaxGroupShards int
 
+	// RatelimitOverridesFile specifies an optional YAML file with
+	// overrides of the limits of cluster ratelimit groups and
+	// clients, see ratelimit.ParseOverrides
+	RatelimitOverridesFile string
+
+	// RatelimitOverridesUpdateInterval sets the interval to reread
+	// the RatelimitOverridesFile
+	RatelimitOverridesUpdateInterval time.Duration
+
 	// KubernetesEnableTLS enables kubernetes to use resources to terminate tls
 	KubernetesEnableTLS bool
 
+	// KubernetesEnableSecrets enables filters to read the Kubernetes
+	// secrets selected by KubernetesSecretsLabelSelectors with the
+	// secret names in the format namespace/name/key, e.g. the
+	// jwtValidationSecret filter
+	KubernetesEnableSecrets bool
+
 	// EnableLua allows to use lua() filters, if not enabled
 	// skipper does not support Lua, because of security
 	// considerations.
@@ -1154,6 +1275,16 @@ type Options struct {
 
 	PassiveHealthCheck map[string]string
 
+	// ActiveHealthCheck enables probing the endpoints of all LB routes,
+	// see activehealthcheck.InitActiveHealthChecker for the parameters.
+	// Without it, only the routes with the activeHealthCheck filter are
+	// probed.
+	ActiveHealthCheck map[string]string
+
+	// OutlierDetection enables ejecting the LB endpoints after consecutive
+	// errors, see proxy.InitOutlierDetection for the parameters.
+	OutlierDetection map[string]string
+
 	// proxy protocol options
 	EnableProxyProtocol bool
 	ProxyAllowListCIDRs []string
@@ -1209,7 +1340,15 @@ func (o *Options) KubernetesDataClientOptions() kubernetes.Options {
 		DefaultLoadBalancerAlgorithm:                   o.KubernetesDefaultLoadBalancerAlgorithm,
 		ForwardBackendURL:                              o.ForwardBackendURL,
 		TopologyZone:                                   o.KubernetesTopologyZone,
+		ZoneAwareLoadBalancing:                         o.LoadBalancerZoneAware,
 		IngressStatusFromService:                       o.KubernetesStatusFromService,
+		EnableGatewayAPI:                               o.KubernetesEnableGatewayAPI,
+		GatewayControllerName:                          o.KubernetesGatewayControllerName,
+		EnableWatch:                                    o.KubernetesEnableWatch,
+		EnableRouteGroupStatus:                         o.KubernetesEnableRouteGroupStatus,
+		EnableRouteGroupCrossNamespaceBackends:         o.KubernetesEnableRouteGroupCrossNamespace,
+		RemoteClusters:                                 o.KubernetesRemoteClusters,
+		EnablePolicies:                                 o.KubernetesEnablePolicies,
 		KubernetesApplicationAnnotationLabelKey:        o.KubernetesApplicationAnnotationLabelKey,
 	}
 }
@@ -1265,7 +1404,7 @@ func newServerErrorLog() *stdlog.Logger {
 	return stdlog.New(&serverErrorLogWriter{}, "", 0)
 }
 
-func createDataClients(o Options, cr *certregistry.CertRegistry) ([]routing.DataClient, error) {
+func createDataClients(o Options, cr *certregistry.CertRegistry, ksm *secrets.SecretsMap) ([]routing.DataClient, error) {
 	var clients []routing.DataClient
 
 	if o.RoutesFile != "" {
@@ -1329,6 +1468,7 @@ func createDataClients(o Options, cr *certregistry.CertRegistry) ([]routing.Data
 	if o.Kubernetes {
 		kops := o.KubernetesDataClientOptions()
 		kops.CertificateRegistry = cr
+		kops.SecretsMap = ksm
 
 		kubernetesClient, err := kubernetes.New(kops)
 		if err != nil {
@@ -1480,7 +1620,7 @@ func (o *Options) TlsConfig(cr *certregistry.CertRegistry) (*tls.Config, error)
 		MinVersion:       o.TLSMinVersion,
 		ClientAuth:       o.TLSClientAuth,
 		KeyLogWriter:     o.KeyLogWriter,
-		VerifyConnection: o.VerifyConnection,
+		VerifyConnection: o.verifyConnection(),
 	}
 
 	if o.CipherSuites != nil {
@@ -1510,9 +1650,47 @@ func (o *Options) TlsConfig(cr *certregistry.CertRegistry) (*tls.Config, error)
 		}
 		config.Certificates = append(config.Certificates, keypair)
 	}
+
+	if o.TLSOCSPStapling && o.revocationChecker != nil {
+		stapler := o.revocationChecker.NewStapler(config.Certificates)
+		if cr == nil {
+			config.GetCertificate = stapler.GetCertificate
+		} else {
+			config.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+				if cert, err := cr.GetCertFromHello(hello); cert != nil || err != nil {
+					return cert, err
+				}
+				return stapler.GetCertificate(hello)
+			}
+		}
+	}
+
 	return config, nil
 }
 
+// verifyConnection combines the revocation checking of the client
+// certificates with the configured VerifyConnection.
+func (o *Options) verifyConnection() func(tls.ConnectionState) error {
+	if o.revocationChecker == nil || o.TLSClientRevocationFilterOnly || !o.revocationEnabled() {
+		return o.VerifyConnection
+	}
+
+	checker, verify := o.revocationChecker, o.VerifyConnection
+	return func(cs tls.ConnectionState) error {
+		if err := checker.VerifyConnection(cs); err != nil {
+			return err
+		}
+		if verify != nil {
+			return verify(cs)
+		}
+		return nil
+	}
+}
+
+func (o *Options) revocationEnabled() bool {
+	return len(o.TLSClientCRLs) > 0 || o.TLSClientCRLDistributionPoints || o.TLSClientOCSP
+}
+
 func (o *Options) openTracingTracerInstance() (ot.Tracer, error) {
 	if o.OpenTracingTracer != nil {
 		return o.OpenTracingTracer, nil
@@ -1890,8 +2068,13 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		cr = certregistry.NewCertRegistry()
 	}
 
+	var ksm *secrets.SecretsMap
+	if o.KubernetesEnableSecrets {
+		ksm = secrets.NewSecretsMap()
+	}
+
 	// create data clients
-	dataClients, err := createDataClients(o, cr)
+	dataClients, err := createDataClients(o, cr, ksm)
 	if err != nil {
 		return err
 	}
@@ -1964,23 +2147,6 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		}),
 	)
 
-	// cache() filter registered here (not in filterRegistry) so the resolved tracer
-	// and connection options from skipper.Options can be wired through.
-	if !slices.Contains(o.DisabledFilters, cache.Name) {
-		o.CustomFilters = append(o.CustomFilters, cache.NewCacheFilter(
-			o.cacheBudget(),
-			o.Address,
-			skpnet.Options{
-				IdleConnTimeout:         o.CloseIdleConnsPeriod,
-				MaxIdleConnsPerHost:     o.IdleConnectionsPerHost,
-				Tracer:                  tracer,
-				OpentracingComponentTag: "skipper",
-				OpentracingSpanName:     "cache_revalidation",
-				OpentracingEventsByTag:  o.OpenTracingClientTraceByTag,
-			},
-		))
-	}
-
 	if o.OAuthTokeninfoURL != "" {
 		tio := auth.TokeninfoOptions{
 			URL:                         o.OAuthTokeninfoURL,
@@ -2015,6 +2181,11 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		}
 	}
 
+	var filterSecrets secrets.SecretsReader = sp
+	if ksm != nil {
+		filterSecrets = secrets.MultiSecret{sp, ksm}
+	}
+
 	tio := auth.TokenintrospectionOptions{
 		Timeout:                     o.OAuthTokenintrospectionTimeout,
 		MaxIdleConns:                o.IdleConnectionsPerHost,
@@ -2046,6 +2217,8 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		auth.NewSetRequestHeaderFromSecret(sp),
 		auth.NewJwtValidationWithOptions(tio),
 		auth.NewJwtValidationKeys(),
+		auth.NewJwtValidationSecret(filterSecrets),
+		auth.NewVerifyHmacSignature(filterSecrets),
 		auth.NewJwtMetrics(),
 		auth.TokenintrospectionWithOptions(auth.NewOAuthTokenintrospectionAnyClaims, tio),
 		auth.TokenintrospectionWithOptions(auth.NewOAuthTokenintrospectionAllClaims, tio),
@@ -2258,6 +2431,9 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 	var (
 		ratelimitRegistry                *ratelimit.Registry
 		failClosedRatelimitPostProcessor *ratelimitfilters.FailClosedPostProcessor
+		headersRatelimitPostProcessor    *ratelimitfilters.HeadersPostProcessor
+		costRatelimitPostProcessor       *ratelimitfilters.CostPostProcessor
+		shadowRatelimitPostProcessor     *ratelimitfilters.ShadowPostProcessor
 	)
 	if o.EnableRatelimiters || len(o.RatelimitSettings) > 0 {
 		log.Infof("enabled ratelimiters %v: %v", o.EnableRatelimiters, o.RatelimitSettings)
@@ -2284,16 +2460,29 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 			hook(ratelimitRegistry)
 		}
 
+		if o.RatelimitOverridesFile != "" {
+			if err := ratelimitRegistry.WatchOverrides(o.RatelimitOverridesFile, o.RatelimitOverridesUpdateInterval); err != nil {
+				return err
+			}
+		}
+
 		if o.ClusterRatelimitMaxGroupShards < 1 {
 			log.Warn("ClusterRatelimitMaxGroupShards must be positive, reset to 1")
 			o.ClusterRatelimitMaxGroupShards = 1
 		}
 
 		failClosedRatelimitPostProcessor = ratelimitfilters.NewFailClosedPostProcessor()
+		headersRatelimitPostProcessor = ratelimitfilters.NewHeadersPostProcessor()
+		costRatelimitPostProcessor = ratelimitfilters.NewCostPostProcessor()
+		shadowRatelimitPostProcessor = ratelimitfilters.NewShadowPostProcessor()
 
 		provider := ratelimitfilters.NewRatelimitProvider(ratelimitRegistry)
 		o.CustomFilters = append(o.CustomFilters,
 			ratelimitfilters.NewFailClosed(),
+			ratelimitfilters.NewHeaders(),
+			ratelimitfilters.NewCost(),
+			ratelimitfilters.NewResponseCost(),
+			ratelimitfilters.NewShadow(),
 			ratelimitfilters.NewClientRatelimit(provider),
 			ratelimitfilters.NewLocalRatelimit(provider),
 			ratelimitfilters.NewRatelimit(provider),
@@ -2304,10 +2493,86 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		)
 
 		if redisOptions != nil || valkeyOptions != nil {
-			o.CustomFilters = append(o.CustomFilters, ratelimitfilters.NewClusterLeakyBucketRatelimit(ratelimitRegistry))
+			o.CustomFilters = append(o.CustomFilters,
+				ratelimitfilters.NewClusterLeakyBucketRatelimit(ratelimitRegistry),
+				ratelimitfilters.NewClusterTokenBucketRatelimit(ratelimitRegistry),
+			)
 		}
 	}
 
+	// cache() filter registered here (not in filterRegistry) so the resolved tracer,
+	// connection options and swarm rings from skipper.Options can be wired through.
+	var (
+		cacheStorage cache.Storage
+		cacheStats   = cache.NewStats()
+	)
+	if !slices.Contains(o.DisabledFilters, cache.Name) {
+		cacheNetOptions := skpnet.Options{
+			IdleConnTimeout:         o.CloseIdleConnsPeriod,
+			MaxIdleConnsPerHost:     o.IdleConnectionsPerHost,
+			Tracer:                  tracer,
+			OpentracingComponentTag: "skipper",
+			OpentracingSpanName:     "cache_revalidation",
+			OpentracingEventsByTag:  o.OpenTracingClientTraceByTag,
+		}
+
+		switch o.ResponseCacheStorage {
+		case "", "memory":
+			cacheStorage = cache.NewMemoryStorage(o.cacheBudget())
+
+		case "redis":
+			if redisOptions == nil {
+				return fmt.Errorf("response cache storage redis requires the redis based swarm")
+			}
+			cacheRedisOptions := *redisOptions
+			cacheRedisOptions.MetricsPrefix = cache.RedisMetricsPrefix
+			cacheRedisRing := skpnet.NewRedisRingClient(&cacheRedisOptions)
+			defer cacheRedisRing.Close()
+
+			cacheStorage = cache.NewTieredStorage(o.cacheBudget(), cache.NewRedisStorage(cacheRedisRing), o.ResponseCacheL1MaxAge)
+
+		case "valkey":
+			if valkeyRing == nil {
+				return fmt.Errorf("response cache storage valkey requires the valkey based swarm")
+			}
+
+			cacheStorage = cache.NewTieredStorage(o.cacheBudget(), cache.NewValkeyStorage(valkeyRing), o.ResponseCacheL1MaxAge)
+
+		default:
+			return fmt.Errorf("invalid response cache storage: %s", o.ResponseCacheStorage)
+		}
+
+		o.CustomFilters = append(o.CustomFilters, cache.NewCacheFilterWithStorage(cacheStorage, cacheStats, o.Address, cacheNetOptions))
+	}
+
+	var dpopReplayCache auth.DPoPReplayCache
+	switch o.DPoPReplayStorage {
+	case "", "memory":
+		dpopReplayCache = auth.NewDPoPMemoryReplayCache()
+
+	case "redis":
+		if redisOptions == nil {
+			return fmt.Errorf("dpop replay storage redis requires the redis based swarm")
+		}
+		dpopRedisOptions := *redisOptions
+		dpopRedisOptions.MetricsPrefix = auth.DPoPRedisMetricsPrefix
+		dpopRedisRing := skpnet.NewRedisRingClient(&dpopRedisOptions)
+		defer dpopRedisRing.Close()
+
+		dpopReplayCache = auth.NewDPoPRedisReplayCache(dpopRedisRing)
+
+	case "valkey":
+		if valkeyRing == nil {
+			return fmt.Errorf("dpop replay storage valkey requires the valkey based swarm")
+		}
+
+		dpopReplayCache = auth.NewDPoPValkeyReplayCache(valkeyRing)
+
+	default:
+		return fmt.Errorf("invalid dpop replay storage: %s", o.DPoPReplayStorage)
+	}
+	o.CustomFilters = append(o.CustomFilters, auth.NewDPoP(dpopReplayCache))
+
 	if o.TLSMinVersion == 0 {
 		o.TLSMinVersion = tls.VersionTLS12
 	}
@@ -2406,6 +2671,23 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		o.CustomFilters = append(o.CustomFilters, lua)
 	}
 
+	if o.revocationEnabled() || o.TLSOCSPStapling {
+		o.revocationChecker, err = revocation.New(revocation.Options{
+			CRLs:                    o.TLSClientCRLs,
+			FetchDistributionPoints: o.TLSClientCRLDistributionPoints,
+			OCSP:                    o.TLSClientOCSP,
+			FailClosed:              o.TLSClientRevocationFailClosed,
+			RefreshInterval:         o.TLSClientRevocationRefreshInterval,
+			Metrics:                 mtr,
+		})
+		if err != nil {
+			log.Errorf("Failed to create revocation checker: %v", err)
+			return err
+		}
+		defer o.revocationChecker.Close()
+	}
+	o.CustomFilters = append(o.CustomFilters, tlsfilters.NewMtlsRevocation(o.revocationChecker))
+
 	if o.MtlsAuthnCA == nil {
 		o.MtlsAuthnCA, err = x509.SystemCertPool()
 		if err != nil {
@@ -2482,6 +2764,27 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		return err
 	}
 
+	outlierDetectionEnabled, outlierDetection, err := proxy.InitOutlierDetection(o.OutlierDetection)
+	if err != nil {
+		return err
+	}
+
+	lbOptions := loadbalancer.Options{
+		RingHashEndpointEntries:    o.LoadBalancerRingHashEndpointEntries,
+		ZoneAwareMinHealthyPercent: o.LoadBalancerZoneAwareMinHealthy,
+		Metrics:                    mtr,
+	}
+	if o.LoadBalancerZoneAware {
+		lbOptions.Zone = o.LoadBalancerZone
+		if lbOptions.Zone == "" {
+			lbOptions.Zone = o.KubernetesTopologyZone
+		}
+
+		if lbOptions.Zone == "" {
+			return fmt.Errorf("zone aware load balancing requires the zone of skipper")
+		}
+	}
+
 	// create a routing engine
 	endpointRegistry := routing.NewEndpointRegistry(routing.RegistryOptions{
 		PassiveHealthCheckEnabled:     passiveHealthCheckEnabled,
@@ -2490,6 +2793,20 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		MinHealthCheckDropProbability: passiveHealthCheck.MinDropProbability,
 		MaxHealthCheckDropProbability: passiveHealthCheck.MaxDropProbability,
 	})
+
+	activeHealthCheckEnabled, activeHealthCheckSettings, err := activehealthcheck.InitActiveHealthChecker(o.ActiveHealthCheck)
+	if err != nil {
+		return err
+	}
+
+	prober := activehealthcheck.NewProber(activehealthcheck.Options{
+		Enabled:          activeHealthCheckEnabled,
+		Settings:         activeHealthCheckSettings,
+		EndpointRegistry: endpointRegistry,
+		Metrics:          mtr,
+	})
+	defer prober.Close()
+
 	ro := routing.Options{
 		FilterRegistry:  o.filterRegistry(),
 		MatchingOptions: mo,
@@ -2499,8 +2816,9 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		UpdateBuffer:    updateBuffer,
 		SuppressLogs:    o.SuppressRouteUpdateLogs,
 		PostProcessors: []routing.PostProcessor{
-			loadbalancer.NewAlgorithmProvider(),
+			loadbalancer.NewAlgorithmProviderWithOptions(lbOptions),
 			endpointRegistry,
+			prober,
 			schedulerRegistry,
 			builtin.NewRouteCreationMetrics(mtr),
 			fadein.NewPostProcessor(fadein.PostProcessorOptions{EndpointRegistry: endpointRegistry}),
@@ -2513,6 +2831,18 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 	if failClosedRatelimitPostProcessor != nil {
 		ro.PostProcessors = append(ro.PostProcessors, failClosedRatelimitPostProcessor)
 	}
+	if headersRatelimitPostProcessor != nil {
+		ro.PostProcessors = append(ro.PostProcessors, headersRatelimitPostProcessor)
+	}
+	if costRatelimitPostProcessor != nil {
+		ro.PostProcessors = append(ro.PostProcessors, costRatelimitPostProcessor)
+	}
+	if shadowRatelimitPostProcessor != nil {
+		ro.PostProcessors = append(ro.PostProcessors, shadowRatelimitPostProcessor)
+	}
+	if !slices.Contains(o.DisabledFilters, cache.Name) {
+		ro.PostProcessors = append(ro.PostProcessors, cacheStats)
+	}
 
 	if o.DefaultFilters != nil {
 		ro.PreProcessors = append(ro.PreProcessors, o.DefaultFilters)
@@ -2603,6 +2933,8 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		EndpointRegistry:                 endpointRegistry,
 		EnablePassiveHealthCheck:         passiveHealthCheckEnabled,
 		PassiveHealthCheck:               passiveHealthCheck,
+		EnableOutlierDetection:           outlierDetectionEnabled,
+		OutlierDetection:                 outlierDetection,
 	}
 
 	if o.EnableBreakers || len(o.BreakerSettings) > 0 {
@@ -2629,6 +2961,10 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		mux := http.NewServeMux()
 		mux.Handle("/routes", routing)
 		mux.Handle("/routes/", routing)
+		mux.Handle("/active-health-check", prober)
+		if cacheStorage != nil && o.ResponseCacheAdminToken != "" {
+			mux.Handle(cache.AdminPath, cache.NewAdminHandler(cacheStorage, cacheStats, o.ResponseCacheAdminToken))
+		}
 
 		metricsHandler := metrics.NewHandler(mtrOpts, mtr)
 		mux.Handle("/metrics", metricsHandler)
@@ -3,8 +3,10 @@ package skipper
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"net/http"
 	stdlibhttptest "net/http/httptest"
@@ -30,6 +32,7 @@ import (
 	"github.com/zalando/skipper/routing"
 	"github.com/zalando/skipper/scheduler"
 	"github.com/zalando/skipper/secrets/certregistry"
+	"github.com/zalando/skipper/secrets/revocation"
 	"github.com/zalando/skipper/tracing/tracingtest"
 
 	"github.com/stretchr/testify/assert"
@@ -247,6 +250,32 @@ func TestOptionsTLSConfig(t *testing.T) {
 
 }
 
+func TestOptionsTLSConfigRevocation(t *testing.T) {
+	checker, err := revocation.New(revocation.Options{OCSP: true, FailClosed: true})
+	require.NoError(t, err)
+	defer checker.Close()
+
+	var verified bool
+	o := &Options{
+		TLSClientOCSP:     true,
+		VerifyConnection:  func(tls.ConnectionState) error { verified = true; return nil },
+		revocationChecker: checker,
+	}
+	c, err := o.TlsConfig(certregistry.NewCertRegistry())
+	require.NoError(t, err)
+
+	assert.NoError(t, c.VerifyConnection(tls.ConnectionState{}))
+	assert.True(t, verified)
+
+	unknown := &x509.Certificate{SerialNumber: big.NewInt(1)}
+	assert.ErrorIs(t, c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{unknown, unknown}}), revocation.ErrUnknownStatus)
+
+	o.TLSClientRevocationFilterOnly = true
+	c, err = o.TlsConfig(certregistry.NewCertRegistry())
+	require.NoError(t, err)
+	assert.NoError(t, c.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{unknown, unknown}}))
+}
+
 func TestOptionsTLSConfigInvalidPaths(t *testing.T) {
 	cr := certregistry.NewCertRegistry()
 
@@ -579,7 +608,7 @@ func TestDataClients(t *testing.T) {
 			StatusChecks:                    []string{"http://127.0.0.1:8091/metrics", "http://127.0.0.1:8092"},
 		}
 
-		dcs, err := createDataClients(o, nil)
+		dcs, err := createDataClients(o, nil, nil)
 		if err != nil {
 			t.Fatalf("Failed to createDataclients: %v", err)
 		}
@@ -703,7 +732,7 @@ func TestDataClients(t *testing.T) {
 			StatusChecks:                    []string{"http://127.0.0.1:8091/metrics", "http://127.0.0.1:8092"},
 		}
 
-		dcs, err := createDataClients(o, nil)
+		dcs, err := createDataClients(o, nil, nil)
 		if err != nil {
 			t.Fatalf("Failed to createDataclients: %v", err)
 		}
@@ -828,7 +857,7 @@ func TestDataClients(t *testing.T) {
 			StatusChecks:                    []string{"http://127.0.0.1:8091/metrics", "http://127.0.0.1:8092"},
 		}
 
-		dcs, err := createDataClients(o, nil)
+		dcs, err := createDataClients(o, nil, nil)
 		if err != nil {
 			t.Fatalf("Failed to createDataclients: %v", err)
 		}